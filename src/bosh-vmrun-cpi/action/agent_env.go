@@ -0,0 +1,44 @@
+package action
+
+import (
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+
+	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/vm"
+)
+
+// VMs keep the agent settings source they were created with, guestinfo when the variable is set, otherwise the env ISO
+func readAgentEnv(driverClient driver.Client, agentSettings vm.AgentSettings, vmId string) (apiv1.AgentEnv, bool, error) {
+	guestInfoValue, err := driverClient.GetVMGuestInfo(vmId, vm.AGENT_ENV_GUESTINFO_NAME)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if guestInfoValue != "" {
+		agentEnv, err := agentSettings.GetGuestInfoAgentEnv(guestInfoValue)
+		return agentEnv, true, err
+	}
+
+	agentEnv, err := agentSettings.GetIsoAgentEnv(driverClient.GetVMIsoPath(vmId))
+	return agentEnv, false, err
+}
+
+func writeAgentEnv(driverClient driver.Client, agentSettings vm.AgentSettings, vmId string, agentEnv apiv1.AgentEnv, useGuestInfo bool) error {
+	if useGuestInfo {
+		guestInfoValue, err := agentSettings.GenerateAgentEnvGuestInfo(agentEnv)
+		if err != nil {
+			return err
+		}
+
+		return driverClient.SetVMGuestInfo(vmId, vm.AGENT_ENV_GUESTINFO_NAME, guestInfoValue)
+	}
+
+	envIsoPath, err := agentSettings.GenerateAgentEnvIso(agentEnv)
+	defer agentSettings.Cleanup()
+
+	if err != nil {
+		return err
+	}
+
+	return driverClient.UpdateVMIso(vmId, envIsoPath)
+}
@@ -2,7 +2,9 @@ package action
 
 import (
 	"fmt"
+	"strings"
 
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/cppforlife/bosh-cpi-go/apiv1"
 
 	"bosh-vmrun-cpi/driver"
@@ -13,18 +15,19 @@ type AttachDiskMethod struct {
 	driverClient    driver.Client
 	agentSettings   vm.AgentSettings
 	agentEnvFactory apiv1.AgentEnvFactory
+	logger          boshlog.Logger
 }
 
-func NewAttachDiskMethod(driverClient driver.Client, agentSettings vm.AgentSettings) AttachDiskMethod {
+func NewAttachDiskMethod(driverClient driver.Client, agentSettings vm.AgentSettings, logger boshlog.Logger) AttachDiskMethod {
 	return AttachDiskMethod{
 		driverClient:  driverClient,
 		agentSettings: agentSettings,
+		logger:        logger,
 	}
 }
 
 func (c AttachDiskMethod) AttachDisk(vmCID apiv1.VMCID, diskCID apiv1.DiskCID) error {
 	var err error
-	var agentEnv apiv1.AgentEnv
 	vmId := "vm-" + vmCID.AsString()
 	diskId := "disk-" + diskCID.AsString()
 
@@ -32,6 +35,12 @@ func (c AttachDiskMethod) AttachDisk(vmCID apiv1.VMCID, diskCID apiv1.DiskCID) e
 		return fmt.Errorf("disk does not exist: %s", diskId)
 	}
 
+	err = c.hotAttachDisk(vmId, diskId, diskCID)
+	if err == nil {
+		return nil
+	}
+	c.logger.Info("cpi", "hot attach of %s unavailable, attaching with the vm powered off: %s", diskId, err)
+
 	err = c.driverClient.StopVM(vmId)
 	if err != nil {
 		return err
@@ -42,34 +51,70 @@ func (c AttachDiskMethod) AttachDisk(vmCID apiv1.VMCID, diskCID apiv1.DiskCID) e
 		return err
 	}
 
-	currentIsoPath := c.driverClient.GetVMIsoPath(vmId)
-	agentEnv, err = c.agentSettings.GetIsoAgentEnv(currentIsoPath)
+	agentEnv, usesGuestInfo, err := readAgentEnv(c.driverClient, c.agentSettings, vmId)
 	if err != nil {
 		return err
 	}
 
-	agentEnv.AttachPersistentDisk(diskCID, struct {
-		Path     string `json:"path"`      //can be removed?
-		VolumeID string `json:"volume_id"` //should be 3?
-		Lun      string `json:"lun"`
-	}{"/dev/sdc", "2", "0"})
+	vmInfo, err := c.driverClient.GetVMInfo(vmId)
+	if err != nil {
+		return err
+	}
+
+	var diskVMXID string
+	for _, disk := range vmInfo.Disks {
+		if strings.Contains(disk.Path, diskId) {
+			diskVMXID = disk.ID
+		}
+	}
+
+	agentEnv.AttachPersistentDisk(diskCID, vm.PersistentDiskHint(diskVMXID, vmInfo.SCSIDisks))
 
-	envIsoPath, err := c.agentSettings.GenerateAgentEnvIso(agentEnv)
+	err = writeAgentEnv(c.driverClient, c.agentSettings, vmId, agentEnv, usesGuestInfo)
 	if err != nil {
 		return err
 	}
 
-	err = c.driverClient.UpdateVMIso(vmId, envIsoPath)
+	err = c.driverClient.StartVM(vmId)
 	if err != nil {
 		return err
 	}
 
-	c.agentSettings.Cleanup()
+	return nil
+}
 
-	err = c.driverClient.StartVM(vmId)
+// a disk plugged in before the agent env update fails is unplugged again, so the powered off path starts clean
+func (c AttachDiskMethod) hotAttachDisk(vmId string, diskId string, diskCID apiv1.DiskCID) error {
+	diskVMXID, err := c.driverClient.HotAttachDisk(vmId, diskId)
+	if err != nil {
+		return err
+	}
+
+	err = c.updateAgentEnv(vmId, diskCID, diskVMXID)
 	if err != nil {
+		detachErr := c.driverClient.HotDetachDisk(vmId, diskId)
+		if detachErr != nil {
+			c.logger.Error("cpi", "unplugging %s after failed hot attach: %s", diskId, detachErr)
+		}
+
 		return err
 	}
 
 	return nil
 }
+
+func (c AttachDiskMethod) updateAgentEnv(vmId string, diskCID apiv1.DiskCID, diskVMXID string) error {
+	agentEnv, usesGuestInfo, err := readAgentEnv(c.driverClient, c.agentSettings, vmId)
+	if err != nil {
+		return err
+	}
+
+	vmInfo, err := c.driverClient.GetVMInfo(vmId)
+	if err != nil {
+		return err
+	}
+
+	agentEnv.AttachPersistentDisk(diskCID, vm.PersistentDiskHint(diskVMXID, vmInfo.SCSIDisks))
+
+	return writeAgentEnv(c.driverClient, c.agentSettings, vmId, agentEnv, usesGuestInfo)
+}
@@ -0,0 +1,87 @@
+package action_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-vmrun-cpi/driver"
+	fakedriver "bosh-vmrun-cpi/driver/fakes"
+	fakevm "bosh-vmrun-cpi/vm/fakes"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/action"
+)
+
+var _ = Describe("AttachDisk", func() {
+	var (
+		driverClient  *fakedriver.FakeClient
+		agentSettings *fakevm.FakeAgentSettings
+		agentEnv      apiv1.AgentEnv
+		m             action.AttachDiskMethod
+	)
+
+	BeforeEach(func() {
+		driverClient = &fakedriver.FakeClient{}
+		agentSettings = &fakevm.FakeAgentSettings{}
+		logger := &fakelogger.FakeLogger{}
+
+		agentEnv = apiv1.AgentEnvFactory{}.ForVM(apiv1.NewAgentID("agent-0"), apiv1.NewVMCID("foo"), apiv1.Networks{}, apiv1.VMEnv{}, apiv1.AgentOptions{})
+
+		driverClient.HasDiskReturns(true)
+		driverClient.GetVMInfoReturns(driver.VMInfo{DiskController: "lsilogic"}, nil)
+		agentSettings.GetIsoAgentEnvReturns(agentEnv, nil)
+		agentSettings.GenerateAgentEnvIsoReturns("iso-path", nil)
+
+		m = action.NewAttachDiskMethod(driverClient, agentSettings, logger)
+	})
+
+	It("hot-plugs disks into running vms", func() {
+		driverClient.HotAttachDiskReturns("scsi0:2", nil)
+
+		Expect(m.AttachDisk(apiv1.NewVMCID("foo"), apiv1.NewDiskCID("bar"))).To(Succeed())
+
+		vmId, diskId := driverClient.HotAttachDiskArgsForCall(0)
+		Expect(vmId).To(Equal("vm-foo"))
+		Expect(diskId).To(Equal("disk-bar"))
+
+		agentEnvBytes, err := agentSettings.GenerateAgentEnvIsoArgsForCall(0).AsBytes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(agentEnvBytes)).To(ContainSubstring(`"bar":{"path":"/dev/sdc"`))
+
+		vmId, isoPath := driverClient.UpdateVMIsoArgsForCall(0)
+		Expect(vmId).To(Equal("vm-foo"))
+		Expect(isoPath).To(Equal("iso-path"))
+
+		Expect(driverClient.StopVMCallCount()).To(Equal(0))
+		Expect(driverClient.AttachDiskCallCount()).To(Equal(0))
+		Expect(driverClient.StartVMCallCount()).To(Equal(0))
+	})
+
+	It("falls back to attaching with the vm powered off", func() {
+		driverClient.HotAttachDiskReturns("", errors.New("vm is not running"))
+
+		Expect(m.AttachDisk(apiv1.NewVMCID("foo"), apiv1.NewDiskCID("bar"))).To(Succeed())
+
+		Expect(driverClient.StopVMCallCount()).To(Equal(1))
+		Expect(driverClient.AttachDiskCallCount()).To(Equal(1))
+		Expect(driverClient.UpdateVMIsoCallCount()).To(Equal(1))
+		Expect(driverClient.StartVMCallCount()).To(Equal(1))
+	})
+
+	It("unplugs hot-plugged disks when the agent env update fails", func() {
+		driverClient.HotAttachDiskReturns("scsi0:2", nil)
+		driverClient.UpdateVMIsoReturnsOnCall(0, errors.New("cdrom busy"))
+
+		Expect(m.AttachDisk(apiv1.NewVMCID("foo"), apiv1.NewDiskCID("bar"))).To(Succeed())
+
+		vmId, diskId := driverClient.HotDetachDiskArgsForCall(0)
+		Expect(vmId).To(Equal("vm-foo"))
+		Expect(diskId).To(Equal("disk-bar"))
+		Expect(driverClient.AttachDiskCallCount()).To(Equal(1))
+		Expect(driverClient.UpdateVMIsoCallCount()).To(Equal(2))
+	})
+})
@@ -2,6 +2,7 @@ package action
 
 import (
 	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/vm"
 
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 	"github.com/cppforlife/bosh-cpi-go/apiv1"
@@ -26,7 +27,12 @@ func (c CreateDiskMethod) CreateDisk(sizeMB int,
 	diskId := "disk-" + diskUuid
 	newDiskCID := apiv1.NewDiskCID(diskUuid)
 
-	err := c.driverClient.CreateDisk(diskId, sizeMB)
+	diskProps, err := vm.NewDiskProps(cloudProps)
+	if err != nil {
+		return newDiskCID, err
+	}
+
+	err = c.driverClient.CreateDisk(diskId, sizeMB, diskProps.Datastore)
 	if err != nil {
 		return newDiskCID, err
 	}
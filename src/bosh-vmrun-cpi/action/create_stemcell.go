@@ -2,6 +2,8 @@ package action
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -26,12 +28,9 @@ func NewCreateStemcellMethod(driverClient driver.Client, stemcellClient stemcell
 }
 
 func (c CreateStemcellMethod) CreateStemcell(localImagePath string, stemcellCloudProps apiv1.StemcellCloudProps) (apiv1.StemcellCID, error) {
-	defer c.stemcellStore.Cleanup()
-
 	stemcellUuid, _ := c.uuidGen.Generate()
 	stemcellId := "cs-" + stemcellUuid
 	stemcellCID := apiv1.NewStemcellCID(stemcellUuid)
-	storeImagePath := ""
 
 	c.logger.Debug("cpi", "stemcellCloudProps: %#+v", stemcellCloudProps)
 	stemcellProps, err := stemcell.NewStemcellProps(stemcellCloudProps)
@@ -41,37 +40,116 @@ func (c CreateStemcellMethod) CreateStemcell(localImagePath string, stemcellClou
 
 	c.logger.Debug("cpi", "LocalImagePath: %s", localImagePath)
 
-	storeImagePath, err = c.stemcellStore.GetByImagePathMapping(localImagePath)
-	if err != nil {
+	var stemcellTarballPath, stemcellKey string
+	if stemcellProps.Light {
+		stemcellTarballPath, err = c.lightStemcellTarball(stemcellProps.Name, stemcellProps.Version)
+	} else if c.fs.FileExists(localImagePath) {
 		c.logger.Debug("cpi", "Stemcell image found at path:", localImagePath)
+	} else {
+		stemcellTarballPath, err = c.storeStemcellTarball(localImagePath, stemcellProps.Name, stemcellProps.Version)
+	}
+	if err != nil {
+		return stemcellCID, err
+	}
 
+	if stemcellTarballPath != "" {
+		stemcellKey, err = c.stemcellClient.StemcellImageKey(stemcellTarballPath)
+	} else {
+		stemcellKey, err = c.stemcellClient.ImageKey(localImagePath)
+	}
+	if err != nil {
 		return stemcellCID, err
 	}
 
-	if storeImagePath == "" {
-		storeImagePath, err = c.stemcellStore.GetByMetadata(stemcellProps.Name, stemcellProps.Version)
+	defer c.stemcellClient.Cleanup()
+
+	// without an image sha1 in the stemcell manifest the image is keyed by the sha1 hashed while it is extracted
+	var ovfPath string
+	if stemcellKey == "" {
+		ovfPath, stemcellKey, err = c.extractStemcell(localImagePath, stemcellTarballPath)
 		if err != nil {
-			c.logger.Debug("cpi", "Stemcell image found by metadata")
 			return stemcellCID, err
 		}
 	}
 
-	c.logger.DebugWithDetails("cpi", "StoreImagePath:", storeImagePath)
+	err = c.driverClient.LockStemcellVM(stemcellKey, func() error {
+		existingStemcellId, err := c.driverClient.AcquireStemcellVM(stemcellKey)
+		if err != nil {
+			return err
+		}
+
+		if existingStemcellId != "" {
+			c.logger.Debug("cpi", "Reusing imported stemcell: %s", existingStemcellId)
+			stemcellCID = apiv1.NewStemcellCID(strings.TrimPrefix(existingStemcellId, "cs-"))
+			return nil
+		}
+
+		if ovfPath == "" {
+			ovfPath, _, err = c.extractStemcell(localImagePath, stemcellTarballPath)
+			if err != nil {
+				return err
+			}
+		}
 
-	if storeImagePath == "" {
-		return stemcellCID, errors.New("stemcell image not found locally or in image store")
+		_, err = c.driverClient.ImportOvf(ovfPath, stemcellId)
+		if err != nil {
+			return err
+		}
+
+		return c.driverClient.RegisterStemcellVM(stemcellKey, stemcellId)
+	})
+
+	return stemcellCID, err
+}
+
+// extractStemcell unpacks the image of a stemcell tarball, or the director image when there is no tarball
+func (c CreateStemcellMethod) extractStemcell(localImagePath string, stemcellTarballPath string) (string, string, error) {
+	if stemcellTarballPath != "" {
+		return c.stemcellClient.ExtractStemcellOvf(stemcellTarballPath)
 	}
 
-	ovfPath, err := c.stemcellClient.ExtractOvf(storeImagePath)
+	return c.stemcellClient.ExtractOvf(localImagePath)
+}
+
+func (c CreateStemcellMethod) storeStemcellTarball(localImagePath string, name string, version string) (string, error) {
+	stemcellTarballPath, err := c.stemcellStore.GetByImagePathMapping(localImagePath)
 	if err != nil {
-		return stemcellCID, err
+		return "", err
 	}
-	defer c.stemcellClient.Cleanup()
 
-	_, err = c.driverClient.ImportOvf(ovfPath, stemcellId)
+	if stemcellTarballPath == "" {
+		stemcellTarballPath, err = c.stemcellStore.GetByMetadata(name, version)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	c.logger.DebugWithDetails("cpi", "StemcellTarballPath:", stemcellTarballPath)
+
+	if stemcellTarballPath == "" {
+		return "", errors.New("stemcell image not found locally or in image store")
+	}
+
+	return stemcellTarballPath, nil
+}
+
+// lightStemcellTarball finds the stemcell named by a light stemcell in the stemcell store, light stemcells have
+// an empty image
+func (c CreateStemcellMethod) lightStemcellTarball(name string, version string) (string, error) {
+	if name == "" || version == "" {
+		return "", errors.New("light stemcell requires name and version cloud properties")
+	}
+
+	stemcellTarballPath, err := c.stemcellStore.GetByMetadata(name, version)
 	if err != nil {
-		return stemcellCID, err
+		return "", err
+	}
+
+	c.logger.DebugWithDetails("cpi", "Light stemcell StemcellTarballPath:", stemcellTarballPath)
+
+	if stemcellTarballPath == "" {
+		return "", fmt.Errorf("light stemcell %s/%s not found in stemcell store", name, version)
 	}
 
-	return stemcellCID, nil
+	return stemcellTarballPath, nil
 }
@@ -2,6 +2,7 @@ package action_test
 
 import (
 	"encoding/json"
+	"errors"
 
 	"github.com/cppforlife/bosh-cpi-go/apiv1"
 	. "github.com/onsi/ginkgo"
@@ -36,16 +37,19 @@ var _ = Describe("CreateStemcell", func() {
 		fs = fakesys.NewFakeFileSystem()
 		uuidGen := &fakeuuid.FakeGenerator{}
 		m = action.NewCreateStemcellMethod(driverClient, stemcellClient, stemcellStore, uuidGen, fs, logger)
+
+		driverClient.LockStemcellVMStub = func(stemcellKey string, fn func() error) error {
+			return fn()
+		}
 	})
 
 	It("uses the supplied image", func() {
-		err = fs.MkdirAll("image-path", 0777)
-		Expect(err).ToNot(HaveOccurred())
-
 		localImagePath := "/path/to/image"
 
-		stemcellStore.GetByImagePathMappingReturns(localImagePath, nil)
-		stemcellClient.ExtractOvfReturns("extracted-path", nil)
+		err = fs.WriteFileString(localImagePath, "image")
+		Expect(err).ToNot(HaveOccurred())
+
+		stemcellClient.ExtractOvfReturns("extracted-path", "image-sha1", nil)
 
 		var resourceCloudProps apiv1.CloudPropsImpl
 		json.Unmarshal([]byte(`{}`), &resourceCloudProps)
@@ -60,34 +64,211 @@ var _ = Describe("CreateStemcell", func() {
 		Expect(clientImportOvfVmId).To(Equal("cs-fake-uuid-0"))
 
 		Expect(stemcellClient.ExtractOvfArgsForCall(0)).To(Equal(localImagePath))
+		Expect(stemcellStore.GetByImagePathMappingCallCount()).To(Equal(0))
 		Expect(stemcellClient.CleanupCallCount()).To(Equal(1))
 	})
 
-	It("uses the stemcell store if supplied image path does not exist", func() {
-		var err error
+	It("streams the mapped stemcell tarball if supplied image path does not exist", func() {
+		stemcellStore.GetByImagePathMappingReturns("/path/to/store/stemcell.tgz", nil)
+		stemcellClient.ExtractStemcellOvfReturns("extracted-path", "image-sha1", nil)
 
-		err = fs.MkdirAll("image-path", 0777)
+		var resourceCloudProps apiv1.CloudPropsImpl
+		json.Unmarshal([]byte(`{}`), &resourceCloudProps)
+
+		_, err := m.CreateStemcell("local-image-does-not-exist", resourceCloudProps)
 		Expect(err).ToNot(HaveOccurred())
 
-		storeImagePath := "/path/to/store/image"
+		Expect(stemcellStore.GetByImagePathMappingArgsForCall(0)).To(Equal("local-image-does-not-exist"))
+		Expect(stemcellStore.GetByMetadataCallCount()).To(Equal(0))
+		Expect(stemcellClient.ExtractStemcellOvfArgsForCall(0)).To(Equal("/path/to/store/stemcell.tgz"))
+		Expect(stemcellClient.ExtractOvfCallCount()).To(Equal(0))
+	})
+
+	It("uses the stemcell store if supplied image path does not exist", func() {
+		var err error
 
 		stemcellStore.GetByImagePathMappingReturns("", nil)
-		stemcellStore.GetByMetadataReturns(storeImagePath, nil)
-		stemcellClient.ExtractOvfReturns("extracted-path", nil)
+		stemcellStore.GetByMetadataReturns("/path/to/store/stemcell.tgz", nil)
+		stemcellClient.ExtractStemcellOvfReturns("extracted-path", "image-sha1", nil)
 
 		var resourceCloudProps apiv1.CloudPropsImpl
-		json.Unmarshal([]byte(`{}`), &resourceCloudProps)
+		json.Unmarshal([]byte(`{"name":"stemcell-a","version":"1"}`), &resourceCloudProps)
 
 		cid, err := m.CreateStemcell("local-image-does-not-exist", resourceCloudProps)
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(cid.AsString()).To(Equal("fake-uuid-0"))
 
+		name, version := stemcellStore.GetByMetadataArgsForCall(0)
+		Expect(name).To(Equal("stemcell-a"))
+		Expect(version).To(Equal("1"))
+
 		clientImportOvfPath, clientImportOvfVmId := driverClient.ImportOvfArgsForCall(0)
 		Expect(clientImportOvfPath).To(Equal("extracted-path"))
 		Expect(clientImportOvfVmId).To(Equal("cs-fake-uuid-0"))
 
-		Expect(stemcellClient.ExtractOvfArgsForCall(0)).To(Equal(storeImagePath))
+		Expect(stemcellClient.ExtractStemcellOvfArgsForCall(0)).To(Equal("/path/to/store/stemcell.tgz"))
 		Expect(stemcellClient.CleanupCallCount()).To(Equal(1))
 	})
+
+	It("fails when the stemcell is not in the store", func() {
+		var resourceCloudProps apiv1.CloudPropsImpl
+		json.Unmarshal([]byte(`{"name":"stemcell-a","version":"1"}`), &resourceCloudProps)
+
+		_, err := m.CreateStemcell("local-image-does-not-exist", resourceCloudProps)
+		Expect(err).To(MatchError("stemcell image not found locally or in image store"))
+		Expect(stemcellClient.ExtractStemcellOvfCallCount()).To(Equal(0))
+	})
+	Describe("light stemcells", func() {
+		var resourceCloudProps apiv1.CloudPropsImpl
+
+		BeforeEach(func() {
+			err = fs.WriteFileString("/path/to/image", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			json.Unmarshal([]byte(`{"name":"stemcell-a","version":"1","light":true}`), &resourceCloudProps)
+		})
+
+		It("resolves the stemcell from the store by metadata without using the image", func() {
+			stemcellStore.GetByMetadataReturns("/path/to/store/stemcell.tgz", nil)
+			stemcellClient.ExtractStemcellOvfReturns("extracted-path", "image-sha1", nil)
+
+			_, err := m.CreateStemcell("/path/to/image", resourceCloudProps)
+			Expect(err).ToNot(HaveOccurred())
+
+			name, version := stemcellStore.GetByMetadataArgsForCall(0)
+			Expect(name).To(Equal("stemcell-a"))
+			Expect(version).To(Equal("1"))
+			Expect(stemcellStore.GetByImagePathMappingCallCount()).To(Equal(0))
+			Expect(stemcellClient.ExtractOvfCallCount()).To(Equal(0))
+			Expect(stemcellClient.ExtractStemcellOvfArgsForCall(0)).To(Equal("/path/to/store/stemcell.tgz"))
+
+			clientImportOvfPath, _ := driverClient.ImportOvfArgsForCall(0)
+			Expect(clientImportOvfPath).To(Equal("extracted-path"))
+		})
+
+		It("fails when the stemcell is not in the store", func() {
+			_, err := m.CreateStemcell("/path/to/image", resourceCloudProps)
+			Expect(err).To(MatchError("light stemcell stemcell-a/1 not found in stemcell store"))
+			Expect(driverClient.ImportOvfCallCount()).To(Equal(0))
+		})
+
+		It("requires a name and version", func() {
+			json.Unmarshal([]byte(`{"light":true}`), &resourceCloudProps)
+
+			_, err := m.CreateStemcell("/path/to/image", resourceCloudProps)
+			Expect(err).To(MatchError("light stemcell requires name and version cloud properties"))
+			Expect(stemcellStore.GetByMetadataCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("imported stemcell reuse", func() {
+		var (
+			resourceCloudProps apiv1.CloudPropsImpl
+			locked             bool
+			importedLocked     bool
+		)
+
+		BeforeEach(func() {
+			err = fs.WriteFileString("/path/to/image", "image")
+			Expect(err).ToNot(HaveOccurred())
+
+			stemcellClient.ExtractOvfReturns("extracted-path", "image-sha1", nil)
+			stemcellClient.ImageKeyReturns("image-sha1", nil)
+			json.Unmarshal([]byte(`{"name":"stemcell-a","version":"1"}`), &resourceCloudProps)
+
+			driverClient.LockStemcellVMStub = func(stemcellKey string, fn func() error) error {
+				locked = true
+				defer func() { locked = false }()
+				return fn()
+			}
+			driverClient.ImportOvfStub = func(string, string) (bool, error) {
+				importedLocked = locked
+				return true, nil
+			}
+		})
+
+		It("reuses the imported stemcell of the same image", func() {
+			driverClient.AcquireStemcellVMReturns("cs-existing-uuid", nil)
+
+			cid, err := m.CreateStemcell("/path/to/image", resourceCloudProps)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cid.AsString()).To(Equal("existing-uuid"))
+			Expect(stemcellClient.ImageKeyArgsForCall(0)).To(Equal("/path/to/image"))
+			Expect(driverClient.AcquireStemcellVMArgsForCall(0)).To(Equal("image-sha1"))
+			Expect(stemcellClient.ExtractOvfCallCount()).To(Equal(0))
+			Expect(driverClient.ImportOvfCallCount()).To(Equal(0))
+			Expect(driverClient.RegisterStemcellVMCallCount()).To(Equal(0))
+		})
+
+		It("imports and registers a new stemcell holding the stemcell lock", func() {
+			cid, err := m.CreateStemcell("/path/to/image", resourceCloudProps)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cid.AsString()).To(Equal("fake-uuid-0"))
+			stemcellKey, _ := driverClient.LockStemcellVMArgsForCall(0)
+			Expect(stemcellKey).To(Equal("image-sha1"))
+			Expect(driverClient.ImportOvfCallCount()).To(Equal(1))
+			Expect(importedLocked).To(BeTrue())
+
+			stemcellKey, stemcellVmName := driverClient.RegisterStemcellVMArgsForCall(0)
+			Expect(stemcellKey).To(Equal("image-sha1"))
+			Expect(stemcellVmName).To(Equal("cs-fake-uuid-0"))
+		})
+
+		It("does not register a stemcell that failed to import", func() {
+			driverClient.ImportOvfStub = nil
+			driverClient.ImportOvfReturns(false, errors.New("import failed"))
+
+			_, err := m.CreateStemcell("/path/to/image", resourceCloudProps)
+			Expect(err).To(MatchError("import failed"))
+			Expect(driverClient.RegisterStemcellVMCallCount()).To(Equal(0))
+		})
+
+		It("keys stemcells from the store by the image sha1 of their manifest", func() {
+			json.Unmarshal([]byte(`{"name":"stemcell-a","version":"1","light":true}`), &resourceCloudProps)
+			stemcellStore.GetByMetadataReturns("/path/to/store/stemcell.tgz", nil)
+			stemcellClient.StemcellImageKeyReturns("image-sha1", nil)
+
+			_, err := m.CreateStemcell("/path/to/image", resourceCloudProps)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(stemcellClient.StemcellImageKeyArgsForCall(0)).To(Equal("/path/to/store/stemcell.tgz"))
+			Expect(stemcellClient.ImageKeyCallCount()).To(Equal(0))
+			Expect(driverClient.AcquireStemcellVMArgsForCall(0)).To(Equal("image-sha1"))
+		})
+
+		It("keys stemcells without an image sha1 by the sha1 hashed while extracting them", func() {
+			stemcellStore.GetByImagePathMappingReturns("/path/to/store/stemcell.tgz", nil)
+			stemcellClient.StemcellImageKeyReturns("", nil)
+			stemcellClient.ExtractStemcellOvfReturns("extracted-path", "extracted-sha1", nil)
+
+			_, err := m.CreateStemcell("local-image-does-not-exist", resourceCloudProps)
+			Expect(err).ToNot(HaveOccurred())
+
+			stemcellKey, _ := driverClient.LockStemcellVMArgsForCall(0)
+			Expect(stemcellKey).To(Equal("extracted-sha1"))
+			Expect(stemcellClient.ExtractStemcellOvfCallCount()).To(Equal(1))
+			clientImportOvfPath, _ := driverClient.ImportOvfArgsForCall(0)
+			Expect(clientImportOvfPath).To(Equal("extracted-path"))
+			stemcellKey, _ = driverClient.RegisterStemcellVMArgsForCall(0)
+			Expect(stemcellKey).To(Equal("extracted-sha1"))
+			Expect(stemcellClient.CleanupCallCount()).To(Equal(1))
+		})
+
+		It("extracts a stemcell without an image sha1 once when it was imported before", func() {
+			stemcellClient.ImageKeyReturns("", nil)
+			driverClient.AcquireStemcellVMReturns("cs-existing-uuid", nil)
+
+			cid, err := m.CreateStemcell("/path/to/image", resourceCloudProps)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cid.AsString()).To(Equal("existing-uuid"))
+			Expect(stemcellClient.ExtractOvfCallCount()).To(Equal(1))
+			Expect(driverClient.AcquireStemcellVMArgsForCall(0)).To(Equal("image-sha1"))
+			Expect(driverClient.ImportOvfCallCount()).To(Equal(0))
+			Expect(stemcellClient.CleanupCallCount()).To(Equal(1))
+		})
+	})
 })
@@ -2,6 +2,9 @@ package action
 
 import (
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
@@ -9,6 +12,8 @@ import (
 
 	"bosh-vmrun-cpi/driver"
 	"bosh-vmrun-cpi/vm"
+	"bosh-vmrun-cpi/vmnet"
+	"bosh-vmrun-cpi/vmx"
 )
 
 type CreateVMMethod struct {
@@ -34,10 +39,10 @@ func NewCreateVMMethod(driverClient driver.Client, agentSettings vm.AgentSetting
 func (c CreateVMMethod) CreateVM(
 	agentID apiv1.AgentID, stemcellCID apiv1.StemcellCID,
 	cloudProps apiv1.VMCloudProps, networks apiv1.Networks,
-	associatedDiskCIDs []apiv1.DiskCID, vmEnv apiv1.VMEnv) (apiv1.VMCID, error) {
+	associatedDiskCIDs []apiv1.DiskCID, vmEnv apiv1.VMEnv) (newVMCID apiv1.VMCID, err error) {
 
 	vmUuid, _ := c.uuidGen.Generate()
-	newVMCID := apiv1.NewVMCID(vmUuid)
+	newVMCID = apiv1.NewVMCID(vmUuid)
 
 	stemcellId := "cs-" + stemcellCID.AsString()
 	vmId := "vm-" + vmUuid
@@ -51,7 +56,45 @@ func (c CreateVMMethod) CreateVM(
 		return newVMCID, err
 	}
 
-	err = c.driverClient.CloneVM(stemcellId, vmId)
+	env, err := vm.NewVMEnv(vmEnv)
+	if err != nil {
+		return newVMCID, err
+	}
+
+	networksProps := map[string]*vm.NetworkProps{}
+	for networkName, network := range networks {
+		networkProps, err := c.agentSettings.GetNetworkSettings(network)
+		if err != nil {
+			return newVMCID, err
+		}
+
+		// LAN segments are keyed by the deployment and bosh network name unless named explicitly, explicitly
+		// named segments are shared across deployments
+		if networkProps.Isolated && networkProps.Name == "" {
+			networkProps.Name = env.SegmentName(networkName)
+		}
+
+		networksProps[networkName] = networkProps
+	}
+
+	err = c.resolveNetworkNames(networks, networksProps)
+	if err != nil {
+		return newVMCID, err
+	}
+
+	portForwards, err := c.portForwards(vmProps, networks)
+	if err != nil {
+		return newVMCID, err
+	}
+
+	// the director never learns the cid of a vm that failed to create, so nothing would release its resources
+	defer func() {
+		if err != nil {
+			c.releaseVMResources(vmId)
+		}
+	}()
+
+	err = c.driverClient.CloneVM(stemcellId, vmId, vmProps.Datastore, vmProps.Disk+vmProps.RAM)
 	if err != nil {
 		return newVMCID, err
 	}
@@ -61,20 +104,43 @@ func (c CreateVMMethod) CreateVM(
 		return newVMCID, err
 	}
 
-	for _, network := range networks {
-		adapterName, macAddress, err := c.agentSettings.GetNetworkSettings(network)
+	if vmProps.Disk_Controller != "" {
+		err = c.driverClient.SetVMDiskController(vmId, vmProps.Disk_Controller)
+		if err != nil {
+			return newVMCID, err
+		}
+	}
+
+	for _, networkName := range orderedNetworkNames(networks) {
+		network := networks[networkName]
+		networkProps := networksProps[networkName]
+
+		macAddress, err := c.driverClient.AllocateMACAddress(vmId)
 		if err != nil {
 			return newVMCID, err
 		}
 
 		network.SetMAC(macAddress)
 
-		err = c.driverClient.SetVMNetworkAdapter(vmId, adapterName, macAddress)
+		// LAN segments have no dhcp server, the agent configures their static ips
+		if !network.IsDynamic() && network.IP() != "" && !networkProps.Isolated {
+			err = c.driverClient.AddDHCPReservation(vmId, macAddress, network.IP())
+			if err != nil {
+				return newVMCID, err
+			}
+		}
+
+		err = c.driverClient.SetVMNetworkAdapter(vmId, networkProps.Name, macAddress, networkProps.Connection_Type, networkProps.Device_Type)
 		if err != nil {
 			return newVMCID, err
 		}
 	}
 
+	err = c.driverClient.AddPortForwards(vmId, portForwards)
+	if err != nil {
+		return newVMCID, err
+	}
+
 	agentEnv := c.agentEnvFactory.ForVM(agentID, newVMCID, networks, vmEnv, c.agentOptions)
 
 	if vmProps.NeedsBootstrap() {
@@ -102,17 +168,21 @@ func (c CreateVMMethod) CreateVM(
 			return newVMCID, err
 		}
 
-		agentEnv.AttachEphemeralDisk("1")
-	}
+		var vmInfo driver.VMInfo
+		vmInfo, err = c.driverClient.GetVMInfo(vmId)
+		if err != nil {
+			return newVMCID, err
+		}
 
-	newIsoPath, err := c.agentSettings.GenerateAgentEnvIso(agentEnv)
-	defer c.agentSettings.Cleanup()
+		diskVMXID := ephemeralDiskVMXID(vmInfo)
+		if diskVMXID == "" {
+			return newVMCID, fmt.Errorf("ephemeral disk of %s not found", vmId)
+		}
 
-	if err != nil {
-		return newVMCID, err
+		agentEnv.AttachEphemeralDisk(vm.EphemeralDiskHint(diskVMXID, vmInfo.SCSIDisks))
 	}
 
-	err = c.driverClient.UpdateVMIso(vmId, newIsoPath)
+	err = writeAgentEnv(c.driverClient, c.agentSettings, vmId, agentEnv, c.driverClient.UsesGuestInfoAgentSettings())
 	if err != nil {
 		return newVMCID, err
 	}
@@ -122,7 +192,141 @@ func (c CreateVMMethod) CreateVM(
 		if err != nil {
 			return newVMCID, err
 		}
+
+		c.discoverDynamicIPs(vmId, networks)
 	}
 
 	return newVMCID, nil
 }
+
+func (c CreateVMMethod) releaseVMResources(vmId string) {
+	err := c.driverClient.RemoveDHCPReservations(vmId)
+	if err != nil {
+		c.logger.Error("cpi", "removing dhcp reservations of failed vm %s: %s", vmId, err)
+	}
+
+	err = c.driverClient.RemovePortForwards(vmId)
+	if err != nil {
+		c.logger.Error("cpi", "removing port forwards of failed vm %s: %s", vmId, err)
+	}
+
+	err = c.driverClient.ReleaseMACAddresses(vmId)
+	if err != nil {
+		c.logger.Error("cpi", "releasing mac addresses of failed vm %s: %s", vmId, err)
+	}
+}
+
+// only custom connections name a vmnet, built-in connection types are resolved by the hypervisor. Display names
+// (ex: NAT) are replaced with their vmnet device, which is what the VMX references
+func (c CreateVMMethod) resolveNetworkNames(networks apiv1.Networks, networksProps map[string]*vm.NetworkProps) error {
+	hostNetworkNames, err := c.driverClient.GetHostNetworkNames()
+	if err != nil {
+		return err
+	}
+
+	if len(hostNetworkNames) == 0 {
+		return nil
+	}
+
+	for _, networkName := range orderedNetworkNames(networks) {
+		networkProps := networksProps[networkName]
+		if networkProps.Connection_Type != vmx.NETWORK_CONNECTION_CUSTOM {
+			continue
+		}
+
+		device, found := hostNetworkNames[networkProps.Name]
+		if !found {
+			validNames := []string{}
+			for hostNetworkName := range hostNetworkNames {
+				validNames = append(validNames, hostNetworkName)
+			}
+			sort.Strings(validNames)
+
+			return fmt.Errorf("network %s: unknown vmnet name %q, valid names: %s", networkName, networkProps.Name, strings.Join(validNames, ", "))
+		}
+
+		networkProps.Name = device
+	}
+
+	return nil
+}
+
+// NICs are added in this order so eth0 is always the default gateway network, followed by the rest by name
+// the ephemeral disk is the one in the ephemeral-disks directory of the vm
+func ephemeralDiskVMXID(vmInfo driver.VMInfo) string {
+	for _, disk := range vmInfo.Disks {
+		if filepath.Base(filepath.Dir(disk.Path)) == "ephemeral-disks" {
+			return disk.ID
+		}
+	}
+
+	return ""
+}
+
+func orderedNetworkNames(networks apiv1.Networks) []string {
+	networkNames := []string{}
+	for networkName := range networks {
+		networkNames = append(networkNames, networkName)
+	}
+
+	sort.Slice(networkNames, func(i, j int) bool {
+		iDefault := networks[networkNames[i]].IsDefaultFor("gateway")
+		jDefault := networks[networkNames[j]].IsDefaultFor("gateway")
+		if iDefault != jDefault {
+			return iDefault
+		}
+
+		return networkNames[i] < networkNames[j]
+	})
+
+	return networkNames
+}
+
+// port forwards target the static IP of their network, or of the default gateway network
+func (c CreateVMMethod) portForwards(vmProps *vm.VMProps, networks apiv1.Networks) ([]vmnet.PortForward, error) {
+	portForwards := []vmnet.PortForward{}
+
+	for _, portForwardProps := range vmProps.Port_Forwards {
+		network := networks.Default()
+		if portForwardProps.Network != "" {
+			var found bool
+			network, found = networks[portForwardProps.Network]
+			if !found {
+				return nil, fmt.Errorf("port forward network not found: %s", portForwardProps.Network)
+			}
+		}
+
+		if network.IsDynamic() || network.IP() == "" {
+			return nil, fmt.Errorf("port forward to guest port %d requires a network with a static ip", portForwardProps.Guest_Port)
+		}
+
+		portForwards = append(portForwards, vmnet.PortForward{
+			Protocol:  portForwardProps.Protocol,
+			HostPort:  portForwardProps.Host_Port,
+			GuestIP:   network.IP(),
+			GuestPort: portForwardProps.Guest_Port,
+		})
+	}
+
+	return portForwards, nil
+}
+
+// vmrun only reports the guest's primary address, which is attributed to the first dynamic network
+// TODO: report it in a CPI API v2 create_vm response once bosh-cpi-go supports API v2, v1 can only log it
+func (c CreateVMMethod) discoverDynamicIPs(vmId string, networks apiv1.Networks) {
+	for _, networkName := range orderedNetworkNames(networks) {
+		network := networks[networkName]
+		if !network.IsDynamic() {
+			continue
+		}
+
+		ipAddress, err := c.driverClient.GetVMIPAddress(vmId)
+		if err != nil {
+			c.logger.Error("cpi", "discovering ip address for dynamic network %s: %s", networkName, err)
+			return
+		}
+
+		c.logger.Info("cpi", "discovered ip address %s for dynamic network %s", ipAddress, networkName)
+		return
+	}
+}
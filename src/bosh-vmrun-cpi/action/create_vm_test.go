@@ -2,11 +2,14 @@ package action_test
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 
 	"github.com/cppforlife/bosh-cpi-go/apiv1"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"bosh-vmrun-cpi/driver"
 	fakedriver "bosh-vmrun-cpi/driver/fakes"
 	fakevm "bosh-vmrun-cpi/vm/fakes"
 
@@ -14,23 +17,42 @@ import (
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 
 	"bosh-vmrun-cpi/action"
+	"bosh-vmrun-cpi/vm"
+	"bosh-vmrun-cpi/vmnet"
 )
 
 var _ = Describe("CreateVM", func() {
+	var (
+		driverClient       *fakedriver.FakeClient
+		agentSettings      *fakevm.FakeAgentSettings
+		agentEnvFactory    apiv1.AgentEnvFactory
+		resourceCloudProps apiv1.CloudPropsImpl
+		networks           apiv1.Networks
+		vmEnv              apiv1.VMEnv
+		createVM           func() (apiv1.VMCID, error)
+	)
+
+	BeforeEach(func() {
+		driverClient = &fakedriver.FakeClient{}
+		agentSettings = &fakevm.FakeAgentSettings{}
+		agentEnvFactory = apiv1.NewAgentEnvFactory()
+
+		resourceCloudProps = apiv1.CloudPropsImpl{}
+		json.Unmarshal([]byte(`{}`), &resourceCloudProps)
+		networks = apiv1.Networks{}
+		vmEnv = apiv1.NewVMEnv(map[string]interface{}{})
+
+		driverClient.HasVMReturns(true)
+
+		m := action.NewCreateVMMethod(driverClient, agentSettings, apiv1.AgentOptions{}, agentEnvFactory, &fakeuuid.FakeGenerator{}, &fakelogger.FakeLogger{})
+		createVM = func() (apiv1.VMCID, error) {
+			return m.CreateVM(apiv1.NewAgentID("agent-0"), apiv1.NewStemcellCID("stemcell"), resourceCloudProps, networks, []apiv1.DiskCID{}, vmEnv)
+		}
+	})
+
 	It("runs the cpi", func() {
-		driverClient := &fakedriver.FakeClient{}
-		agentSettings := &fakevm.FakeAgentSettings{}
-		uuidGen := &fakeuuid.FakeGenerator{}
-		agentOptions := apiv1.AgentOptions{}
-		disks := []apiv1.DiskCID{}
-		logger := &fakelogger.FakeLogger{}
-		agentEnvFactory := apiv1.NewAgentEnvFactory()
-
-		agentId := apiv1.NewAgentID("agent-0")
-		stemcellCid := apiv1.NewStemcellCID("stemcell")
-		vmEnv := apiv1.NewVMEnv(map[string]interface{}{"A": "B"})
-
-		var resourceCloudProps apiv1.CloudPropsImpl
+		vmEnv = apiv1.NewVMEnv(map[string]interface{}{"A": "B"})
+
 		json.Unmarshal([]byte(`{
 			"cpu":  1,
 			"ram":  1024,
@@ -45,7 +67,6 @@ var _ = Describe("CreateVM", func() {
 			}
 		}`), &resourceCloudProps)
 
-		networks := apiv1.Networks{}
 		networks.UnmarshalJSON([]byte(`{
 		  "first":{
 		    "cloud_properties":{
@@ -55,15 +76,16 @@ var _ = Describe("CreateVM", func() {
 		  }
 		}`))
 
-		driverClient.HasVMReturns(true)
 		driverClient.NeedsVMNameChangeReturns(false)
 
 		agentSettings.GenerateAgentEnvIsoReturns("iso-path", nil)
-		agentSettings.GetNetworkSettingsReturnsOnCall(0, "VM Network", "00:11:22:33:44:55", nil)
-		agentSettings.GetNetworkSettingsReturnsOnCall(1, "BOSH Network", "55:44:33:22:11:00", nil)
+		agentSettings.GetNetworkSettingsReturnsOnCall(0, &vm.NetworkProps{Name: "VM Network", Connection_Type: "custom", Device_Type: "e1000"}, nil)
+		agentSettings.GetNetworkSettingsReturnsOnCall(1, &vm.NetworkProps{Name: "BOSH Network", Connection_Type: "custom", Device_Type: "vmxnet3"}, nil)
+		driverClient.AllocateMACAddressReturnsOnCall(0, "00:11:22:33:44:55", nil)
+		driverClient.AllocateMACAddressReturnsOnCall(1, "55:44:33:22:11:00", nil)
+		driverClient.GetVMInfoReturns(vmInfoWithDisks("scsi0:0", "/vms/vm-fake-uuid-0/vm-fake-uuid-0.vmdk", "scsi0:1", "/vms/vm-fake-uuid-0/ephemeral-disks/vm-fake-uuid-0.vmdk"), nil)
 
-		m := action.NewCreateVMMethod(driverClient, agentSettings, agentOptions, agentEnvFactory, uuidGen, logger)
-		cid, err := m.CreateVM(agentId, stemcellCid, resourceCloudProps, networks, disks, vmEnv)
+		cid, err := createVM()
 
 		Expect(err).ToNot(HaveOccurred())
 		Expect(cid.AsString()).To(Equal("fake-uuid-0"))
@@ -71,19 +93,25 @@ var _ = Describe("CreateVM", func() {
 		driverStemcellId := driverClient.HasVMArgsForCall(0)
 		Expect(driverStemcellId).To(Equal("cs-stemcell"))
 
-		driverStemcellId, driverVMID := driverClient.CloneVMArgsForCall(0)
+		driverStemcellId, driverVMID, datastoreName, requiredMB := driverClient.CloneVMArgsForCall(0)
 		Expect(driverStemcellId).To(Equal("cs-stemcell"))
 		Expect(driverVMID).To(Equal("vm-fake-uuid-0"))
+		Expect(datastoreName).To(Equal(""))
+		Expect(requiredMB).To(Equal(2048 + 1024))
 
 		driverVMID, vmPropsCPU, vmPropsRAM := driverClient.SetVMResourcesArgsForCall(0)
 		Expect(driverVMID).To(Equal("vm-fake-uuid-0"))
 		Expect(vmPropsCPU).To(Equal(1))
 		Expect(vmPropsRAM).To(Equal(1024))
 
-		driverVMID, adapterNetworkName, macAddress := driverClient.SetVMNetworkAdapterArgsForCall(0)
+		Expect(driverClient.AllocateMACAddressArgsForCall(0)).To(Equal("vm-fake-uuid-0"))
+
+		driverVMID, adapterNetworkName, macAddress, connectionType, deviceType := driverClient.SetVMNetworkAdapterArgsForCall(0)
 		Expect(driverVMID).To(Equal("vm-fake-uuid-0"))
 		Expect(adapterNetworkName).To(Equal("VM Network"))
 		Expect(macAddress).To(Equal("00:11:22:33:44:55"))
+		Expect(connectionType).To(Equal("custom"))
+		Expect(deviceType).To(Equal("e1000"))
 
 		driverVMID, a, _, _, _, _, _, _, _ := driverClient.BootstrapVMArgsForCall(0)
 		Expect(driverVMID).To(Equal("vm-fake-uuid-0"))
@@ -113,5 +141,257 @@ var _ = Describe("CreateVM", func() {
 
 		driverVMID = driverClient.StartVMArgsForCall(0)
 		Expect(driverVMID).To(Equal("vm-fake-uuid-0"))
+
+		Expect(driverClient.GetVMIPAddressCallCount()).To(Equal(0))
+	})
+
+	It("discovers the guest ip for dynamic networks", func() {
+		networks.UnmarshalJSON([]byte(`{
+		  "first":{
+		    "type":"dynamic",
+		    "cloud_properties":{"name":"VM Network"}
+		  }
+		}`))
+
+		driverClient.GetVMIPAddressReturns("192.168.80.128", nil)
+		agentSettings.GetNetworkSettingsReturns(&vm.NetworkProps{Name: "VM Network", Connection_Type: "custom", Device_Type: "vmxnet3"}, nil)
+		driverClient.AllocateMACAddressReturns("00:11:22:33:44:55", nil)
+
+		_, err := createVM()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(driverClient.AddDHCPReservationCallCount()).To(Equal(0))
+		Expect(driverClient.GetVMIPAddressCallCount()).To(Equal(1))
+		Expect(driverClient.GetVMIPAddressArgsForCall(0)).To(Equal("vm-fake-uuid-0"))
+	})
+
+	It("fails before cloning when a network name is not a host vmnet", func() {
+		networks.UnmarshalJSON([]byte(`{
+		  "first":{
+		    "cloud_properties":{"name":"vmnet9"}
+		  }
+		}`))
+
+		driverClient.GetHostNetworkNamesReturns(map[string]string{"vmnet8": "vmnet8", "vmnet1": "vmnet1"}, nil)
+		agentSettings.GetNetworkSettingsReturns(&vm.NetworkProps{Name: "vmnet9", Connection_Type: "custom", Device_Type: "vmxnet3"}, nil)
+
+		_, err := createVM()
+		Expect(err).To(MatchError(`network first: unknown vmnet name "vmnet9", valid names: vmnet1, vmnet8`))
+
+		Expect(driverClient.CloneVMCallCount()).To(Equal(0))
+	})
+
+	It("resolves host display names to their vmnet device and skips built-in connection types", func() {
+		networks.UnmarshalJSON([]byte(`{
+		  "first":{
+		    "cloud_properties":{"name":"NAT"}
+		  }
+		}`))
+
+		driverClient.GetHostNetworkNamesReturns(map[string]string{"NAT": "vmnet8", "vmnet8": "vmnet8"}, nil)
+		agentSettings.GetNetworkSettingsReturnsOnCall(0, &vm.NetworkProps{Name: "NAT", Connection_Type: "custom", Device_Type: "vmxnet3"}, nil)
+
+		_, err := createVM()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(driverClient.CloneVMCallCount()).To(Equal(1))
+
+		_, adapterNetworkName, _, _, _ := driverClient.SetVMNetworkAdapterArgsForCall(0)
+		Expect(adapterNetworkName).To(Equal("vmnet8"))
+
+		agentSettings.GetNetworkSettingsReturnsOnCall(1, &vm.NetworkProps{Name: "", Connection_Type: "bridged", Device_Type: "vmxnet3"}, nil)
+
+		_, err = createVM()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(driverClient.CloneVMCallCount()).To(Equal(2))
+	})
+
+	It("matches host network names exactly", func() {
+		networks.UnmarshalJSON([]byte(`{
+		  "first":{
+		    "cloud_properties":{"name":"nat"}
+		  }
+		}`))
+
+		driverClient.GetHostNetworkNamesReturns(map[string]string{"NAT": "vmnet8", "vmnet8": "vmnet8"}, nil)
+		agentSettings.GetNetworkSettingsReturns(&vm.NetworkProps{Name: "nat", Connection_Type: "custom", Device_Type: "vmxnet3"}, nil)
+
+		_, err := createVM()
+		Expect(err).To(MatchError(`network first: unknown vmnet name "nat", valid names: NAT, vmnet8`))
+		Expect(driverClient.CloneVMCallCount()).To(Equal(0))
+	})
+
+	It("forwards host ports to the static ip of the default network", func() {
+		json.Unmarshal([]byte(`{
+			"port_forwards": [
+				{"host_port": 8443, "guest_port": 443},
+				{"host_port": 5353, "guest_port": 53, "protocol": "udp", "network": "second"}
+			]
+		}`), &resourceCloudProps)
+
+		networks.UnmarshalJSON([]byte(`{
+		  "first":{"type":"manual","ip":"192.168.80.10","default":["dns","gateway"],"cloud_properties":{"name":"vmnet8"}},
+		  "second":{"type":"manual","ip":"192.168.90.10","cloud_properties":{"name":"vmnet9"}}
+		}`))
+
+		agentSettings.GetNetworkSettingsReturns(&vm.NetworkProps{Name: "vmnet8", Connection_Type: "custom", Device_Type: "vmxnet3"}, nil)
+
+		_, err := createVM()
+		Expect(err).ToNot(HaveOccurred())
+
+		vmId, portForwards := driverClient.AddPortForwardsArgsForCall(0)
+		Expect(vmId).To(Equal("vm-fake-uuid-0"))
+		Expect(portForwards).To(Equal([]vmnet.PortForward{
+			{Protocol: "tcp", HostPort: 8443, GuestIP: "192.168.80.10", GuestPort: 443},
+			{Protocol: "udp", HostPort: 5353, GuestIP: "192.168.90.10", GuestPort: 53},
+		}))
+	})
+
+	It("fails before cloning when a port forward has no static ip", func() {
+		json.Unmarshal([]byte(`{"port_forwards": [{"host_port": 8080, "guest_port": 80}]}`), &resourceCloudProps)
+
+		networks.UnmarshalJSON([]byte(`{
+		  "first":{"type":"dynamic","default":["gateway"],"cloud_properties":{"name":"vmnet8"}}
+		}`))
+
+		agentSettings.GetNetworkSettingsReturns(&vm.NetworkProps{Name: "vmnet8", Connection_Type: "custom", Device_Type: "vmxnet3"}, nil)
+
+		_, err := createVM()
+		Expect(err).To(MatchError("port forward to guest port 80 requires a network with a static ip"))
+		Expect(driverClient.CloneVMCallCount()).To(Equal(0))
+	})
+
+	It("adds NICs for the default gateway network first, then by name", func() {
+		networks.UnmarshalJSON([]byte(`{
+		  "c":{"type":"manual","ip":"10.0.3.10","cloud_properties":{"name":"vmnet3"}},
+		  "z":{"type":"manual","ip":"10.0.26.10","default":["dns","gateway"],"cloud_properties":{"name":"vmnet26"}},
+		  "a":{"type":"manual","ip":"10.0.1.10","cloud_properties":{"name":"vmnet1"}}
+		}`))
+
+		agentSettings.GetNetworkSettingsStub = func(network apiv1.Network) (*vm.NetworkProps, error) {
+			return vm.NewNetworkProps(network.CloudProps())
+		}
+
+		_, err := createVM()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(driverClient.SetVMNetworkAdapterCallCount()).To(Equal(3))
+		for i, expectedName := range []string{"vmnet26", "vmnet1", "vmnet3"} {
+			_, adapterNetworkName, _, _, _ := driverClient.SetVMNetworkAdapterArgsForCall(i)
+			Expect(adapterNetworkName).To(Equal(expectedName))
+
+			_, _, ipAddress := driverClient.AddDHCPReservationArgsForCall(i)
+			Expect(ipAddress).To(Equal(networks[[]string{"z", "a", "c"}[i]].IP()))
+		}
+	})
+
+	It("puts isolated networks on LAN segments named after the deployment and network", func() {
+		networks.UnmarshalJSON([]byte(`{
+		  "private":{"type":"manual","ip":"10.0.0.10","cloud_properties":{"isolated":true}}
+		}`))
+
+		driverClient.GetHostNetworkNamesReturns(map[string]string{"vmnet8": "vmnet8"}, nil)
+		agentSettings.GetNetworkSettingsStub = func(network apiv1.Network) (*vm.NetworkProps, error) {
+			return vm.NewNetworkProps(network.CloudProps())
+		}
+
+		vmEnv = apiv1.NewVMEnv(map[string]interface{}{
+			"bosh": map[string]interface{}{"groups": []interface{}{"director-1", "deployment-1", "web"}},
+		})
+
+		_, err := createVM()
+		Expect(err).ToNot(HaveOccurred())
+
+		_, adapterNetworkName, _, connectionType, _ := driverClient.SetVMNetworkAdapterArgsForCall(0)
+		Expect(adapterNetworkName).To(Equal("director-1/deployment-1/private"))
+		Expect(connectionType).To(Equal("pvn"))
+		Expect(driverClient.AddDHCPReservationCallCount()).To(Equal(0))
+	})
+
+	It("releases the mac addresses of a vm that fails to create", func() {
+		networks.UnmarshalJSON([]byte(`{
+		  "first":{"type":"manual","ip":"10.0.0.10","cloud_properties":{"name":"vmnet8"}}
+		}`))
+
+		driverClient.AllocateMACAddressReturns("00:50:56:00:00:01", nil)
+		driverClient.SetVMNetworkAdapterReturns(errors.New("adapter-err"))
+		agentSettings.GetNetworkSettingsStub = func(network apiv1.Network) (*vm.NetworkProps, error) {
+			return vm.NewNetworkProps(network.CloudProps())
+		}
+
+		_, err := createVM()
+		Expect(err).To(MatchError("adapter-err"))
+
+		Expect(driverClient.ReleaseMACAddressesCallCount()).To(Equal(1))
+		Expect(driverClient.ReleaseMACAddressesArgsForCall(0)).To(Equal("vm-fake-uuid-0"))
+		Expect(driverClient.RemoveDHCPReservationsArgsForCall(0)).To(Equal("vm-fake-uuid-0"))
+	})
+
+	It("removes the port forwards of a vm that fails to start", func() {
+		json.Unmarshal([]byte(`{"port_forwards": [{"host_port": 8443, "guest_port": 443}]}`), &resourceCloudProps)
+
+		networks.UnmarshalJSON([]byte(`{
+		  "first":{"type":"manual","ip":"10.0.0.10","cloud_properties":{"name":"vmnet8"}}
+		}`))
+
+		driverClient.StartVMReturns(errors.New("start-err"))
+		agentSettings.GetNetworkSettingsStub = func(network apiv1.Network) (*vm.NetworkProps, error) {
+			return vm.NewNetworkProps(network.CloudProps())
+		}
+
+		_, err := createVM()
+		Expect(err).To(MatchError("start-err"))
+
+		Expect(driverClient.AddPortForwardsCallCount()).To(Equal(1))
+		Expect(driverClient.RemovePortForwardsCallCount()).To(Equal(1))
+		Expect(driverClient.RemovePortForwardsArgsForCall(0)).To(Equal("vm-fake-uuid-0"))
+	})
+
+	It("writes the agent env to guestinfo when configured", func() {
+		driverClient.UsesGuestInfoAgentSettingsReturns(true)
+		agentSettings.GenerateAgentEnvGuestInfoReturns("e30=", nil)
+
+		_, err := createVM()
+		Expect(err).ToNot(HaveOccurred())
+
+		envBytes, err := agentSettings.GenerateAgentEnvGuestInfoArgsForCall(0).AsBytes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(envBytes)).To(ContainSubstring(`"agent_id":"agent-0"`))
+		vmId, name, value := driverClient.SetVMGuestInfoArgsForCall(0)
+		Expect(vmId).To(Equal("vm-fake-uuid-0"))
+		Expect(name).To(Equal("bosh.agent_env"))
+		Expect(value).To(Equal("e30="))
+
+		Expect(agentSettings.GenerateAgentEnvIsoCallCount()).To(Equal(0))
+		Expect(driverClient.UpdateVMIsoCallCount()).To(Equal(0))
+	})
+
+	It("hints the ephemeral disk at the device it was attached to", func() {
+		json.Unmarshal([]byte(`{"disk": 2048}`), &resourceCloudProps)
+
+		driverClient.GetVMInfoReturns(vmInfoWithDisks("sata0:0", "/vms/vm-fake-uuid-0/vm-fake-uuid-0.vmdk", "sata0:1", "/vms/vm-fake-uuid-0/ephemeral-disks/vm-fake-uuid-0.vmdk"), nil)
+
+		_, err := createVM()
+		Expect(err).ToNot(HaveOccurred())
+
+		envBytes, err := agentSettings.GenerateAgentEnvIsoArgsForCall(0).AsBytes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(envBytes)).To(ContainSubstring(`"ephemeral":{"path":"/dev/sdb","volume_id":"1","lun":"0"}`))
 	})
 })
+
+// vmInfoWithDisks returns vm info with the disks given as pairs of vmx id and path
+func vmInfoWithDisks(idsAndPaths ...string) driver.VMInfo {
+	vmInfo := driver.VMInfo{}
+	for i := 0; i+1 < len(idsAndPaths); i += 2 {
+		vmInfo.Disks = append(vmInfo.Disks, struct {
+			ID   string
+			Path string
+		}{ID: idsAndPaths[i], Path: idsAndPaths[i+1]})
+
+		if strings.HasPrefix(idsAndPaths[i], "scsi") {
+			vmInfo.SCSIDisks++
+		}
+	}
+
+	return vmInfo
+}
@@ -22,7 +22,19 @@ func NewDeleteStemcellMethod(driverClient driver.Client, logger boshlog.Logger)
 
 func (c DeleteStemcellMethod) DeleteStemcell(stemcellCid apiv1.StemcellCID) error {
 	stemcellId := "cs-" + stemcellCid.AsString()
-	err := c.driverClient.DestroyVM(stemcellId)
+
+	unused, err := c.driverClient.ReleaseStemcellVM(stemcellId)
+	if err != nil {
+		c.logger.Error("delete-stemcell", fmt.Sprintf("failed to release stemcell. cid: %s", stemcellCid))
+		return err
+	}
+
+	if !unused {
+		c.logger.Debug("delete-stemcell", fmt.Sprintf("stemcell is still referenced, keeping it. cid: %s", stemcellCid))
+		return nil
+	}
+
+	err = c.driverClient.DestroyVM(stemcellId)
 	if err != nil {
 		c.logger.Error("delete-stemcell", fmt.Sprintf("failed to delete stemcell. cid: %s", stemcellCid))
 		return err
@@ -0,0 +1,55 @@
+package action_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakedriver "bosh-vmrun-cpi/driver/fakes"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/action"
+)
+
+var _ = Describe("DeleteStemcell", func() {
+	var (
+		driverClient *fakedriver.FakeClient
+		m            action.DeleteStemcellMethod
+	)
+
+	BeforeEach(func() {
+		driverClient = &fakedriver.FakeClient{}
+		m = action.NewDeleteStemcellMethod(driverClient, &fakelogger.FakeLogger{})
+	})
+
+	It("destroys the stemcell vm when it is no longer referenced", func() {
+		driverClient.ReleaseStemcellVMReturns(true, nil)
+
+		err := m.DeleteStemcell(apiv1.NewStemcellCID("stemcell-uuid"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(driverClient.ReleaseStemcellVMArgsForCall(0)).To(Equal("cs-stemcell-uuid"))
+		Expect(driverClient.DestroyVMArgsForCall(0)).To(Equal("cs-stemcell-uuid"))
+	})
+
+	It("keeps the stemcell vm while other uploads reference it", func() {
+		driverClient.ReleaseStemcellVMReturns(false, nil)
+
+		err := m.DeleteStemcell(apiv1.NewStemcellCID("stemcell-uuid"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(driverClient.DestroyVMCallCount()).To(Equal(0))
+	})
+
+	It("does not destroy the stemcell vm when releasing fails", func() {
+		driverClient.ReleaseStemcellVMReturns(false, errors.New("registry locked"))
+
+		err := m.DeleteStemcell(apiv1.NewStemcellCID("stemcell-uuid"))
+		Expect(err).To(MatchError("registry locked"))
+
+		Expect(driverClient.DestroyVMCallCount()).To(Equal(0))
+	})
+})
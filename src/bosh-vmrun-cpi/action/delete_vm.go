@@ -27,5 +27,23 @@ func (c DeleteVMMethod) DeleteVM(vmCid apiv1.VMCID) error {
 		return err
 	}
 
+	err = c.driverClient.RemoveDHCPReservations(vmId)
+	if err != nil {
+		c.logger.Error("cpi", "removing dhcp reservations: %s\n", vmCid)
+		return err
+	}
+
+	err = c.driverClient.RemovePortForwards(vmId)
+	if err != nil {
+		c.logger.Error("cpi", "removing port forwards: %s\n", vmCid)
+		return err
+	}
+
+	err = c.driverClient.ReleaseMACAddresses(vmId)
+	if err != nil {
+		c.logger.Error("cpi", "releasing mac addresses: %s\n", vmCid)
+		return err
+	}
+
 	return nil
 }
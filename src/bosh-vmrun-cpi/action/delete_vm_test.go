@@ -0,0 +1,44 @@
+package action_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakedriver "bosh-vmrun-cpi/driver/fakes"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/action"
+)
+
+var _ = Describe("DeleteVM", func() {
+	var (
+		driverClient *fakedriver.FakeClient
+		m            action.DeleteVMMethod
+	)
+
+	BeforeEach(func() {
+		driverClient = &fakedriver.FakeClient{}
+		m = action.NewDeleteVMMethod(driverClient, &fakelogger.FakeLogger{})
+	})
+
+	It("destroys the vm and releases its host resources", func() {
+		err := m.DeleteVM(apiv1.NewVMCID("vm-uuid"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(driverClient.DestroyVMArgsForCall(0)).To(Equal("vm-vm-uuid"))
+		Expect(driverClient.RemoveDHCPReservationsArgsForCall(0)).To(Equal("vm-vm-uuid"))
+		Expect(driverClient.RemovePortForwardsArgsForCall(0)).To(Equal("vm-vm-uuid"))
+		Expect(driverClient.ReleaseMACAddressesArgsForCall(0)).To(Equal("vm-vm-uuid"))
+	})
+
+	It("fails when releasing the mac addresses fails", func() {
+		driverClient.ReleaseMACAddressesReturns(errors.New("release-err"))
+
+		err := m.DeleteVM(apiv1.NewVMCID("vm-uuid"))
+		Expect(err).To(MatchError("release-err"))
+	})
+})
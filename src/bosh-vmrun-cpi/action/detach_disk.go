@@ -1,6 +1,7 @@
 package action
 
 import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/cppforlife/bosh-cpi-go/apiv1"
 
 	"bosh-vmrun-cpi/driver"
@@ -11,21 +12,35 @@ type DetachDiskMethod struct {
 	driverClient    driver.Client
 	agentSettings   vm.AgentSettings
 	agentEnvFactory apiv1.AgentEnvFactory
+	logger          boshlog.Logger
 }
 
-func NewDetachDiskMethod(driverClient driver.Client, agentSettings vm.AgentSettings) DetachDiskMethod {
+func NewDetachDiskMethod(driverClient driver.Client, agentSettings vm.AgentSettings, logger boshlog.Logger) DetachDiskMethod {
 	return DetachDiskMethod{
 		driverClient:  driverClient,
 		agentSettings: agentSettings,
+		logger:        logger,
 	}
 }
 
 func (c DetachDiskMethod) DetachDisk(vmCID apiv1.VMCID, diskCID apiv1.DiskCID) error {
 	var err error
-	var agentEnv apiv1.AgentEnv
 	vmId := "vm-" + vmCID.AsString()
 	diskId := "disk-" + diskCID.AsString()
 
+	// a disk unplugged before the agent env update fails is detached again with the vm powered off, which also
+	// retries the agent env update
+	err = c.driverClient.HotDetachDisk(vmId, diskId)
+	if err == nil {
+		err = c.updateAgentEnv(vmId, diskCID)
+		if err == nil {
+			return nil
+		}
+		c.logger.Error("cpi", "updating agent env after hot detach of %s, detaching with the vm powered off: %s", diskId, err)
+	} else {
+		c.logger.Info("cpi", "hot detach of %s unavailable, detaching with the vm powered off: %s", diskId, err)
+	}
+
 	err = c.driverClient.StopVM(vmId)
 	if err != nil {
 		return err
@@ -36,29 +51,26 @@ func (c DetachDiskMethod) DetachDisk(vmCID apiv1.VMCID, diskCID apiv1.DiskCID) e
 		return err
 	}
 
-	currentIsoPath := c.driverClient.GetVMIsoPath(vmId)
-	agentEnv, err = c.agentSettings.GetIsoAgentEnv(currentIsoPath)
+	err = c.updateAgentEnv(vmId, diskCID)
 	if err != nil {
 		return err
 	}
 
-	agentEnv.DetachPersistentDisk(diskCID)
-
-	envIsoPath, err := c.agentSettings.GenerateAgentEnvIso(agentEnv)
+	err = c.driverClient.StartVM(vmId)
 	if err != nil {
 		return err
 	}
 
-	err = c.driverClient.UpdateVMIso(vmId, envIsoPath)
-	if err != nil {
-		return err
-	}
-	c.agentSettings.Cleanup()
+	return nil
+}
 
-	err = c.driverClient.StartVM(vmId)
+func (c DetachDiskMethod) updateAgentEnv(vmId string, diskCID apiv1.DiskCID) error {
+	agentEnv, usesGuestInfo, err := readAgentEnv(c.driverClient, c.agentSettings, vmId)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	agentEnv.DetachPersistentDisk(diskCID)
+
+	return writeAgentEnv(c.driverClient, c.agentSettings, vmId, agentEnv, usesGuestInfo)
 }
@@ -0,0 +1,81 @@
+package action_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakedriver "bosh-vmrun-cpi/driver/fakes"
+	fakevm "bosh-vmrun-cpi/vm/fakes"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/action"
+	"bosh-vmrun-cpi/vm"
+)
+
+var _ = Describe("DetachDisk", func() {
+	var (
+		driverClient  *fakedriver.FakeClient
+		agentSettings *fakevm.FakeAgentSettings
+		m             action.DetachDiskMethod
+	)
+
+	BeforeEach(func() {
+		driverClient = &fakedriver.FakeClient{}
+		agentSettings = &fakevm.FakeAgentSettings{}
+		logger := &fakelogger.FakeLogger{}
+
+		agentEnv := apiv1.AgentEnvFactory{}.ForVM(apiv1.NewAgentID("agent-0"), apiv1.NewVMCID("foo"), apiv1.Networks{}, apiv1.VMEnv{}, apiv1.AgentOptions{})
+		agentEnv.AttachPersistentDisk(apiv1.NewDiskCID("bar"), vm.PersistentDiskHint("scsi0:2", 1))
+
+		agentSettings.GetIsoAgentEnvReturns(agentEnv, nil)
+		agentSettings.GenerateAgentEnvIsoReturns("iso-path", nil)
+
+		m = action.NewDetachDiskMethod(driverClient, agentSettings, logger)
+	})
+
+	It("unplugs disks from running vms", func() {
+		Expect(m.DetachDisk(apiv1.NewVMCID("foo"), apiv1.NewDiskCID("bar"))).To(Succeed())
+
+		vmId, diskId := driverClient.HotDetachDiskArgsForCall(0)
+		Expect(vmId).To(Equal("vm-foo"))
+		Expect(diskId).To(Equal("disk-bar"))
+
+		agentEnvBytes, err := agentSettings.GenerateAgentEnvIsoArgsForCall(0).AsBytes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(agentEnvBytes)).ToNot(ContainSubstring(`"bar"`))
+
+		Expect(driverClient.UpdateVMIsoCallCount()).To(Equal(1))
+		Expect(driverClient.StopVMCallCount()).To(Equal(0))
+		Expect(driverClient.DetachDiskCallCount()).To(Equal(0))
+		Expect(driverClient.StartVMCallCount()).To(Equal(0))
+	})
+
+	It("falls back to detaching with the vm powered off", func() {
+		driverClient.HotDetachDiskReturns(errors.New("vm is not running"))
+
+		Expect(m.DetachDisk(apiv1.NewVMCID("foo"), apiv1.NewDiskCID("bar"))).To(Succeed())
+
+		Expect(driverClient.StopVMCallCount()).To(Equal(1))
+		vmId, diskId := driverClient.DetachDiskArgsForCall(0)
+		Expect(vmId).To(Equal("vm-foo"))
+		Expect(diskId).To(Equal("disk-bar"))
+		Expect(driverClient.UpdateVMIsoCallCount()).To(Equal(1))
+		Expect(driverClient.StartVMCallCount()).To(Equal(1))
+	})
+
+	It("detaches with the vm powered off when the agent env update after unplugging fails", func() {
+		driverClient.UpdateVMIsoReturnsOnCall(0, errors.New("iso-err"))
+
+		Expect(m.DetachDisk(apiv1.NewVMCID("foo"), apiv1.NewDiskCID("bar"))).To(Succeed())
+
+		Expect(driverClient.HotDetachDiskCallCount()).To(Equal(1))
+		Expect(driverClient.StopVMCallCount()).To(Equal(1))
+		Expect(driverClient.DetachDiskCallCount()).To(Equal(1))
+		Expect(driverClient.UpdateVMIsoCallCount()).To(Equal(2))
+		Expect(driverClient.StartVMCallCount()).To(Equal(1))
+	})
+})
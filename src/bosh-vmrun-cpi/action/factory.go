@@ -77,8 +77,8 @@ func (f Factory) New(_ apiv1.CallContext) (apiv1.CPI, error) {
 		NewHasVMMethod(f.driverClient),
 		NewSetVMMetadataMethod(f.driverClient, f.logger),
 		NewCreateDiskMethod(f.driverClient, f.uuidGen),
-		NewAttachDiskMethod(f.driverClient, f.agentSettings),
-		NewDetachDiskMethod(f.driverClient, f.agentSettings),
+		NewAttachDiskMethod(f.driverClient, f.agentSettings, f.logger),
+		NewDetachDiskMethod(f.driverClient, f.agentSettings, f.logger),
 		NewDeleteDiskMethod(f.driverClient, f.logger),
 		NewInfoMethod(),
 	}, nil
@@ -12,6 +12,6 @@ func NewInfoMethod() InfoMethod {
 
 func (c InfoMethod) Info() (apiv1.Info, error) {
 	return apiv1.Info{
-		StemcellFormats: []string{"general-ovf", "vsphere-ovf"},
+		StemcellFormats: []string{"general-ovf", "vsphere-ovf", "general-ova", "vsphere-ova"},
 	}, nil
 }
@@ -21,12 +21,17 @@ import (
 	"bosh-vmrun-cpi/driver"
 	"bosh-vmrun-cpi/stemcell"
 	"bosh-vmrun-cpi/vm"
+	"bosh-vmrun-cpi/vmdk"
+	"bosh-vmrun-cpi/vmnet"
 	"bosh-vmrun-cpi/vmx"
 )
 
 var (
 	configPathOpt       = flag.String("configPath", "", "Path to configuration file")
 	configBase64JsonOpt = flag.String("configBase64JSON", "", "Base64-encoded JSON string of configuration")
+	keepVersionsOpt     = flag.Int("keepVersions", 0, "gc-stemcell-store: number of versions to keep per stemcell name")
+	maxUnusedDaysOpt    = flag.Int("maxUnusedDays", 0, "gc-stemcell-store: days after which unreferenced stemcells and mappings are removed")
+	dryRunOpt           = flag.Bool("dryRun", false, "gc-stemcell-store: list what would be removed without removing it")
 	versionOpt          = flag.Bool("version", false, "Version")
 
 	//set by X build flag
@@ -88,10 +93,30 @@ func main() {
 	stemcellConfig := stemcell.NewConfig(cpiConfig)
 	retryFileLock := driver.NewRetryFileLock(logger)
 
-	ovftoolRunner := driver.NewOvftoolRunner(driverConfig.OvftoolPath(), cmdRunner, logger)
-	if err = ovftoolRunner.Configure(); err != nil {
-		logger.ErrorWithDetails("main", "ovftool is invalid", err)
-		os.Exit(1)
+	//maintenance of the stemcell store does not need vmrun
+	if flag.Arg(0) == "gc-stemcell-store" {
+		importedImageKeys, err := driver.NewStemcellRegistry(driverConfig, retryFileLock, logger).Keys()
+		if err != nil {
+			logger.ErrorWithDetails("main", "reading imported stemcells", err)
+			os.Exit(1)
+		}
+
+		retentionPolicy := stemcell.RetentionPolicy{
+			KeepVersions:      *keepVersionsOpt,
+			MaxUnusedAge:      time.Duration(*maxUnusedDaysOpt) * 24 * time.Hour,
+			ImportedImageKeys: importedImageKeys,
+		}
+
+		removedPaths, err := stemcell.NewStemcellStore(stemcellConfig, retryFileLock, compressor, fs, logger).GarbageCollect(retentionPolicy, *dryRunOpt)
+		if err != nil {
+			logger.ErrorWithDetails("main", "garbage collecting stemcell store", err)
+			os.Exit(1)
+		}
+
+		for _, removedPath := range removedPaths {
+			fmt.Println(removedPath)
+		}
+		os.Exit(0)
 	}
 
 	vmrunRunner := driver.NewVmrunRunner(driverConfig.VmrunPath(), retryFileLock, logger)
@@ -100,6 +125,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	//ovftool is only needed to clone on player, unless it imports OVFs and creates disks
+	nativeOvfImporter := driverConfig.OvfImporter() == config.OVF_IMPORTER_NATIVE
+	ovftoolRunner := driver.NewOvftoolRunner(driverConfig.OvftoolPath(), cmdRunner, logger)
+	if !nativeOvfImporter || vmrunRunner.IsPlayer() {
+		if err = ovftoolRunner.Configure(); err != nil {
+			logger.ErrorWithDetails("main", "ovftool is invalid", err)
+			os.Exit(1)
+		}
+	}
+
 	var cloneRunner driver.CloneRunner
 	if vmrunRunner.IsPlayer() {
 		cloneRunner = ovftoolRunner
@@ -108,9 +143,25 @@ func main() {
 	}
 
 	vmxBuilder := vmx.NewVmxBuilder(logger)
-	driverClient := driver.NewClient(vmrunRunner, ovftoolRunner, cloneRunner, vmxBuilder, driverConfig, logger)
+	vmdkReader := vmdk.NewVmdkReader(logger)
+	vmdkWriter := vmdk.NewVmdkWriter(logger)
+
+	var ovfImporter driver.OvfImporter = ovftoolRunner
+	var diskCreator driver.DiskCreator = ovftoolRunner
+	if nativeOvfImporter {
+		ovfImporter = driver.NewNativeOvfImporter(vmxBuilder, vmdkReader, vmdkWriter, logger)
+		diskCreator = driver.NewNativeDiskCreator(vmdkWriter)
+	}
+
+	datastorePlacer := driver.NewDatastorePlacer(driverConfig, retryFileLock, logger)
+	diskMigrator := driver.NewDiskMigrator(logger)
+	dhcpReservations := vmnet.NewDhcpReservations(retryFileLock, logger)
+	portForwards := vmnet.NewPortForwards(retryFileLock, logger)
+	macAllocator := driver.NewMacAllocator(driverConfig, retryFileLock, logger)
+	stemcellRegistry := driver.NewStemcellRegistry(driverConfig, retryFileLock, logger)
+	driverClient := driver.NewClient(vmrunRunner, diskCreator, ovfImporter, cloneRunner, vmxBuilder, vmdkReader, datastorePlacer, diskMigrator, macAllocator, stemcellRegistry, dhcpReservations, portForwards, driverConfig, logger)
 	stemcellClient := stemcell.NewClient(compressor, fs, logger)
-	stemcellStore := stemcell.NewStemcellStore(stemcellConfig, compressor, fs, logger)
+	stemcellStore := stemcell.NewStemcellStore(stemcellConfig, retryFileLock, compressor, fs, logger)
 	agentEnvFactory := apiv1.NewAgentEnvFactory()
 	agentSettings := vm.NewAgentSettings(fs, logger, agentEnvFactory)
 	cpiFactory := action.NewFactory(driverClient, stemcellClient, stemcellStore, agentSettings, agentEnvFactory, cpiConfig, fs, uuidGen, logger)
@@ -17,6 +17,11 @@ import (
 var (
 	configPathOpt     = flag.String("configPath", "", "Path to configuration file")
 	directorTmpDirOpt = flag.String("directorTmpDirPath", "", "Path to director's temp file containing extracted stemcell images")
+	stemcellOpt       = flag.String("stemcellPath", "", "Path to a stemcell tarball, to create a light stemcell from")
+	lightStemcellOpt  = flag.String("lightStemcellPath", "", "Path to write the light stemcell to (default: light-<stemcell> next to the stemcell)")
+	keepVersionsOpt   = flag.Int("keepVersions", 0, "gc-stemcell-store: number of versions to keep per stemcell name")
+	maxUnusedDaysOpt  = flag.Int("maxUnusedDays", 0, "gc-stemcell-store: days after which unreferenced stemcells and mappings are removed")
+	dryRunOpt         = flag.Bool("dryRun", false, "gc-stemcell-store: list what would be removed without removing it")
 	versionOpt        = flag.Bool("version", false, "Version")
 
 	//set by X build flag
@@ -57,6 +62,17 @@ func main() {
 		os.Exit(0)
 	}
 
+	if command == "create-light-stemcell" {
+		lightStemcellPath, err := install.CreateLightStemcell(*stemcellOpt, *lightStemcellOpt, logger)
+		if err != nil {
+			logger.Error("main", "creating light stemcell", err)
+			os.Exit(1)
+		}
+
+		fmt.Println(lightStemcellPath)
+		os.Exit(0)
+	}
+
 	if command == "encoded-config" {
 		configBase64 := base64.StdEncoding.EncodeToString([]byte(configJSON))
 		fmt.Println(configBase64)
@@ -86,6 +102,8 @@ func main() {
 		err = installer.InstallCPI(version)
 	case "sync-director-stemcells":
 		err = installer.SyncDirectorStemcells(directorTmpDirPath)
+	case "gc-stemcell-store":
+		err = installer.GarbageCollectStemcellStore(configJSON, *keepVersionsOpt, *maxUnusedDaysOpt, *dryRunOpt)
 	default:
 		err = errors.New("command required")
 	}
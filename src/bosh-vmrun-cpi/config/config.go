@@ -2,6 +2,8 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -30,7 +32,16 @@ type Vmrun struct {
 	Vm_Start_Max_Wait_Seconds         int
 	Vm_Soft_Shutdown_Max_Wait_Seconds int
 	Stemcell_Store_Path               string
+	Stemcell_Mirror_Urls              []string
+	Persistent_Disk_Store_Path        string
 	Enable_Human_Readable_Name        bool
+	Datastores                        []Datastore
+	Datastore_Placement               string
+	Dhcp_Config_Path                  string
+	Nat_Config_Path                   string
+	Vmnet_Config_Paths                []string
+	Agent_Settings_Source             string
+	Ovf_Importer                      string
 
 	//calculated
 	Vm_Start_Max_Wait         time.Duration
@@ -44,6 +55,23 @@ type Vmrun struct {
 	}
 }
 
+type Datastore struct {
+	Name            string
+	Path            string
+	Max_Capacity_MB int
+}
+
+const (
+	DATASTORE_PLACEMENT_MOST_FREE_SPACE = "most_free_space"
+	DATASTORE_PLACEMENT_ROUND_ROBIN     = "round_robin"
+
+	AGENT_SETTINGS_SOURCE_CDROM     = "cdrom"
+	AGENT_SETTINGS_SOURCE_GUESTINFO = "guestinfo"
+
+	OVF_IMPORTER_OVFTOOL = "ovftool"
+	OVF_IMPORTER_NATIVE  = "native"
+)
+
 func NewConfigFromJson(configJson string) (Config, error) {
 	var config Config
 	var err error
@@ -60,6 +88,10 @@ func NewConfigFromJson(configJson string) (Config, error) {
 
 	config.Cloud.Properties.Vmrun.setDurations()
 	config.Cloud.Properties.Vmrun.setDefaultStemcellStore()
+	config.Cloud.Properties.Vmrun.setDefaultPersistentDiskStore()
+	config.Cloud.Properties.Vmrun.setDefaultDatastorePlacement()
+	config.Cloud.Properties.Vmrun.setDefaultAgentSettingsSource()
+	config.Cloud.Properties.Vmrun.setDefaultOvfImporter()
 
 	return config, nil
 }
@@ -69,6 +101,45 @@ func (c Config) GetAgentOptions() apiv1.AgentOptions {
 }
 
 func (c Config) Validate() error {
+	vmrun := c.Cloud.Properties.Vmrun
+
+	switch vmrun.Datastore_Placement {
+	case "", DATASTORE_PLACEMENT_MOST_FREE_SPACE, DATASTORE_PLACEMENT_ROUND_ROBIN:
+	default:
+		return fmt.Errorf("unsupported datastore_placement: %s", vmrun.Datastore_Placement)
+	}
+
+	switch vmrun.Agent_Settings_Source {
+	case "", AGENT_SETTINGS_SOURCE_CDROM, AGENT_SETTINGS_SOURCE_GUESTINFO:
+	default:
+		return fmt.Errorf("unsupported agent_settings_source: %s", vmrun.Agent_Settings_Source)
+	}
+
+	switch vmrun.Ovf_Importer {
+	case "", OVF_IMPORTER_OVFTOOL, OVF_IMPORTER_NATIVE:
+	default:
+		return fmt.Errorf("unsupported ovf_importer: %s", vmrun.Ovf_Importer)
+	}
+
+	for _, mirrorURL := range vmrun.Stemcell_Mirror_Urls {
+		parsedURL, err := url.Parse(mirrorURL)
+		if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+			return fmt.Errorf("unsupported stemcell_mirror_urls entry: %s", mirrorURL)
+		}
+	}
+
+	datastoreNames := map[string]bool{}
+	for _, datastore := range vmrun.Datastores {
+		if datastore.Name == "" || datastore.Path == "" {
+			return fmt.Errorf("datastores require a name and path")
+		}
+
+		if datastoreNames[datastore.Name] {
+			return fmt.Errorf("duplicate datastore name: %s", datastore.Name)
+		}
+		datastoreNames[datastore.Name] = true
+	}
+
 	return nil
 }
 
@@ -90,6 +161,30 @@ func (v *Vmrun) setDefaultStemcellStore() {
 	}
 }
 
+func (v *Vmrun) setDefaultPersistentDiskStore() {
+	if v.Persistent_Disk_Store_Path == "" {
+		v.Persistent_Disk_Store_Path = strings.Join([]string{v.Vm_Store_Path, "persistent-disks"}, v.PlatformPathSeparator())
+	}
+}
+
+func (v *Vmrun) setDefaultDatastorePlacement() {
+	if v.Datastore_Placement == "" {
+		v.Datastore_Placement = DATASTORE_PLACEMENT_MOST_FREE_SPACE
+	}
+}
+
+func (v *Vmrun) setDefaultAgentSettingsSource() {
+	if v.Agent_Settings_Source == "" {
+		v.Agent_Settings_Source = AGENT_SETTINGS_SOURCE_CDROM
+	}
+}
+
+func (v *Vmrun) setDefaultOvfImporter() {
+	if v.Ovf_Importer == "" {
+		v.Ovf_Importer = OVF_IMPORTER_OVFTOOL
+	}
+}
+
 func secsIntToDuration(secs int) time.Duration {
 	return time.Duration(float64(secs) * float64(time.Second))
 }
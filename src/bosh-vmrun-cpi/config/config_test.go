@@ -21,9 +21,21 @@ var _ = Describe("Config", func() {
 						"vmrun_bin_path":"/vmrun-bin",
 						"ovftool_bin_path":"/ovftool-bin",
 						"stemcell_store_path":"/stemcell-store-dir",
+						"stemcell_mirror_urls":["http://mirror.example.com/stemcells"],
+						"persistent_disk_store_path":"/persistent-disk-store-dir",
 						"vm_soft_shutdown_max_wait_seconds":20,
 						"vm_start_max_wait_seconds":10,
 						"enable_human_readable_name":true,
+						"datastores":[
+							{"name":"ssd","path":"/ssd-store-dir","max_capacity_mb":2048},
+							{"name":"hdd","path":"/hdd-store-dir"}
+						],
+						"datastore_placement":"round_robin",
+						"dhcp_config_path":"/etc/vmware/vmnet8/dhcpd/dhcpd.conf",
+						"nat_config_path":"/etc/vmware/vmnet8/nat/nat.conf",
+						"vmnet_config_paths":["/etc/vmware/networking","/etc/vmware/netmap.conf"],
+						"agent_settings_source":"guestinfo",
+						"ovf_importer":"native",
 						"director_stemcell_tmp_path": "/var/vcap/data/director/tmp",
 						"ssh_tunnel":{
 							"host":"localhost",
@@ -56,6 +68,8 @@ var _ = Describe("Config", func() {
 					"Vmrun": MatchAllFields(Fields{
 						"Vm_Store_Path":                     Equal("/store-dir"),
 						"Stemcell_Store_Path":               Equal("/stemcell-store-dir"),
+						"Stemcell_Mirror_Urls":              Equal([]string{"http://mirror.example.com/stemcells"}),
+						"Persistent_Disk_Store_Path":        Equal("/persistent-disk-store-dir"),
 						"Vmrun_Bin_Path":                    Equal("/vmrun-bin"),
 						"Ovftool_Bin_Path":                  Equal("/ovftool-bin"),
 						"Vm_Soft_Shutdown_Max_Wait":         Equal(20 * time.Second),
@@ -63,6 +77,16 @@ var _ = Describe("Config", func() {
 						"Vm_Soft_Shutdown_Max_Wait_Seconds": Equal(20),
 						"Vm_Start_Max_Wait_Seconds":         Equal(10),
 						"Enable_Human_Readable_Name":        Equal(true),
+						"Datastores": Equal([]config.Datastore{
+							{Name: "ssd", Path: "/ssd-store-dir", Max_Capacity_MB: 2048},
+							{Name: "hdd", Path: "/hdd-store-dir"},
+						}),
+						"Datastore_Placement":   Equal("round_robin"),
+						"Dhcp_Config_Path":      Equal("/etc/vmware/vmnet8/dhcpd/dhcpd.conf"),
+						"Nat_Config_Path":       Equal("/etc/vmware/vmnet8/nat/nat.conf"),
+						"Vmnet_Config_Paths":    Equal([]string{"/etc/vmware/networking", "/etc/vmware/netmap.conf"}),
+						"Agent_Settings_Source": Equal("guestinfo"),
+						"Ovf_Importer":          Equal("native"),
 						"Ssh_Tunnel": MatchAllFields(Fields{
 							"Host":        Equal("localhost"),
 							"Port":        Equal("22"),
@@ -83,4 +107,54 @@ var _ = Describe("Config", func() {
 			}),
 		}))
 	})
+
+	It("defaults datastore placement to most free space", func() {
+		c, err := config.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"vm_store_path":"/store-dir"}}}}`)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(c.Cloud.Properties.Vmrun.Datastores).To(BeEmpty())
+		Expect(c.Cloud.Properties.Vmrun.Datastore_Placement).To(Equal("most_free_space"))
+	})
+
+	It("defaults agent settings to the env cdrom", func() {
+		c, err := config.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"vm_store_path":"/store-dir"}}}}`)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(c.Cloud.Properties.Vmrun.Agent_Settings_Source).To(Equal("cdrom"))
+	})
+
+	It("rejects unsupported agent settings sources", func() {
+		_, err := config.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"agent_settings_source":"http"}}}}`)
+		Expect(err).To(MatchError(ContainSubstring("unsupported agent_settings_source: http")))
+	})
+
+	It("defaults to importing ovfs with ovftool", func() {
+		c, err := config.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"vm_store_path":"/store-dir"}}}}`)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(c.Cloud.Properties.Vmrun.Ovf_Importer).To(Equal("ovftool"))
+	})
+
+	It("rejects stemcell mirrors that are not http urls", func() {
+		_, err := config.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"stemcell_mirror_urls":["/local/stemcells"]}}}}`)
+		Expect(err).To(MatchError(ContainSubstring("unsupported stemcell_mirror_urls entry: /local/stemcells")))
+	})
+
+	It("rejects unsupported ovf importers", func() {
+		_, err := config.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"ovf_importer":"qemu-img"}}}}`)
+		Expect(err).To(MatchError(ContainSubstring("unsupported ovf_importer: qemu-img")))
+	})
+
+	It("rejects unsupported datastore placement", func() {
+		_, err := config.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"datastore_placement":"random"}}}}`)
+		Expect(err).To(MatchError(ContainSubstring("unsupported datastore_placement: random")))
+	})
+
+	It("rejects invalid datastores", func() {
+		_, err := config.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"datastores":[{"name":"ssd"}]}}}}`)
+		Expect(err).To(MatchError(ContainSubstring("datastores require a name and path")))
+
+		_, err = config.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"datastores":[{"name":"ssd","path":"/a"},{"name":"ssd","path":"/b"}]}}}}`)
+		Expect(err).To(MatchError(ContainSubstring("duplicate datastore name: ssd")))
+	})
 })
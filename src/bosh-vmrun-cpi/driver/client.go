@@ -2,24 +2,38 @@ package driver
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	cpiconfig "bosh-vmrun-cpi/config"
+	"bosh-vmrun-cpi/vmdk"
+	"bosh-vmrun-cpi/vmnet"
 	"bosh-vmrun-cpi/vmx"
 )
 
 //TODO: use boshfs for fs operations
 type ClientImpl struct {
-	vmrunRunner   VmrunRunner
-	ovftoolRunner OvftoolRunner
-	cloneRunner   CloneRunner
-	vmxBuilder    vmx.VmxBuilder
-	config        Config
-	logger        boshlog.Logger
+	vmrunRunner      VmrunRunner
+	diskCreator      DiskCreator
+	ovfImporter      OvfImporter
+	cloneRunner      CloneRunner
+	vmxBuilder       vmx.VmxBuilder
+	vmdkReader       vmdk.VmdkReader
+	datastorePlacer  DatastorePlacer
+	diskMigrator     DiskMigrator
+	macAllocator     MacAllocator
+	stemcellRegistry StemcellRegistry
+	dhcpReservations vmnet.DhcpReservations
+	portForwards     vmnet.PortForwards
+	config           Config
+	logger           boshlog.Logger
 }
 
 var (
@@ -28,12 +42,12 @@ var (
 	STATE_POWER_OFF = "state-off"
 )
 
-func NewClient(vmrunRunner VmrunRunner, ovftoolRunner OvftoolRunner, cloneRunner CloneRunner, vmxBuilder vmx.VmxBuilder, config Config, logger boshlog.Logger) Client {
-	return ClientImpl{vmrunRunner, ovftoolRunner, cloneRunner, vmxBuilder, config, logger}
+func NewClient(vmrunRunner VmrunRunner, diskCreator DiskCreator, ovfImporter OvfImporter, cloneRunner CloneRunner, vmxBuilder vmx.VmxBuilder, vmdkReader vmdk.VmdkReader, datastorePlacer DatastorePlacer, diskMigrator DiskMigrator, macAllocator MacAllocator, stemcellRegistry StemcellRegistry, dhcpReservations vmnet.DhcpReservations, portForwards vmnet.PortForwards, config Config, logger boshlog.Logger) Client {
+	return ClientImpl{vmrunRunner, diskCreator, ovfImporter, cloneRunner, vmxBuilder, vmdkReader, datastorePlacer, diskMigrator, macAllocator, stemcellRegistry, dhcpReservations, portForwards, config, logger}
 }
 
 func (c ClientImpl) ImportOvf(ovfPath string, vmName string) (bool, error) {
-	err := c.ovftoolRunner.ImportOvf(ovfPath, c.config.VmxPath(vmName), vmName)
+	err := c.ovfImporter.ImportOvf(ovfPath, c.config.VmxPath(vmName), vmName)
 	if err != nil {
 		c.logger.ErrorWithDetails("client", "import ovf: runner", err)
 		return false, err
@@ -42,12 +56,35 @@ func (c ClientImpl) ImportOvf(ovfPath string, vmName string) (bool, error) {
 	return true, nil
 }
 
-func (c ClientImpl) CloneVM(sourceVmName string, cloneVmName string) error {
+// requiredMB is the space the clone needs besides the copied stemcell disk, ex: its ephemeral disk and memory
+func (c ClientImpl) CloneVM(sourceVmName string, cloneVmName string, datastoreName string, requiredMB int) error {
 	var err error
 
+	sourceSizeBytes, err := dirSizeBytes(filepath.Dir(c.config.VmxPath(sourceVmName)))
+	if err != nil && !os.IsNotExist(err) {
+		c.logger.ErrorWithDetails("client", "clone vm: sizing stemcell", err)
+		return err
+	}
+
+	datastore, err := c.datastorePlacer.Place(datastoreName, requiredMB+int(sourceSizeBytes/(1024*1024)))
+	if err != nil {
+		c.logger.ErrorWithDetails("client", "clone vm: placing on datastore", err)
+		return err
+	}
+
+	// the clone is located by its mapping, so the mapping is written first and removed when cloning fails
+	if datastore.Path != c.config.VmStorePath() {
+		err = ioutil.WriteFile(c.config.VmMappingPath(cloneVmName), []byte(datastore.Path), 0644)
+		if err != nil {
+			c.logger.ErrorWithDetails("client", "clone vm: datastore mapping", err)
+			return err
+		}
+	}
+
 	err = c.cloneRunner.Clone(c.config.VmxPath(sourceVmName), c.config.VmxPath(cloneVmName), cloneVmName)
 	if err != nil {
 		c.logger.ErrorWithDetails("client", "clone vm: clone stemcell", err)
+		os.Remove(c.config.VmMappingPath(cloneVmName))
 		return err
 	}
 
@@ -60,12 +97,154 @@ func (c ClientImpl) CloneVM(sourceVmName string, cloneVmName string) error {
 	return nil
 }
 
-func (c ClientImpl) SetVMNetworkAdapter(vmName string, networkName string, macAddress string) error {
+func (c ClientImpl) LockStemcellVM(stemcellKey string, fn func() error) error {
+	return c.stemcellRegistry.Lock(stemcellKey, fn)
+}
+
+func (c ClientImpl) AcquireStemcellVM(stemcellKey string) (string, error) {
+	stemcellVmName, err := c.stemcellRegistry.Acquire(stemcellKey)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "acquiring stemcell vm", err, stemcellKey)
+		return "", err
+	}
+
+	return stemcellVmName, nil
+}
+
+func (c ClientImpl) RegisterStemcellVM(stemcellKey string, stemcellVmName string) error {
+	err := c.stemcellRegistry.Register(stemcellKey, stemcellVmName)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "registering stemcell vm", err, stemcellVmName)
+		return err
+	}
+
+	return nil
+}
+
+// returns whether the stemcell vm has no references left and can be destroyed
+func (c ClientImpl) ReleaseStemcellVM(stemcellVmName string) (bool, error) {
+	unused, err := c.stemcellRegistry.Release(stemcellVmName)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "releasing stemcell vm", err, stemcellVmName)
+		return false, err
+	}
+
+	return unused, nil
+}
+
+func (c ClientImpl) AllocateMACAddress(vmName string) (string, error) {
+	macAddress, err := c.macAllocator.Allocate(vmName)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "allocating mac address", err, vmName)
+		return "", err
+	}
+
+	return macAddress, nil
+}
+
+func (c ClientImpl) ReleaseMACAddresses(vmName string) error {
+	err := c.macAllocator.Release(vmName)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "releasing mac addresses", err, vmName)
+		return err
+	}
+
+	return nil
+}
+
+// GetHostNetworkNames maps the vmnet devices and display names of the host to their vmnet device, it returns no names
+// when vmnet config paths are not configured
+func (c ClientImpl) GetHostNetworkNames() (map[string]string, error) {
+	if len(c.config.VmnetConfigPaths()) == 0 {
+		return nil, nil
+	}
+
+	networkNames, err := vmnet.ReadNetworkNames(c.config.VmnetConfigPaths())
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "reading host networks", err)
+		return nil, err
+	}
+
+	return networkNames, nil
+}
+
+// reservations are only written when a dhcp config path is configured
+func (c ClientImpl) AddDHCPReservation(vmName string, macAddress string, ipAddress string) error {
+	if c.config.DhcpConfigPath() == "" {
+		return nil
+	}
+
+	host := vmnet.DhcpHost{
+		Name:       fmt.Sprintf("%s-%s", vmName, strings.Replace(macAddress, ":", "", -1)),
+		MACAddress: macAddress,
+		IPAddress:  ipAddress,
+	}
+
+	err := c.dhcpReservations.Add(c.config.DhcpConfigPath(), host)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "adding dhcp reservation", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c ClientImpl) RemoveDHCPReservations(vmName string) error {
+	if c.config.DhcpConfigPath() == "" {
+		return nil
+	}
+
+	err := c.dhcpReservations.Remove(c.config.DhcpConfigPath(), vmName+"-")
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "removing dhcp reservations", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c ClientImpl) AddPortForwards(vmName string, portForwards []vmnet.PortForward) error {
+	if len(portForwards) == 0 {
+		return nil
+	}
+
+	if c.config.NatConfigPath() == "" {
+		return errors.New("port forwards require vmrun.nat_config_path to be configured")
+	}
+
+	for i := range portForwards {
+		portForwards[i].Owner = vmName
+	}
+
+	err := c.portForwards.Add(c.config.NatConfigPath(), portForwards)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "adding port forwards", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c ClientImpl) RemovePortForwards(vmName string) error {
+	if c.config.NatConfigPath() == "" {
+		return nil
+	}
+
+	err := c.portForwards.Remove(c.config.NatConfigPath(), vmName)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "removing port forwards", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c ClientImpl) SetVMNetworkAdapter(vmName string, networkName string, macAddress string, connectionType string, deviceType string) error {
 	var err error
 
-	err = c.vmxBuilder.AddNetworkInterface(networkName, macAddress, c.config.VmxPath(vmName))
+	err = c.vmxBuilder.AddNetworkInterface(networkName, macAddress, connectionType, deviceType, c.config.VmxPath(vmName))
 	if err != nil {
-		c.logger.ErrorWithDetails("driver", "adding network", err, vmName, networkName, macAddress)
+		c.logger.ErrorWithDetails("driver", "adding network", err, vmName, networkName, macAddress, connectionType, deviceType)
 		return err
 	}
 
@@ -82,6 +261,16 @@ func (c ClientImpl) SetVMResources(vmName string, cpuCount int, ramMB int) error
 	return nil
 }
 
+func (c ClientImpl) SetVMDiskController(vmName string, diskController string) error {
+	err := c.vmxBuilder.SetDiskController(diskController, c.config.VmxPath(vmName))
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "setting vm disk controller", err)
+		return err
+	}
+
+	return nil
+}
+
 func (c ClientImpl) GetVMIsoPath(vmName string) string {
 	path := c.config.EnvIsoPath(vmName)
 	if _, err := os.Stat(path); err != nil {
@@ -106,6 +295,25 @@ func (c ClientImpl) UpdateVMIso(vmName string, localIsoPath string) error {
 		return err
 	}
 
+	vmState, err := c.vmState(vmName)
+	if err != nil {
+		return err
+	}
+
+	// the VMX of a running VM is overwritten on power off, the already attached cdrom is reconnected instead
+	if vmState == STATE_POWER_ON {
+		err = c.vmrunRunner.DisconnectNamedDevice(c.config.VmxPath(vmName), envCdromDeviceID)
+		if err == nil {
+			err = c.vmrunRunner.ConnectNamedDevice(c.config.VmxPath(vmName), envCdromDeviceID)
+		}
+		if err != nil {
+			c.logger.ErrorWithDetails("driver", "reconnecting ENV cdrom", err)
+			return err
+		}
+
+		return nil
+	}
+
 	err = c.vmxBuilder.AttachCdrom(c.config.EnvIsoPath(vmName), c.config.VmxPath(vmName))
 	if err != nil {
 		c.logger.ErrorWithDetails("driver", "connecting ENV cdrom", err)
@@ -115,6 +323,60 @@ func (c ClientImpl) UpdateVMIso(vmName string, localIsoPath string) error {
 	return nil
 }
 
+func (c ClientImpl) UsesGuestInfoAgentSettings() bool {
+	return c.config.AgentSettingsSource() == cpiconfig.AGENT_SETTINGS_SOURCE_GUESTINFO
+}
+
+// running VMs are read through vmrun since the VMX file is only updated on power off
+func (c ClientImpl) GetVMGuestInfo(vmName string, name string) (string, error) {
+	vmState, err := c.vmState(vmName)
+	if err != nil {
+		return "", err
+	}
+
+	if vmState == STATE_POWER_ON {
+		value, err := c.vmrunRunner.ReadVariable(c.config.VmxPath(vmName), VARIABLE_TYPE_GUEST_VAR, name)
+		if err != nil {
+			c.logger.ErrorWithDetails("driver", "reading guestinfo variable", err)
+			return "", err
+		}
+
+		return value, nil
+	}
+
+	vmxVM, err := c.vmxBuilder.GetVmx(c.config.VmxPath(vmName))
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "reading vmx", err)
+		return "", err
+	}
+
+	return vmxVM.GuestInfo[strings.ToLower(name)], nil
+}
+
+// guest variables of running VMs are not saved to the VMX, the value is also written to the runtime config so it
+// survives a power cycle
+func (c ClientImpl) SetVMGuestInfo(vmName string, name string, value string) error {
+	vmState, err := c.vmState(vmName)
+	if err != nil {
+		return err
+	}
+
+	if vmState == STATE_POWER_ON {
+		err = c.vmrunRunner.WriteVariable(c.config.VmxPath(vmName), VARIABLE_TYPE_GUEST_VAR, name, value)
+		if err == nil {
+			err = c.vmrunRunner.WriteVariable(c.config.VmxPath(vmName), VARIABLE_TYPE_RUNTIME_CONFIG, "guestinfo."+name, value)
+		}
+	} else {
+		err = c.vmxBuilder.SetGuestInfo(name, value, c.config.VmxPath(vmName))
+	}
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "writing guestinfo variable", err)
+		return err
+	}
+
+	return nil
+}
+
 func (c ClientImpl) StartVM(vmName string) error {
 	var err error
 
@@ -157,6 +419,30 @@ func (c ClientImpl) waitForVMStart(vmName string) error {
 	return errors.New("timeout")
 }
 
+// requires vmware tools running in the guest, polls until the guest reports an address
+func (c ClientImpl) GetVMIPAddress(vmName string) (string, error) {
+	var ipAddress string
+	var err error
+
+	interval := time.Second
+	for i := time.Duration(0); i < c.config.VmStartMaxWait(); i += interval {
+		ipAddress, err = c.vmrunRunner.GetGuestIPAddress(c.config.VmxPath(vmName))
+		if err == nil {
+			return ipAddress, nil
+		}
+
+		c.logger.DebugWithDetails("driver", "polling vm guest ip address:", err)
+		time.Sleep(interval)
+	}
+
+	if err == nil {
+		err = errors.New("timeout")
+	}
+
+	c.logger.ErrorWithDetails("driver", "getting VM IP address", err)
+	return "", err
+}
+
 func (c ClientImpl) BootstrapVM(vmName, scriptContent, scriptPath, interpreterPath, readyProcessName, username, password string, vmReadyMinWait, vmReadyMaxWait time.Duration) error {
 	var err error
 
@@ -287,7 +573,7 @@ func (c ClientImpl) SetVMDisplayName(vmName, displayName string) error {
 func (c ClientImpl) CreateEphemeralDisk(vmName string, diskMB int) error {
 	var err error
 
-	err = c.ovftoolRunner.CreateDisk(c.config.EphemeralDiskPath(vmName), diskMB)
+	err = c.diskCreator.CreateDisk(c.config.EphemeralDiskPath(vmName), diskMB)
 	if err != nil {
 		c.logger.ErrorWithDetails("driver", "CreateEphemeralDisk create", err)
 		return err
@@ -302,22 +588,55 @@ func (c ClientImpl) CreateEphemeralDisk(vmName string, diskMB int) error {
 	return nil
 }
 
-func (c ClientImpl) CreateDisk(diskId string, diskMB int) error {
+func (c ClientImpl) CreateDisk(diskId string, diskMB int, datastore string) error {
 	var err error
 
-	err = c.ovftoolRunner.CreateDisk(c.config.PersistentDiskPath(diskId), diskMB)
+	datastorePath, err := c.persistentDiskDatastorePath(datastore, diskMB)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "CreateDisk placement", err)
+		return err
+	}
+
+	diskPath := c.config.DatastoreDiskPath(datastorePath, diskId)
+	err = c.diskCreator.CreateDisk(diskPath, diskMB)
 	if err != nil {
 		c.logger.ErrorWithDetails("driver", "CreateDisk", err)
 		return err
 	}
 
+	// the mapping is only written once the disk exists, a disk without its mapping would not be found
+	if datastorePath != c.config.PersistentDiskStorePath() {
+		err = ioutil.WriteFile(c.config.PersistentDiskMappingPath(diskId), []byte(datastorePath), 0644)
+		if err == nil && datastore != "" {
+			err = ioutil.WriteFile(c.persistentDiskPinnedPath(diskId), []byte(datastore), 0644)
+		}
+		if err != nil {
+			c.logger.ErrorWithDetails("driver", "CreateDisk mapping", err)
+			os.Remove(c.config.PersistentDiskMappingPath(diskId))
+			os.Remove(diskPath)
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (c ClientImpl) AttachDisk(vmName string, diskId string) error {
 	var err error
 
-	err = c.vmxBuilder.AttachDisk(c.config.PersistentDiskPath(diskId), c.config.VmxPath(vmName))
+	diskPath, err := c.migrateDiskToVM(vmName, diskId)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "AttachDisk migrate", err)
+		return err
+	}
+
+	err = c.validateDisk(diskId, diskPath)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "AttachDisk validate", err)
+		return err
+	}
+
+	err = c.vmxBuilder.AttachDisk(diskPath, c.config.VmxPath(vmName))
 	if err != nil {
 		c.logger.ErrorWithDetails("driver", "AttachDisk", err)
 		return err
@@ -325,10 +644,97 @@ func (c ClientImpl) AttachDisk(vmName string, diskId string) error {
 	return nil
 }
 
+func (c ClientImpl) validateDisk(diskId string, diskPath string) error {
+	diskInfo, err := c.vmdkReader.GetInfo(diskPath)
+	if err != nil {
+		return fmt.Errorf("invalid disk %s: %s", diskId, err)
+	}
+
+	if diskInfo.CreateType == vmdk.CREATE_TYPE_STREAM_OPTIMIZED {
+		return fmt.Errorf("invalid disk %s: %s disks cannot be attached", diskId, diskInfo.CreateType)
+	}
+
+	c.logger.Info("driver", "disk %s: type %s, virtual size %d MB, allocated size %d MB", diskId, diskInfo.CreateType, diskInfo.VirtualSizeBytes/(1024*1024), diskInfo.AllocatedSizeBytes/(1024*1024))
+
+	return nil
+}
+
+// disks placed on a datastore are moved to the datastore of the vm when it is on a different volume, disks of vms in
+// the vm store move to the persistent disk store. Disks in the persistent disk store or pinned with the datastore disk
+// property stay where they are. The disk is copied, its mapping updated and only then the source removed, so a failed
+// move leaves the disk where it was
+func (c ClientImpl) migrateDiskToVM(vmName string, diskId string) (string, error) {
+	diskPath := c.persistentDiskPath(diskId)
+
+	if c.persistentDiskPinned(diskId) {
+		return diskPath, nil
+	}
+
+	if _, err := os.Stat(c.config.PersistentDiskMappingPath(diskId)); err != nil {
+		return diskPath, nil
+	}
+
+	vmDatastorePath := c.config.VmDatastorePath(vmName)
+	targetDatastorePath := filepath.Join(vmDatastorePath, "persistent-disks")
+	if vmDatastorePath == c.config.VmStorePath() {
+		targetDatastorePath = c.config.PersistentDiskStorePath()
+	}
+
+	needsMigration, err := c.diskMigrator.NeedsMigration(diskPath, targetDatastorePath)
+	if err != nil || !needsMigration {
+		return diskPath, err
+	}
+
+	c.logger.Info("driver", "migrating disk %s to datastore %s", diskId, targetDatastorePath)
+
+	targetDiskPath, err := c.diskMigrator.Copy(diskPath, targetDatastorePath)
+	if err != nil {
+		return "", err
+	}
+
+	// disks in the persistent disk store are found without a mapping
+	if targetDatastorePath == c.config.PersistentDiskStorePath() {
+		err = os.Remove(c.config.PersistentDiskMappingPath(diskId))
+	} else {
+		err = writeFileAtomic(c.config.PersistentDiskMappingPath(diskId), []byte(targetDatastorePath))
+	}
+	if err != nil {
+		c.diskMigrator.Remove(targetDiskPath)
+		return "", err
+	}
+
+	err = c.diskMigrator.Remove(diskPath)
+	if err != nil {
+		c.logger.Warn("driver", "removing migrated disk %s: %s", diskPath, err)
+	}
+
+	return targetDiskPath, nil
+}
+
+// disks created with the datastore disk property keep their datastore when attached
+func (c ClientImpl) persistentDiskPinned(diskId string) bool {
+	_, err := os.Stat(c.persistentDiskPinnedPath(diskId))
+	return err == nil
+}
+
+func (c ClientImpl) persistentDiskPinnedPath(diskId string) string {
+	return c.config.PersistentDiskMappingPath(diskId) + ".pinned"
+}
+
+func writeFileAtomic(path string, content []byte) error {
+	tmpPath := path + ".tmp"
+	err := ioutil.WriteFile(tmpPath, content, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
 func (c ClientImpl) DetachDisk(vmName string, diskId string) error {
 	var err error
 
-	err = c.vmxBuilder.DetachDisk(c.config.PersistentDiskPath(vmName), c.config.VmxPath(vmName))
+	err = c.vmxBuilder.DetachDisk(c.persistentDiskPath(diskId), c.config.VmxPath(vmName))
 	if err != nil {
 		c.logger.ErrorWithDetails("driver", "DetachDisk", err)
 		return err
@@ -336,20 +742,214 @@ func (c ClientImpl) DetachDisk(vmName string, diskId string) error {
 	return nil
 }
 
+// HotAttachDisk plugs a disk into a running VM through vmrun runtime config and returns its device id (ex: scsi0:1).
+// Only scsi and sata controllers support hot-plug, other cases return an error so callers can fall back to the
+// powered off path
+func (c ClientImpl) HotAttachDisk(vmName string, diskId string) (string, error) {
+	vmxPath := c.config.VmxPath(vmName)
+
+	bus, err := c.hotPlugBus(vmName)
+	if err != nil {
+		return "", err
+	}
+
+	diskPath, err := c.migrateDiskToVM(vmName, diskId)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "HotAttachDisk migrate", err)
+		return "", err
+	}
+
+	err = c.validateDisk(diskId, diskPath)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "HotAttachDisk validate", err)
+		return "", err
+	}
+
+	diskUnits, err := c.runningDiskUnits(vmName, bus)
+	if err != nil {
+		return "", err
+	}
+
+	deviceID := ""
+	for unit := 0; unit <= hotPlugMaxUnits[bus]; unit++ {
+		if _, used := diskUnits[unit]; !used && !(bus == vmx.DISK_CONTROLLER_LSILOGIC && unit == 7) {
+			deviceID = fmt.Sprintf("%s0:%d", hotPlugVariablePrefix[bus], unit)
+			break
+		}
+	}
+	if deviceID == "" {
+		return "", fmt.Errorf("no free %s unit for hot-plug", bus)
+	}
+
+	for _, variable := range [][]string{{"fileName", diskPath}, {"deviceType", "disk"}, {"present", "TRUE"}} {
+		err = c.vmrunRunner.WriteVariable(vmxPath, VARIABLE_TYPE_RUNTIME_CONFIG, deviceID+"."+variable[0], variable[1])
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = c.vmrunRunner.ConnectNamedDevice(vmxPath, deviceID)
+	}
+
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "HotAttachDisk %s", deviceID, err)
+
+		// keep the device out of the VMX written on power off
+		c.vmrunRunner.WriteVariable(vmxPath, VARIABLE_TYPE_RUNTIME_CONFIG, deviceID+".present", "FALSE")
+		return "", err
+	}
+
+	return deviceID, nil
+}
+
+func (c ClientImpl) HotDetachDisk(vmName string, diskId string) error {
+	vmxPath := c.config.VmxPath(vmName)
+
+	bus, err := c.hotPlugBus(vmName)
+	if err != nil {
+		return err
+	}
+
+	diskUnits, err := c.runningDiskUnits(vmName, bus)
+	if err != nil {
+		return err
+	}
+
+	deviceID := ""
+	for unit, diskPath := range diskUnits {
+		if diskPath == c.persistentDiskPath(diskId) {
+			deviceID = fmt.Sprintf("%s0:%d", hotPlugVariablePrefix[bus], unit)
+		}
+	}
+	if deviceID == "" {
+		return fmt.Errorf("disk %s is not attached", diskId)
+	}
+
+	err = c.vmrunRunner.DisconnectNamedDevice(vmxPath, deviceID)
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "HotDetachDisk %s", deviceID, err)
+		return err
+	}
+
+	return c.vmrunRunner.WriteVariable(vmxPath, VARIABLE_TYPE_RUNTIME_CONFIG, deviceID+".present", "FALSE")
+}
+
+// AttachCdrom keeps a single ide device for the env ISO
+const envCdromDeviceID = "ide0:0"
+
+var (
+	hotPlugVariablePrefix = map[string]string{vmx.DISK_CONTROLLER_LSILOGIC: "scsi", vmx.DISK_CONTROLLER_SATA: "sata"}
+	hotPlugMaxUnits       = map[string]int{vmx.DISK_CONTROLLER_LSILOGIC: 15, vmx.DISK_CONTROLLER_SATA: 29}
+)
+
+// scsi controllers (lsilogic, pvscsi, lsisas1068) share the lsilogic bus key
+func (c ClientImpl) hotPlugBus(vmName string) (string, error) {
+	vmState, err := c.vmState(vmName)
+	if err != nil {
+		return "", err
+	}
+
+	if vmState != STATE_POWER_ON {
+		return "", errors.New("vm is not running")
+	}
+
+	vmxVM, err := c.vmxBuilder.GetVmx(c.config.VmxPath(vmName))
+	if err != nil {
+		return "", err
+	}
+
+	switch vmxVM.DiskController() {
+	case vmx.DISK_CONTROLLER_SATA:
+		return vmx.DISK_CONTROLLER_SATA, nil
+	case vmx.DISK_CONTROLLER_NVME:
+		return "", errors.New("hot-plug is not supported for nvme disks")
+	default:
+		return vmx.DISK_CONTROLLER_LSILOGIC, nil
+	}
+}
+
+// disk units of a running VM are the ones in its VMX from power on, updated with those changed through vmrun since.
+// Hot-plug takes the first free unit, so every unit changed through vmrun comes before the first unit that is
+// neither in the VMX nor set through vmrun, and the scan stops there. vmrun may fail to read variables that were
+// never set, which counts as unset for units outside the VMX
+func (c ClientImpl) runningDiskUnits(vmName string, bus string) (map[int]string, error) {
+	vmxPath := c.config.VmxPath(vmName)
+	prefix := hotPlugVariablePrefix[bus]
+
+	vmInfo, err := c.GetVMInfo(vmName)
+	if err != nil {
+		return nil, err
+	}
+
+	diskUnits := map[int]string{}
+	for _, disk := range vmInfo.Disks {
+		parts := strings.SplitN(disk.ID, ":", 2)
+		if len(parts) != 2 || parts[0] != prefix+"0" {
+			continue
+		}
+
+		unit, err := strconv.Atoi(parts[1])
+		if err == nil {
+			diskUnits[unit] = disk.Path
+		}
+	}
+
+	for unit := 0; unit <= hotPlugMaxUnits[bus]; unit++ {
+		if bus == vmx.DISK_CONTROLLER_LSILOGIC && unit == 7 {
+			continue
+		}
+
+		deviceID := fmt.Sprintf("%s0:%d", prefix, unit)
+		_, inVmx := diskUnits[unit]
+
+		present, err := c.vmrunRunner.ReadVariable(vmxPath, VARIABLE_TYPE_RUNTIME_CONFIG, deviceID+".present")
+		if err != nil {
+			if inVmx {
+				return nil, err
+			}
+			present = ""
+		}
+
+		switch strings.ToLower(present) {
+		case "true":
+			diskPath, err := c.vmrunRunner.ReadVariable(vmxPath, VARIABLE_TYPE_RUNTIME_CONFIG, deviceID+".fileName")
+			if err != nil {
+				return nil, err
+			}
+			if diskPath != "" {
+				diskUnits[unit] = diskPath
+			}
+		case "false":
+			delete(diskUnits, unit)
+		default:
+			if !inVmx {
+				return diskUnits, nil
+			}
+		}
+	}
+
+	return diskUnits, nil
+}
+
 func (c ClientImpl) DestroyDisk(diskId string) error {
 	var err error
 
-	err = os.Remove(c.config.PersistentDiskPath(diskId))
+	err = os.Remove(c.persistentDiskPath(diskId))
 	if err != nil {
 		c.logger.ErrorWithDetails("driver", "DestroyDisk", err)
 		return err
 	}
 
+	//attempt to cleanup datastore mapping, ignore error
+	_ = os.Remove(c.config.PersistentDiskMappingPath(diskId))
+	_ = os.Remove(c.persistentDiskPinnedPath(diskId))
+
 	return nil
 }
 
 func (c ClientImpl) HasDisk(diskId string) bool {
-	diskPath := c.config.PersistentDiskPath(diskId)
+	diskPath := c.persistentDiskPath(diskId)
 	if _, err := os.Stat(diskPath); err != nil {
 		c.logger.Debug("driver", "persistent disk file does not exist %s", diskPath)
 		return false
@@ -359,6 +959,64 @@ func (c ClientImpl) HasDisk(diskId string) bool {
 	}
 }
 
+func (c ClientImpl) GetDiskInfo(diskId string) (vmdk.Info, error) {
+	diskInfo, err := c.vmdkReader.GetInfo(c.persistentDiskPath(diskId))
+	if err != nil {
+		c.logger.ErrorWithDetails("driver", "GetDiskInfo", err)
+		return diskInfo, err
+	}
+
+	return diskInfo, nil
+}
+
+// datastore is either a configured datastore name or a directory path. Configured datastores take precedence over
+// the persistent disk store path, which then only keeps the disk mappings
+func (c ClientImpl) persistentDiskDatastorePath(datastore string, diskMB int) (string, error) {
+	if filepath.IsAbs(datastore) {
+		if !c.isDatastorePath(datastore) {
+			return "", fmt.Errorf("datastore path is not within a configured datastore: %s", datastore)
+		}
+		return datastore, nil
+	}
+
+	if datastore == "" && len(c.config.Datastores()) == 0 {
+		return c.config.PersistentDiskStorePath(), nil
+	}
+
+	placedDatastore, err := c.datastorePlacer.Place(datastore, diskMB)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(placedDatastore.Path, "persistent-disks"), nil
+}
+
+func (c ClientImpl) isDatastorePath(path string) bool {
+	basePaths := []string{c.config.VmStorePath(), c.config.PersistentDiskStorePath()}
+	for _, datastore := range c.config.Datastores() {
+		basePaths = append(basePaths, datastore.Path)
+	}
+
+	for _, basePath := range basePaths {
+		relPath, err := filepath.Rel(basePath, filepath.Clean(path))
+		if err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// disks created on a separate datastore are located by their mapping file
+func (c ClientImpl) persistentDiskPath(diskId string) string {
+	datastorePathBytes, err := ioutil.ReadFile(c.config.PersistentDiskMappingPath(diskId))
+	if err != nil {
+		return c.config.PersistentDiskPath(diskId)
+	}
+
+	return c.config.DatastoreDiskPath(string(datastorePathBytes), diskId)
+}
+
 func (c ClientImpl) StopVM(vmName string) error {
 	var err error
 	var vmState string
@@ -432,8 +1090,9 @@ func (c ClientImpl) DestroyVM(vmName string) error {
 		}
 	}
 
-	//attempt to cleanup ephemeral disk, ignore error
+	//attempt to cleanup ephemeral disk and datastore mapping, ignore error
 	_ = os.Remove(c.config.EphemeralDiskPath(vmName))
+	_ = os.Remove(c.config.VmMappingPath(vmName))
 
 	return nil
 }
@@ -445,10 +1104,12 @@ func (c ClientImpl) GetVMInfo(vmName string) (VMInfo, error) {
 		return VMInfo{}, err
 	}
 	vmInfo := VMInfo{
-		Name:          vmxVM.DisplayName,
-		CPUs:          int(vmxVM.NumvCPUs),
-		RAM:           int(vmxVM.Memsize),
-		CleanShutdown: vmxVM.CleanShutdown,
+		Name:           vmxVM.DisplayName,
+		CPUs:           int(vmxVM.NumvCPUs),
+		RAM:            int(vmxVM.Memsize),
+		DiskController: vmxVM.DiskController(),
+		SCSIDisks:      vmxVM.SCSIDisks(),
+		CleanShutdown:  vmxVM.CleanShutdown,
 	}
 
 	for _, vmxNic := range vmxVM.Ethernet {
@@ -471,6 +1132,26 @@ func (c ClientImpl) GetVMInfo(vmName string) (VMInfo, error) {
 		})
 	}
 
+	for _, sataDevice := range vmxVM.SATADevices {
+		vmInfo.Disks = append(vmInfo.Disks, struct {
+			ID   string
+			Path string
+		}{
+			ID:   sataDevice.VMXID,
+			Path: sataDevice.Filename,
+		})
+	}
+
+	for _, nvmeDevice := range vmxVM.NVMeDevices {
+		vmInfo.Disks = append(vmInfo.Disks, struct {
+			ID   string
+			Path string
+		}{
+			ID:   nvmeDevice.VMXID,
+			Path: nvmeDevice.Filename,
+		})
+	}
+
 	return vmInfo, err
 }
 
@@ -0,0 +1,643 @@
+package driver_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	govmx "github.com/hooklift/govmx"
+
+	cpiconfig "bosh-vmrun-cpi/config"
+	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/driver/fakes"
+	"bosh-vmrun-cpi/vmdk"
+	"bosh-vmrun-cpi/vmnet"
+	fakevmnet "bosh-vmrun-cpi/vmnet/fakes"
+	"bosh-vmrun-cpi/vmx"
+	fakevmx "bosh-vmrun-cpi/vmx/fakes"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		storeDir         string
+		datastoreDir     string
+		ovftoolRunner    *fakes.FakeOvftoolRunner
+		vmxBuilder       *fakevmx.FakeVmxBuilder
+		diskMigrator     *fakes.FakeDiskMigrator
+		dhcpReservations *fakevmnet.FakeDhcpReservations
+		config           driver.Config
+		client           driver.Client
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		storeDir, err = ioutil.TempDir("", "vm-store-")
+		Expect(err).ToNot(HaveOccurred())
+
+		datastoreDir, err = ioutil.TempDir("", "datastore-")
+		Expect(err).ToNot(HaveOccurred())
+
+		cpiConfig, err := cpiconfig.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"vm_store_path":"` + storeDir + `"}}}}`)
+		Expect(err).ToNot(HaveOccurred())
+
+		ovftoolRunner = &fakes.FakeOvftoolRunner{}
+		ovftoolRunner.CreateDiskStub = func(diskPath string, diskMB int) error {
+			var header bytes.Buffer
+			binary.Write(&header, binary.LittleEndian, vmdk.NewSparseExtentHeader(uint64(diskMB)*2048))
+
+			return ioutil.WriteFile(diskPath, header.Bytes(), 0644)
+		}
+		vmxBuilder = &fakevmx.FakeVmxBuilder{}
+		diskMigrator = &fakes.FakeDiskMigrator{}
+		dhcpReservations = &fakevmnet.FakeDhcpReservations{}
+		config = driver.NewConfig(cpiConfig)
+		logger := &fakelogger.FakeLogger{}
+
+		client = driver.NewClient(&fakes.FakeVmrunRunner{}, ovftoolRunner, &fakes.FakeOvfImporter{}, &fakes.FakeCloneRunner{}, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, &fakevmnet.FakePortForwards{}, config, logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(storeDir)
+		os.RemoveAll(datastoreDir)
+	})
+
+	Describe("persistent disks", func() {
+		var diskDir string
+
+		BeforeEach(func() {
+			diskDir = filepath.Join(storeDir, "disks")
+		})
+
+		It("creates disks in the persistent disk store by default", func() {
+			Expect(client.CreateDisk("disk-1", 1024, "")).To(Succeed())
+
+			diskPath, diskMB := ovftoolRunner.CreateDiskArgsForCall(0)
+			Expect(diskPath).To(Equal(filepath.Join(storeDir, "persistent-disks", "disk-1.vmdk")))
+			Expect(diskMB).To(Equal(1024))
+			Expect(client.HasDisk("disk-1")).To(BeTrue())
+
+			Expect(client.DestroyDisk("disk-1")).To(Succeed())
+			Expect(client.HasDisk("disk-1")).To(BeFalse())
+		})
+
+		It("creates disks on the requested datastore and finds them later", func() {
+			Expect(client.CreateDisk("disk-1", 1024, diskDir)).To(Succeed())
+
+			diskPath, _ := ovftoolRunner.CreateDiskArgsForCall(0)
+			Expect(diskPath).To(Equal(filepath.Join(diskDir, "disk-1.vmdk")))
+			Expect(client.HasDisk("disk-1")).To(BeTrue())
+
+			Expect(client.AttachDisk("vm-1", "disk-1")).To(Succeed())
+			attachedDiskPath, vmxPath := vmxBuilder.AttachDiskArgsForCall(0)
+			Expect(attachedDiskPath).To(Equal(filepath.Join(diskDir, "disk-1.vmdk")))
+			Expect(vmxPath).To(Equal(config.VmxPath("vm-1")))
+
+			Expect(client.DetachDisk("vm-1", "disk-1")).To(Succeed())
+			detachedDiskPath, _ := vmxBuilder.DetachDiskArgsForCall(0)
+			Expect(detachedDiskPath).To(Equal(filepath.Join(diskDir, "disk-1.vmdk")))
+
+			Expect(client.DestroyDisk("disk-1")).To(Succeed())
+			Expect(client.HasDisk("disk-1")).To(BeFalse())
+			Expect(config.PersistentDiskMappingPath("disk-1")).ToNot(BeAnExistingFile())
+		})
+
+		It("does not leave a mapping when creating the disk fails", func() {
+			ovftoolRunner.CreateDiskReturns(errors.New("create-err"))
+
+			Expect(client.CreateDisk("disk-1", 1024, diskDir)).To(MatchError("create-err"))
+			Expect(config.PersistentDiskMappingPath("disk-1")).ToNot(BeAnExistingFile())
+			Expect(client.HasDisk("disk-1")).To(BeFalse())
+		})
+
+		It("refuses datastore paths outside the vm store and the configured datastores", func() {
+			err := client.CreateDisk("disk-1", 1024, datastoreDir)
+			Expect(err).To(MatchError("datastore path is not within a configured datastore: " + datastoreDir))
+			Expect(ovftoolRunner.CreateDiskCallCount()).To(Equal(0))
+			Expect(client.HasDisk("disk-1")).To(BeFalse())
+		})
+
+		It("finds disks left in the vm store after a persistent disk store path is configured", func() {
+			Expect(client.CreateDisk("disk-1", 1024, "")).To(Succeed())
+			Expect(client.CreateDisk("disk-2", 1024, diskDir)).To(Succeed())
+
+			cpiConfig, err := cpiconfig.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"vm_store_path":"` + storeDir + `","persistent_disk_store_path":"` + datastoreDir + `/disks"}}}}`)
+			Expect(err).ToNot(HaveOccurred())
+			config = driver.NewConfig(cpiConfig)
+			logger := &fakelogger.FakeLogger{}
+			client = driver.NewClient(&fakes.FakeVmrunRunner{}, ovftoolRunner, &fakes.FakeOvfImporter{}, &fakes.FakeCloneRunner{}, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, &fakevmnet.FakePortForwards{}, config, logger)
+
+			Expect(client.HasDisk("disk-1")).To(BeTrue())
+			Expect(client.HasDisk("disk-2")).To(BeTrue())
+
+			Expect(client.CreateDisk("disk-3", 1024, "")).To(Succeed())
+			diskPath, _ := ovftoolRunner.CreateDiskArgsForCall(2)
+			Expect(diskPath).To(Equal(filepath.Join(datastoreDir, "disks", "disk-3.vmdk")))
+
+			Expect(client.DestroyDisk("disk-1")).To(Succeed())
+			Expect(client.DestroyDisk("disk-2")).To(Succeed())
+			Expect(filepath.Join(storeDir, "persistent-disks", "disk-1.vmdk")).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(storeDir, "persistent-disks", "mappings", "disk-2.mapping")).ToNot(BeAnExistingFile())
+			Expect(client.HasDisk("disk-2")).To(BeFalse())
+		})
+	})
+
+	Describe("GetVMIPAddress", func() {
+		var vmrunRunner *fakes.FakeVmrunRunner
+
+		BeforeEach(func() {
+			cpiConfig, err := cpiconfig.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"vm_store_path":"` + storeDir + `","vm_start_max_wait_seconds":2}}}}`)
+			Expect(err).ToNot(HaveOccurred())
+			config = driver.NewConfig(cpiConfig)
+			logger := &fakelogger.FakeLogger{}
+
+			vmrunRunner = &fakes.FakeVmrunRunner{}
+			client = driver.NewClient(vmrunRunner, ovftoolRunner, &fakes.FakeOvfImporter{}, &fakes.FakeCloneRunner{}, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, &fakevmnet.FakePortForwards{}, config, logger)
+		})
+
+		It("polls until the guest reports an address", func() {
+			vmrunRunner.GetGuestIPAddressReturnsOnCall(0, "", errors.New("guest tools not running"))
+			vmrunRunner.GetGuestIPAddressReturnsOnCall(1, "192.168.80.128", nil)
+
+			ipAddress, err := client.GetVMIPAddress("vm-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ipAddress).To(Equal("192.168.80.128"))
+			Expect(vmrunRunner.GetGuestIPAddressArgsForCall(1)).To(Equal(config.VmxPath("vm-1")))
+		})
+
+		It("gives up after the vm start max wait", func() {
+			vmrunRunner.GetGuestIPAddressReturns("", errors.New("guest tools not running"))
+
+			_, err := client.GetVMIPAddress("vm-1")
+			Expect(err).To(MatchError("guest tools not running"))
+			Expect(vmrunRunner.GetGuestIPAddressCallCount()).To(Equal(2))
+		})
+	})
+
+	Describe("GetHostNetworkNames", func() {
+		It("returns no names without vmnet config paths", func() {
+			networkNames, err := client.GetHostNetworkNames()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(networkNames).To(BeEmpty())
+		})
+
+		It("reads names from the configured vmnet config files", func() {
+			networkingPath := filepath.Join(storeDir, "networking")
+			Expect(ioutil.WriteFile(networkingPath, []byte("answer VNET_8_NAT yes\n"), 0644)).To(Succeed())
+
+			cpiConfig, err := cpiconfig.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"vm_store_path":"` + storeDir + `","vmnet_config_paths":["` + networkingPath + `"]}}}}`)
+			Expect(err).ToNot(HaveOccurred())
+			config = driver.NewConfig(cpiConfig)
+			logger := &fakelogger.FakeLogger{}
+			client = driver.NewClient(&fakes.FakeVmrunRunner{}, ovftoolRunner, &fakes.FakeOvfImporter{}, &fakes.FakeCloneRunner{}, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, &fakevmnet.FakePortForwards{}, config, logger)
+
+			networkNames, err := client.GetHostNetworkNames()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(networkNames).To(Equal(map[string]string{"vmnet8": "vmnet8"}))
+		})
+	})
+
+	Describe("guestinfo", func() {
+		var vmrunRunner *fakes.FakeVmrunRunner
+
+		BeforeEach(func() {
+			logger := &fakelogger.FakeLogger{}
+			vmrunRunner = &fakes.FakeVmrunRunner{}
+			client = driver.NewClient(vmrunRunner, ovftoolRunner, &fakes.FakeOvfImporter{}, &fakes.FakeCloneRunner{}, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, &fakevmnet.FakePortForwards{}, config, logger)
+
+			Expect(os.MkdirAll(filepath.Dir(config.VmxPath("vm-1")), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(config.VmxPath("vm-1"), []byte{}, 0644)).To(Succeed())
+		})
+
+		It("uses the vmx of powered off vms", func() {
+			vmxBuilder.GetVmxReturns(&vmx.VM{GuestInfo: map[string]string{"bosh.agent_env": "e30="}}, nil)
+
+			Expect(client.SetVMGuestInfo("vm-1", "bosh.agent_env", "e30=")).To(Succeed())
+			name, value, vmxPath := vmxBuilder.SetGuestInfoArgsForCall(0)
+			Expect(name).To(Equal("bosh.agent_env"))
+			Expect(value).To(Equal("e30="))
+			Expect(vmxPath).To(Equal(config.VmxPath("vm-1")))
+
+			Expect(client.GetVMGuestInfo("vm-1", "bosh.agent_env")).To(Equal("e30="))
+			Expect(vmrunRunner.WriteVariableCallCount()).To(Equal(0))
+			Expect(vmrunRunner.ReadVariableCallCount()).To(Equal(0))
+		})
+
+		It("uses vmrun variables of running vms", func() {
+			vmrunRunner.ListReturns(config.VmxPath("vm-1"), nil)
+			vmrunRunner.ReadVariableReturns("e30=", nil)
+
+			Expect(client.SetVMGuestInfo("vm-1", "bosh.agent_env", "e30=")).To(Succeed())
+			vmxPath, variableType, name, value := vmrunRunner.WriteVariableArgsForCall(0)
+			Expect(vmxPath).To(Equal(config.VmxPath("vm-1")))
+			Expect(variableType).To(Equal("guestVar"))
+			Expect(name).To(Equal("bosh.agent_env"))
+			Expect(value).To(Equal("e30="))
+
+			vmxPath, variableType, name, value = vmrunRunner.WriteVariableArgsForCall(1)
+			Expect(vmxPath).To(Equal(config.VmxPath("vm-1")))
+			Expect(variableType).To(Equal("runtimeConfig"))
+			Expect(name).To(Equal("guestinfo.bosh.agent_env"))
+			Expect(value).To(Equal("e30="))
+
+			Expect(client.GetVMGuestInfo("vm-1", "bosh.agent_env")).To(Equal("e30="))
+			_, variableType, name = vmrunRunner.ReadVariableArgsForCall(0)
+			Expect(variableType).To(Equal("guestVar"))
+			Expect(name).To(Equal("bosh.agent_env"))
+			Expect(vmxBuilder.SetGuestInfoCallCount()).To(Equal(0))
+		})
+
+		It("is only used when configured", func() {
+			Expect(client.UsesGuestInfoAgentSettings()).To(BeFalse())
+
+			cpiConfig, err := cpiconfig.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"vm_store_path":"` + storeDir + `","agent_settings_source":"guestinfo"}}}}`)
+			Expect(err).ToNot(HaveOccurred())
+			logger := &fakelogger.FakeLogger{}
+			client = driver.NewClient(vmrunRunner, ovftoolRunner, &fakes.FakeOvfImporter{}, &fakes.FakeCloneRunner{}, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, &fakevmnet.FakePortForwards{}, driver.NewConfig(cpiConfig), logger)
+
+			Expect(client.UsesGuestInfoAgentSettings()).To(BeTrue())
+		})
+	})
+
+	Describe("hot-plug", func() {
+		var (
+			vmrunRunner *fakes.FakeVmrunRunner
+			variables   map[string]string
+		)
+
+		BeforeEach(func() {
+			logger := &fakelogger.FakeLogger{}
+			vmrunRunner = &fakes.FakeVmrunRunner{}
+			client = driver.NewClient(vmrunRunner, ovftoolRunner, &fakes.FakeOvfImporter{}, &fakes.FakeCloneRunner{}, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, &fakevmnet.FakePortForwards{}, config, logger)
+
+			Expect(os.MkdirAll(filepath.Dir(config.VmxPath("vm-1")), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(config.VmxPath("vm-1"), []byte{}, 0644)).To(Succeed())
+			Expect(client.CreateDisk("disk-1", 1, "")).To(Succeed())
+
+			vmxVM := &vmx.VM{}
+			vmxVM.SCSIDevices = []govmx.SCSIDevice{{Device: govmx.Device{VMXID: "scsi0:0", Filename: "ephemeral.vmdk"}}}
+			vmxBuilder.GetVmxReturns(vmxVM, nil)
+
+			variables = map[string]string{}
+			vmrunRunner.ListReturns(config.VmxPath("vm-1"), nil)
+			vmrunRunner.ReadVariableStub = func(_ string, variableType string, name string) (string, error) {
+				Expect(variableType).To(Equal("runtimeConfig"))
+				return variables[name], nil
+			}
+			vmrunRunner.WriteVariableStub = func(_ string, variableType string, name string, value string) error {
+				Expect(variableType).To(Equal("runtimeConfig"))
+				variables[name] = value
+				return nil
+			}
+		})
+
+		It("plugs disks into the first free unit of a running vm", func() {
+			variables["scsi0:1.present"] = "TRUE"
+			variables["scsi0:1.fileName"] = "other-disk.vmdk"
+
+			deviceID, err := client.HotAttachDisk("vm-1", "disk-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deviceID).To(Equal("scsi0:2"))
+
+			Expect(variables["scsi0:2.fileName"]).To(Equal(filepath.Join(storeDir, "persistent-disks", "disk-1.vmdk")))
+			Expect(variables["scsi0:2.present"]).To(Equal("TRUE"))
+			vmxPath, deviceName := vmrunRunner.ConnectNamedDeviceArgsForCall(0)
+			Expect(vmxPath).To(Equal(config.VmxPath("vm-1")))
+			Expect(deviceName).To(Equal("scsi0:2"))
+			Expect(vmxBuilder.AttachDiskCallCount()).To(Equal(0))
+		})
+
+		It("unplugs disks from a running vm", func() {
+			variables["scsi0:1.present"] = "TRUE"
+			variables["scsi0:1.fileName"] = filepath.Join(storeDir, "persistent-disks", "disk-1.vmdk")
+
+			Expect(client.HotDetachDisk("vm-1", "disk-1")).To(Succeed())
+
+			_, deviceName := vmrunRunner.DisconnectNamedDeviceArgsForCall(0)
+			Expect(deviceName).To(Equal("scsi0:1"))
+			Expect(variables["scsi0:1.present"]).To(Equal("FALSE"))
+			Expect(vmxBuilder.DetachDiskCallCount()).To(Equal(0))
+
+			Expect(client.HotDetachDisk("vm-1", "disk-1")).To(MatchError("disk disk-1 is not attached"))
+		})
+
+		It("stops reading vmrun variables after the first unused unit", func() {
+			variables["scsi0:1.present"] = "TRUE"
+			variables["scsi0:1.fileName"] = "other-disk.vmdk"
+
+			deviceID, err := client.HotAttachDisk("vm-1", "disk-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deviceID).To(Equal("scsi0:2"))
+
+			// scsi0:0 present, scsi0:1 present and fileName, scsi0:2 present
+			Expect(vmrunRunner.ReadVariableCallCount()).To(Equal(4))
+		})
+
+		It("treats vmrun errors reading unset variables as unused units", func() {
+			vmrunRunner.ReadVariableStub = func(_ string, _ string, name string) (string, error) {
+				value, found := variables[name]
+				if !found {
+					return "", errors.New("Error: Unknown variable")
+				}
+				return value, nil
+			}
+			variables["scsi0:0.present"] = "TRUE"
+			variables["scsi0:0.fileName"] = "ephemeral.vmdk"
+
+			deviceID, err := client.HotAttachDisk("vm-1", "disk-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deviceID).To(Equal("scsi0:1"))
+		})
+
+		It("fails when vmrun cannot read the variables of a unit in the vmx", func() {
+			vmrunRunner.ReadVariableReturns("", errors.New("Error: The virtual machine is not powered on"))
+			vmrunRunner.ReadVariableStub = nil
+
+			_, err := client.HotAttachDisk("vm-1", "disk-1")
+			Expect(err).To(MatchError("Error: The virtual machine is not powered on"))
+			Expect(vmrunRunner.WriteVariableCallCount()).To(Equal(0))
+		})
+
+		It("marks the device not present when connecting fails", func() {
+			vmrunRunner.ConnectNamedDeviceReturns(errors.New("operation not supported"))
+
+			_, err := client.HotAttachDisk("vm-1", "disk-1")
+			Expect(err).To(MatchError("operation not supported"))
+			Expect(variables["scsi0:1.present"]).To(Equal("FALSE"))
+		})
+
+		It("refuses vms that are not running", func() {
+			vmrunRunner.ListReturns("", nil)
+
+			_, err := client.HotAttachDisk("vm-1", "disk-1")
+			Expect(err).To(MatchError("vm is not running"))
+			Expect(vmrunRunner.WriteVariableCallCount()).To(Equal(0))
+		})
+
+		It("refuses nvme disk controllers", func() {
+			vmxBuilder.GetVmxReturns(&vmx.VM{NVMeDevices: []vmx.NVMeDevice{{Device: govmx.Device{VMXID: "nvme0", Present: true}}}}, nil)
+
+			_, err := client.HotAttachDisk("vm-1", "disk-1")
+			Expect(err).To(MatchError("hot-plug is not supported for nvme disks"))
+		})
+	})
+
+	Describe("dhcp reservations", func() {
+		It("does nothing without a dhcp config path", func() {
+			Expect(client.AddDHCPReservation("vm-1", "00:50:56:00:00:01", "10.0.0.5")).To(Succeed())
+			Expect(client.RemoveDHCPReservations("vm-1")).To(Succeed())
+
+			Expect(dhcpReservations.AddCallCount()).To(Equal(0))
+			Expect(dhcpReservations.RemoveCallCount()).To(Equal(0))
+		})
+
+		It("writes reservations named after the vm", func() {
+			cpiConfig, err := cpiconfig.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"vm_store_path":"` + storeDir + `","dhcp_config_path":"/dhcpd.conf"}}}}`)
+			Expect(err).ToNot(HaveOccurred())
+			config = driver.NewConfig(cpiConfig)
+			logger := &fakelogger.FakeLogger{}
+			client = driver.NewClient(&fakes.FakeVmrunRunner{}, ovftoolRunner, &fakes.FakeOvfImporter{}, &fakes.FakeCloneRunner{}, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, &fakevmnet.FakePortForwards{}, config, logger)
+
+			Expect(client.AddDHCPReservation("vm-1", "00:50:56:00:00:01", "10.0.0.5")).To(Succeed())
+			configPath, host := dhcpReservations.AddArgsForCall(0)
+			Expect(configPath).To(Equal("/dhcpd.conf"))
+			Expect(host).To(Equal(vmnet.DhcpHost{Name: "vm-1-005056000001", MACAddress: "00:50:56:00:00:01", IPAddress: "10.0.0.5"}))
+
+			Expect(client.RemoveDHCPReservations("vm-1")).To(Succeed())
+			configPath, hostNamePrefix := dhcpReservations.RemoveArgsForCall(0)
+			Expect(configPath).To(Equal("/dhcpd.conf"))
+			Expect(hostNamePrefix).To(Equal("vm-1-"))
+		})
+	})
+
+	Describe("port forwards", func() {
+		var portForwards *fakevmnet.FakePortForwards
+
+		BeforeEach(func() {
+			portForwards = &fakevmnet.FakePortForwards{}
+			logger := &fakelogger.FakeLogger{}
+			client = driver.NewClient(&fakes.FakeVmrunRunner{}, ovftoolRunner, &fakes.FakeOvfImporter{}, &fakes.FakeCloneRunner{}, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, portForwards, config, logger)
+		})
+
+		It("requires a nat config path for port forwards", func() {
+			Expect(client.AddPortForwards("vm-1", []vmnet.PortForward{})).To(Succeed())
+
+			err := client.AddPortForwards("vm-1", []vmnet.PortForward{{Protocol: "tcp", HostPort: 8080, GuestIP: "10.0.0.5", GuestPort: 80}})
+			Expect(err).To(MatchError("port forwards require vmrun.nat_config_path to be configured"))
+
+			Expect(client.RemovePortForwards("vm-1")).To(Succeed())
+			Expect(portForwards.AddCallCount()).To(Equal(0))
+			Expect(portForwards.RemoveCallCount()).To(Equal(0))
+		})
+
+		It("writes port forwards owned by the vm", func() {
+			cpiConfig, err := cpiconfig.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{"vm_store_path":"` + storeDir + `","nat_config_path":"/nat.conf"}}}}`)
+			Expect(err).ToNot(HaveOccurred())
+			config = driver.NewConfig(cpiConfig)
+			logger := &fakelogger.FakeLogger{}
+			client = driver.NewClient(&fakes.FakeVmrunRunner{}, ovftoolRunner, &fakes.FakeOvfImporter{}, &fakes.FakeCloneRunner{}, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, portForwards, config, logger)
+
+			Expect(client.AddPortForwards("vm-1", []vmnet.PortForward{{Protocol: "tcp", HostPort: 8080, GuestIP: "10.0.0.5", GuestPort: 80}})).To(Succeed())
+			configPath, added := portForwards.AddArgsForCall(0)
+			Expect(configPath).To(Equal("/nat.conf"))
+			Expect(added).To(Equal([]vmnet.PortForward{{Protocol: "tcp", HostPort: 8080, GuestIP: "10.0.0.5", GuestPort: 80, Owner: "vm-1"}}))
+
+			Expect(client.RemovePortForwards("vm-1")).To(Succeed())
+			configPath, owner := portForwards.RemoveArgsForCall(0)
+			Expect(configPath).To(Equal("/nat.conf"))
+			Expect(owner).To(Equal("vm-1"))
+		})
+	})
+
+	Describe("configured datastores", func() {
+		var cloneRunner *fakes.FakeCloneRunner
+
+		BeforeEach(func() {
+			cpiConfig, err := cpiconfig.NewConfigFromJson(`{"cloud":{"properties":{"vmrun":{
+				"vm_store_path":"` + storeDir + `",
+				"datastores":[{"name":"ssd","path":"` + datastoreDir + `"}]
+			}}}}`)
+			Expect(err).ToNot(HaveOccurred())
+
+			cloneRunner = &fakes.FakeCloneRunner{}
+			config = driver.NewConfig(cpiConfig)
+			logger := &fakelogger.FakeLogger{}
+
+			client = driver.NewClient(&fakes.FakeVmrunRunner{}, ovftoolRunner, &fakes.FakeOvfImporter{}, cloneRunner, vmxBuilder, vmdk.NewVmdkReader(logger), driver.NewDatastorePlacer(config, &fakes.FakeRetryFileLock{}, logger), diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, &fakevmnet.FakePortForwards{}, config, logger)
+		})
+
+		It("clones vms onto the placed datastore and finds them later", func() {
+			Expect(client.CloneVM("cs-stemcell", "vm-1", "", 0)).To(Succeed())
+
+			sourceVmxPath, targetVmxPath, targetVmName := cloneRunner.CloneArgsForCall(0)
+			Expect(sourceVmxPath).To(Equal(filepath.Join(storeDir, "cs-stemcell", "cs-stemcell.vmx")))
+			Expect(targetVmxPath).To(Equal(filepath.Join(datastoreDir, "vm-1", "vm-1.vmx")))
+			Expect(targetVmName).To(Equal("vm-1"))
+
+			Expect(config.VmxPath("vm-1")).To(Equal(filepath.Join(datastoreDir, "vm-1", "vm-1.vmx")))
+			Expect(config.EphemeralDiskPath("vm-1")).To(Equal(filepath.Join(datastoreDir, "ephemeral-disks", "vm-1.vmdk")))
+		})
+
+		It("reports disk info", func() {
+			Expect(client.CreateDisk("disk-1", 16, "ssd")).To(Succeed())
+
+			diskInfo, err := client.GetDiskInfo("disk-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskInfo.Path).To(Equal(filepath.Join(datastoreDir, "persistent-disks", "disk-1.vmdk")))
+			Expect(diskInfo.CreateType).To(Equal("monolithicSparse"))
+			Expect(diskInfo.VirtualSizeBytes).To(Equal(int64(16 * 1024 * 1024)))
+			Expect(diskInfo.AllocatedSizeBytes).To(Equal(int64(512)))
+		})
+
+		It("refuses to attach invalid disks", func() {
+			Expect(client.CreateDisk("disk-1", 16, "ssd")).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(datastoreDir, "persistent-disks", "disk-1.vmdk"), []byte("garbage"), 0644)).To(Succeed())
+
+			err := client.AttachDisk("vm-1", "disk-1")
+			Expect(err).To(MatchError(ContainSubstring("invalid disk disk-1")))
+			Expect(vmxBuilder.AttachDiskCallCount()).To(Equal(0))
+		})
+
+		It("removes the datastore mapping when cloning fails", func() {
+			cloneRunner.CloneReturns(errors.New("clone-err"))
+
+			Expect(client.CloneVM("cs-stemcell", "vm-1", "", 0)).To(MatchError("clone-err"))
+			Expect(config.VmMappingPath("vm-1")).ToNot(BeAnExistingFile())
+			Expect(config.VmxPath("vm-1")).To(Equal(filepath.Join(storeDir, "vm-1", "vm-1.vmx")))
+		})
+
+		It("places clones on a datastore with room for the stemcell disk and the required space", func() {
+			Expect(os.MkdirAll(filepath.Join(storeDir, "cs-stemcell"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(storeDir, "cs-stemcell", "cs-stemcell-disk1.vmdk"), make([]byte, 2*1024*1024), 0644)).To(Succeed())
+
+			datastorePlacer := &fakes.FakeDatastorePlacer{}
+			datastorePlacer.PlaceReturns(driver.Datastore{Name: "ssd", Path: datastoreDir}, nil)
+			logger := &fakelogger.FakeLogger{}
+			client = driver.NewClient(&fakes.FakeVmrunRunner{}, ovftoolRunner, &fakes.FakeOvfImporter{}, cloneRunner, vmxBuilder, vmdk.NewVmdkReader(logger), datastorePlacer, diskMigrator, &fakes.FakeMacAllocator{}, &fakes.FakeStemcellRegistry{}, dhcpReservations, &fakevmnet.FakePortForwards{}, config, logger)
+
+			Expect(client.CloneVM("cs-stemcell", "vm-1", "ssd", 3072)).To(Succeed())
+
+			datastoreName, requiredMB := datastorePlacer.PlaceArgsForCall(0)
+			Expect(datastoreName).To(Equal("ssd"))
+			Expect(requiredMB).To(Equal(3072 + 2))
+		})
+
+		It("fails to clone onto an unknown datastore", func() {
+			Expect(client.CloneVM("cs-stemcell", "vm-1", "hdd", 0)).To(MatchError("datastore not found: hdd"))
+			Expect(cloneRunner.CloneCallCount()).To(Equal(0))
+		})
+
+		It("creates disks on the placed datastore and finds them later", func() {
+			Expect(client.CreateDisk("disk-1", 1, "ssd")).To(Succeed())
+
+			diskPath, _ := ovftoolRunner.CreateDiskArgsForCall(0)
+			Expect(diskPath).To(Equal(filepath.Join(datastoreDir, "persistent-disks", "disk-1.vmdk")))
+			Expect(client.HasDisk("disk-1")).To(BeTrue())
+		})
+
+		It("migrates placed disks on another volume to the vm's datastore when attaching", func() {
+			otherDatastoreDir := filepath.Join(storeDir, "other-datastore", "persistent-disks")
+			Expect(os.MkdirAll(otherDatastoreDir, 0755)).To(Succeed())
+			Expect(ovftoolRunner.CreateDiskStub(filepath.Join(otherDatastoreDir, "disk-1.vmdk"), 1)).To(Succeed())
+			Expect(ioutil.WriteFile(config.PersistentDiskMappingPath("disk-1"), []byte(otherDatastoreDir), 0644)).To(Succeed())
+			Expect(client.CloneVM("cs-stemcell", "vm-1", "ssd", 0)).To(Succeed())
+
+			diskMigrator.NeedsMigrationReturns(true, nil)
+			diskMigrator.CopyStub = driver.NewDiskMigrator(&fakelogger.FakeLogger{}).Copy
+			diskMigrator.RemoveStub = driver.NewDiskMigrator(&fakelogger.FakeLogger{}).Remove
+
+			Expect(client.AttachDisk("vm-1", "disk-1")).To(Succeed())
+
+			diskPath, targetDir := diskMigrator.NeedsMigrationArgsForCall(0)
+			Expect(diskPath).To(Equal(filepath.Join(otherDatastoreDir, "disk-1.vmdk")))
+			Expect(targetDir).To(Equal(filepath.Join(datastoreDir, "persistent-disks")))
+
+			attachedDiskPath, _ := vmxBuilder.AttachDiskArgsForCall(0)
+			Expect(attachedDiskPath).To(Equal(filepath.Join(datastoreDir, "persistent-disks", "disk-1.vmdk")))
+			Expect(attachedDiskPath).To(BeAnExistingFile())
+			Expect(filepath.Join(otherDatastoreDir, "disk-1.vmdk")).ToNot(BeAnExistingFile())
+			Expect(ioutil.ReadFile(config.PersistentDiskMappingPath("disk-1"))).To(Equal([]byte(filepath.Join(datastoreDir, "persistent-disks"))))
+			Expect(client.HasDisk("disk-1")).To(BeTrue())
+		})
+
+		It("migrates placed disks on another volume to the persistent disk store when attaching to a vm in the vm store", func() {
+			placedDiskDir := filepath.Join(datastoreDir, "persistent-disks")
+			Expect(os.MkdirAll(placedDiskDir, 0755)).To(Succeed())
+			Expect(ovftoolRunner.CreateDiskStub(filepath.Join(placedDiskDir, "disk-1.vmdk"), 1)).To(Succeed())
+			Expect(ioutil.WriteFile(config.PersistentDiskMappingPath("disk-1"), []byte(placedDiskDir), 0644)).To(Succeed())
+
+			diskMigrator.NeedsMigrationReturns(true, nil)
+			diskMigrator.CopyStub = driver.NewDiskMigrator(&fakelogger.FakeLogger{}).Copy
+			diskMigrator.RemoveStub = driver.NewDiskMigrator(&fakelogger.FakeLogger{}).Remove
+
+			Expect(client.AttachDisk("vm-1", "disk-1")).To(Succeed())
+
+			diskPath, targetDir := diskMigrator.NeedsMigrationArgsForCall(0)
+			Expect(diskPath).To(Equal(filepath.Join(placedDiskDir, "disk-1.vmdk")))
+			Expect(targetDir).To(Equal(filepath.Join(storeDir, "persistent-disks")))
+
+			attachedDiskPath, vmxPath := vmxBuilder.AttachDiskArgsForCall(0)
+			Expect(vmxPath).To(Equal(filepath.Join(storeDir, "vm-1", "vm-1.vmx")))
+			Expect(attachedDiskPath).To(Equal(filepath.Join(storeDir, "persistent-disks", "disk-1.vmdk")))
+			Expect(attachedDiskPath).To(BeAnExistingFile())
+			Expect(filepath.Join(placedDiskDir, "disk-1.vmdk")).ToNot(BeAnExistingFile())
+			Expect(config.PersistentDiskMappingPath("disk-1")).ToNot(BeAnExistingFile())
+			Expect(client.HasDisk("disk-1")).To(BeTrue())
+		})
+
+		It("keeps disks pinned to a datastore when attaching", func() {
+			pinnedDatastoreDir := filepath.Join(storeDir, "pinned-datastore")
+			Expect(os.MkdirAll(pinnedDatastoreDir, 0755)).To(Succeed())
+			Expect(client.CreateDisk("disk-1", 1, pinnedDatastoreDir)).To(Succeed())
+			Expect(client.CloneVM("cs-stemcell", "vm-1", "ssd", 0)).To(Succeed())
+
+			diskMigrator.NeedsMigrationReturns(true, nil)
+
+			Expect(client.AttachDisk("vm-1", "disk-1")).To(Succeed())
+
+			Expect(diskMigrator.CopyCallCount()).To(Equal(0))
+			attachedDiskPath, _ := vmxBuilder.AttachDiskArgsForCall(0)
+			Expect(attachedDiskPath).To(Equal(filepath.Join(pinnedDatastoreDir, "disk-1.vmdk")))
+			Expect(ioutil.ReadFile(config.PersistentDiskMappingPath("disk-1"))).To(Equal([]byte(pinnedDatastoreDir)))
+
+			Expect(client.DestroyDisk("disk-1")).To(Succeed())
+			Expect(config.PersistentDiskMappingPath("disk-1") + ".pinned").ToNot(BeAnExistingFile())
+		})
+
+		It("leaves the disk and its mapping in place when copying fails", func() {
+			otherDatastoreDir := filepath.Join(storeDir, "other-datastore", "persistent-disks")
+			Expect(os.MkdirAll(otherDatastoreDir, 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(otherDatastoreDir, "disk-1.vmdk"), []byte("disk"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(config.PersistentDiskMappingPath("disk-1"), []byte(otherDatastoreDir), 0644)).To(Succeed())
+			Expect(client.CloneVM("cs-stemcell", "vm-1", "ssd", 0)).To(Succeed())
+
+			diskMigrator.NeedsMigrationReturns(true, nil)
+			diskMigrator.CopyReturns("", errors.New("copy-err"))
+
+			Expect(client.AttachDisk("vm-1", "disk-1")).To(MatchError("copy-err"))
+
+			Expect(diskMigrator.RemoveCallCount()).To(Equal(0))
+			Expect(vmxBuilder.AttachDiskCallCount()).To(Equal(0))
+			Expect(ioutil.ReadFile(config.PersistentDiskMappingPath("disk-1"))).To(Equal([]byte(otherDatastoreDir)))
+			Expect(client.HasDisk("disk-1")).To(BeTrue())
+		})
+
+		It("attaches disks in place when no migration is needed", func() {
+			Expect(client.CreateDisk("disk-1", 1, filepath.Join(storeDir, "persistent-disks"))).To(Succeed())
+			Expect(client.CloneVM("cs-stemcell", "vm-1", "ssd", 0)).To(Succeed())
+
+			Expect(client.AttachDisk("vm-1", "disk-1")).To(Succeed())
+
+			Expect(diskMigrator.CopyCallCount()).To(Equal(0))
+			attachedDiskPath, _ := vmxBuilder.AttachDiskArgsForCall(0)
+			Expect(attachedDiskPath).To(Equal(filepath.Join(storeDir, "persistent-disks", "disk-1.vmdk")))
+		})
+	})
+})
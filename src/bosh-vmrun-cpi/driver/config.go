@@ -3,6 +3,7 @@ package driver
 import (
 	cpiconfig "bosh-vmrun-cpi/config"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"time"
@@ -17,11 +18,20 @@ func NewConfig(cpiConfig cpiconfig.Config) Config {
 }
 
 func (c ConfigImpl) VmxPath(vmName string) string {
-	return filepath.Join(c.vmPath(), fmt.Sprintf("%s", vmName), fmt.Sprintf("%s.vmx", vmName))
+	return filepath.Join(c.VmDatastorePath(vmName), fmt.Sprintf("%s", vmName), fmt.Sprintf("%s.vmx", vmName))
+}
+
+func (c ConfigImpl) VmMappingPath(vmName string) string {
+	baseDir := filepath.Join(c.vmPath(), "mappings")
+	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
+		os.MkdirAll(baseDir, 0755)
+	}
+
+	return filepath.Join(baseDir, fmt.Sprintf("%s.mapping", vmName))
 }
 
 func (c ConfigImpl) EphemeralDiskPath(vmName string) string {
-	baseDir := filepath.Join(c.vmPath(), "ephemeral-disks")
+	baseDir := filepath.Join(c.VmDatastorePath(vmName), "ephemeral-disks")
 	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
 		os.MkdirAll(baseDir, 0755)
 	}
@@ -30,7 +40,14 @@ func (c ConfigImpl) EphemeralDiskPath(vmName string) string {
 }
 
 func (c ConfigImpl) PersistentDiskPath(diskId string) string {
-	baseDir := filepath.Join(c.vmPath(), "persistent-disks")
+	diskPath := c.DatastoreDiskPath(c.persistentDiskStorePath(), diskId)
+	legacyDiskPath := filepath.Join(c.legacyPersistentDiskStorePath(), fmt.Sprintf("%s.vmdk", diskId))
+
+	return existingPath(diskPath, legacyDiskPath)
+}
+
+func (c ConfigImpl) DatastoreDiskPath(datastorePath string, diskId string) string {
+	baseDir := datastorePath
 	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
 		os.MkdirAll(baseDir, 0755)
 	}
@@ -38,6 +55,18 @@ func (c ConfigImpl) PersistentDiskPath(diskId string) string {
 	return filepath.Join(baseDir, fmt.Sprintf("%s.vmdk", diskId))
 }
 
+func (c ConfigImpl) PersistentDiskMappingPath(diskId string) string {
+	baseDir := filepath.Join(c.persistentDiskStorePath(), "mappings")
+	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
+		os.MkdirAll(baseDir, 0755)
+	}
+
+	mappingPath := filepath.Join(baseDir, fmt.Sprintf("%s.mapping", diskId))
+	legacyMappingPath := filepath.Join(c.legacyPersistentDiskStorePath(), "mappings", fmt.Sprintf("%s.mapping", diskId))
+
+	return existingPath(mappingPath, legacyMappingPath)
+}
+
 func (c ConfigImpl) EnvIsoPath(vmName string) string {
 	baseDir := filepath.Join(c.vmPath(), "env-isos")
 	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
@@ -47,6 +76,59 @@ func (c ConfigImpl) EnvIsoPath(vmName string) string {
 	return filepath.Join(baseDir, fmt.Sprintf("%s.iso", vmName))
 }
 
+func (c ConfigImpl) DatastorePlacementStatePath() string {
+	return filepath.Join(c.vmPath(), "datastore-placement.state")
+}
+
+func (c ConfigImpl) MacAddressRegistryPath() string {
+	return filepath.Join(c.vmPath(), "mac-addresses.json")
+}
+
+func (c ConfigImpl) StemcellRegistryPath() string {
+	return filepath.Join(c.vmPath(), "stemcell-registry.json")
+}
+
+func (c ConfigImpl) DhcpConfigPath() string {
+	return c.cpiConfig.Cloud.Properties.Vmrun.Dhcp_Config_Path
+}
+
+func (c ConfigImpl) AgentSettingsSource() string {
+	return c.cpiConfig.Cloud.Properties.Vmrun.Agent_Settings_Source
+}
+
+func (c ConfigImpl) NatConfigPath() string {
+	return c.cpiConfig.Cloud.Properties.Vmrun.Nat_Config_Path
+}
+
+func (c ConfigImpl) VmnetConfigPaths() []string {
+	return c.cpiConfig.Cloud.Properties.Vmrun.Vmnet_Config_Paths
+}
+
+func (c ConfigImpl) Datastores() []Datastore {
+	datastores := []Datastore{}
+	for _, datastore := range c.cpiConfig.Cloud.Properties.Vmrun.Datastores {
+		datastores = append(datastores, Datastore{
+			Name:          datastore.Name,
+			Path:          datastore.Path,
+			MaxCapacityMB: datastore.Max_Capacity_MB,
+		})
+	}
+
+	return datastores
+}
+
+func (c ConfigImpl) DatastorePlacement() string {
+	return c.cpiConfig.Cloud.Properties.Vmrun.Datastore_Placement
+}
+
+func (c ConfigImpl) VmStorePath() string {
+	return c.vmPath()
+}
+
+func (c ConfigImpl) PersistentDiskStorePath() string {
+	return c.persistentDiskStorePath()
+}
+
 func (c ConfigImpl) VmrunPath() string {
 	return c.cpiConfig.Cloud.Properties.Vmrun.Vmrun_Bin_Path
 }
@@ -55,6 +137,10 @@ func (c ConfigImpl) OvftoolPath() string {
 	return c.cpiConfig.Cloud.Properties.Vmrun.Ovftool_Bin_Path
 }
 
+func (c ConfigImpl) OvfImporter() string {
+	return c.cpiConfig.Cloud.Properties.Vmrun.Ovf_Importer
+}
+
 func (c ConfigImpl) VmStartMaxWait() time.Duration {
 	return c.cpiConfig.Cloud.Properties.Vmrun.Vm_Start_Max_Wait
 }
@@ -70,3 +156,33 @@ func (c ConfigImpl) EnableHumanReadableName() bool {
 func (c ConfigImpl) vmPath() string {
 	return c.cpiConfig.Cloud.Properties.Vmrun.Vm_Store_Path
 }
+
+// vms cloned onto a separate datastore are located by their mapping file
+func (c ConfigImpl) VmDatastorePath(vmName string) string {
+	datastorePathBytes, err := ioutil.ReadFile(filepath.Join(c.vmPath(), "mappings", fmt.Sprintf("%s.mapping", vmName)))
+	if err != nil {
+		return c.vmPath()
+	}
+
+	return string(datastorePathBytes)
+}
+
+func (c ConfigImpl) persistentDiskStorePath() string {
+	return c.cpiConfig.Cloud.Properties.Vmrun.Persistent_Disk_Store_Path
+}
+
+// disks created before persistent_disk_store_path was set stay in the vm store
+func (c ConfigImpl) legacyPersistentDiskStorePath() string {
+	return filepath.Join(c.vmPath(), "persistent-disks")
+}
+
+func existingPath(path string, legacyPath string) string {
+	if _, err := os.Stat(path); err == nil || path == legacyPath {
+		return path
+	}
+	if _, err := os.Stat(legacyPath); err == nil {
+		return legacyPath
+	}
+
+	return path
+}
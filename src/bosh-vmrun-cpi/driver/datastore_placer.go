@@ -0,0 +1,178 @@
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	cpiconfig "bosh-vmrun-cpi/config"
+)
+
+type datastorePlacerImpl struct {
+	config        Config
+	retryFileLock RetryFileLock
+	logger        boshlog.Logger
+}
+
+const (
+	placementLockMaxWait = 30 * time.Second
+)
+
+func NewDatastorePlacer(config Config, retryFileLock RetryFileLock, logger boshlog.Logger) DatastorePlacer {
+	return &datastorePlacerImpl{config: config, retryFileLock: retryFileLock, logger: logger}
+}
+
+func (p datastorePlacerImpl) Place(datastoreName string, requiredMB int) (Datastore, error) {
+	datastores := p.config.Datastores()
+	if len(datastores) == 0 {
+		if datastoreName != "" {
+			return Datastore{}, fmt.Errorf("datastore not found: %s", datastoreName)
+		}
+
+		return Datastore{Path: p.config.VmStorePath()}, nil
+	}
+
+	if datastoreName != "" {
+		for _, datastore := range datastores {
+			if datastore.Name == datastoreName {
+				return p.checkCapacity(datastore, requiredMB)
+			}
+		}
+
+		return Datastore{}, fmt.Errorf("datastore not found: %s", datastoreName)
+	}
+
+	switch p.config.DatastorePlacement() {
+	case cpiconfig.DATASTORE_PLACEMENT_ROUND_ROBIN:
+		return p.placeRoundRobin(datastores, requiredMB)
+	default:
+		return p.placeMostFreeSpace(datastores, requiredMB)
+	}
+}
+
+func (p datastorePlacerImpl) placeMostFreeSpace(datastores []Datastore, requiredMB int) (Datastore, error) {
+	var placed Datastore
+	var placedFreeMB int64 = -1
+
+	for _, datastore := range datastores {
+		freeMB, err := p.freeMB(datastore)
+		if err != nil {
+			p.logger.Error("datastore-placer", "skipping datastore %s: %s", datastore.Name, err)
+			continue
+		}
+
+		if freeMB >= int64(requiredMB) && freeMB > placedFreeMB {
+			placed = datastore
+			placedFreeMB = freeMB
+		}
+	}
+
+	if placedFreeMB < 0 {
+		return Datastore{}, fmt.Errorf("no datastore has %d MB free", requiredMB)
+	}
+
+	p.logger.Debug("datastore-placer", "placed on datastore %s with %d MB free", placed.Name, placedFreeMB)
+
+	return placed, nil
+}
+
+func (p datastorePlacerImpl) placeRoundRobin(datastores []Datastore, requiredMB int) (Datastore, error) {
+	var placed Datastore
+	statePath := p.config.DatastorePlacementStatePath()
+
+	err := p.retryFileLock.Try(statePath+".lock", placementLockMaxWait, func() error {
+		lastIndex := -1
+		if stateBytes, err := ioutil.ReadFile(statePath); err == nil {
+			if index, err := strconv.Atoi(strings.TrimSpace(string(stateBytes))); err == nil {
+				lastIndex = index
+			}
+		}
+
+		for i := 1; i <= len(datastores); i++ {
+			index := (lastIndex + i) % len(datastores)
+			if index < 0 {
+				index += len(datastores)
+			}
+
+			datastore, err := p.checkCapacity(datastores[index], requiredMB)
+			if err != nil {
+				p.logger.Debug("datastore-placer", "skipping datastore %s: %s", datastores[index].Name, err)
+				continue
+			}
+
+			placed = datastore
+			return ioutil.WriteFile(statePath, []byte(strconv.Itoa(index)), 0644)
+		}
+
+		return fmt.Errorf("no datastore has %d MB free", requiredMB)
+	})
+	if err != nil {
+		return Datastore{}, err
+	}
+
+	p.logger.Debug("datastore-placer", "placed on datastore %s", placed.Name)
+
+	return placed, nil
+}
+
+func (p datastorePlacerImpl) checkCapacity(datastore Datastore, requiredMB int) (Datastore, error) {
+	freeMB, err := p.freeMB(datastore)
+	if err != nil {
+		return Datastore{}, err
+	}
+
+	if freeMB < int64(requiredMB) {
+		return Datastore{}, fmt.Errorf("datastore %s has %d MB free, %d MB required", datastore.Name, freeMB, requiredMB)
+	}
+
+	return datastore, nil
+}
+
+// free space is limited by the filesystem and the datastore's optional capacity limit
+func (p datastorePlacerImpl) freeMB(datastore Datastore) (int64, error) {
+	if _, err := os.Stat(datastore.Path); os.IsNotExist(err) {
+		os.MkdirAll(datastore.Path, 0755)
+	}
+
+	freeBytes, err := diskFreeBytes(datastore.Path)
+	if err != nil {
+		return 0, err
+	}
+	freeMB := int64(freeBytes / (1024 * 1024))
+
+	if datastore.MaxCapacityMB > 0 {
+		usedBytes, err := dirSizeBytes(datastore.Path)
+		if err != nil {
+			return 0, err
+		}
+
+		capacityFreeMB := int64(datastore.MaxCapacityMB) - usedBytes/(1024*1024)
+		if capacityFreeMB < freeMB {
+			freeMB = capacityFreeMB
+		}
+	}
+
+	return freeMB, nil
+}
+
+func dirSizeBytes(dirPath string) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size, err
+}
@@ -0,0 +1,140 @@
+package driver_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/driver/fakes"
+)
+
+var _ = Describe("DatastorePlacer", func() {
+	var (
+		storeDir      string
+		datastoreDirs []string
+		config        *fakes.FakeConfig
+		retryFileLock *fakes.FakeRetryFileLock
+		placer        driver.DatastorePlacer
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		storeDir, err = ioutil.TempDir("", "vm-store-")
+		Expect(err).ToNot(HaveOccurred())
+
+		datastoreDirs = []string{}
+		for i := 0; i < 3; i++ {
+			datastoreDir, err := ioutil.TempDir("", "datastore-")
+			Expect(err).ToNot(HaveOccurred())
+			datastoreDirs = append(datastoreDirs, datastoreDir)
+		}
+
+		config = &fakes.FakeConfig{}
+		config.VmStorePathReturns(storeDir)
+		config.DatastorePlacementStatePathReturns(filepath.Join(storeDir, "datastore-placement.state"))
+
+		retryFileLock = &fakes.FakeRetryFileLock{}
+		retryFileLock.TryStub = func(lockPath string, maxWait time.Duration, fn func() error) error {
+			return fn()
+		}
+
+		placer = driver.NewDatastorePlacer(config, retryFileLock, &fakelogger.FakeLogger{})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(storeDir)
+		for _, datastoreDir := range datastoreDirs {
+			os.RemoveAll(datastoreDir)
+		}
+	})
+
+	Context("without configured datastores", func() {
+		It("places on the vm store", func() {
+			datastore, err := placer.Place("", 1024)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(datastore.Path).To(Equal(storeDir))
+		})
+
+		It("fails for a pinned datastore", func() {
+			_, err := placer.Place("ssd", 0)
+			Expect(err).To(MatchError("datastore not found: ssd"))
+		})
+	})
+
+	Context("with configured datastores", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(filepath.Join(datastoreDirs[0], "used.vmdk"), make([]byte, 3*1024*1024), 0644)).To(Succeed())
+
+			config.DatastoresReturns([]driver.Datastore{
+				{Name: "small", Path: datastoreDirs[0], MaxCapacityMB: 4},
+				{Name: "medium", Path: datastoreDirs[1], MaxCapacityMB: 8},
+				{Name: "large", Path: datastoreDirs[2], MaxCapacityMB: 16},
+			})
+		})
+
+		It("places on the pinned datastore", func() {
+			datastore, err := placer.Place("medium", 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(datastore.Name).To(Equal("medium"))
+			Expect(datastore.Path).To(Equal(datastoreDirs[1]))
+		})
+
+		It("fails when the pinned datastore is over capacity", func() {
+			_, err := placer.Place("small", 2)
+			Expect(err).To(MatchError("datastore small has 1 MB free, 2 MB required"))
+		})
+
+		It("fails for an unknown pinned datastore", func() {
+			_, err := placer.Place("unknown", 0)
+			Expect(err).To(MatchError("datastore not found: unknown"))
+		})
+
+		Context("most free space", func() {
+			BeforeEach(func() {
+				config.DatastorePlacementReturns("most_free_space")
+			})
+
+			It("places on the datastore with the most free space", func() {
+				datastore, err := placer.Place("", 1)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(datastore.Name).To(Equal("large"))
+			})
+
+			It("fails when no datastore has enough free space", func() {
+				_, err := placer.Place("", 32)
+				Expect(err).To(MatchError("no datastore has 32 MB free"))
+			})
+		})
+
+		Context("round robin", func() {
+			BeforeEach(func() {
+				config.DatastorePlacementReturns("round_robin")
+			})
+
+			It("cycles through datastores with enough free space", func() {
+				placedNames := []string{}
+				for i := 0; i < 4; i++ {
+					datastore, err := placer.Place("", 2)
+					Expect(err).ToNot(HaveOccurred())
+					placedNames = append(placedNames, datastore.Name)
+				}
+
+				Expect(placedNames).To(Equal([]string{"medium", "large", "medium", "large"}))
+				Expect(retryFileLock.TryCallCount()).To(Equal(4))
+			})
+
+			It("fails when no datastore has enough free space", func() {
+				_, err := placer.Place("", 32)
+				Expect(err).To(MatchError("no datastore has 32 MB free"))
+			})
+		})
+	})
+})
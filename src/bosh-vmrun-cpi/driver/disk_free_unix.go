@@ -0,0 +1,18 @@
+// +build linux darwin
+
+package driver
+
+import (
+	"syscall"
+)
+
+func diskFreeBytes(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, err
+	}
+
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,24 @@
+package driver
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceExW = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+func diskFreeBytes(path string) (uint64, error) {
+	var freeBytesAvailable uint64
+
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	ret, _, err := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(&freeBytesAvailable)), 0, 0)
+	if ret == 0 {
+		return 0, err
+	}
+
+	return freeBytesAvailable, nil
+}
@@ -0,0 +1,217 @@
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type diskMigratorImpl struct {
+	logger boshlog.Logger
+}
+
+const (
+	vmdkDescriptorHeader = "# Disk DescriptorFile"
+)
+
+// extent lines look like: RW 8323072 SPARSE "disk-1-s001.vmdk"
+var vmdkExtentRegexp = regexp.MustCompile(`^(RW|RDONLY|NOACCESS)\s+\d+\s+\S+\s+"([^"]+)"`)
+
+func NewDiskMigrator(logger boshlog.Logger) DiskMigrator {
+	return &diskMigratorImpl{logger: logger}
+}
+
+func (m diskMigratorImpl) NeedsMigration(diskPath string, targetDir string) (bool, error) {
+	if filepath.Clean(filepath.Dir(diskPath)) == filepath.Clean(targetDir) {
+		return false, nil
+	}
+
+	if _, err := os.Stat(targetDir); os.IsNotExist(err) {
+		os.MkdirAll(targetDir, 0755)
+	}
+
+	onSameVolume, err := sameVolume(filepath.Dir(diskPath), targetDir)
+	if err != nil {
+		return false, err
+	}
+
+	return !onSameVolume, nil
+}
+
+// copies the disk and any extent files referenced by a descriptor file, rewriting extent paths relative to the new
+// location. The source is left in place until Remove, a failed copy removes what it copied
+func (m diskMigratorImpl) Copy(diskPath string, targetDir string) (string, error) {
+	targetDiskPath := filepath.Join(targetDir, filepath.Base(diskPath))
+	if _, err := os.Stat(targetDiskPath); err == nil {
+		return "", fmt.Errorf("disk already exists at target: %s", targetDiskPath)
+	}
+
+	if _, err := os.Stat(targetDir); os.IsNotExist(err) {
+		os.MkdirAll(targetDir, 0755)
+	}
+
+	descriptor, extentPaths, err := readVMDKExtents(diskPath)
+	if err != nil {
+		return "", err
+	}
+
+	if descriptor == nil {
+		m.logger.Debug("disk-migrator", "copying disk %s to %s", diskPath, targetDiskPath)
+
+		return targetDiskPath, copyFile(diskPath, targetDiskPath)
+	}
+
+	var copiedPaths []string
+	for _, extentPath := range extentPaths {
+		targetExtentPath := filepath.Join(targetDir, filepath.Base(extentPath))
+
+		m.logger.Debug("disk-migrator", "copying disk extent %s to %s", extentPath, targetExtentPath)
+
+		err = copyFile(extentPath, targetExtentPath)
+		if err != nil {
+			removeFiles(copiedPaths)
+			return "", err
+		}
+		copiedPaths = append(copiedPaths, targetExtentPath)
+	}
+
+	tmpTargetDiskPath := targetDiskPath + ".tmp"
+	err = ioutil.WriteFile(tmpTargetDiskPath, descriptor, 0644)
+	if err == nil {
+		err = os.Rename(tmpTargetDiskPath, targetDiskPath)
+	}
+	if err != nil {
+		os.Remove(tmpTargetDiskPath)
+		removeFiles(copiedPaths)
+		return "", err
+	}
+
+	return targetDiskPath, nil
+}
+
+// removes a disk and any extent files referenced by its descriptor file
+func (m diskMigratorImpl) Remove(diskPath string) error {
+	_, extentPaths, err := readVMDKExtents(diskPath)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Debug("disk-migrator", "removing disk %s", diskPath)
+
+	for _, extentPath := range extentPaths {
+		err = os.Remove(extentPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return os.Remove(diskPath)
+}
+
+// returns the descriptor of a disk with extent paths relative to its directory and the extent file paths, monolithic
+// disks embed their descriptor and have neither
+func readVMDKExtents(diskPath string) ([]byte, []string, error) {
+	descriptor, isDescriptor, err := readVMDKDescriptor(diskPath)
+	if err != nil || !isDescriptor {
+		return nil, nil, err
+	}
+
+	var rewrittenDescriptor bytes.Buffer
+	var extentPaths []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(descriptor))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := vmdkExtentRegexp.FindStringSubmatch(line); match != nil {
+			extentPath := match[2]
+			if !filepath.IsAbs(extentPath) {
+				extentPath = filepath.Join(filepath.Dir(diskPath), extentPath)
+			}
+			extentPaths = append(extentPaths, extentPath)
+
+			line = strings.Replace(line, `"`+match[2]+`"`, `"`+filepath.Base(extentPath)+`"`, 1)
+		}
+
+		rewrittenDescriptor.WriteString(line + "\n")
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return rewrittenDescriptor.Bytes(), extentPaths, nil
+}
+
+// returns the content of a text descriptor file, monolithic disks embed their descriptor and are moved as-is
+func readVMDKDescriptor(diskPath string) ([]byte, bool, error) {
+	diskFile, err := os.Open(diskPath)
+	if err != nil {
+		return nil, false, err
+	}
+	defer diskFile.Close()
+
+	header := make([]byte, len(vmdkDescriptorHeader))
+	_, err = io.ReadFull(diskFile, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	if string(header) != vmdkDescriptorHeader {
+		return nil, false, nil
+	}
+
+	descriptor, err := ioutil.ReadFile(diskPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return descriptor, true, nil
+}
+
+// copies through a temporary file so the target only exists once it is complete
+func copyFile(sourcePath string, targetPath string) error {
+	sourceFile, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	tmpTargetPath := targetPath + ".tmp"
+	tmpTargetFile, err := os.OpenFile(tmpTargetPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tmpTargetFile, sourceFile)
+	if err == nil {
+		err = tmpTargetFile.Sync()
+	}
+	if closeErr := tmpTargetFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpTargetPath, targetPath)
+	}
+	if err != nil {
+		os.Remove(tmpTargetPath)
+		return err
+	}
+
+	return nil
+}
+
+func removeFiles(paths []string) {
+	for _, path := range paths {
+		os.Remove(path)
+	}
+}
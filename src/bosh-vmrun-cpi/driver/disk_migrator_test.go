@@ -0,0 +1,141 @@
+package driver_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/driver"
+)
+
+var _ = Describe("DiskMigrator", func() {
+	var (
+		sourceDir    string
+		targetDir    string
+		diskMigrator driver.DiskMigrator
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		sourceDir, err = ioutil.TempDir("", "source-datastore-")
+		Expect(err).ToNot(HaveOccurred())
+
+		targetDir, err = ioutil.TempDir("", "target-datastore-")
+		Expect(err).ToNot(HaveOccurred())
+
+		diskMigrator = driver.NewDiskMigrator(&fakelogger.FakeLogger{})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(sourceDir)
+		os.RemoveAll(targetDir)
+	})
+
+	Describe("NeedsMigration", func() {
+		It("does not migrate disks already in the target directory", func() {
+			needsMigration, err := diskMigrator.NeedsMigration(filepath.Join(targetDir, "disk-1.vmdk"), targetDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(needsMigration).To(BeFalse())
+		})
+
+		It("does not migrate disks on the same volume", func() {
+			needsMigration, err := diskMigrator.NeedsMigration(filepath.Join(sourceDir, "disk-1.vmdk"), filepath.Join(sourceDir, "other"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(needsMigration).To(BeFalse())
+		})
+	})
+
+	Describe("Copy", func() {
+		It("copies monolithic disks", func() {
+			diskPath := filepath.Join(sourceDir, "disk-1.vmdk")
+			Expect(ioutil.WriteFile(diskPath, []byte("KDMV-sparse-content"), 0644)).To(Succeed())
+
+			targetDiskPath, err := diskMigrator.Copy(diskPath, filepath.Join(targetDir, "persistent-disks"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(targetDiskPath).To(Equal(filepath.Join(targetDir, "persistent-disks", "disk-1.vmdk")))
+			Expect(ioutil.ReadFile(targetDiskPath)).To(Equal([]byte("KDMV-sparse-content")))
+			Expect(diskPath).To(BeAnExistingFile())
+		})
+
+		It("copies descriptor extents and rewrites their paths", func() {
+			diskPath := filepath.Join(sourceDir, "disk-1.vmdk")
+			Expect(ioutil.WriteFile(diskPath, []byte(`# Disk DescriptorFile
+version=1
+createType="twoGbMaxExtentSparse"
+
+# Extent description
+RW 4192256 SPARSE "disk-1-s001.vmdk"
+RW 2048 SPARSE "`+filepath.Join(sourceDir, "disk-1-s002.vmdk")+`"
+
+ddb.adapterType = "lsilogic"
+`), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(sourceDir, "disk-1-s001.vmdk"), []byte("extent-1"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(sourceDir, "disk-1-s002.vmdk"), []byte("extent-2"), 0644)).To(Succeed())
+
+			targetDiskPath, err := diskMigrator.Copy(diskPath, targetDir)
+			Expect(err).ToNot(HaveOccurred())
+
+			descriptor, err := ioutil.ReadFile(targetDiskPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(descriptor)).To(ContainSubstring(`RW 4192256 SPARSE "disk-1-s001.vmdk"`))
+			Expect(string(descriptor)).To(ContainSubstring(`RW 2048 SPARSE "disk-1-s002.vmdk"`))
+			Expect(string(descriptor)).To(ContainSubstring(`ddb.adapterType = "lsilogic"`))
+
+			Expect(ioutil.ReadFile(filepath.Join(targetDir, "disk-1-s001.vmdk"))).To(Equal([]byte("extent-1")))
+			Expect(ioutil.ReadFile(filepath.Join(targetDir, "disk-1-s002.vmdk"))).To(Equal([]byte("extent-2")))
+
+			files, err := ioutil.ReadDir(sourceDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(HaveLen(3))
+		})
+
+		It("removes copied extents when a copy fails", func() {
+			diskPath := filepath.Join(sourceDir, "disk-1.vmdk")
+			Expect(ioutil.WriteFile(diskPath, []byte(`# Disk DescriptorFile
+RW 4192256 SPARSE "disk-1-s001.vmdk"
+RW 2048 SPARSE "disk-1-s002.vmdk"
+`), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(sourceDir, "disk-1-s001.vmdk"), []byte("extent-1"), 0644)).To(Succeed())
+
+			_, err := diskMigrator.Copy(diskPath, targetDir)
+			Expect(err).To(HaveOccurred())
+
+			files, err := ioutil.ReadDir(targetDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(BeEmpty())
+			Expect(filepath.Join(sourceDir, "disk-1-s001.vmdk")).To(BeAnExistingFile())
+		})
+
+		It("refuses to overwrite an existing disk", func() {
+			Expect(ioutil.WriteFile(filepath.Join(sourceDir, "disk-1.vmdk"), []byte("source"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(targetDir, "disk-1.vmdk"), []byte("target"), 0644)).To(Succeed())
+
+			_, err := diskMigrator.Copy(filepath.Join(sourceDir, "disk-1.vmdk"), targetDir)
+			Expect(err).To(MatchError(ContainSubstring("disk already exists at target")))
+			Expect(filepath.Join(sourceDir, "disk-1.vmdk")).To(BeAnExistingFile())
+		})
+	})
+
+	Describe("Remove", func() {
+		It("removes the descriptor and its extents", func() {
+			diskPath := filepath.Join(sourceDir, "disk-1.vmdk")
+			Expect(ioutil.WriteFile(diskPath, []byte(`# Disk DescriptorFile
+RW 4192256 SPARSE "disk-1-s001.vmdk"
+`), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(sourceDir, "disk-1-s001.vmdk"), []byte("extent-1"), 0644)).To(Succeed())
+
+			Expect(diskMigrator.Remove(diskPath)).To(Succeed())
+
+			files, err := ioutil.ReadDir(sourceDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(files).To(BeEmpty())
+		})
+	})
+})
@@ -2,29 +2,51 @@ package driver
 
 import (
 	"time"
+
+	"bosh-vmrun-cpi/vmdk"
+	"bosh-vmrun-cpi/vmnet"
 )
 
 //go:generate counterfeiter -o fakes/fake_client.go driver.go Client
 type Client interface {
 	ImportOvf(string, string) (bool, error)
-	CloneVM(string, string) error
+	CloneVM(string, string, string, int) error
 	GetVMIsoPath(string) string
 	UpdateVMIso(string, string) error
+	UsesGuestInfoAgentSettings() bool
+	GetVMGuestInfo(string, string) (string, error)
+	SetVMGuestInfo(string, string, string) error
 	StartVM(string) error
 	StopVM(string) error
 	NeedsVMNameChange(vmName string) bool
 	HasVM(string) bool
 	SetVMDisplayName(vmName string, displayName string) error
-	SetVMNetworkAdapter(string, string, string) error
+	LockStemcellVM(string, func() error) error
+	AcquireStemcellVM(string) (string, error)
+	RegisterStemcellVM(string, string) error
+	ReleaseStemcellVM(string) (bool, error)
+	AllocateMACAddress(string) (string, error)
+	ReleaseMACAddresses(string) error
+	GetHostNetworkNames() (map[string]string, error)
+	AddDHCPReservation(string, string, string) error
+	RemoveDHCPReservations(string) error
+	AddPortForwards(string, []vmnet.PortForward) error
+	RemovePortForwards(string) error
+	SetVMNetworkAdapter(string, string, string, string, string) error
 	SetVMResources(string, int, int) error
+	SetVMDiskController(string, string) error
 	CreateEphemeralDisk(string, int) error
-	CreateDisk(string, int) error
+	CreateDisk(string, int, string) error
 	AttachDisk(string, string) error
+	HotAttachDisk(string, string) (string, error)
+	HotDetachDisk(string, string) error
 	DetachDisk(string, string) error
 	DestroyDisk(string) error
 	HasDisk(string) bool
+	GetDiskInfo(string) (vmdk.Info, error)
 	DestroyVM(string) error
 	GetVMInfo(string) (VMInfo, error)
+	GetVMIPAddress(string) (string, error)
 	BootstrapVM(string, string, string, string, string, string, string, time.Duration, time.Duration) error
 }
 
@@ -34,13 +56,56 @@ type Config interface {
 	EphemeralDiskPath(vmName string) string
 	EnvIsoPath(vmName string) string
 	PersistentDiskPath(diskId string) string
+	DatastoreDiskPath(datastorePath string, diskId string) string
+	PersistentDiskMappingPath(diskId string) string
+	VmMappingPath(vmName string) string
+	VmDatastorePath(vmName string) string
+	VmStorePath() string
+	PersistentDiskStorePath() string
+	Datastores() []Datastore
+	DatastorePlacement() string
+	DatastorePlacementStatePath() string
+	MacAddressRegistryPath() string
+	StemcellRegistryPath() string
+	DhcpConfigPath() string
+	NatConfigPath() string
+	AgentSettingsSource() string
+	VmnetConfigPaths() []string
 	OvftoolPath() string
+	OvfImporter() string
 	VmrunPath() string
 	VmStartMaxWait() time.Duration
 	VmSoftShutdownMaxWait() time.Duration
 	EnableHumanReadableName() bool
 }
 
+//go:generate counterfeiter -o fakes/fake_datastore_placer.go driver.go DatastorePlacer
+type DatastorePlacer interface {
+	Place(datastoreName string, requiredMB int) (Datastore, error)
+}
+
+//go:generate counterfeiter -o fakes/fake_disk_migrator.go driver.go DiskMigrator
+type DiskMigrator interface {
+	NeedsMigration(diskPath string, targetDir string) (bool, error)
+	Copy(diskPath string, targetDir string) (string, error)
+	Remove(diskPath string) error
+}
+
+//go:generate counterfeiter -o fakes/fake_mac_allocator.go driver.go MacAllocator
+type MacAllocator interface {
+	Allocate(vmName string) (string, error)
+	Release(vmName string) error
+}
+
+//go:generate counterfeiter -o fakes/fake_stemcell_registry.go driver.go StemcellRegistry
+type StemcellRegistry interface {
+	Lock(stemcellKey string, fn func() error) error
+	Acquire(stemcellKey string) (string, error)
+	Register(stemcellKey string, stemcellVmName string) error
+	Release(stemcellVmName string) (bool, error)
+	Keys() ([]string, error)
+}
+
 //go:generate counterfeiter -o fakes/fake_retry_file_lock.go driver.go RetryFileLock
 type RetryFileLock interface {
 	Try(string, time.Duration, func() error) error
@@ -56,6 +121,11 @@ type VmrunRunner interface {
 	SoftStop(string) error
 	HardStop(string) error
 	Delete(string) error
+	GetGuestIPAddress(string) (string, error)
+	ReadVariable(string, string, string) (string, error)
+	WriteVariable(string, string, string, string) error
+	ConnectNamedDevice(string, string) error
+	DisconnectNamedDevice(string, string) error
 	CopyFileFromHostToGuest(string, string, string, string, string) error
 	RunProgramInGuest(string, string, string, string, string) error
 	ListProcessesInGuest(string, string, string) (string, error)
@@ -69,11 +139,27 @@ type OvftoolRunner interface {
 	CreateDisk(string, int) error
 }
 
+//go:generate counterfeiter -o fakes/fake_disk_creator.go driver.go DiskCreator
+type DiskCreator interface {
+	CreateDisk(diskPath string, diskMB int) error
+}
+
+//go:generate counterfeiter -o fakes/fake_ovf_importer.go driver.go OvfImporter
+type OvfImporter interface {
+	ImportOvf(ovfPath, vmxPath, vmName string) error
+}
+
 //go:generate counterfeiter -o fakes/fake_clone_runner.go driver.go CloneRunner
 type CloneRunner interface {
 	Clone(sourceVmxPath, targetVmxPath, targetVmName string) error
 }
 
+type Datastore struct {
+	Name          string
+	Path          string
+	MaxCapacityMB int
+}
+
 //TODO: move to vm package
 type VMInfo struct {
 	Name string
@@ -87,5 +173,7 @@ type VMInfo struct {
 		ID   string
 		Path string
 	}
-	CleanShutdown bool
+	DiskController string
+	SCSIDisks      int
+	CleanShutdown  bool
 }
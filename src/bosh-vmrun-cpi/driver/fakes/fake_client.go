@@ -3,11 +3,64 @@ package fakes
 
 import (
 	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/vmdk"
+	"bosh-vmrun-cpi/vmnet"
 	"sync"
 	"time"
 )
 
 type FakeClient struct {
+	AcquireStemcellVMStub        func(string) (string, error)
+	acquireStemcellVMMutex       sync.RWMutex
+	acquireStemcellVMArgsForCall []struct {
+		arg1 string
+	}
+	acquireStemcellVMReturns struct {
+		result1 string
+		result2 error
+	}
+	acquireStemcellVMReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	AddDHCPReservationStub        func(string, string, string) error
+	addDHCPReservationMutex       sync.RWMutex
+	addDHCPReservationArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	addDHCPReservationReturns struct {
+		result1 error
+	}
+	addDHCPReservationReturnsOnCall map[int]struct {
+		result1 error
+	}
+	AddPortForwardsStub        func(string, []vmnet.PortForward) error
+	addPortForwardsMutex       sync.RWMutex
+	addPortForwardsArgsForCall []struct {
+		arg1 string
+		arg2 []vmnet.PortForward
+	}
+	addPortForwardsReturns struct {
+		result1 error
+	}
+	addPortForwardsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	AllocateMACAddressStub        func(string) (string, error)
+	allocateMACAddressMutex       sync.RWMutex
+	allocateMACAddressArgsForCall []struct {
+		arg1 string
+	}
+	allocateMACAddressReturns struct {
+		result1 string
+		result2 error
+	}
+	allocateMACAddressReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	AttachDiskStub        func(string, string) error
 	attachDiskMutex       sync.RWMutex
 	attachDiskArgsForCall []struct {
@@ -39,11 +92,13 @@ type FakeClient struct {
 	bootstrapVMReturnsOnCall map[int]struct {
 		result1 error
 	}
-	CloneVMStub        func(string, string) error
+	CloneVMStub        func(string, string, string, int) error
 	cloneVMMutex       sync.RWMutex
 	cloneVMArgsForCall []struct {
 		arg1 string
 		arg2 string
+		arg3 string
+		arg4 int
 	}
 	cloneVMReturns struct {
 		result1 error
@@ -51,11 +106,12 @@ type FakeClient struct {
 	cloneVMReturnsOnCall map[int]struct {
 		result1 error
 	}
-	CreateDiskStub        func(string, int) error
+	CreateDiskStub        func(string, int, string) error
 	createDiskMutex       sync.RWMutex
 	createDiskArgsForCall []struct {
 		arg1 string
 		arg2 int
+		arg3 string
 	}
 	createDiskReturns struct {
 		result1 error
@@ -109,6 +165,58 @@ type FakeClient struct {
 	detachDiskReturnsOnCall map[int]struct {
 		result1 error
 	}
+	GetDiskInfoStub        func(string) (vmdk.Info, error)
+	getDiskInfoMutex       sync.RWMutex
+	getDiskInfoArgsForCall []struct {
+		arg1 string
+	}
+	getDiskInfoReturns struct {
+		result1 vmdk.Info
+		result2 error
+	}
+	getDiskInfoReturnsOnCall map[int]struct {
+		result1 vmdk.Info
+		result2 error
+	}
+	GetHostNetworkNamesStub        func() (map[string]string, error)
+	getHostNetworkNamesMutex       sync.RWMutex
+	getHostNetworkNamesArgsForCall []struct {
+	}
+	getHostNetworkNamesReturns struct {
+		result1 map[string]string
+		result2 error
+	}
+	getHostNetworkNamesReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 error
+	}
+	GetVMGuestInfoStub        func(string, string) (string, error)
+	getVMGuestInfoMutex       sync.RWMutex
+	getVMGuestInfoArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getVMGuestInfoReturns struct {
+		result1 string
+		result2 error
+	}
+	getVMGuestInfoReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	GetVMIPAddressStub        func(string) (string, error)
+	getVMIPAddressMutex       sync.RWMutex
+	getVMIPAddressArgsForCall []struct {
+		arg1 string
+	}
+	getVMIPAddressReturns struct {
+		result1 string
+		result2 error
+	}
+	getVMIPAddressReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	GetVMInfoStub        func(string) (driver.VMInfo, error)
 	getVMInfoMutex       sync.RWMutex
 	getVMInfoArgsForCall []struct {
@@ -155,6 +263,32 @@ type FakeClient struct {
 	hasVMReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	HotAttachDiskStub        func(string, string) (string, error)
+	hotAttachDiskMutex       sync.RWMutex
+	hotAttachDiskArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	hotAttachDiskReturns struct {
+		result1 string
+		result2 error
+	}
+	hotAttachDiskReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	HotDetachDiskStub        func(string, string) error
+	hotDetachDiskMutex       sync.RWMutex
+	hotDetachDiskArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	hotDetachDiskReturns struct {
+		result1 error
+	}
+	hotDetachDiskReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ImportOvfStub        func(string, string) (bool, error)
 	importOvfMutex       sync.RWMutex
 	importOvfArgsForCall []struct {
@@ -169,6 +303,18 @@ type FakeClient struct {
 		result1 bool
 		result2 error
 	}
+	LockStemcellVMStub        func(string, func() error) error
+	lockStemcellVMMutex       sync.RWMutex
+	lockStemcellVMArgsForCall []struct {
+		arg1 string
+		arg2 func() error
+	}
+	lockStemcellVMReturns struct {
+		result1 error
+	}
+	lockStemcellVMReturnsOnCall map[int]struct {
+		result1 error
+	}
 	NeedsVMNameChangeStub        func(string) bool
 	needsVMNameChangeMutex       sync.RWMutex
 	needsVMNameChangeArgsForCall []struct {
@@ -180,6 +326,76 @@ type FakeClient struct {
 	needsVMNameChangeReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	RegisterStemcellVMStub        func(string, string) error
+	registerStemcellVMMutex       sync.RWMutex
+	registerStemcellVMArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	registerStemcellVMReturns struct {
+		result1 error
+	}
+	registerStemcellVMReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ReleaseMACAddressesStub        func(string) error
+	releaseMACAddressesMutex       sync.RWMutex
+	releaseMACAddressesArgsForCall []struct {
+		arg1 string
+	}
+	releaseMACAddressesReturns struct {
+		result1 error
+	}
+	releaseMACAddressesReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ReleaseStemcellVMStub        func(string) (bool, error)
+	releaseStemcellVMMutex       sync.RWMutex
+	releaseStemcellVMArgsForCall []struct {
+		arg1 string
+	}
+	releaseStemcellVMReturns struct {
+		result1 bool
+		result2 error
+	}
+	releaseStemcellVMReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	RemoveDHCPReservationsStub        func(string) error
+	removeDHCPReservationsMutex       sync.RWMutex
+	removeDHCPReservationsArgsForCall []struct {
+		arg1 string
+	}
+	removeDHCPReservationsReturns struct {
+		result1 error
+	}
+	removeDHCPReservationsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RemovePortForwardsStub        func(string) error
+	removePortForwardsMutex       sync.RWMutex
+	removePortForwardsArgsForCall []struct {
+		arg1 string
+	}
+	removePortForwardsReturns struct {
+		result1 error
+	}
+	removePortForwardsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetVMDiskControllerStub        func(string, string) error
+	setVMDiskControllerMutex       sync.RWMutex
+	setVMDiskControllerArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	setVMDiskControllerReturns struct {
+		result1 error
+	}
+	setVMDiskControllerReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetVMDisplayNameStub        func(string, string) error
 	setVMDisplayNameMutex       sync.RWMutex
 	setVMDisplayNameArgsForCall []struct {
@@ -192,12 +408,27 @@ type FakeClient struct {
 	setVMDisplayNameReturnsOnCall map[int]struct {
 		result1 error
 	}
-	SetVMNetworkAdapterStub        func(string, string, string) error
+	SetVMGuestInfoStub        func(string, string, string) error
+	setVMGuestInfoMutex       sync.RWMutex
+	setVMGuestInfoArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	setVMGuestInfoReturns struct {
+		result1 error
+	}
+	setVMGuestInfoReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetVMNetworkAdapterStub        func(string, string, string, string, string) error
 	setVMNetworkAdapterMutex       sync.RWMutex
 	setVMNetworkAdapterArgsForCall []struct {
 		arg1 string
 		arg2 string
 		arg3 string
+		arg4 string
+		arg5 string
 	}
 	setVMNetworkAdapterReturns struct {
 		result1 error
@@ -252,10 +483,274 @@ type FakeClient struct {
 	updateVMIsoReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UsesGuestInfoAgentSettingsStub        func() bool
+	usesGuestInfoAgentSettingsMutex       sync.RWMutex
+	usesGuestInfoAgentSettingsArgsForCall []struct {
+	}
+	usesGuestInfoAgentSettingsReturns struct {
+		result1 bool
+	}
+	usesGuestInfoAgentSettingsReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeClient) AcquireStemcellVM(arg1 string) (string, error) {
+	fake.acquireStemcellVMMutex.Lock()
+	ret, specificReturn := fake.acquireStemcellVMReturnsOnCall[len(fake.acquireStemcellVMArgsForCall)]
+	fake.acquireStemcellVMArgsForCall = append(fake.acquireStemcellVMArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("AcquireStemcellVM", []interface{}{arg1})
+	fake.acquireStemcellVMMutex.Unlock()
+	if fake.AcquireStemcellVMStub != nil {
+		return fake.AcquireStemcellVMStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.acquireStemcellVMReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) AcquireStemcellVMCallCount() int {
+	fake.acquireStemcellVMMutex.RLock()
+	defer fake.acquireStemcellVMMutex.RUnlock()
+	return len(fake.acquireStemcellVMArgsForCall)
+}
+
+func (fake *FakeClient) AcquireStemcellVMCalls(stub func(string) (string, error)) {
+	fake.acquireStemcellVMMutex.Lock()
+	defer fake.acquireStemcellVMMutex.Unlock()
+	fake.AcquireStemcellVMStub = stub
+}
+
+func (fake *FakeClient) AcquireStemcellVMArgsForCall(i int) string {
+	fake.acquireStemcellVMMutex.RLock()
+	defer fake.acquireStemcellVMMutex.RUnlock()
+	argsForCall := fake.acquireStemcellVMArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) AcquireStemcellVMReturns(result1 string, result2 error) {
+	fake.acquireStemcellVMMutex.Lock()
+	defer fake.acquireStemcellVMMutex.Unlock()
+	fake.AcquireStemcellVMStub = nil
+	fake.acquireStemcellVMReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) AcquireStemcellVMReturnsOnCall(i int, result1 string, result2 error) {
+	fake.acquireStemcellVMMutex.Lock()
+	defer fake.acquireStemcellVMMutex.Unlock()
+	fake.AcquireStemcellVMStub = nil
+	if fake.acquireStemcellVMReturnsOnCall == nil {
+		fake.acquireStemcellVMReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.acquireStemcellVMReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) AddDHCPReservation(arg1 string, arg2 string, arg3 string) error {
+	fake.addDHCPReservationMutex.Lock()
+	ret, specificReturn := fake.addDHCPReservationReturnsOnCall[len(fake.addDHCPReservationArgsForCall)]
+	fake.addDHCPReservationArgsForCall = append(fake.addDHCPReservationArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("AddDHCPReservation", []interface{}{arg1, arg2, arg3})
+	fake.addDHCPReservationMutex.Unlock()
+	if fake.AddDHCPReservationStub != nil {
+		return fake.AddDHCPReservationStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.addDHCPReservationReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) AddDHCPReservationCallCount() int {
+	fake.addDHCPReservationMutex.RLock()
+	defer fake.addDHCPReservationMutex.RUnlock()
+	return len(fake.addDHCPReservationArgsForCall)
+}
+
+func (fake *FakeClient) AddDHCPReservationCalls(stub func(string, string, string) error) {
+	fake.addDHCPReservationMutex.Lock()
+	defer fake.addDHCPReservationMutex.Unlock()
+	fake.AddDHCPReservationStub = stub
+}
+
+func (fake *FakeClient) AddDHCPReservationArgsForCall(i int) (string, string, string) {
+	fake.addDHCPReservationMutex.RLock()
+	defer fake.addDHCPReservationMutex.RUnlock()
+	argsForCall := fake.addDHCPReservationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeClient) AddDHCPReservationReturns(result1 error) {
+	fake.addDHCPReservationMutex.Lock()
+	defer fake.addDHCPReservationMutex.Unlock()
+	fake.AddDHCPReservationStub = nil
+	fake.addDHCPReservationReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) AddDHCPReservationReturnsOnCall(i int, result1 error) {
+	fake.addDHCPReservationMutex.Lock()
+	defer fake.addDHCPReservationMutex.Unlock()
+	fake.AddDHCPReservationStub = nil
+	if fake.addDHCPReservationReturnsOnCall == nil {
+		fake.addDHCPReservationReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addDHCPReservationReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) AddPortForwards(arg1 string, arg2 []vmnet.PortForward) error {
+	var arg2Copy []vmnet.PortForward
+	if arg2 != nil {
+		arg2Copy = make([]vmnet.PortForward, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.addPortForwardsMutex.Lock()
+	ret, specificReturn := fake.addPortForwardsReturnsOnCall[len(fake.addPortForwardsArgsForCall)]
+	fake.addPortForwardsArgsForCall = append(fake.addPortForwardsArgsForCall, struct {
+		arg1 string
+		arg2 []vmnet.PortForward
+	}{arg1, arg2Copy})
+	fake.recordInvocation("AddPortForwards", []interface{}{arg1, arg2Copy})
+	fake.addPortForwardsMutex.Unlock()
+	if fake.AddPortForwardsStub != nil {
+		return fake.AddPortForwardsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.addPortForwardsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) AddPortForwardsCallCount() int {
+	fake.addPortForwardsMutex.RLock()
+	defer fake.addPortForwardsMutex.RUnlock()
+	return len(fake.addPortForwardsArgsForCall)
+}
+
+func (fake *FakeClient) AddPortForwardsCalls(stub func(string, []vmnet.PortForward) error) {
+	fake.addPortForwardsMutex.Lock()
+	defer fake.addPortForwardsMutex.Unlock()
+	fake.AddPortForwardsStub = stub
+}
+
+func (fake *FakeClient) AddPortForwardsArgsForCall(i int) (string, []vmnet.PortForward) {
+	fake.addPortForwardsMutex.RLock()
+	defer fake.addPortForwardsMutex.RUnlock()
+	argsForCall := fake.addPortForwardsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) AddPortForwardsReturns(result1 error) {
+	fake.addPortForwardsMutex.Lock()
+	defer fake.addPortForwardsMutex.Unlock()
+	fake.AddPortForwardsStub = nil
+	fake.addPortForwardsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) AddPortForwardsReturnsOnCall(i int, result1 error) {
+	fake.addPortForwardsMutex.Lock()
+	defer fake.addPortForwardsMutex.Unlock()
+	fake.AddPortForwardsStub = nil
+	if fake.addPortForwardsReturnsOnCall == nil {
+		fake.addPortForwardsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addPortForwardsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) AllocateMACAddress(arg1 string) (string, error) {
+	fake.allocateMACAddressMutex.Lock()
+	ret, specificReturn := fake.allocateMACAddressReturnsOnCall[len(fake.allocateMACAddressArgsForCall)]
+	fake.allocateMACAddressArgsForCall = append(fake.allocateMACAddressArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("AllocateMACAddress", []interface{}{arg1})
+	fake.allocateMACAddressMutex.Unlock()
+	if fake.AllocateMACAddressStub != nil {
+		return fake.AllocateMACAddressStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.allocateMACAddressReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) AllocateMACAddressCallCount() int {
+	fake.allocateMACAddressMutex.RLock()
+	defer fake.allocateMACAddressMutex.RUnlock()
+	return len(fake.allocateMACAddressArgsForCall)
+}
+
+func (fake *FakeClient) AllocateMACAddressCalls(stub func(string) (string, error)) {
+	fake.allocateMACAddressMutex.Lock()
+	defer fake.allocateMACAddressMutex.Unlock()
+	fake.AllocateMACAddressStub = stub
+}
+
+func (fake *FakeClient) AllocateMACAddressArgsForCall(i int) string {
+	fake.allocateMACAddressMutex.RLock()
+	defer fake.allocateMACAddressMutex.RUnlock()
+	argsForCall := fake.allocateMACAddressArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) AllocateMACAddressReturns(result1 string, result2 error) {
+	fake.allocateMACAddressMutex.Lock()
+	defer fake.allocateMACAddressMutex.Unlock()
+	fake.AllocateMACAddressStub = nil
+	fake.allocateMACAddressReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) AllocateMACAddressReturnsOnCall(i int, result1 string, result2 error) {
+	fake.allocateMACAddressMutex.Lock()
+	defer fake.allocateMACAddressMutex.Unlock()
+	fake.AllocateMACAddressStub = nil
+	if fake.allocateMACAddressReturnsOnCall == nil {
+		fake.allocateMACAddressReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.allocateMACAddressReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeClient) AttachDisk(arg1 string, arg2 string) error {
 	fake.attachDiskMutex.Lock()
 	ret, specificReturn := fake.attachDiskReturnsOnCall[len(fake.attachDiskArgsForCall)]
@@ -385,17 +880,19 @@ func (fake *FakeClient) BootstrapVMReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeClient) CloneVM(arg1 string, arg2 string) error {
+func (fake *FakeClient) CloneVM(arg1 string, arg2 string, arg3 string, arg4 int) error {
 	fake.cloneVMMutex.Lock()
 	ret, specificReturn := fake.cloneVMReturnsOnCall[len(fake.cloneVMArgsForCall)]
 	fake.cloneVMArgsForCall = append(fake.cloneVMArgsForCall, struct {
 		arg1 string
 		arg2 string
-	}{arg1, arg2})
-	fake.recordInvocation("CloneVM", []interface{}{arg1, arg2})
+		arg3 string
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("CloneVM", []interface{}{arg1, arg2, arg3, arg4})
 	fake.cloneVMMutex.Unlock()
 	if fake.CloneVMStub != nil {
-		return fake.CloneVMStub(arg1, arg2)
+		return fake.CloneVMStub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
@@ -410,17 +907,17 @@ func (fake *FakeClient) CloneVMCallCount() int {
 	return len(fake.cloneVMArgsForCall)
 }
 
-func (fake *FakeClient) CloneVMCalls(stub func(string, string) error) {
+func (fake *FakeClient) CloneVMCalls(stub func(string, string, string, int) error) {
 	fake.cloneVMMutex.Lock()
 	defer fake.cloneVMMutex.Unlock()
 	fake.CloneVMStub = stub
 }
 
-func (fake *FakeClient) CloneVMArgsForCall(i int) (string, string) {
+func (fake *FakeClient) CloneVMArgsForCall(i int) (string, string, string, int) {
 	fake.cloneVMMutex.RLock()
 	defer fake.cloneVMMutex.RUnlock()
 	argsForCall := fake.cloneVMArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeClient) CloneVMReturns(result1 error) {
@@ -446,17 +943,18 @@ func (fake *FakeClient) CloneVMReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeClient) CreateDisk(arg1 string, arg2 int) error {
+func (fake *FakeClient) CreateDisk(arg1 string, arg2 int, arg3 string) error {
 	fake.createDiskMutex.Lock()
 	ret, specificReturn := fake.createDiskReturnsOnCall[len(fake.createDiskArgsForCall)]
 	fake.createDiskArgsForCall = append(fake.createDiskArgsForCall, struct {
 		arg1 string
 		arg2 int
-	}{arg1, arg2})
-	fake.recordInvocation("CreateDisk", []interface{}{arg1, arg2})
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("CreateDisk", []interface{}{arg1, arg2, arg3})
 	fake.createDiskMutex.Unlock()
 	if fake.CreateDiskStub != nil {
-		return fake.CreateDiskStub(arg1, arg2)
+		return fake.CreateDiskStub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -471,17 +969,17 @@ func (fake *FakeClient) CreateDiskCallCount() int {
 	return len(fake.createDiskArgsForCall)
 }
 
-func (fake *FakeClient) CreateDiskCalls(stub func(string, int) error) {
+func (fake *FakeClient) CreateDiskCalls(stub func(string, int, string) error) {
 	fake.createDiskMutex.Lock()
 	defer fake.createDiskMutex.Unlock()
 	fake.CreateDiskStub = stub
 }
 
-func (fake *FakeClient) CreateDiskArgsForCall(i int) (string, int) {
+func (fake *FakeClient) CreateDiskArgsForCall(i int) (string, int, string) {
 	fake.createDiskMutex.RLock()
 	defer fake.createDiskMutex.RUnlock()
 	argsForCall := fake.createDiskArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeClient) CreateDiskReturns(result1 error) {
@@ -749,6 +1247,251 @@ func (fake *FakeClient) DetachDiskReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeClient) GetDiskInfo(arg1 string) (vmdk.Info, error) {
+	fake.getDiskInfoMutex.Lock()
+	ret, specificReturn := fake.getDiskInfoReturnsOnCall[len(fake.getDiskInfoArgsForCall)]
+	fake.getDiskInfoArgsForCall = append(fake.getDiskInfoArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetDiskInfo", []interface{}{arg1})
+	fake.getDiskInfoMutex.Unlock()
+	if fake.GetDiskInfoStub != nil {
+		return fake.GetDiskInfoStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getDiskInfoReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) GetDiskInfoCallCount() int {
+	fake.getDiskInfoMutex.RLock()
+	defer fake.getDiskInfoMutex.RUnlock()
+	return len(fake.getDiskInfoArgsForCall)
+}
+
+func (fake *FakeClient) GetDiskInfoCalls(stub func(string) (vmdk.Info, error)) {
+	fake.getDiskInfoMutex.Lock()
+	defer fake.getDiskInfoMutex.Unlock()
+	fake.GetDiskInfoStub = stub
+}
+
+func (fake *FakeClient) GetDiskInfoArgsForCall(i int) string {
+	fake.getDiskInfoMutex.RLock()
+	defer fake.getDiskInfoMutex.RUnlock()
+	argsForCall := fake.getDiskInfoArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) GetDiskInfoReturns(result1 vmdk.Info, result2 error) {
+	fake.getDiskInfoMutex.Lock()
+	defer fake.getDiskInfoMutex.Unlock()
+	fake.GetDiskInfoStub = nil
+	fake.getDiskInfoReturns = struct {
+		result1 vmdk.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetDiskInfoReturnsOnCall(i int, result1 vmdk.Info, result2 error) {
+	fake.getDiskInfoMutex.Lock()
+	defer fake.getDiskInfoMutex.Unlock()
+	fake.GetDiskInfoStub = nil
+	if fake.getDiskInfoReturnsOnCall == nil {
+		fake.getDiskInfoReturnsOnCall = make(map[int]struct {
+			result1 vmdk.Info
+			result2 error
+		})
+	}
+	fake.getDiskInfoReturnsOnCall[i] = struct {
+		result1 vmdk.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetHostNetworkNames() (map[string]string, error) {
+	fake.getHostNetworkNamesMutex.Lock()
+	ret, specificReturn := fake.getHostNetworkNamesReturnsOnCall[len(fake.getHostNetworkNamesArgsForCall)]
+	fake.getHostNetworkNamesArgsForCall = append(fake.getHostNetworkNamesArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetHostNetworkNames", []interface{}{})
+	fake.getHostNetworkNamesMutex.Unlock()
+	if fake.GetHostNetworkNamesStub != nil {
+		return fake.GetHostNetworkNamesStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getHostNetworkNamesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) GetHostNetworkNamesCallCount() int {
+	fake.getHostNetworkNamesMutex.RLock()
+	defer fake.getHostNetworkNamesMutex.RUnlock()
+	return len(fake.getHostNetworkNamesArgsForCall)
+}
+
+func (fake *FakeClient) GetHostNetworkNamesCalls(stub func() (map[string]string, error)) {
+	fake.getHostNetworkNamesMutex.Lock()
+	defer fake.getHostNetworkNamesMutex.Unlock()
+	fake.GetHostNetworkNamesStub = stub
+}
+
+func (fake *FakeClient) GetHostNetworkNamesReturns(result1 map[string]string, result2 error) {
+	fake.getHostNetworkNamesMutex.Lock()
+	defer fake.getHostNetworkNamesMutex.Unlock()
+	fake.GetHostNetworkNamesStub = nil
+	fake.getHostNetworkNamesReturns = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetHostNetworkNamesReturnsOnCall(i int, result1 map[string]string, result2 error) {
+	fake.getHostNetworkNamesMutex.Lock()
+	defer fake.getHostNetworkNamesMutex.Unlock()
+	fake.GetHostNetworkNamesStub = nil
+	if fake.getHostNetworkNamesReturnsOnCall == nil {
+		fake.getHostNetworkNamesReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 error
+		})
+	}
+	fake.getHostNetworkNamesReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetVMGuestInfo(arg1 string, arg2 string) (string, error) {
+	fake.getVMGuestInfoMutex.Lock()
+	ret, specificReturn := fake.getVMGuestInfoReturnsOnCall[len(fake.getVMGuestInfoArgsForCall)]
+	fake.getVMGuestInfoArgsForCall = append(fake.getVMGuestInfoArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetVMGuestInfo", []interface{}{arg1, arg2})
+	fake.getVMGuestInfoMutex.Unlock()
+	if fake.GetVMGuestInfoStub != nil {
+		return fake.GetVMGuestInfoStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getVMGuestInfoReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) GetVMGuestInfoCallCount() int {
+	fake.getVMGuestInfoMutex.RLock()
+	defer fake.getVMGuestInfoMutex.RUnlock()
+	return len(fake.getVMGuestInfoArgsForCall)
+}
+
+func (fake *FakeClient) GetVMGuestInfoCalls(stub func(string, string) (string, error)) {
+	fake.getVMGuestInfoMutex.Lock()
+	defer fake.getVMGuestInfoMutex.Unlock()
+	fake.GetVMGuestInfoStub = stub
+}
+
+func (fake *FakeClient) GetVMGuestInfoArgsForCall(i int) (string, string) {
+	fake.getVMGuestInfoMutex.RLock()
+	defer fake.getVMGuestInfoMutex.RUnlock()
+	argsForCall := fake.getVMGuestInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) GetVMGuestInfoReturns(result1 string, result2 error) {
+	fake.getVMGuestInfoMutex.Lock()
+	defer fake.getVMGuestInfoMutex.Unlock()
+	fake.GetVMGuestInfoStub = nil
+	fake.getVMGuestInfoReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetVMGuestInfoReturnsOnCall(i int, result1 string, result2 error) {
+	fake.getVMGuestInfoMutex.Lock()
+	defer fake.getVMGuestInfoMutex.Unlock()
+	fake.GetVMGuestInfoStub = nil
+	if fake.getVMGuestInfoReturnsOnCall == nil {
+		fake.getVMGuestInfoReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.getVMGuestInfoReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetVMIPAddress(arg1 string) (string, error) {
+	fake.getVMIPAddressMutex.Lock()
+	ret, specificReturn := fake.getVMIPAddressReturnsOnCall[len(fake.getVMIPAddressArgsForCall)]
+	fake.getVMIPAddressArgsForCall = append(fake.getVMIPAddressArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetVMIPAddress", []interface{}{arg1})
+	fake.getVMIPAddressMutex.Unlock()
+	if fake.GetVMIPAddressStub != nil {
+		return fake.GetVMIPAddressStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getVMIPAddressReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) GetVMIPAddressCallCount() int {
+	fake.getVMIPAddressMutex.RLock()
+	defer fake.getVMIPAddressMutex.RUnlock()
+	return len(fake.getVMIPAddressArgsForCall)
+}
+
+func (fake *FakeClient) GetVMIPAddressCalls(stub func(string) (string, error)) {
+	fake.getVMIPAddressMutex.Lock()
+	defer fake.getVMIPAddressMutex.Unlock()
+	fake.GetVMIPAddressStub = stub
+}
+
+func (fake *FakeClient) GetVMIPAddressArgsForCall(i int) string {
+	fake.getVMIPAddressMutex.RLock()
+	defer fake.getVMIPAddressMutex.RUnlock()
+	argsForCall := fake.getVMIPAddressArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) GetVMIPAddressReturns(result1 string, result2 error) {
+	fake.getVMIPAddressMutex.Lock()
+	defer fake.getVMIPAddressMutex.Unlock()
+	fake.GetVMIPAddressStub = nil
+	fake.getVMIPAddressReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetVMIPAddressReturnsOnCall(i int, result1 string, result2 error) {
+	fake.getVMIPAddressMutex.Lock()
+	defer fake.getVMIPAddressMutex.Unlock()
+	fake.GetVMIPAddressStub = nil
+	if fake.getVMIPAddressReturnsOnCall == nil {
+		fake.getVMIPAddressReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.getVMIPAddressReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeClient) GetVMInfo(arg1 string) (driver.VMInfo, error) {
 	fake.getVMInfoMutex.Lock()
 	ret, specificReturn := fake.getVMInfoReturnsOnCall[len(fake.getVMInfoArgsForCall)]
@@ -987,8 +1730,133 @@ func (fake *FakeClient) HasVMReturnsOnCall(i int, result1 bool) {
 			result1 bool
 		})
 	}
-	fake.hasVMReturnsOnCall[i] = struct {
-		result1 bool
+	fake.hasVMReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeClient) HotAttachDisk(arg1 string, arg2 string) (string, error) {
+	fake.hotAttachDiskMutex.Lock()
+	ret, specificReturn := fake.hotAttachDiskReturnsOnCall[len(fake.hotAttachDiskArgsForCall)]
+	fake.hotAttachDiskArgsForCall = append(fake.hotAttachDiskArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("HotAttachDisk", []interface{}{arg1, arg2})
+	fake.hotAttachDiskMutex.Unlock()
+	if fake.HotAttachDiskStub != nil {
+		return fake.HotAttachDiskStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.hotAttachDiskReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) HotAttachDiskCallCount() int {
+	fake.hotAttachDiskMutex.RLock()
+	defer fake.hotAttachDiskMutex.RUnlock()
+	return len(fake.hotAttachDiskArgsForCall)
+}
+
+func (fake *FakeClient) HotAttachDiskCalls(stub func(string, string) (string, error)) {
+	fake.hotAttachDiskMutex.Lock()
+	defer fake.hotAttachDiskMutex.Unlock()
+	fake.HotAttachDiskStub = stub
+}
+
+func (fake *FakeClient) HotAttachDiskArgsForCall(i int) (string, string) {
+	fake.hotAttachDiskMutex.RLock()
+	defer fake.hotAttachDiskMutex.RUnlock()
+	argsForCall := fake.hotAttachDiskArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) HotAttachDiskReturns(result1 string, result2 error) {
+	fake.hotAttachDiskMutex.Lock()
+	defer fake.hotAttachDiskMutex.Unlock()
+	fake.HotAttachDiskStub = nil
+	fake.hotAttachDiskReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) HotAttachDiskReturnsOnCall(i int, result1 string, result2 error) {
+	fake.hotAttachDiskMutex.Lock()
+	defer fake.hotAttachDiskMutex.Unlock()
+	fake.HotAttachDiskStub = nil
+	if fake.hotAttachDiskReturnsOnCall == nil {
+		fake.hotAttachDiskReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.hotAttachDiskReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) HotDetachDisk(arg1 string, arg2 string) error {
+	fake.hotDetachDiskMutex.Lock()
+	ret, specificReturn := fake.hotDetachDiskReturnsOnCall[len(fake.hotDetachDiskArgsForCall)]
+	fake.hotDetachDiskArgsForCall = append(fake.hotDetachDiskArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("HotDetachDisk", []interface{}{arg1, arg2})
+	fake.hotDetachDiskMutex.Unlock()
+	if fake.HotDetachDiskStub != nil {
+		return fake.HotDetachDiskStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.hotDetachDiskReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) HotDetachDiskCallCount() int {
+	fake.hotDetachDiskMutex.RLock()
+	defer fake.hotDetachDiskMutex.RUnlock()
+	return len(fake.hotDetachDiskArgsForCall)
+}
+
+func (fake *FakeClient) HotDetachDiskCalls(stub func(string, string) error) {
+	fake.hotDetachDiskMutex.Lock()
+	defer fake.hotDetachDiskMutex.Unlock()
+	fake.HotDetachDiskStub = stub
+}
+
+func (fake *FakeClient) HotDetachDiskArgsForCall(i int) (string, string) {
+	fake.hotDetachDiskMutex.RLock()
+	defer fake.hotDetachDiskMutex.RUnlock()
+	argsForCall := fake.hotDetachDiskArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) HotDetachDiskReturns(result1 error) {
+	fake.hotDetachDiskMutex.Lock()
+	defer fake.hotDetachDiskMutex.Unlock()
+	fake.HotDetachDiskStub = nil
+	fake.hotDetachDiskReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) HotDetachDiskReturnsOnCall(i int, result1 error) {
+	fake.hotDetachDiskMutex.Lock()
+	defer fake.hotDetachDiskMutex.Unlock()
+	fake.HotDetachDiskStub = nil
+	if fake.hotDetachDiskReturnsOnCall == nil {
+		fake.hotDetachDiskReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.hotDetachDiskReturnsOnCall[i] = struct {
+		result1 error
 	}{result1}
 }
 
@@ -1056,6 +1924,67 @@ func (fake *FakeClient) ImportOvfReturnsOnCall(i int, result1 bool, result2 erro
 	}{result1, result2}
 }
 
+func (fake *FakeClient) LockStemcellVM(arg1 string, arg2 func() error) error {
+	fake.lockStemcellVMMutex.Lock()
+	ret, specificReturn := fake.lockStemcellVMReturnsOnCall[len(fake.lockStemcellVMArgsForCall)]
+	fake.lockStemcellVMArgsForCall = append(fake.lockStemcellVMArgsForCall, struct {
+		arg1 string
+		arg2 func() error
+	}{arg1, arg2})
+	fake.recordInvocation("LockStemcellVM", []interface{}{arg1, arg2})
+	fake.lockStemcellVMMutex.Unlock()
+	if fake.LockStemcellVMStub != nil {
+		return fake.LockStemcellVMStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.lockStemcellVMReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) LockStemcellVMCallCount() int {
+	fake.lockStemcellVMMutex.RLock()
+	defer fake.lockStemcellVMMutex.RUnlock()
+	return len(fake.lockStemcellVMArgsForCall)
+}
+
+func (fake *FakeClient) LockStemcellVMCalls(stub func(string, func() error) error) {
+	fake.lockStemcellVMMutex.Lock()
+	defer fake.lockStemcellVMMutex.Unlock()
+	fake.LockStemcellVMStub = stub
+}
+
+func (fake *FakeClient) LockStemcellVMArgsForCall(i int) (string, func() error) {
+	fake.lockStemcellVMMutex.RLock()
+	defer fake.lockStemcellVMMutex.RUnlock()
+	argsForCall := fake.lockStemcellVMArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) LockStemcellVMReturns(result1 error) {
+	fake.lockStemcellVMMutex.Lock()
+	defer fake.lockStemcellVMMutex.Unlock()
+	fake.LockStemcellVMStub = nil
+	fake.lockStemcellVMReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) LockStemcellVMReturnsOnCall(i int, result1 error) {
+	fake.lockStemcellVMMutex.Lock()
+	defer fake.lockStemcellVMMutex.Unlock()
+	fake.LockStemcellVMStub = nil
+	if fake.lockStemcellVMReturnsOnCall == nil {
+		fake.lockStemcellVMReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.lockStemcellVMReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeClient) NeedsVMNameChange(arg1 string) bool {
 	fake.needsVMNameChangeMutex.Lock()
 	ret, specificReturn := fake.needsVMNameChangeReturnsOnCall[len(fake.needsVMNameChangeArgsForCall)]
@@ -1116,6 +2045,371 @@ func (fake *FakeClient) NeedsVMNameChangeReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *FakeClient) RegisterStemcellVM(arg1 string, arg2 string) error {
+	fake.registerStemcellVMMutex.Lock()
+	ret, specificReturn := fake.registerStemcellVMReturnsOnCall[len(fake.registerStemcellVMArgsForCall)]
+	fake.registerStemcellVMArgsForCall = append(fake.registerStemcellVMArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("RegisterStemcellVM", []interface{}{arg1, arg2})
+	fake.registerStemcellVMMutex.Unlock()
+	if fake.RegisterStemcellVMStub != nil {
+		return fake.RegisterStemcellVMStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.registerStemcellVMReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) RegisterStemcellVMCallCount() int {
+	fake.registerStemcellVMMutex.RLock()
+	defer fake.registerStemcellVMMutex.RUnlock()
+	return len(fake.registerStemcellVMArgsForCall)
+}
+
+func (fake *FakeClient) RegisterStemcellVMCalls(stub func(string, string) error) {
+	fake.registerStemcellVMMutex.Lock()
+	defer fake.registerStemcellVMMutex.Unlock()
+	fake.RegisterStemcellVMStub = stub
+}
+
+func (fake *FakeClient) RegisterStemcellVMArgsForCall(i int) (string, string) {
+	fake.registerStemcellVMMutex.RLock()
+	defer fake.registerStemcellVMMutex.RUnlock()
+	argsForCall := fake.registerStemcellVMArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) RegisterStemcellVMReturns(result1 error) {
+	fake.registerStemcellVMMutex.Lock()
+	defer fake.registerStemcellVMMutex.Unlock()
+	fake.RegisterStemcellVMStub = nil
+	fake.registerStemcellVMReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) RegisterStemcellVMReturnsOnCall(i int, result1 error) {
+	fake.registerStemcellVMMutex.Lock()
+	defer fake.registerStemcellVMMutex.Unlock()
+	fake.RegisterStemcellVMStub = nil
+	if fake.registerStemcellVMReturnsOnCall == nil {
+		fake.registerStemcellVMReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.registerStemcellVMReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) ReleaseMACAddresses(arg1 string) error {
+	fake.releaseMACAddressesMutex.Lock()
+	ret, specificReturn := fake.releaseMACAddressesReturnsOnCall[len(fake.releaseMACAddressesArgsForCall)]
+	fake.releaseMACAddressesArgsForCall = append(fake.releaseMACAddressesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ReleaseMACAddresses", []interface{}{arg1})
+	fake.releaseMACAddressesMutex.Unlock()
+	if fake.ReleaseMACAddressesStub != nil {
+		return fake.ReleaseMACAddressesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.releaseMACAddressesReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) ReleaseMACAddressesCallCount() int {
+	fake.releaseMACAddressesMutex.RLock()
+	defer fake.releaseMACAddressesMutex.RUnlock()
+	return len(fake.releaseMACAddressesArgsForCall)
+}
+
+func (fake *FakeClient) ReleaseMACAddressesCalls(stub func(string) error) {
+	fake.releaseMACAddressesMutex.Lock()
+	defer fake.releaseMACAddressesMutex.Unlock()
+	fake.ReleaseMACAddressesStub = stub
+}
+
+func (fake *FakeClient) ReleaseMACAddressesArgsForCall(i int) string {
+	fake.releaseMACAddressesMutex.RLock()
+	defer fake.releaseMACAddressesMutex.RUnlock()
+	argsForCall := fake.releaseMACAddressesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) ReleaseMACAddressesReturns(result1 error) {
+	fake.releaseMACAddressesMutex.Lock()
+	defer fake.releaseMACAddressesMutex.Unlock()
+	fake.ReleaseMACAddressesStub = nil
+	fake.releaseMACAddressesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) ReleaseMACAddressesReturnsOnCall(i int, result1 error) {
+	fake.releaseMACAddressesMutex.Lock()
+	defer fake.releaseMACAddressesMutex.Unlock()
+	fake.ReleaseMACAddressesStub = nil
+	if fake.releaseMACAddressesReturnsOnCall == nil {
+		fake.releaseMACAddressesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.releaseMACAddressesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) ReleaseStemcellVM(arg1 string) (bool, error) {
+	fake.releaseStemcellVMMutex.Lock()
+	ret, specificReturn := fake.releaseStemcellVMReturnsOnCall[len(fake.releaseStemcellVMArgsForCall)]
+	fake.releaseStemcellVMArgsForCall = append(fake.releaseStemcellVMArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ReleaseStemcellVM", []interface{}{arg1})
+	fake.releaseStemcellVMMutex.Unlock()
+	if fake.ReleaseStemcellVMStub != nil {
+		return fake.ReleaseStemcellVMStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.releaseStemcellVMReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) ReleaseStemcellVMCallCount() int {
+	fake.releaseStemcellVMMutex.RLock()
+	defer fake.releaseStemcellVMMutex.RUnlock()
+	return len(fake.releaseStemcellVMArgsForCall)
+}
+
+func (fake *FakeClient) ReleaseStemcellVMCalls(stub func(string) (bool, error)) {
+	fake.releaseStemcellVMMutex.Lock()
+	defer fake.releaseStemcellVMMutex.Unlock()
+	fake.ReleaseStemcellVMStub = stub
+}
+
+func (fake *FakeClient) ReleaseStemcellVMArgsForCall(i int) string {
+	fake.releaseStemcellVMMutex.RLock()
+	defer fake.releaseStemcellVMMutex.RUnlock()
+	argsForCall := fake.releaseStemcellVMArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) ReleaseStemcellVMReturns(result1 bool, result2 error) {
+	fake.releaseStemcellVMMutex.Lock()
+	defer fake.releaseStemcellVMMutex.Unlock()
+	fake.ReleaseStemcellVMStub = nil
+	fake.releaseStemcellVMReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) ReleaseStemcellVMReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.releaseStemcellVMMutex.Lock()
+	defer fake.releaseStemcellVMMutex.Unlock()
+	fake.ReleaseStemcellVMStub = nil
+	if fake.releaseStemcellVMReturnsOnCall == nil {
+		fake.releaseStemcellVMReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.releaseStemcellVMReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) RemoveDHCPReservations(arg1 string) error {
+	fake.removeDHCPReservationsMutex.Lock()
+	ret, specificReturn := fake.removeDHCPReservationsReturnsOnCall[len(fake.removeDHCPReservationsArgsForCall)]
+	fake.removeDHCPReservationsArgsForCall = append(fake.removeDHCPReservationsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("RemoveDHCPReservations", []interface{}{arg1})
+	fake.removeDHCPReservationsMutex.Unlock()
+	if fake.RemoveDHCPReservationsStub != nil {
+		return fake.RemoveDHCPReservationsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.removeDHCPReservationsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) RemoveDHCPReservationsCallCount() int {
+	fake.removeDHCPReservationsMutex.RLock()
+	defer fake.removeDHCPReservationsMutex.RUnlock()
+	return len(fake.removeDHCPReservationsArgsForCall)
+}
+
+func (fake *FakeClient) RemoveDHCPReservationsCalls(stub func(string) error) {
+	fake.removeDHCPReservationsMutex.Lock()
+	defer fake.removeDHCPReservationsMutex.Unlock()
+	fake.RemoveDHCPReservationsStub = stub
+}
+
+func (fake *FakeClient) RemoveDHCPReservationsArgsForCall(i int) string {
+	fake.removeDHCPReservationsMutex.RLock()
+	defer fake.removeDHCPReservationsMutex.RUnlock()
+	argsForCall := fake.removeDHCPReservationsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) RemoveDHCPReservationsReturns(result1 error) {
+	fake.removeDHCPReservationsMutex.Lock()
+	defer fake.removeDHCPReservationsMutex.Unlock()
+	fake.RemoveDHCPReservationsStub = nil
+	fake.removeDHCPReservationsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) RemoveDHCPReservationsReturnsOnCall(i int, result1 error) {
+	fake.removeDHCPReservationsMutex.Lock()
+	defer fake.removeDHCPReservationsMutex.Unlock()
+	fake.RemoveDHCPReservationsStub = nil
+	if fake.removeDHCPReservationsReturnsOnCall == nil {
+		fake.removeDHCPReservationsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeDHCPReservationsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) RemovePortForwards(arg1 string) error {
+	fake.removePortForwardsMutex.Lock()
+	ret, specificReturn := fake.removePortForwardsReturnsOnCall[len(fake.removePortForwardsArgsForCall)]
+	fake.removePortForwardsArgsForCall = append(fake.removePortForwardsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("RemovePortForwards", []interface{}{arg1})
+	fake.removePortForwardsMutex.Unlock()
+	if fake.RemovePortForwardsStub != nil {
+		return fake.RemovePortForwardsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.removePortForwardsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) RemovePortForwardsCallCount() int {
+	fake.removePortForwardsMutex.RLock()
+	defer fake.removePortForwardsMutex.RUnlock()
+	return len(fake.removePortForwardsArgsForCall)
+}
+
+func (fake *FakeClient) RemovePortForwardsCalls(stub func(string) error) {
+	fake.removePortForwardsMutex.Lock()
+	defer fake.removePortForwardsMutex.Unlock()
+	fake.RemovePortForwardsStub = stub
+}
+
+func (fake *FakeClient) RemovePortForwardsArgsForCall(i int) string {
+	fake.removePortForwardsMutex.RLock()
+	defer fake.removePortForwardsMutex.RUnlock()
+	argsForCall := fake.removePortForwardsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) RemovePortForwardsReturns(result1 error) {
+	fake.removePortForwardsMutex.Lock()
+	defer fake.removePortForwardsMutex.Unlock()
+	fake.RemovePortForwardsStub = nil
+	fake.removePortForwardsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) RemovePortForwardsReturnsOnCall(i int, result1 error) {
+	fake.removePortForwardsMutex.Lock()
+	defer fake.removePortForwardsMutex.Unlock()
+	fake.RemovePortForwardsStub = nil
+	if fake.removePortForwardsReturnsOnCall == nil {
+		fake.removePortForwardsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removePortForwardsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) SetVMDiskController(arg1 string, arg2 string) error {
+	fake.setVMDiskControllerMutex.Lock()
+	ret, specificReturn := fake.setVMDiskControllerReturnsOnCall[len(fake.setVMDiskControllerArgsForCall)]
+	fake.setVMDiskControllerArgsForCall = append(fake.setVMDiskControllerArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("SetVMDiskController", []interface{}{arg1, arg2})
+	fake.setVMDiskControllerMutex.Unlock()
+	if fake.SetVMDiskControllerStub != nil {
+		return fake.SetVMDiskControllerStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.setVMDiskControllerReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) SetVMDiskControllerCallCount() int {
+	fake.setVMDiskControllerMutex.RLock()
+	defer fake.setVMDiskControllerMutex.RUnlock()
+	return len(fake.setVMDiskControllerArgsForCall)
+}
+
+func (fake *FakeClient) SetVMDiskControllerCalls(stub func(string, string) error) {
+	fake.setVMDiskControllerMutex.Lock()
+	defer fake.setVMDiskControllerMutex.Unlock()
+	fake.SetVMDiskControllerStub = stub
+}
+
+func (fake *FakeClient) SetVMDiskControllerArgsForCall(i int) (string, string) {
+	fake.setVMDiskControllerMutex.RLock()
+	defer fake.setVMDiskControllerMutex.RUnlock()
+	argsForCall := fake.setVMDiskControllerArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) SetVMDiskControllerReturns(result1 error) {
+	fake.setVMDiskControllerMutex.Lock()
+	defer fake.setVMDiskControllerMutex.Unlock()
+	fake.SetVMDiskControllerStub = nil
+	fake.setVMDiskControllerReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) SetVMDiskControllerReturnsOnCall(i int, result1 error) {
+	fake.setVMDiskControllerMutex.Lock()
+	defer fake.setVMDiskControllerMutex.Unlock()
+	fake.SetVMDiskControllerStub = nil
+	if fake.setVMDiskControllerReturnsOnCall == nil {
+		fake.setVMDiskControllerReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setVMDiskControllerReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeClient) SetVMDisplayName(arg1 string, arg2 string) error {
 	fake.setVMDisplayNameMutex.Lock()
 	ret, specificReturn := fake.setVMDisplayNameReturnsOnCall[len(fake.setVMDisplayNameArgsForCall)]
@@ -1177,18 +2471,82 @@ func (fake *FakeClient) SetVMDisplayNameReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeClient) SetVMNetworkAdapter(arg1 string, arg2 string, arg3 string) error {
+func (fake *FakeClient) SetVMGuestInfo(arg1 string, arg2 string, arg3 string) error {
+	fake.setVMGuestInfoMutex.Lock()
+	ret, specificReturn := fake.setVMGuestInfoReturnsOnCall[len(fake.setVMGuestInfoArgsForCall)]
+	fake.setVMGuestInfoArgsForCall = append(fake.setVMGuestInfoArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("SetVMGuestInfo", []interface{}{arg1, arg2, arg3})
+	fake.setVMGuestInfoMutex.Unlock()
+	if fake.SetVMGuestInfoStub != nil {
+		return fake.SetVMGuestInfoStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.setVMGuestInfoReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) SetVMGuestInfoCallCount() int {
+	fake.setVMGuestInfoMutex.RLock()
+	defer fake.setVMGuestInfoMutex.RUnlock()
+	return len(fake.setVMGuestInfoArgsForCall)
+}
+
+func (fake *FakeClient) SetVMGuestInfoCalls(stub func(string, string, string) error) {
+	fake.setVMGuestInfoMutex.Lock()
+	defer fake.setVMGuestInfoMutex.Unlock()
+	fake.SetVMGuestInfoStub = stub
+}
+
+func (fake *FakeClient) SetVMGuestInfoArgsForCall(i int) (string, string, string) {
+	fake.setVMGuestInfoMutex.RLock()
+	defer fake.setVMGuestInfoMutex.RUnlock()
+	argsForCall := fake.setVMGuestInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeClient) SetVMGuestInfoReturns(result1 error) {
+	fake.setVMGuestInfoMutex.Lock()
+	defer fake.setVMGuestInfoMutex.Unlock()
+	fake.SetVMGuestInfoStub = nil
+	fake.setVMGuestInfoReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) SetVMGuestInfoReturnsOnCall(i int, result1 error) {
+	fake.setVMGuestInfoMutex.Lock()
+	defer fake.setVMGuestInfoMutex.Unlock()
+	fake.SetVMGuestInfoStub = nil
+	if fake.setVMGuestInfoReturnsOnCall == nil {
+		fake.setVMGuestInfoReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setVMGuestInfoReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) SetVMNetworkAdapter(arg1 string, arg2 string, arg3 string, arg4 string, arg5 string) error {
 	fake.setVMNetworkAdapterMutex.Lock()
 	ret, specificReturn := fake.setVMNetworkAdapterReturnsOnCall[len(fake.setVMNetworkAdapterArgsForCall)]
 	fake.setVMNetworkAdapterArgsForCall = append(fake.setVMNetworkAdapterArgsForCall, struct {
 		arg1 string
 		arg2 string
 		arg3 string
-	}{arg1, arg2, arg3})
-	fake.recordInvocation("SetVMNetworkAdapter", []interface{}{arg1, arg2, arg3})
+		arg4 string
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("SetVMNetworkAdapter", []interface{}{arg1, arg2, arg3, arg4, arg5})
 	fake.setVMNetworkAdapterMutex.Unlock()
 	if fake.SetVMNetworkAdapterStub != nil {
-		return fake.SetVMNetworkAdapterStub(arg1, arg2, arg3)
+		return fake.SetVMNetworkAdapterStub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1
@@ -1203,17 +2561,17 @@ func (fake *FakeClient) SetVMNetworkAdapterCallCount() int {
 	return len(fake.setVMNetworkAdapterArgsForCall)
 }
 
-func (fake *FakeClient) SetVMNetworkAdapterCalls(stub func(string, string, string) error) {
+func (fake *FakeClient) SetVMNetworkAdapterCalls(stub func(string, string, string, string, string) error) {
 	fake.setVMNetworkAdapterMutex.Lock()
 	defer fake.setVMNetworkAdapterMutex.Unlock()
 	fake.SetVMNetworkAdapterStub = stub
 }
 
-func (fake *FakeClient) SetVMNetworkAdapterArgsForCall(i int) (string, string, string) {
+func (fake *FakeClient) SetVMNetworkAdapterArgsForCall(i int) (string, string, string, string, string) {
 	fake.setVMNetworkAdapterMutex.RLock()
 	defer fake.setVMNetworkAdapterMutex.RUnlock()
 	argsForCall := fake.setVMNetworkAdapterArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *FakeClient) SetVMNetworkAdapterReturns(result1 error) {
@@ -1482,9 +2840,69 @@ func (fake *FakeClient) UpdateVMIsoReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeClient) UsesGuestInfoAgentSettings() bool {
+	fake.usesGuestInfoAgentSettingsMutex.Lock()
+	ret, specificReturn := fake.usesGuestInfoAgentSettingsReturnsOnCall[len(fake.usesGuestInfoAgentSettingsArgsForCall)]
+	fake.usesGuestInfoAgentSettingsArgsForCall = append(fake.usesGuestInfoAgentSettingsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("UsesGuestInfoAgentSettings", []interface{}{})
+	fake.usesGuestInfoAgentSettingsMutex.Unlock()
+	if fake.UsesGuestInfoAgentSettingsStub != nil {
+		return fake.UsesGuestInfoAgentSettingsStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.usesGuestInfoAgentSettingsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) UsesGuestInfoAgentSettingsCallCount() int {
+	fake.usesGuestInfoAgentSettingsMutex.RLock()
+	defer fake.usesGuestInfoAgentSettingsMutex.RUnlock()
+	return len(fake.usesGuestInfoAgentSettingsArgsForCall)
+}
+
+func (fake *FakeClient) UsesGuestInfoAgentSettingsCalls(stub func() bool) {
+	fake.usesGuestInfoAgentSettingsMutex.Lock()
+	defer fake.usesGuestInfoAgentSettingsMutex.Unlock()
+	fake.UsesGuestInfoAgentSettingsStub = stub
+}
+
+func (fake *FakeClient) UsesGuestInfoAgentSettingsReturns(result1 bool) {
+	fake.usesGuestInfoAgentSettingsMutex.Lock()
+	defer fake.usesGuestInfoAgentSettingsMutex.Unlock()
+	fake.UsesGuestInfoAgentSettingsStub = nil
+	fake.usesGuestInfoAgentSettingsReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeClient) UsesGuestInfoAgentSettingsReturnsOnCall(i int, result1 bool) {
+	fake.usesGuestInfoAgentSettingsMutex.Lock()
+	defer fake.usesGuestInfoAgentSettingsMutex.Unlock()
+	fake.UsesGuestInfoAgentSettingsStub = nil
+	if fake.usesGuestInfoAgentSettingsReturnsOnCall == nil {
+		fake.usesGuestInfoAgentSettingsReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.usesGuestInfoAgentSettingsReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *FakeClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.acquireStemcellVMMutex.RLock()
+	defer fake.acquireStemcellVMMutex.RUnlock()
+	fake.addDHCPReservationMutex.RLock()
+	defer fake.addDHCPReservationMutex.RUnlock()
+	fake.addPortForwardsMutex.RLock()
+	defer fake.addPortForwardsMutex.RUnlock()
+	fake.allocateMACAddressMutex.RLock()
+	defer fake.allocateMACAddressMutex.RUnlock()
 	fake.attachDiskMutex.RLock()
 	defer fake.attachDiskMutex.RUnlock()
 	fake.bootstrapVMMutex.RLock()
@@ -1501,6 +2919,14 @@ func (fake *FakeClient) Invocations() map[string][][]interface{} {
 	defer fake.destroyVMMutex.RUnlock()
 	fake.detachDiskMutex.RLock()
 	defer fake.detachDiskMutex.RUnlock()
+	fake.getDiskInfoMutex.RLock()
+	defer fake.getDiskInfoMutex.RUnlock()
+	fake.getHostNetworkNamesMutex.RLock()
+	defer fake.getHostNetworkNamesMutex.RUnlock()
+	fake.getVMGuestInfoMutex.RLock()
+	defer fake.getVMGuestInfoMutex.RUnlock()
+	fake.getVMIPAddressMutex.RLock()
+	defer fake.getVMIPAddressMutex.RUnlock()
 	fake.getVMInfoMutex.RLock()
 	defer fake.getVMInfoMutex.RUnlock()
 	fake.getVMIsoPathMutex.RLock()
@@ -1509,12 +2935,32 @@ func (fake *FakeClient) Invocations() map[string][][]interface{} {
 	defer fake.hasDiskMutex.RUnlock()
 	fake.hasVMMutex.RLock()
 	defer fake.hasVMMutex.RUnlock()
+	fake.hotAttachDiskMutex.RLock()
+	defer fake.hotAttachDiskMutex.RUnlock()
+	fake.hotDetachDiskMutex.RLock()
+	defer fake.hotDetachDiskMutex.RUnlock()
 	fake.importOvfMutex.RLock()
 	defer fake.importOvfMutex.RUnlock()
+	fake.lockStemcellVMMutex.RLock()
+	defer fake.lockStemcellVMMutex.RUnlock()
 	fake.needsVMNameChangeMutex.RLock()
 	defer fake.needsVMNameChangeMutex.RUnlock()
+	fake.registerStemcellVMMutex.RLock()
+	defer fake.registerStemcellVMMutex.RUnlock()
+	fake.releaseMACAddressesMutex.RLock()
+	defer fake.releaseMACAddressesMutex.RUnlock()
+	fake.releaseStemcellVMMutex.RLock()
+	defer fake.releaseStemcellVMMutex.RUnlock()
+	fake.removeDHCPReservationsMutex.RLock()
+	defer fake.removeDHCPReservationsMutex.RUnlock()
+	fake.removePortForwardsMutex.RLock()
+	defer fake.removePortForwardsMutex.RUnlock()
+	fake.setVMDiskControllerMutex.RLock()
+	defer fake.setVMDiskControllerMutex.RUnlock()
 	fake.setVMDisplayNameMutex.RLock()
 	defer fake.setVMDisplayNameMutex.RUnlock()
+	fake.setVMGuestInfoMutex.RLock()
+	defer fake.setVMGuestInfoMutex.RUnlock()
 	fake.setVMNetworkAdapterMutex.RLock()
 	defer fake.setVMNetworkAdapterMutex.RUnlock()
 	fake.setVMResourcesMutex.RLock()
@@ -1525,6 +2971,8 @@ func (fake *FakeClient) Invocations() map[string][][]interface{} {
 	defer fake.stopVMMutex.RUnlock()
 	fake.updateVMIsoMutex.RLock()
 	defer fake.updateVMIsoMutex.RUnlock()
+	fake.usesGuestInfoAgentSettingsMutex.RLock()
+	defer fake.usesGuestInfoAgentSettingsMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -8,6 +8,68 @@ import (
 )
 
 type FakeConfig struct {
+	AgentSettingsSourceStub        func() string
+	agentSettingsSourceMutex       sync.RWMutex
+	agentSettingsSourceArgsForCall []struct {
+	}
+	agentSettingsSourceReturns struct {
+		result1 string
+	}
+	agentSettingsSourceReturnsOnCall map[int]struct {
+		result1 string
+	}
+	DatastoreDiskPathStub        func(string, string) string
+	datastoreDiskPathMutex       sync.RWMutex
+	datastoreDiskPathArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	datastoreDiskPathReturns struct {
+		result1 string
+	}
+	datastoreDiskPathReturnsOnCall map[int]struct {
+		result1 string
+	}
+	DatastorePlacementStub        func() string
+	datastorePlacementMutex       sync.RWMutex
+	datastorePlacementArgsForCall []struct {
+	}
+	datastorePlacementReturns struct {
+		result1 string
+	}
+	datastorePlacementReturnsOnCall map[int]struct {
+		result1 string
+	}
+	DatastorePlacementStatePathStub        func() string
+	datastorePlacementStatePathMutex       sync.RWMutex
+	datastorePlacementStatePathArgsForCall []struct {
+	}
+	datastorePlacementStatePathReturns struct {
+		result1 string
+	}
+	datastorePlacementStatePathReturnsOnCall map[int]struct {
+		result1 string
+	}
+	DatastoresStub        func() []driver.Datastore
+	datastoresMutex       sync.RWMutex
+	datastoresArgsForCall []struct {
+	}
+	datastoresReturns struct {
+		result1 []driver.Datastore
+	}
+	datastoresReturnsOnCall map[int]struct {
+		result1 []driver.Datastore
+	}
+	DhcpConfigPathStub        func() string
+	dhcpConfigPathMutex       sync.RWMutex
+	dhcpConfigPathArgsForCall []struct {
+	}
+	dhcpConfigPathReturns struct {
+		result1 string
+	}
+	dhcpConfigPathReturnsOnCall map[int]struct {
+		result1 string
+	}
 	EnableHumanReadableNameStub        func() bool
 	enableHumanReadableNameMutex       sync.RWMutex
 	enableHumanReadableNameArgsForCall []struct {
@@ -40,6 +102,36 @@ type FakeConfig struct {
 	ephemeralDiskPathReturnsOnCall map[int]struct {
 		result1 string
 	}
+	MacAddressRegistryPathStub        func() string
+	macAddressRegistryPathMutex       sync.RWMutex
+	macAddressRegistryPathArgsForCall []struct {
+	}
+	macAddressRegistryPathReturns struct {
+		result1 string
+	}
+	macAddressRegistryPathReturnsOnCall map[int]struct {
+		result1 string
+	}
+	NatConfigPathStub        func() string
+	natConfigPathMutex       sync.RWMutex
+	natConfigPathArgsForCall []struct {
+	}
+	natConfigPathReturns struct {
+		result1 string
+	}
+	natConfigPathReturnsOnCall map[int]struct {
+		result1 string
+	}
+	OvfImporterStub        func() string
+	ovfImporterMutex       sync.RWMutex
+	ovfImporterArgsForCall []struct {
+	}
+	ovfImporterReturns struct {
+		result1 string
+	}
+	ovfImporterReturnsOnCall map[int]struct {
+		result1 string
+	}
 	OvftoolPathStub        func() string
 	ovftoolPathMutex       sync.RWMutex
 	ovftoolPathArgsForCall []struct {
@@ -50,6 +142,17 @@ type FakeConfig struct {
 	ovftoolPathReturnsOnCall map[int]struct {
 		result1 string
 	}
+	PersistentDiskMappingPathStub        func(string) string
+	persistentDiskMappingPathMutex       sync.RWMutex
+	persistentDiskMappingPathArgsForCall []struct {
+		arg1 string
+	}
+	persistentDiskMappingPathReturns struct {
+		result1 string
+	}
+	persistentDiskMappingPathReturnsOnCall map[int]struct {
+		result1 string
+	}
 	PersistentDiskPathStub        func(string) string
 	persistentDiskPathMutex       sync.RWMutex
 	persistentDiskPathArgsForCall []struct {
@@ -61,6 +164,48 @@ type FakeConfig struct {
 	persistentDiskPathReturnsOnCall map[int]struct {
 		result1 string
 	}
+	PersistentDiskStorePathStub        func() string
+	persistentDiskStorePathMutex       sync.RWMutex
+	persistentDiskStorePathArgsForCall []struct {
+	}
+	persistentDiskStorePathReturns struct {
+		result1 string
+	}
+	persistentDiskStorePathReturnsOnCall map[int]struct {
+		result1 string
+	}
+	StemcellRegistryPathStub        func() string
+	stemcellRegistryPathMutex       sync.RWMutex
+	stemcellRegistryPathArgsForCall []struct {
+	}
+	stemcellRegistryPathReturns struct {
+		result1 string
+	}
+	stemcellRegistryPathReturnsOnCall map[int]struct {
+		result1 string
+	}
+	VmDatastorePathStub        func(string) string
+	vmDatastorePathMutex       sync.RWMutex
+	vmDatastorePathArgsForCall []struct {
+		arg1 string
+	}
+	vmDatastorePathReturns struct {
+		result1 string
+	}
+	vmDatastorePathReturnsOnCall map[int]struct {
+		result1 string
+	}
+	VmMappingPathStub        func(string) string
+	vmMappingPathMutex       sync.RWMutex
+	vmMappingPathArgsForCall []struct {
+		arg1 string
+	}
+	vmMappingPathReturns struct {
+		result1 string
+	}
+	vmMappingPathReturnsOnCall map[int]struct {
+		result1 string
+	}
 	VmSoftShutdownMaxWaitStub        func() time.Duration
 	vmSoftShutdownMaxWaitMutex       sync.RWMutex
 	vmSoftShutdownMaxWaitArgsForCall []struct {
@@ -81,6 +226,26 @@ type FakeConfig struct {
 	vmStartMaxWaitReturnsOnCall map[int]struct {
 		result1 time.Duration
 	}
+	VmStorePathStub        func() string
+	vmStorePathMutex       sync.RWMutex
+	vmStorePathArgsForCall []struct {
+	}
+	vmStorePathReturns struct {
+		result1 string
+	}
+	vmStorePathReturnsOnCall map[int]struct {
+		result1 string
+	}
+	VmnetConfigPathsStub        func() []string
+	vmnetConfigPathsMutex       sync.RWMutex
+	vmnetConfigPathsArgsForCall []struct {
+	}
+	vmnetConfigPathsReturns struct {
+		result1 []string
+	}
+	vmnetConfigPathsReturnsOnCall map[int]struct {
+		result1 []string
+	}
 	VmrunPathStub        func() string
 	vmrunPathMutex       sync.RWMutex
 	vmrunPathArgsForCall []struct {
@@ -106,6 +271,327 @@ type FakeConfig struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeConfig) AgentSettingsSource() string {
+	fake.agentSettingsSourceMutex.Lock()
+	ret, specificReturn := fake.agentSettingsSourceReturnsOnCall[len(fake.agentSettingsSourceArgsForCall)]
+	fake.agentSettingsSourceArgsForCall = append(fake.agentSettingsSourceArgsForCall, struct {
+	}{})
+	fake.recordInvocation("AgentSettingsSource", []interface{}{})
+	fake.agentSettingsSourceMutex.Unlock()
+	if fake.AgentSettingsSourceStub != nil {
+		return fake.AgentSettingsSourceStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.agentSettingsSourceReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) AgentSettingsSourceCallCount() int {
+	fake.agentSettingsSourceMutex.RLock()
+	defer fake.agentSettingsSourceMutex.RUnlock()
+	return len(fake.agentSettingsSourceArgsForCall)
+}
+
+func (fake *FakeConfig) AgentSettingsSourceCalls(stub func() string) {
+	fake.agentSettingsSourceMutex.Lock()
+	defer fake.agentSettingsSourceMutex.Unlock()
+	fake.AgentSettingsSourceStub = stub
+}
+
+func (fake *FakeConfig) AgentSettingsSourceReturns(result1 string) {
+	fake.agentSettingsSourceMutex.Lock()
+	defer fake.agentSettingsSourceMutex.Unlock()
+	fake.AgentSettingsSourceStub = nil
+	fake.agentSettingsSourceReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) AgentSettingsSourceReturnsOnCall(i int, result1 string) {
+	fake.agentSettingsSourceMutex.Lock()
+	defer fake.agentSettingsSourceMutex.Unlock()
+	fake.AgentSettingsSourceStub = nil
+	if fake.agentSettingsSourceReturnsOnCall == nil {
+		fake.agentSettingsSourceReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.agentSettingsSourceReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) DatastoreDiskPath(arg1 string, arg2 string) string {
+	fake.datastoreDiskPathMutex.Lock()
+	ret, specificReturn := fake.datastoreDiskPathReturnsOnCall[len(fake.datastoreDiskPathArgsForCall)]
+	fake.datastoreDiskPathArgsForCall = append(fake.datastoreDiskPathArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("DatastoreDiskPath", []interface{}{arg1, arg2})
+	fake.datastoreDiskPathMutex.Unlock()
+	if fake.DatastoreDiskPathStub != nil {
+		return fake.DatastoreDiskPathStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.datastoreDiskPathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) DatastoreDiskPathCallCount() int {
+	fake.datastoreDiskPathMutex.RLock()
+	defer fake.datastoreDiskPathMutex.RUnlock()
+	return len(fake.datastoreDiskPathArgsForCall)
+}
+
+func (fake *FakeConfig) DatastoreDiskPathCalls(stub func(string, string) string) {
+	fake.datastoreDiskPathMutex.Lock()
+	defer fake.datastoreDiskPathMutex.Unlock()
+	fake.DatastoreDiskPathStub = stub
+}
+
+func (fake *FakeConfig) DatastoreDiskPathArgsForCall(i int) (string, string) {
+	fake.datastoreDiskPathMutex.RLock()
+	defer fake.datastoreDiskPathMutex.RUnlock()
+	argsForCall := fake.datastoreDiskPathArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeConfig) DatastoreDiskPathReturns(result1 string) {
+	fake.datastoreDiskPathMutex.Lock()
+	defer fake.datastoreDiskPathMutex.Unlock()
+	fake.DatastoreDiskPathStub = nil
+	fake.datastoreDiskPathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) DatastoreDiskPathReturnsOnCall(i int, result1 string) {
+	fake.datastoreDiskPathMutex.Lock()
+	defer fake.datastoreDiskPathMutex.Unlock()
+	fake.DatastoreDiskPathStub = nil
+	if fake.datastoreDiskPathReturnsOnCall == nil {
+		fake.datastoreDiskPathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.datastoreDiskPathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) DatastorePlacement() string {
+	fake.datastorePlacementMutex.Lock()
+	ret, specificReturn := fake.datastorePlacementReturnsOnCall[len(fake.datastorePlacementArgsForCall)]
+	fake.datastorePlacementArgsForCall = append(fake.datastorePlacementArgsForCall, struct {
+	}{})
+	fake.recordInvocation("DatastorePlacement", []interface{}{})
+	fake.datastorePlacementMutex.Unlock()
+	if fake.DatastorePlacementStub != nil {
+		return fake.DatastorePlacementStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.datastorePlacementReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) DatastorePlacementCallCount() int {
+	fake.datastorePlacementMutex.RLock()
+	defer fake.datastorePlacementMutex.RUnlock()
+	return len(fake.datastorePlacementArgsForCall)
+}
+
+func (fake *FakeConfig) DatastorePlacementCalls(stub func() string) {
+	fake.datastorePlacementMutex.Lock()
+	defer fake.datastorePlacementMutex.Unlock()
+	fake.DatastorePlacementStub = stub
+}
+
+func (fake *FakeConfig) DatastorePlacementReturns(result1 string) {
+	fake.datastorePlacementMutex.Lock()
+	defer fake.datastorePlacementMutex.Unlock()
+	fake.DatastorePlacementStub = nil
+	fake.datastorePlacementReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) DatastorePlacementReturnsOnCall(i int, result1 string) {
+	fake.datastorePlacementMutex.Lock()
+	defer fake.datastorePlacementMutex.Unlock()
+	fake.DatastorePlacementStub = nil
+	if fake.datastorePlacementReturnsOnCall == nil {
+		fake.datastorePlacementReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.datastorePlacementReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) DatastorePlacementStatePath() string {
+	fake.datastorePlacementStatePathMutex.Lock()
+	ret, specificReturn := fake.datastorePlacementStatePathReturnsOnCall[len(fake.datastorePlacementStatePathArgsForCall)]
+	fake.datastorePlacementStatePathArgsForCall = append(fake.datastorePlacementStatePathArgsForCall, struct {
+	}{})
+	fake.recordInvocation("DatastorePlacementStatePath", []interface{}{})
+	fake.datastorePlacementStatePathMutex.Unlock()
+	if fake.DatastorePlacementStatePathStub != nil {
+		return fake.DatastorePlacementStatePathStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.datastorePlacementStatePathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) DatastorePlacementStatePathCallCount() int {
+	fake.datastorePlacementStatePathMutex.RLock()
+	defer fake.datastorePlacementStatePathMutex.RUnlock()
+	return len(fake.datastorePlacementStatePathArgsForCall)
+}
+
+func (fake *FakeConfig) DatastorePlacementStatePathCalls(stub func() string) {
+	fake.datastorePlacementStatePathMutex.Lock()
+	defer fake.datastorePlacementStatePathMutex.Unlock()
+	fake.DatastorePlacementStatePathStub = stub
+}
+
+func (fake *FakeConfig) DatastorePlacementStatePathReturns(result1 string) {
+	fake.datastorePlacementStatePathMutex.Lock()
+	defer fake.datastorePlacementStatePathMutex.Unlock()
+	fake.DatastorePlacementStatePathStub = nil
+	fake.datastorePlacementStatePathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) DatastorePlacementStatePathReturnsOnCall(i int, result1 string) {
+	fake.datastorePlacementStatePathMutex.Lock()
+	defer fake.datastorePlacementStatePathMutex.Unlock()
+	fake.DatastorePlacementStatePathStub = nil
+	if fake.datastorePlacementStatePathReturnsOnCall == nil {
+		fake.datastorePlacementStatePathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.datastorePlacementStatePathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) Datastores() []driver.Datastore {
+	fake.datastoresMutex.Lock()
+	ret, specificReturn := fake.datastoresReturnsOnCall[len(fake.datastoresArgsForCall)]
+	fake.datastoresArgsForCall = append(fake.datastoresArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Datastores", []interface{}{})
+	fake.datastoresMutex.Unlock()
+	if fake.DatastoresStub != nil {
+		return fake.DatastoresStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.datastoresReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) DatastoresCallCount() int {
+	fake.datastoresMutex.RLock()
+	defer fake.datastoresMutex.RUnlock()
+	return len(fake.datastoresArgsForCall)
+}
+
+func (fake *FakeConfig) DatastoresCalls(stub func() []driver.Datastore) {
+	fake.datastoresMutex.Lock()
+	defer fake.datastoresMutex.Unlock()
+	fake.DatastoresStub = stub
+}
+
+func (fake *FakeConfig) DatastoresReturns(result1 []driver.Datastore) {
+	fake.datastoresMutex.Lock()
+	defer fake.datastoresMutex.Unlock()
+	fake.DatastoresStub = nil
+	fake.datastoresReturns = struct {
+		result1 []driver.Datastore
+	}{result1}
+}
+
+func (fake *FakeConfig) DatastoresReturnsOnCall(i int, result1 []driver.Datastore) {
+	fake.datastoresMutex.Lock()
+	defer fake.datastoresMutex.Unlock()
+	fake.DatastoresStub = nil
+	if fake.datastoresReturnsOnCall == nil {
+		fake.datastoresReturnsOnCall = make(map[int]struct {
+			result1 []driver.Datastore
+		})
+	}
+	fake.datastoresReturnsOnCall[i] = struct {
+		result1 []driver.Datastore
+	}{result1}
+}
+
+func (fake *FakeConfig) DhcpConfigPath() string {
+	fake.dhcpConfigPathMutex.Lock()
+	ret, specificReturn := fake.dhcpConfigPathReturnsOnCall[len(fake.dhcpConfigPathArgsForCall)]
+	fake.dhcpConfigPathArgsForCall = append(fake.dhcpConfigPathArgsForCall, struct {
+	}{})
+	fake.recordInvocation("DhcpConfigPath", []interface{}{})
+	fake.dhcpConfigPathMutex.Unlock()
+	if fake.DhcpConfigPathStub != nil {
+		return fake.DhcpConfigPathStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.dhcpConfigPathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) DhcpConfigPathCallCount() int {
+	fake.dhcpConfigPathMutex.RLock()
+	defer fake.dhcpConfigPathMutex.RUnlock()
+	return len(fake.dhcpConfigPathArgsForCall)
+}
+
+func (fake *FakeConfig) DhcpConfigPathCalls(stub func() string) {
+	fake.dhcpConfigPathMutex.Lock()
+	defer fake.dhcpConfigPathMutex.Unlock()
+	fake.DhcpConfigPathStub = stub
+}
+
+func (fake *FakeConfig) DhcpConfigPathReturns(result1 string) {
+	fake.dhcpConfigPathMutex.Lock()
+	defer fake.dhcpConfigPathMutex.Unlock()
+	fake.DhcpConfigPathStub = nil
+	fake.dhcpConfigPathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) DhcpConfigPathReturnsOnCall(i int, result1 string) {
+	fake.dhcpConfigPathMutex.Lock()
+	defer fake.dhcpConfigPathMutex.Unlock()
+	fake.DhcpConfigPathStub = nil
+	if fake.dhcpConfigPathReturnsOnCall == nil {
+		fake.dhcpConfigPathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.dhcpConfigPathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakeConfig) EnableHumanReadableName() bool {
 	fake.enableHumanReadableNameMutex.Lock()
 	ret, specificReturn := fake.enableHumanReadableNameReturnsOnCall[len(fake.enableHumanReadableNameArgsForCall)]
@@ -278,6 +764,162 @@ func (fake *FakeConfig) EphemeralDiskPathReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeConfig) MacAddressRegistryPath() string {
+	fake.macAddressRegistryPathMutex.Lock()
+	ret, specificReturn := fake.macAddressRegistryPathReturnsOnCall[len(fake.macAddressRegistryPathArgsForCall)]
+	fake.macAddressRegistryPathArgsForCall = append(fake.macAddressRegistryPathArgsForCall, struct {
+	}{})
+	fake.recordInvocation("MacAddressRegistryPath", []interface{}{})
+	fake.macAddressRegistryPathMutex.Unlock()
+	if fake.MacAddressRegistryPathStub != nil {
+		return fake.MacAddressRegistryPathStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.macAddressRegistryPathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) MacAddressRegistryPathCallCount() int {
+	fake.macAddressRegistryPathMutex.RLock()
+	defer fake.macAddressRegistryPathMutex.RUnlock()
+	return len(fake.macAddressRegistryPathArgsForCall)
+}
+
+func (fake *FakeConfig) MacAddressRegistryPathCalls(stub func() string) {
+	fake.macAddressRegistryPathMutex.Lock()
+	defer fake.macAddressRegistryPathMutex.Unlock()
+	fake.MacAddressRegistryPathStub = stub
+}
+
+func (fake *FakeConfig) MacAddressRegistryPathReturns(result1 string) {
+	fake.macAddressRegistryPathMutex.Lock()
+	defer fake.macAddressRegistryPathMutex.Unlock()
+	fake.MacAddressRegistryPathStub = nil
+	fake.macAddressRegistryPathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) MacAddressRegistryPathReturnsOnCall(i int, result1 string) {
+	fake.macAddressRegistryPathMutex.Lock()
+	defer fake.macAddressRegistryPathMutex.Unlock()
+	fake.MacAddressRegistryPathStub = nil
+	if fake.macAddressRegistryPathReturnsOnCall == nil {
+		fake.macAddressRegistryPathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.macAddressRegistryPathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) NatConfigPath() string {
+	fake.natConfigPathMutex.Lock()
+	ret, specificReturn := fake.natConfigPathReturnsOnCall[len(fake.natConfigPathArgsForCall)]
+	fake.natConfigPathArgsForCall = append(fake.natConfigPathArgsForCall, struct {
+	}{})
+	fake.recordInvocation("NatConfigPath", []interface{}{})
+	fake.natConfigPathMutex.Unlock()
+	if fake.NatConfigPathStub != nil {
+		return fake.NatConfigPathStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.natConfigPathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) NatConfigPathCallCount() int {
+	fake.natConfigPathMutex.RLock()
+	defer fake.natConfigPathMutex.RUnlock()
+	return len(fake.natConfigPathArgsForCall)
+}
+
+func (fake *FakeConfig) NatConfigPathCalls(stub func() string) {
+	fake.natConfigPathMutex.Lock()
+	defer fake.natConfigPathMutex.Unlock()
+	fake.NatConfigPathStub = stub
+}
+
+func (fake *FakeConfig) NatConfigPathReturns(result1 string) {
+	fake.natConfigPathMutex.Lock()
+	defer fake.natConfigPathMutex.Unlock()
+	fake.NatConfigPathStub = nil
+	fake.natConfigPathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) NatConfigPathReturnsOnCall(i int, result1 string) {
+	fake.natConfigPathMutex.Lock()
+	defer fake.natConfigPathMutex.Unlock()
+	fake.NatConfigPathStub = nil
+	if fake.natConfigPathReturnsOnCall == nil {
+		fake.natConfigPathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.natConfigPathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) OvfImporter() string {
+	fake.ovfImporterMutex.Lock()
+	ret, specificReturn := fake.ovfImporterReturnsOnCall[len(fake.ovfImporterArgsForCall)]
+	fake.ovfImporterArgsForCall = append(fake.ovfImporterArgsForCall, struct {
+	}{})
+	fake.recordInvocation("OvfImporter", []interface{}{})
+	fake.ovfImporterMutex.Unlock()
+	if fake.OvfImporterStub != nil {
+		return fake.OvfImporterStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.ovfImporterReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) OvfImporterCallCount() int {
+	fake.ovfImporterMutex.RLock()
+	defer fake.ovfImporterMutex.RUnlock()
+	return len(fake.ovfImporterArgsForCall)
+}
+
+func (fake *FakeConfig) OvfImporterCalls(stub func() string) {
+	fake.ovfImporterMutex.Lock()
+	defer fake.ovfImporterMutex.Unlock()
+	fake.OvfImporterStub = stub
+}
+
+func (fake *FakeConfig) OvfImporterReturns(result1 string) {
+	fake.ovfImporterMutex.Lock()
+	defer fake.ovfImporterMutex.Unlock()
+	fake.OvfImporterStub = nil
+	fake.ovfImporterReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) OvfImporterReturnsOnCall(i int, result1 string) {
+	fake.ovfImporterMutex.Lock()
+	defer fake.ovfImporterMutex.Unlock()
+	fake.OvfImporterStub = nil
+	if fake.ovfImporterReturnsOnCall == nil {
+		fake.ovfImporterReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.ovfImporterReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakeConfig) OvftoolPath() string {
 	fake.ovftoolPathMutex.Lock()
 	ret, specificReturn := fake.ovftoolPathReturnsOnCall[len(fake.ovftoolPathArgsForCall)]
@@ -330,6 +972,66 @@ func (fake *FakeConfig) OvftoolPathReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeConfig) PersistentDiskMappingPath(arg1 string) string {
+	fake.persistentDiskMappingPathMutex.Lock()
+	ret, specificReturn := fake.persistentDiskMappingPathReturnsOnCall[len(fake.persistentDiskMappingPathArgsForCall)]
+	fake.persistentDiskMappingPathArgsForCall = append(fake.persistentDiskMappingPathArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("PersistentDiskMappingPath", []interface{}{arg1})
+	fake.persistentDiskMappingPathMutex.Unlock()
+	if fake.PersistentDiskMappingPathStub != nil {
+		return fake.PersistentDiskMappingPathStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.persistentDiskMappingPathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) PersistentDiskMappingPathCallCount() int {
+	fake.persistentDiskMappingPathMutex.RLock()
+	defer fake.persistentDiskMappingPathMutex.RUnlock()
+	return len(fake.persistentDiskMappingPathArgsForCall)
+}
+
+func (fake *FakeConfig) PersistentDiskMappingPathCalls(stub func(string) string) {
+	fake.persistentDiskMappingPathMutex.Lock()
+	defer fake.persistentDiskMappingPathMutex.Unlock()
+	fake.PersistentDiskMappingPathStub = stub
+}
+
+func (fake *FakeConfig) PersistentDiskMappingPathArgsForCall(i int) string {
+	fake.persistentDiskMappingPathMutex.RLock()
+	defer fake.persistentDiskMappingPathMutex.RUnlock()
+	argsForCall := fake.persistentDiskMappingPathArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeConfig) PersistentDiskMappingPathReturns(result1 string) {
+	fake.persistentDiskMappingPathMutex.Lock()
+	defer fake.persistentDiskMappingPathMutex.Unlock()
+	fake.PersistentDiskMappingPathStub = nil
+	fake.persistentDiskMappingPathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) PersistentDiskMappingPathReturnsOnCall(i int, result1 string) {
+	fake.persistentDiskMappingPathMutex.Lock()
+	defer fake.persistentDiskMappingPathMutex.Unlock()
+	fake.PersistentDiskMappingPathStub = nil
+	if fake.persistentDiskMappingPathReturnsOnCall == nil {
+		fake.persistentDiskMappingPathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.persistentDiskMappingPathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakeConfig) PersistentDiskPath(arg1 string) string {
 	fake.persistentDiskPathMutex.Lock()
 	ret, specificReturn := fake.persistentDiskPathReturnsOnCall[len(fake.persistentDiskPathArgsForCall)]
@@ -390,6 +1092,230 @@ func (fake *FakeConfig) PersistentDiskPathReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeConfig) PersistentDiskStorePath() string {
+	fake.persistentDiskStorePathMutex.Lock()
+	ret, specificReturn := fake.persistentDiskStorePathReturnsOnCall[len(fake.persistentDiskStorePathArgsForCall)]
+	fake.persistentDiskStorePathArgsForCall = append(fake.persistentDiskStorePathArgsForCall, struct {
+	}{})
+	fake.recordInvocation("PersistentDiskStorePath", []interface{}{})
+	fake.persistentDiskStorePathMutex.Unlock()
+	if fake.PersistentDiskStorePathStub != nil {
+		return fake.PersistentDiskStorePathStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.persistentDiskStorePathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) PersistentDiskStorePathCallCount() int {
+	fake.persistentDiskStorePathMutex.RLock()
+	defer fake.persistentDiskStorePathMutex.RUnlock()
+	return len(fake.persistentDiskStorePathArgsForCall)
+}
+
+func (fake *FakeConfig) PersistentDiskStorePathCalls(stub func() string) {
+	fake.persistentDiskStorePathMutex.Lock()
+	defer fake.persistentDiskStorePathMutex.Unlock()
+	fake.PersistentDiskStorePathStub = stub
+}
+
+func (fake *FakeConfig) PersistentDiskStorePathReturns(result1 string) {
+	fake.persistentDiskStorePathMutex.Lock()
+	defer fake.persistentDiskStorePathMutex.Unlock()
+	fake.PersistentDiskStorePathStub = nil
+	fake.persistentDiskStorePathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) PersistentDiskStorePathReturnsOnCall(i int, result1 string) {
+	fake.persistentDiskStorePathMutex.Lock()
+	defer fake.persistentDiskStorePathMutex.Unlock()
+	fake.PersistentDiskStorePathStub = nil
+	if fake.persistentDiskStorePathReturnsOnCall == nil {
+		fake.persistentDiskStorePathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.persistentDiskStorePathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) StemcellRegistryPath() string {
+	fake.stemcellRegistryPathMutex.Lock()
+	ret, specificReturn := fake.stemcellRegistryPathReturnsOnCall[len(fake.stemcellRegistryPathArgsForCall)]
+	fake.stemcellRegistryPathArgsForCall = append(fake.stemcellRegistryPathArgsForCall, struct {
+	}{})
+	fake.recordInvocation("StemcellRegistryPath", []interface{}{})
+	fake.stemcellRegistryPathMutex.Unlock()
+	if fake.StemcellRegistryPathStub != nil {
+		return fake.StemcellRegistryPathStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.stemcellRegistryPathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) StemcellRegistryPathCallCount() int {
+	fake.stemcellRegistryPathMutex.RLock()
+	defer fake.stemcellRegistryPathMutex.RUnlock()
+	return len(fake.stemcellRegistryPathArgsForCall)
+}
+
+func (fake *FakeConfig) StemcellRegistryPathCalls(stub func() string) {
+	fake.stemcellRegistryPathMutex.Lock()
+	defer fake.stemcellRegistryPathMutex.Unlock()
+	fake.StemcellRegistryPathStub = stub
+}
+
+func (fake *FakeConfig) StemcellRegistryPathReturns(result1 string) {
+	fake.stemcellRegistryPathMutex.Lock()
+	defer fake.stemcellRegistryPathMutex.Unlock()
+	fake.StemcellRegistryPathStub = nil
+	fake.stemcellRegistryPathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) StemcellRegistryPathReturnsOnCall(i int, result1 string) {
+	fake.stemcellRegistryPathMutex.Lock()
+	defer fake.stemcellRegistryPathMutex.Unlock()
+	fake.StemcellRegistryPathStub = nil
+	if fake.stemcellRegistryPathReturnsOnCall == nil {
+		fake.stemcellRegistryPathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.stemcellRegistryPathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) VmDatastorePath(arg1 string) string {
+	fake.vmDatastorePathMutex.Lock()
+	ret, specificReturn := fake.vmDatastorePathReturnsOnCall[len(fake.vmDatastorePathArgsForCall)]
+	fake.vmDatastorePathArgsForCall = append(fake.vmDatastorePathArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("VmDatastorePath", []interface{}{arg1})
+	fake.vmDatastorePathMutex.Unlock()
+	if fake.VmDatastorePathStub != nil {
+		return fake.VmDatastorePathStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.vmDatastorePathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) VmDatastorePathCallCount() int {
+	fake.vmDatastorePathMutex.RLock()
+	defer fake.vmDatastorePathMutex.RUnlock()
+	return len(fake.vmDatastorePathArgsForCall)
+}
+
+func (fake *FakeConfig) VmDatastorePathCalls(stub func(string) string) {
+	fake.vmDatastorePathMutex.Lock()
+	defer fake.vmDatastorePathMutex.Unlock()
+	fake.VmDatastorePathStub = stub
+}
+
+func (fake *FakeConfig) VmDatastorePathArgsForCall(i int) string {
+	fake.vmDatastorePathMutex.RLock()
+	defer fake.vmDatastorePathMutex.RUnlock()
+	argsForCall := fake.vmDatastorePathArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeConfig) VmDatastorePathReturns(result1 string) {
+	fake.vmDatastorePathMutex.Lock()
+	defer fake.vmDatastorePathMutex.Unlock()
+	fake.VmDatastorePathStub = nil
+	fake.vmDatastorePathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) VmDatastorePathReturnsOnCall(i int, result1 string) {
+	fake.vmDatastorePathMutex.Lock()
+	defer fake.vmDatastorePathMutex.Unlock()
+	fake.VmDatastorePathStub = nil
+	if fake.vmDatastorePathReturnsOnCall == nil {
+		fake.vmDatastorePathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.vmDatastorePathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) VmMappingPath(arg1 string) string {
+	fake.vmMappingPathMutex.Lock()
+	ret, specificReturn := fake.vmMappingPathReturnsOnCall[len(fake.vmMappingPathArgsForCall)]
+	fake.vmMappingPathArgsForCall = append(fake.vmMappingPathArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("VmMappingPath", []interface{}{arg1})
+	fake.vmMappingPathMutex.Unlock()
+	if fake.VmMappingPathStub != nil {
+		return fake.VmMappingPathStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.vmMappingPathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) VmMappingPathCallCount() int {
+	fake.vmMappingPathMutex.RLock()
+	defer fake.vmMappingPathMutex.RUnlock()
+	return len(fake.vmMappingPathArgsForCall)
+}
+
+func (fake *FakeConfig) VmMappingPathCalls(stub func(string) string) {
+	fake.vmMappingPathMutex.Lock()
+	defer fake.vmMappingPathMutex.Unlock()
+	fake.VmMappingPathStub = stub
+}
+
+func (fake *FakeConfig) VmMappingPathArgsForCall(i int) string {
+	fake.vmMappingPathMutex.RLock()
+	defer fake.vmMappingPathMutex.RUnlock()
+	argsForCall := fake.vmMappingPathArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeConfig) VmMappingPathReturns(result1 string) {
+	fake.vmMappingPathMutex.Lock()
+	defer fake.vmMappingPathMutex.Unlock()
+	fake.VmMappingPathStub = nil
+	fake.vmMappingPathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) VmMappingPathReturnsOnCall(i int, result1 string) {
+	fake.vmMappingPathMutex.Lock()
+	defer fake.vmMappingPathMutex.Unlock()
+	fake.VmMappingPathStub = nil
+	if fake.vmMappingPathReturnsOnCall == nil {
+		fake.vmMappingPathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.vmMappingPathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
 func (fake *FakeConfig) VmSoftShutdownMaxWait() time.Duration {
 	fake.vmSoftShutdownMaxWaitMutex.Lock()
 	ret, specificReturn := fake.vmSoftShutdownMaxWaitReturnsOnCall[len(fake.vmSoftShutdownMaxWaitArgsForCall)]
@@ -494,6 +1420,110 @@ func (fake *FakeConfig) VmStartMaxWaitReturnsOnCall(i int, result1 time.Duration
 	}{result1}
 }
 
+func (fake *FakeConfig) VmStorePath() string {
+	fake.vmStorePathMutex.Lock()
+	ret, specificReturn := fake.vmStorePathReturnsOnCall[len(fake.vmStorePathArgsForCall)]
+	fake.vmStorePathArgsForCall = append(fake.vmStorePathArgsForCall, struct {
+	}{})
+	fake.recordInvocation("VmStorePath", []interface{}{})
+	fake.vmStorePathMutex.Unlock()
+	if fake.VmStorePathStub != nil {
+		return fake.VmStorePathStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.vmStorePathReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) VmStorePathCallCount() int {
+	fake.vmStorePathMutex.RLock()
+	defer fake.vmStorePathMutex.RUnlock()
+	return len(fake.vmStorePathArgsForCall)
+}
+
+func (fake *FakeConfig) VmStorePathCalls(stub func() string) {
+	fake.vmStorePathMutex.Lock()
+	defer fake.vmStorePathMutex.Unlock()
+	fake.VmStorePathStub = stub
+}
+
+func (fake *FakeConfig) VmStorePathReturns(result1 string) {
+	fake.vmStorePathMutex.Lock()
+	defer fake.vmStorePathMutex.Unlock()
+	fake.VmStorePathStub = nil
+	fake.vmStorePathReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) VmStorePathReturnsOnCall(i int, result1 string) {
+	fake.vmStorePathMutex.Lock()
+	defer fake.vmStorePathMutex.Unlock()
+	fake.VmStorePathStub = nil
+	if fake.vmStorePathReturnsOnCall == nil {
+		fake.vmStorePathReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.vmStorePathReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) VmnetConfigPaths() []string {
+	fake.vmnetConfigPathsMutex.Lock()
+	ret, specificReturn := fake.vmnetConfigPathsReturnsOnCall[len(fake.vmnetConfigPathsArgsForCall)]
+	fake.vmnetConfigPathsArgsForCall = append(fake.vmnetConfigPathsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("VmnetConfigPaths", []interface{}{})
+	fake.vmnetConfigPathsMutex.Unlock()
+	if fake.VmnetConfigPathsStub != nil {
+		return fake.VmnetConfigPathsStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.vmnetConfigPathsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) VmnetConfigPathsCallCount() int {
+	fake.vmnetConfigPathsMutex.RLock()
+	defer fake.vmnetConfigPathsMutex.RUnlock()
+	return len(fake.vmnetConfigPathsArgsForCall)
+}
+
+func (fake *FakeConfig) VmnetConfigPathsCalls(stub func() []string) {
+	fake.vmnetConfigPathsMutex.Lock()
+	defer fake.vmnetConfigPathsMutex.Unlock()
+	fake.VmnetConfigPathsStub = stub
+}
+
+func (fake *FakeConfig) VmnetConfigPathsReturns(result1 []string) {
+	fake.vmnetConfigPathsMutex.Lock()
+	defer fake.vmnetConfigPathsMutex.Unlock()
+	fake.VmnetConfigPathsStub = nil
+	fake.vmnetConfigPathsReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeConfig) VmnetConfigPathsReturnsOnCall(i int, result1 []string) {
+	fake.vmnetConfigPathsMutex.Lock()
+	defer fake.vmnetConfigPathsMutex.Unlock()
+	fake.VmnetConfigPathsStub = nil
+	if fake.vmnetConfigPathsReturnsOnCall == nil {
+		fake.vmnetConfigPathsReturnsOnCall = make(map[int]struct {
+			result1 []string
+		})
+	}
+	fake.vmnetConfigPathsReturnsOnCall[i] = struct {
+		result1 []string
+	}{result1}
+}
+
 func (fake *FakeConfig) VmrunPath() string {
 	fake.vmrunPathMutex.Lock()
 	ret, specificReturn := fake.vmrunPathReturnsOnCall[len(fake.vmrunPathArgsForCall)]
@@ -609,20 +1639,52 @@ func (fake *FakeConfig) VmxPathReturnsOnCall(i int, result1 string) {
 func (fake *FakeConfig) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.agentSettingsSourceMutex.RLock()
+	defer fake.agentSettingsSourceMutex.RUnlock()
+	fake.datastoreDiskPathMutex.RLock()
+	defer fake.datastoreDiskPathMutex.RUnlock()
+	fake.datastorePlacementMutex.RLock()
+	defer fake.datastorePlacementMutex.RUnlock()
+	fake.datastorePlacementStatePathMutex.RLock()
+	defer fake.datastorePlacementStatePathMutex.RUnlock()
+	fake.datastoresMutex.RLock()
+	defer fake.datastoresMutex.RUnlock()
+	fake.dhcpConfigPathMutex.RLock()
+	defer fake.dhcpConfigPathMutex.RUnlock()
 	fake.enableHumanReadableNameMutex.RLock()
 	defer fake.enableHumanReadableNameMutex.RUnlock()
 	fake.envIsoPathMutex.RLock()
 	defer fake.envIsoPathMutex.RUnlock()
 	fake.ephemeralDiskPathMutex.RLock()
 	defer fake.ephemeralDiskPathMutex.RUnlock()
+	fake.macAddressRegistryPathMutex.RLock()
+	defer fake.macAddressRegistryPathMutex.RUnlock()
+	fake.natConfigPathMutex.RLock()
+	defer fake.natConfigPathMutex.RUnlock()
+	fake.ovfImporterMutex.RLock()
+	defer fake.ovfImporterMutex.RUnlock()
 	fake.ovftoolPathMutex.RLock()
 	defer fake.ovftoolPathMutex.RUnlock()
+	fake.persistentDiskMappingPathMutex.RLock()
+	defer fake.persistentDiskMappingPathMutex.RUnlock()
 	fake.persistentDiskPathMutex.RLock()
 	defer fake.persistentDiskPathMutex.RUnlock()
+	fake.persistentDiskStorePathMutex.RLock()
+	defer fake.persistentDiskStorePathMutex.RUnlock()
+	fake.stemcellRegistryPathMutex.RLock()
+	defer fake.stemcellRegistryPathMutex.RUnlock()
+	fake.vmDatastorePathMutex.RLock()
+	defer fake.vmDatastorePathMutex.RUnlock()
+	fake.vmMappingPathMutex.RLock()
+	defer fake.vmMappingPathMutex.RUnlock()
 	fake.vmSoftShutdownMaxWaitMutex.RLock()
 	defer fake.vmSoftShutdownMaxWaitMutex.RUnlock()
 	fake.vmStartMaxWaitMutex.RLock()
 	defer fake.vmStartMaxWaitMutex.RUnlock()
+	fake.vmStorePathMutex.RLock()
+	defer fake.vmStorePathMutex.RUnlock()
+	fake.vmnetConfigPathsMutex.RLock()
+	defer fake.vmnetConfigPathsMutex.RUnlock()
 	fake.vmrunPathMutex.RLock()
 	defer fake.vmrunPathMutex.RUnlock()
 	fake.vmxPathMutex.RLock()
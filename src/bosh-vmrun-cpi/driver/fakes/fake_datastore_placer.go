@@ -0,0 +1,116 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-vmrun-cpi/driver"
+	"sync"
+)
+
+type FakeDatastorePlacer struct {
+	PlaceStub        func(string, int) (driver.Datastore, error)
+	placeMutex       sync.RWMutex
+	placeArgsForCall []struct {
+		arg1 string
+		arg2 int
+	}
+	placeReturns struct {
+		result1 driver.Datastore
+		result2 error
+	}
+	placeReturnsOnCall map[int]struct {
+		result1 driver.Datastore
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDatastorePlacer) Place(arg1 string, arg2 int) (driver.Datastore, error) {
+	fake.placeMutex.Lock()
+	ret, specificReturn := fake.placeReturnsOnCall[len(fake.placeArgsForCall)]
+	fake.placeArgsForCall = append(fake.placeArgsForCall, struct {
+		arg1 string
+		arg2 int
+	}{arg1, arg2})
+	fake.recordInvocation("Place", []interface{}{arg1, arg2})
+	fake.placeMutex.Unlock()
+	if fake.PlaceStub != nil {
+		return fake.PlaceStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.placeReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDatastorePlacer) PlaceCallCount() int {
+	fake.placeMutex.RLock()
+	defer fake.placeMutex.RUnlock()
+	return len(fake.placeArgsForCall)
+}
+
+func (fake *FakeDatastorePlacer) PlaceCalls(stub func(string, int) (driver.Datastore, error)) {
+	fake.placeMutex.Lock()
+	defer fake.placeMutex.Unlock()
+	fake.PlaceStub = stub
+}
+
+func (fake *FakeDatastorePlacer) PlaceArgsForCall(i int) (string, int) {
+	fake.placeMutex.RLock()
+	defer fake.placeMutex.RUnlock()
+	argsForCall := fake.placeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDatastorePlacer) PlaceReturns(result1 driver.Datastore, result2 error) {
+	fake.placeMutex.Lock()
+	defer fake.placeMutex.Unlock()
+	fake.PlaceStub = nil
+	fake.placeReturns = struct {
+		result1 driver.Datastore
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDatastorePlacer) PlaceReturnsOnCall(i int, result1 driver.Datastore, result2 error) {
+	fake.placeMutex.Lock()
+	defer fake.placeMutex.Unlock()
+	fake.PlaceStub = nil
+	if fake.placeReturnsOnCall == nil {
+		fake.placeReturnsOnCall = make(map[int]struct {
+			result1 driver.Datastore
+			result2 error
+		})
+	}
+	fake.placeReturnsOnCall[i] = struct {
+		result1 driver.Datastore
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDatastorePlacer) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.placeMutex.RLock()
+	defer fake.placeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeDatastorePlacer) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driver.DatastorePlacer = new(FakeDatastorePlacer)
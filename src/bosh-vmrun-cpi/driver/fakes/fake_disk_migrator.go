@@ -0,0 +1,269 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-vmrun-cpi/driver"
+	"sync"
+)
+
+type FakeDiskMigrator struct {
+	CopyStub        func(string, string) (string, error)
+	copyMutex       sync.RWMutex
+	copyArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	copyReturns struct {
+		result1 string
+		result2 error
+	}
+	copyReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	NeedsMigrationStub        func(string, string) (bool, error)
+	needsMigrationMutex       sync.RWMutex
+	needsMigrationArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	needsMigrationReturns struct {
+		result1 bool
+		result2 error
+	}
+	needsMigrationReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	RemoveStub        func(string) error
+	removeMutex       sync.RWMutex
+	removeArgsForCall []struct {
+		arg1 string
+	}
+	removeReturns struct {
+		result1 error
+	}
+	removeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDiskMigrator) Copy(arg1 string, arg2 string) (string, error) {
+	fake.copyMutex.Lock()
+	ret, specificReturn := fake.copyReturnsOnCall[len(fake.copyArgsForCall)]
+	fake.copyArgsForCall = append(fake.copyArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("Copy", []interface{}{arg1, arg2})
+	fake.copyMutex.Unlock()
+	if fake.CopyStub != nil {
+		return fake.CopyStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.copyReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDiskMigrator) CopyCallCount() int {
+	fake.copyMutex.RLock()
+	defer fake.copyMutex.RUnlock()
+	return len(fake.copyArgsForCall)
+}
+
+func (fake *FakeDiskMigrator) CopyCalls(stub func(string, string) (string, error)) {
+	fake.copyMutex.Lock()
+	defer fake.copyMutex.Unlock()
+	fake.CopyStub = stub
+}
+
+func (fake *FakeDiskMigrator) CopyArgsForCall(i int) (string, string) {
+	fake.copyMutex.RLock()
+	defer fake.copyMutex.RUnlock()
+	argsForCall := fake.copyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDiskMigrator) CopyReturns(result1 string, result2 error) {
+	fake.copyMutex.Lock()
+	defer fake.copyMutex.Unlock()
+	fake.CopyStub = nil
+	fake.copyReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskMigrator) CopyReturnsOnCall(i int, result1 string, result2 error) {
+	fake.copyMutex.Lock()
+	defer fake.copyMutex.Unlock()
+	fake.CopyStub = nil
+	if fake.copyReturnsOnCall == nil {
+		fake.copyReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.copyReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskMigrator) NeedsMigration(arg1 string, arg2 string) (bool, error) {
+	fake.needsMigrationMutex.Lock()
+	ret, specificReturn := fake.needsMigrationReturnsOnCall[len(fake.needsMigrationArgsForCall)]
+	fake.needsMigrationArgsForCall = append(fake.needsMigrationArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("NeedsMigration", []interface{}{arg1, arg2})
+	fake.needsMigrationMutex.Unlock()
+	if fake.NeedsMigrationStub != nil {
+		return fake.NeedsMigrationStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.needsMigrationReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDiskMigrator) NeedsMigrationCallCount() int {
+	fake.needsMigrationMutex.RLock()
+	defer fake.needsMigrationMutex.RUnlock()
+	return len(fake.needsMigrationArgsForCall)
+}
+
+func (fake *FakeDiskMigrator) NeedsMigrationCalls(stub func(string, string) (bool, error)) {
+	fake.needsMigrationMutex.Lock()
+	defer fake.needsMigrationMutex.Unlock()
+	fake.NeedsMigrationStub = stub
+}
+
+func (fake *FakeDiskMigrator) NeedsMigrationArgsForCall(i int) (string, string) {
+	fake.needsMigrationMutex.RLock()
+	defer fake.needsMigrationMutex.RUnlock()
+	argsForCall := fake.needsMigrationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDiskMigrator) NeedsMigrationReturns(result1 bool, result2 error) {
+	fake.needsMigrationMutex.Lock()
+	defer fake.needsMigrationMutex.Unlock()
+	fake.NeedsMigrationStub = nil
+	fake.needsMigrationReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskMigrator) NeedsMigrationReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.needsMigrationMutex.Lock()
+	defer fake.needsMigrationMutex.Unlock()
+	fake.NeedsMigrationStub = nil
+	if fake.needsMigrationReturnsOnCall == nil {
+		fake.needsMigrationReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.needsMigrationReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskMigrator) Remove(arg1 string) error {
+	fake.removeMutex.Lock()
+	ret, specificReturn := fake.removeReturnsOnCall[len(fake.removeArgsForCall)]
+	fake.removeArgsForCall = append(fake.removeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Remove", []interface{}{arg1})
+	fake.removeMutex.Unlock()
+	if fake.RemoveStub != nil {
+		return fake.RemoveStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.removeReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDiskMigrator) RemoveCallCount() int {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	return len(fake.removeArgsForCall)
+}
+
+func (fake *FakeDiskMigrator) RemoveCalls(stub func(string) error) {
+	fake.removeMutex.Lock()
+	defer fake.removeMutex.Unlock()
+	fake.RemoveStub = stub
+}
+
+func (fake *FakeDiskMigrator) RemoveArgsForCall(i int) string {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	argsForCall := fake.removeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDiskMigrator) RemoveReturns(result1 error) {
+	fake.removeMutex.Lock()
+	defer fake.removeMutex.Unlock()
+	fake.RemoveStub = nil
+	fake.removeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDiskMigrator) RemoveReturnsOnCall(i int, result1 error) {
+	fake.removeMutex.Lock()
+	defer fake.removeMutex.Unlock()
+	fake.RemoveStub = nil
+	if fake.removeReturnsOnCall == nil {
+		fake.removeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDiskMigrator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.copyMutex.RLock()
+	defer fake.copyMutex.RUnlock()
+	fake.needsMigrationMutex.RLock()
+	defer fake.needsMigrationMutex.RUnlock()
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeDiskMigrator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driver.DiskMigrator = new(FakeDiskMigrator)
@@ -0,0 +1,187 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-vmrun-cpi/driver"
+	"sync"
+)
+
+type FakeMacAllocator struct {
+	AllocateStub        func(string) (string, error)
+	allocateMutex       sync.RWMutex
+	allocateArgsForCall []struct {
+		arg1 string
+	}
+	allocateReturns struct {
+		result1 string
+		result2 error
+	}
+	allocateReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	ReleaseStub        func(string) error
+	releaseMutex       sync.RWMutex
+	releaseArgsForCall []struct {
+		arg1 string
+	}
+	releaseReturns struct {
+		result1 error
+	}
+	releaseReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMacAllocator) Allocate(arg1 string) (string, error) {
+	fake.allocateMutex.Lock()
+	ret, specificReturn := fake.allocateReturnsOnCall[len(fake.allocateArgsForCall)]
+	fake.allocateArgsForCall = append(fake.allocateArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Allocate", []interface{}{arg1})
+	fake.allocateMutex.Unlock()
+	if fake.AllocateStub != nil {
+		return fake.AllocateStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.allocateReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMacAllocator) AllocateCallCount() int {
+	fake.allocateMutex.RLock()
+	defer fake.allocateMutex.RUnlock()
+	return len(fake.allocateArgsForCall)
+}
+
+func (fake *FakeMacAllocator) AllocateCalls(stub func(string) (string, error)) {
+	fake.allocateMutex.Lock()
+	defer fake.allocateMutex.Unlock()
+	fake.AllocateStub = stub
+}
+
+func (fake *FakeMacAllocator) AllocateArgsForCall(i int) string {
+	fake.allocateMutex.RLock()
+	defer fake.allocateMutex.RUnlock()
+	argsForCall := fake.allocateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMacAllocator) AllocateReturns(result1 string, result2 error) {
+	fake.allocateMutex.Lock()
+	defer fake.allocateMutex.Unlock()
+	fake.AllocateStub = nil
+	fake.allocateReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMacAllocator) AllocateReturnsOnCall(i int, result1 string, result2 error) {
+	fake.allocateMutex.Lock()
+	defer fake.allocateMutex.Unlock()
+	fake.AllocateStub = nil
+	if fake.allocateReturnsOnCall == nil {
+		fake.allocateReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.allocateReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMacAllocator) Release(arg1 string) error {
+	fake.releaseMutex.Lock()
+	ret, specificReturn := fake.releaseReturnsOnCall[len(fake.releaseArgsForCall)]
+	fake.releaseArgsForCall = append(fake.releaseArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Release", []interface{}{arg1})
+	fake.releaseMutex.Unlock()
+	if fake.ReleaseStub != nil {
+		return fake.ReleaseStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.releaseReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeMacAllocator) ReleaseCallCount() int {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return len(fake.releaseArgsForCall)
+}
+
+func (fake *FakeMacAllocator) ReleaseCalls(stub func(string) error) {
+	fake.releaseMutex.Lock()
+	defer fake.releaseMutex.Unlock()
+	fake.ReleaseStub = stub
+}
+
+func (fake *FakeMacAllocator) ReleaseArgsForCall(i int) string {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	argsForCall := fake.releaseArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMacAllocator) ReleaseReturns(result1 error) {
+	fake.releaseMutex.Lock()
+	defer fake.releaseMutex.Unlock()
+	fake.ReleaseStub = nil
+	fake.releaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMacAllocator) ReleaseReturnsOnCall(i int, result1 error) {
+	fake.releaseMutex.Lock()
+	defer fake.releaseMutex.Unlock()
+	fake.ReleaseStub = nil
+	if fake.releaseReturnsOnCall == nil {
+		fake.releaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.releaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMacAllocator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.allocateMutex.RLock()
+	defer fake.allocateMutex.RUnlock()
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMacAllocator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driver.MacAllocator = new(FakeMacAllocator)
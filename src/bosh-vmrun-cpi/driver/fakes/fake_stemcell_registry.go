@@ -0,0 +1,411 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-vmrun-cpi/driver"
+	"sync"
+)
+
+type FakeStemcellRegistry struct {
+	AcquireStub        func(string) (string, error)
+	acquireMutex       sync.RWMutex
+	acquireArgsForCall []struct {
+		arg1 string
+	}
+	acquireReturns struct {
+		result1 string
+		result2 error
+	}
+	acquireReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	KeysStub        func() ([]string, error)
+	keysMutex       sync.RWMutex
+	keysArgsForCall []struct {
+	}
+	keysReturns struct {
+		result1 []string
+		result2 error
+	}
+	keysReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	LockStub        func(string, func() error) error
+	lockMutex       sync.RWMutex
+	lockArgsForCall []struct {
+		arg1 string
+		arg2 func() error
+	}
+	lockReturns struct {
+		result1 error
+	}
+	lockReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RegisterStub        func(string, string) error
+	registerMutex       sync.RWMutex
+	registerArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	registerReturns struct {
+		result1 error
+	}
+	registerReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ReleaseStub        func(string) (bool, error)
+	releaseMutex       sync.RWMutex
+	releaseArgsForCall []struct {
+		arg1 string
+	}
+	releaseReturns struct {
+		result1 bool
+		result2 error
+	}
+	releaseReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeStemcellRegistry) Acquire(arg1 string) (string, error) {
+	fake.acquireMutex.Lock()
+	ret, specificReturn := fake.acquireReturnsOnCall[len(fake.acquireArgsForCall)]
+	fake.acquireArgsForCall = append(fake.acquireArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Acquire", []interface{}{arg1})
+	fake.acquireMutex.Unlock()
+	if fake.AcquireStub != nil {
+		return fake.AcquireStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.acquireReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStemcellRegistry) AcquireCallCount() int {
+	fake.acquireMutex.RLock()
+	defer fake.acquireMutex.RUnlock()
+	return len(fake.acquireArgsForCall)
+}
+
+func (fake *FakeStemcellRegistry) AcquireCalls(stub func(string) (string, error)) {
+	fake.acquireMutex.Lock()
+	defer fake.acquireMutex.Unlock()
+	fake.AcquireStub = stub
+}
+
+func (fake *FakeStemcellRegistry) AcquireArgsForCall(i int) string {
+	fake.acquireMutex.RLock()
+	defer fake.acquireMutex.RUnlock()
+	argsForCall := fake.acquireArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStemcellRegistry) AcquireReturns(result1 string, result2 error) {
+	fake.acquireMutex.Lock()
+	defer fake.acquireMutex.Unlock()
+	fake.AcquireStub = nil
+	fake.acquireReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStemcellRegistry) AcquireReturnsOnCall(i int, result1 string, result2 error) {
+	fake.acquireMutex.Lock()
+	defer fake.acquireMutex.Unlock()
+	fake.AcquireStub = nil
+	if fake.acquireReturnsOnCall == nil {
+		fake.acquireReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.acquireReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStemcellRegistry) Keys() ([]string, error) {
+	fake.keysMutex.Lock()
+	ret, specificReturn := fake.keysReturnsOnCall[len(fake.keysArgsForCall)]
+	fake.keysArgsForCall = append(fake.keysArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Keys", []interface{}{})
+	fake.keysMutex.Unlock()
+	if fake.KeysStub != nil {
+		return fake.KeysStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.keysReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStemcellRegistry) KeysCallCount() int {
+	fake.keysMutex.RLock()
+	defer fake.keysMutex.RUnlock()
+	return len(fake.keysArgsForCall)
+}
+
+func (fake *FakeStemcellRegistry) KeysCalls(stub func() ([]string, error)) {
+	fake.keysMutex.Lock()
+	defer fake.keysMutex.Unlock()
+	fake.KeysStub = stub
+}
+
+func (fake *FakeStemcellRegistry) KeysReturns(result1 []string, result2 error) {
+	fake.keysMutex.Lock()
+	defer fake.keysMutex.Unlock()
+	fake.KeysStub = nil
+	fake.keysReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStemcellRegistry) KeysReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.keysMutex.Lock()
+	defer fake.keysMutex.Unlock()
+	fake.KeysStub = nil
+	if fake.keysReturnsOnCall == nil {
+		fake.keysReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.keysReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStemcellRegistry) Lock(arg1 string, arg2 func() error) error {
+	fake.lockMutex.Lock()
+	ret, specificReturn := fake.lockReturnsOnCall[len(fake.lockArgsForCall)]
+	fake.lockArgsForCall = append(fake.lockArgsForCall, struct {
+		arg1 string
+		arg2 func() error
+	}{arg1, arg2})
+	fake.recordInvocation("Lock", []interface{}{arg1, arg2})
+	fake.lockMutex.Unlock()
+	if fake.LockStub != nil {
+		return fake.LockStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.lockReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeStemcellRegistry) LockCallCount() int {
+	fake.lockMutex.RLock()
+	defer fake.lockMutex.RUnlock()
+	return len(fake.lockArgsForCall)
+}
+
+func (fake *FakeStemcellRegistry) LockCalls(stub func(string, func() error) error) {
+	fake.lockMutex.Lock()
+	defer fake.lockMutex.Unlock()
+	fake.LockStub = stub
+}
+
+func (fake *FakeStemcellRegistry) LockArgsForCall(i int) (string, func() error) {
+	fake.lockMutex.RLock()
+	defer fake.lockMutex.RUnlock()
+	argsForCall := fake.lockArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeStemcellRegistry) LockReturns(result1 error) {
+	fake.lockMutex.Lock()
+	defer fake.lockMutex.Unlock()
+	fake.LockStub = nil
+	fake.lockReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStemcellRegistry) LockReturnsOnCall(i int, result1 error) {
+	fake.lockMutex.Lock()
+	defer fake.lockMutex.Unlock()
+	fake.LockStub = nil
+	if fake.lockReturnsOnCall == nil {
+		fake.lockReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.lockReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStemcellRegistry) Register(arg1 string, arg2 string) error {
+	fake.registerMutex.Lock()
+	ret, specificReturn := fake.registerReturnsOnCall[len(fake.registerArgsForCall)]
+	fake.registerArgsForCall = append(fake.registerArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("Register", []interface{}{arg1, arg2})
+	fake.registerMutex.Unlock()
+	if fake.RegisterStub != nil {
+		return fake.RegisterStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.registerReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeStemcellRegistry) RegisterCallCount() int {
+	fake.registerMutex.RLock()
+	defer fake.registerMutex.RUnlock()
+	return len(fake.registerArgsForCall)
+}
+
+func (fake *FakeStemcellRegistry) RegisterCalls(stub func(string, string) error) {
+	fake.registerMutex.Lock()
+	defer fake.registerMutex.Unlock()
+	fake.RegisterStub = stub
+}
+
+func (fake *FakeStemcellRegistry) RegisterArgsForCall(i int) (string, string) {
+	fake.registerMutex.RLock()
+	defer fake.registerMutex.RUnlock()
+	argsForCall := fake.registerArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeStemcellRegistry) RegisterReturns(result1 error) {
+	fake.registerMutex.Lock()
+	defer fake.registerMutex.Unlock()
+	fake.RegisterStub = nil
+	fake.registerReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStemcellRegistry) RegisterReturnsOnCall(i int, result1 error) {
+	fake.registerMutex.Lock()
+	defer fake.registerMutex.Unlock()
+	fake.RegisterStub = nil
+	if fake.registerReturnsOnCall == nil {
+		fake.registerReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.registerReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStemcellRegistry) Release(arg1 string) (bool, error) {
+	fake.releaseMutex.Lock()
+	ret, specificReturn := fake.releaseReturnsOnCall[len(fake.releaseArgsForCall)]
+	fake.releaseArgsForCall = append(fake.releaseArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Release", []interface{}{arg1})
+	fake.releaseMutex.Unlock()
+	if fake.ReleaseStub != nil {
+		return fake.ReleaseStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.releaseReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStemcellRegistry) ReleaseCallCount() int {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return len(fake.releaseArgsForCall)
+}
+
+func (fake *FakeStemcellRegistry) ReleaseCalls(stub func(string) (bool, error)) {
+	fake.releaseMutex.Lock()
+	defer fake.releaseMutex.Unlock()
+	fake.ReleaseStub = stub
+}
+
+func (fake *FakeStemcellRegistry) ReleaseArgsForCall(i int) string {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	argsForCall := fake.releaseArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStemcellRegistry) ReleaseReturns(result1 bool, result2 error) {
+	fake.releaseMutex.Lock()
+	defer fake.releaseMutex.Unlock()
+	fake.ReleaseStub = nil
+	fake.releaseReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStemcellRegistry) ReleaseReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.releaseMutex.Lock()
+	defer fake.releaseMutex.Unlock()
+	fake.ReleaseStub = nil
+	if fake.releaseReturnsOnCall == nil {
+		fake.releaseReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.releaseReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStemcellRegistry) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.acquireMutex.RLock()
+	defer fake.acquireMutex.RUnlock()
+	fake.keysMutex.RLock()
+	defer fake.keysMutex.RUnlock()
+	fake.lockMutex.RLock()
+	defer fake.lockMutex.RUnlock()
+	fake.registerMutex.RLock()
+	defer fake.registerMutex.RUnlock()
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeStemcellRegistry) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driver.StemcellRegistry = new(FakeStemcellRegistry)
@@ -30,6 +30,18 @@ type FakeVmrunRunner struct {
 	configureReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ConnectNamedDeviceStub        func(string, string) error
+	connectNamedDeviceMutex       sync.RWMutex
+	connectNamedDeviceArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	connectNamedDeviceReturns struct {
+		result1 error
+	}
+	connectNamedDeviceReturnsOnCall map[int]struct {
+		result1 error
+	}
 	CopyFileFromHostToGuestStub        func(string, string, string, string, string) error
 	copyFileFromHostToGuestMutex       sync.RWMutex
 	copyFileFromHostToGuestArgsForCall []struct {
@@ -56,6 +68,31 @@ type FakeVmrunRunner struct {
 	deleteReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DisconnectNamedDeviceStub        func(string, string) error
+	disconnectNamedDeviceMutex       sync.RWMutex
+	disconnectNamedDeviceArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	disconnectNamedDeviceReturns struct {
+		result1 error
+	}
+	disconnectNamedDeviceReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetGuestIPAddressStub        func(string) (string, error)
+	getGuestIPAddressMutex       sync.RWMutex
+	getGuestIPAddressArgsForCall []struct {
+		arg1 string
+	}
+	getGuestIPAddressReturns struct {
+		result1 string
+		result2 error
+	}
+	getGuestIPAddressReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	HardStopStub        func(string) error
 	hardStopMutex       sync.RWMutex
 	hardStopArgsForCall []struct {
@@ -104,6 +141,21 @@ type FakeVmrunRunner struct {
 		result1 string
 		result2 error
 	}
+	ReadVariableStub        func(string, string, string) (string, error)
+	readVariableMutex       sync.RWMutex
+	readVariableArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	readVariableReturns struct {
+		result1 string
+		result2 error
+	}
+	readVariableReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	RunProgramInGuestStub        func(string, string, string, string, string) error
 	runProgramInGuestMutex       sync.RWMutex
 	runProgramInGuestArgsForCall []struct {
@@ -141,6 +193,20 @@ type FakeVmrunRunner struct {
 	startReturnsOnCall map[int]struct {
 		result1 error
 	}
+	WriteVariableStub        func(string, string, string, string) error
+	writeVariableMutex       sync.RWMutex
+	writeVariableArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+	}
+	writeVariableReturns struct {
+		result1 error
+	}
+	writeVariableReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -259,6 +325,67 @@ func (fake *FakeVmrunRunner) ConfigureReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeVmrunRunner) ConnectNamedDevice(arg1 string, arg2 string) error {
+	fake.connectNamedDeviceMutex.Lock()
+	ret, specificReturn := fake.connectNamedDeviceReturnsOnCall[len(fake.connectNamedDeviceArgsForCall)]
+	fake.connectNamedDeviceArgsForCall = append(fake.connectNamedDeviceArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("ConnectNamedDevice", []interface{}{arg1, arg2})
+	fake.connectNamedDeviceMutex.Unlock()
+	if fake.ConnectNamedDeviceStub != nil {
+		return fake.ConnectNamedDeviceStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.connectNamedDeviceReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeVmrunRunner) ConnectNamedDeviceCallCount() int {
+	fake.connectNamedDeviceMutex.RLock()
+	defer fake.connectNamedDeviceMutex.RUnlock()
+	return len(fake.connectNamedDeviceArgsForCall)
+}
+
+func (fake *FakeVmrunRunner) ConnectNamedDeviceCalls(stub func(string, string) error) {
+	fake.connectNamedDeviceMutex.Lock()
+	defer fake.connectNamedDeviceMutex.Unlock()
+	fake.ConnectNamedDeviceStub = stub
+}
+
+func (fake *FakeVmrunRunner) ConnectNamedDeviceArgsForCall(i int) (string, string) {
+	fake.connectNamedDeviceMutex.RLock()
+	defer fake.connectNamedDeviceMutex.RUnlock()
+	argsForCall := fake.connectNamedDeviceArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeVmrunRunner) ConnectNamedDeviceReturns(result1 error) {
+	fake.connectNamedDeviceMutex.Lock()
+	defer fake.connectNamedDeviceMutex.Unlock()
+	fake.ConnectNamedDeviceStub = nil
+	fake.connectNamedDeviceReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmrunRunner) ConnectNamedDeviceReturnsOnCall(i int, result1 error) {
+	fake.connectNamedDeviceMutex.Lock()
+	defer fake.connectNamedDeviceMutex.Unlock()
+	fake.ConnectNamedDeviceStub = nil
+	if fake.connectNamedDeviceReturnsOnCall == nil {
+		fake.connectNamedDeviceReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.connectNamedDeviceReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeVmrunRunner) CopyFileFromHostToGuest(arg1 string, arg2 string, arg3 string, arg4 string, arg5 string) error {
 	fake.copyFileFromHostToGuestMutex.Lock()
 	ret, specificReturn := fake.copyFileFromHostToGuestReturnsOnCall[len(fake.copyFileFromHostToGuestArgsForCall)]
@@ -383,6 +510,130 @@ func (fake *FakeVmrunRunner) DeleteReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeVmrunRunner) DisconnectNamedDevice(arg1 string, arg2 string) error {
+	fake.disconnectNamedDeviceMutex.Lock()
+	ret, specificReturn := fake.disconnectNamedDeviceReturnsOnCall[len(fake.disconnectNamedDeviceArgsForCall)]
+	fake.disconnectNamedDeviceArgsForCall = append(fake.disconnectNamedDeviceArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("DisconnectNamedDevice", []interface{}{arg1, arg2})
+	fake.disconnectNamedDeviceMutex.Unlock()
+	if fake.DisconnectNamedDeviceStub != nil {
+		return fake.DisconnectNamedDeviceStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.disconnectNamedDeviceReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeVmrunRunner) DisconnectNamedDeviceCallCount() int {
+	fake.disconnectNamedDeviceMutex.RLock()
+	defer fake.disconnectNamedDeviceMutex.RUnlock()
+	return len(fake.disconnectNamedDeviceArgsForCall)
+}
+
+func (fake *FakeVmrunRunner) DisconnectNamedDeviceCalls(stub func(string, string) error) {
+	fake.disconnectNamedDeviceMutex.Lock()
+	defer fake.disconnectNamedDeviceMutex.Unlock()
+	fake.DisconnectNamedDeviceStub = stub
+}
+
+func (fake *FakeVmrunRunner) DisconnectNamedDeviceArgsForCall(i int) (string, string) {
+	fake.disconnectNamedDeviceMutex.RLock()
+	defer fake.disconnectNamedDeviceMutex.RUnlock()
+	argsForCall := fake.disconnectNamedDeviceArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeVmrunRunner) DisconnectNamedDeviceReturns(result1 error) {
+	fake.disconnectNamedDeviceMutex.Lock()
+	defer fake.disconnectNamedDeviceMutex.Unlock()
+	fake.DisconnectNamedDeviceStub = nil
+	fake.disconnectNamedDeviceReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmrunRunner) DisconnectNamedDeviceReturnsOnCall(i int, result1 error) {
+	fake.disconnectNamedDeviceMutex.Lock()
+	defer fake.disconnectNamedDeviceMutex.Unlock()
+	fake.DisconnectNamedDeviceStub = nil
+	if fake.disconnectNamedDeviceReturnsOnCall == nil {
+		fake.disconnectNamedDeviceReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.disconnectNamedDeviceReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmrunRunner) GetGuestIPAddress(arg1 string) (string, error) {
+	fake.getGuestIPAddressMutex.Lock()
+	ret, specificReturn := fake.getGuestIPAddressReturnsOnCall[len(fake.getGuestIPAddressArgsForCall)]
+	fake.getGuestIPAddressArgsForCall = append(fake.getGuestIPAddressArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetGuestIPAddress", []interface{}{arg1})
+	fake.getGuestIPAddressMutex.Unlock()
+	if fake.GetGuestIPAddressStub != nil {
+		return fake.GetGuestIPAddressStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getGuestIPAddressReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVmrunRunner) GetGuestIPAddressCallCount() int {
+	fake.getGuestIPAddressMutex.RLock()
+	defer fake.getGuestIPAddressMutex.RUnlock()
+	return len(fake.getGuestIPAddressArgsForCall)
+}
+
+func (fake *FakeVmrunRunner) GetGuestIPAddressCalls(stub func(string) (string, error)) {
+	fake.getGuestIPAddressMutex.Lock()
+	defer fake.getGuestIPAddressMutex.Unlock()
+	fake.GetGuestIPAddressStub = stub
+}
+
+func (fake *FakeVmrunRunner) GetGuestIPAddressArgsForCall(i int) string {
+	fake.getGuestIPAddressMutex.RLock()
+	defer fake.getGuestIPAddressMutex.RUnlock()
+	argsForCall := fake.getGuestIPAddressArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeVmrunRunner) GetGuestIPAddressReturns(result1 string, result2 error) {
+	fake.getGuestIPAddressMutex.Lock()
+	defer fake.getGuestIPAddressMutex.Unlock()
+	fake.GetGuestIPAddressStub = nil
+	fake.getGuestIPAddressReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVmrunRunner) GetGuestIPAddressReturnsOnCall(i int, result1 string, result2 error) {
+	fake.getGuestIPAddressMutex.Lock()
+	defer fake.getGuestIPAddressMutex.Unlock()
+	fake.GetGuestIPAddressStub = nil
+	if fake.getGuestIPAddressReturnsOnCall == nil {
+		fake.getGuestIPAddressReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.getGuestIPAddressReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeVmrunRunner) HardStop(arg1 string) error {
 	fake.hardStopMutex.Lock()
 	ret, specificReturn := fake.hardStopReturnsOnCall[len(fake.hardStopArgsForCall)]
@@ -615,6 +866,71 @@ func (fake *FakeVmrunRunner) ListProcessesInGuestReturnsOnCall(i int, result1 st
 	}{result1, result2}
 }
 
+func (fake *FakeVmrunRunner) ReadVariable(arg1 string, arg2 string, arg3 string) (string, error) {
+	fake.readVariableMutex.Lock()
+	ret, specificReturn := fake.readVariableReturnsOnCall[len(fake.readVariableArgsForCall)]
+	fake.readVariableArgsForCall = append(fake.readVariableArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("ReadVariable", []interface{}{arg1, arg2, arg3})
+	fake.readVariableMutex.Unlock()
+	if fake.ReadVariableStub != nil {
+		return fake.ReadVariableStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.readVariableReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVmrunRunner) ReadVariableCallCount() int {
+	fake.readVariableMutex.RLock()
+	defer fake.readVariableMutex.RUnlock()
+	return len(fake.readVariableArgsForCall)
+}
+
+func (fake *FakeVmrunRunner) ReadVariableCalls(stub func(string, string, string) (string, error)) {
+	fake.readVariableMutex.Lock()
+	defer fake.readVariableMutex.Unlock()
+	fake.ReadVariableStub = stub
+}
+
+func (fake *FakeVmrunRunner) ReadVariableArgsForCall(i int) (string, string, string) {
+	fake.readVariableMutex.RLock()
+	defer fake.readVariableMutex.RUnlock()
+	argsForCall := fake.readVariableArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeVmrunRunner) ReadVariableReturns(result1 string, result2 error) {
+	fake.readVariableMutex.Lock()
+	defer fake.readVariableMutex.Unlock()
+	fake.ReadVariableStub = nil
+	fake.readVariableReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVmrunRunner) ReadVariableReturnsOnCall(i int, result1 string, result2 error) {
+	fake.readVariableMutex.Lock()
+	defer fake.readVariableMutex.Unlock()
+	fake.ReadVariableStub = nil
+	if fake.readVariableReturnsOnCall == nil {
+		fake.readVariableReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.readVariableReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeVmrunRunner) RunProgramInGuest(arg1 string, arg2 string, arg3 string, arg4 string, arg5 string) error {
 	fake.runProgramInGuestMutex.Lock()
 	ret, specificReturn := fake.runProgramInGuestReturnsOnCall[len(fake.runProgramInGuestArgsForCall)]
@@ -799,6 +1115,69 @@ func (fake *FakeVmrunRunner) StartReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeVmrunRunner) WriteVariable(arg1 string, arg2 string, arg3 string, arg4 string) error {
+	fake.writeVariableMutex.Lock()
+	ret, specificReturn := fake.writeVariableReturnsOnCall[len(fake.writeVariableArgsForCall)]
+	fake.writeVariableArgsForCall = append(fake.writeVariableArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("WriteVariable", []interface{}{arg1, arg2, arg3, arg4})
+	fake.writeVariableMutex.Unlock()
+	if fake.WriteVariableStub != nil {
+		return fake.WriteVariableStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.writeVariableReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeVmrunRunner) WriteVariableCallCount() int {
+	fake.writeVariableMutex.RLock()
+	defer fake.writeVariableMutex.RUnlock()
+	return len(fake.writeVariableArgsForCall)
+}
+
+func (fake *FakeVmrunRunner) WriteVariableCalls(stub func(string, string, string, string) error) {
+	fake.writeVariableMutex.Lock()
+	defer fake.writeVariableMutex.Unlock()
+	fake.WriteVariableStub = stub
+}
+
+func (fake *FakeVmrunRunner) WriteVariableArgsForCall(i int) (string, string, string, string) {
+	fake.writeVariableMutex.RLock()
+	defer fake.writeVariableMutex.RUnlock()
+	argsForCall := fake.writeVariableArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeVmrunRunner) WriteVariableReturns(result1 error) {
+	fake.writeVariableMutex.Lock()
+	defer fake.writeVariableMutex.Unlock()
+	fake.WriteVariableStub = nil
+	fake.writeVariableReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmrunRunner) WriteVariableReturnsOnCall(i int, result1 error) {
+	fake.writeVariableMutex.Lock()
+	defer fake.writeVariableMutex.Unlock()
+	fake.WriteVariableStub = nil
+	if fake.writeVariableReturnsOnCall == nil {
+		fake.writeVariableReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.writeVariableReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeVmrunRunner) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -806,10 +1185,16 @@ func (fake *FakeVmrunRunner) Invocations() map[string][][]interface{} {
 	defer fake.cloneMutex.RUnlock()
 	fake.configureMutex.RLock()
 	defer fake.configureMutex.RUnlock()
+	fake.connectNamedDeviceMutex.RLock()
+	defer fake.connectNamedDeviceMutex.RUnlock()
 	fake.copyFileFromHostToGuestMutex.RLock()
 	defer fake.copyFileFromHostToGuestMutex.RUnlock()
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
+	fake.disconnectNamedDeviceMutex.RLock()
+	defer fake.disconnectNamedDeviceMutex.RUnlock()
+	fake.getGuestIPAddressMutex.RLock()
+	defer fake.getGuestIPAddressMutex.RUnlock()
 	fake.hardStopMutex.RLock()
 	defer fake.hardStopMutex.RUnlock()
 	fake.isPlayerMutex.RLock()
@@ -818,12 +1203,16 @@ func (fake *FakeVmrunRunner) Invocations() map[string][][]interface{} {
 	defer fake.listMutex.RUnlock()
 	fake.listProcessesInGuestMutex.RLock()
 	defer fake.listProcessesInGuestMutex.RUnlock()
+	fake.readVariableMutex.RLock()
+	defer fake.readVariableMutex.RUnlock()
 	fake.runProgramInGuestMutex.RLock()
 	defer fake.runProgramInGuestMutex.RUnlock()
 	fake.softStopMutex.RLock()
 	defer fake.softStopMutex.RUnlock()
 	fake.startMutex.RLock()
 	defer fake.startMutex.RUnlock()
+	fake.writeVariableMutex.RLock()
+	defer fake.writeVariableMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
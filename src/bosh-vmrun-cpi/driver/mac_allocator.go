@@ -0,0 +1,187 @@
+package driver
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type macAllocatorImpl struct {
+	config        Config
+	retryFileLock RetryFileLock
+	logger        boshlog.Logger
+}
+
+const (
+	// MAC OUI range when manually set: https://pubs.vmware.com/vsphere-4-esx-vcenter/index.jsp?topic=/com.vmware.vsphere.server_configclassic.doc_41/esx_server_config/advanced_networking/c_setting_up_mac_addresses.html
+	// 00:50:56:00:00:00 to 00:50:56:3f:ff:ff
+	macAddressPrefix    = "00:50:56"
+	macAddressRangeSize = 0x400000
+
+	macRegistryLockMaxWait = 30 * time.Second
+	macRandomAttempts      = 64
+)
+
+var vmxEthernetAddressRegexp = regexp.MustCompile(`(?mi)^\s*ethernet\d+\.(?:address|generatedAddress)\s*=\s*"([0-9a-f:]+)"`)
+
+func NewMacAllocator(config Config, retryFileLock RetryFileLock, logger boshlog.Logger) MacAllocator {
+	return &macAllocatorImpl{config: config, retryFileLock: retryFileLock, logger: logger}
+}
+
+func (a macAllocatorImpl) Allocate(vmName string) (string, error) {
+	var macAddress string
+	registryPath := a.config.MacAddressRegistryPath()
+
+	err := a.retryFileLock.Try(registryPath+".lock", macRegistryLockMaxWait, func() error {
+		registry, err := a.readRegistry(registryPath)
+		if err != nil {
+			return err
+		}
+
+		usedAddresses, err := a.vmxAddresses()
+		if err != nil {
+			return err
+		}
+		for address := range registry {
+			usedAddresses[address] = true
+		}
+
+		macAddress, err = a.findFreeAddress(usedAddresses)
+		if err != nil {
+			return err
+		}
+
+		registry[macAddress] = vmName
+
+		return a.writeRegistry(registryPath, registry)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	a.logger.Debug("mac-allocator", "allocated %s to %s", macAddress, vmName)
+
+	return macAddress, nil
+}
+
+func (a macAllocatorImpl) Release(vmName string) error {
+	registryPath := a.config.MacAddressRegistryPath()
+
+	return a.retryFileLock.Try(registryPath+".lock", macRegistryLockMaxWait, func() error {
+		registry, err := a.readRegistry(registryPath)
+		if err != nil {
+			return err
+		}
+
+		for address, allocatedVmName := range registry {
+			if allocatedVmName == vmName {
+				a.logger.Debug("mac-allocator", "released %s from %s", address, vmName)
+				delete(registry, address)
+			}
+		}
+
+		return a.writeRegistry(registryPath, registry)
+	})
+}
+
+// picks random addresses first, then falls back to scanning the whole range
+func (a macAllocatorImpl) findFreeAddress(usedAddresses map[string]bool) (string, error) {
+	buf := make([]byte, 3)
+
+	for i := 0; i < macRandomAttempts; i++ {
+		_, err := rand.Read(buf)
+		if err != nil {
+			return "", err
+		}
+
+		address := macAddressFromIndex((int(buf[0])<<16 | int(buf[1])<<8 | int(buf[2])) % macAddressRangeSize)
+		if !usedAddresses[address] {
+			return address, nil
+		}
+	}
+
+	for index := 0; index < macAddressRangeSize; index++ {
+		address := macAddressFromIndex(index)
+		if !usedAddresses[address] {
+			return address, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free mac addresses in %s:00:00:00-%s:3f:ff:ff", macAddressPrefix, macAddressPrefix)
+}
+
+// collects addresses of every vm in the vm store and configured datastores
+func (a macAllocatorImpl) vmxAddresses() (map[string]bool, error) {
+	addresses := map[string]bool{}
+
+	storePaths := []string{a.config.VmStorePath()}
+	for _, datastore := range a.config.Datastores() {
+		storePaths = append(storePaths, datastore.Path)
+	}
+
+	for _, storePath := range storePaths {
+		vmxPaths, err := filepath.Glob(filepath.Join(storePath, "*", "*.vmx"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, vmxPath := range vmxPaths {
+			vmxBytes, err := ioutil.ReadFile(vmxPath)
+			if err != nil {
+				a.logger.Debug("mac-allocator", "skipping unreadable vmx %s: %s", vmxPath, err)
+				continue
+			}
+
+			for _, match := range vmxEthernetAddressRegexp.FindAllStringSubmatch(string(vmxBytes), -1) {
+				addresses[strings.ToLower(match[1])] = true
+			}
+		}
+	}
+
+	return addresses, nil
+}
+
+func (a macAllocatorImpl) readRegistry(registryPath string) (map[string]string, error) {
+	registry := map[string]string{}
+
+	registryBytes, err := ioutil.ReadFile(registryPath)
+	if os.IsNotExist(err) {
+		return registry, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(registryBytes, &registry)
+	if err != nil {
+		return nil, fmt.Errorf("reading mac address registry %s: %s", registryPath, err)
+	}
+
+	return registry, nil
+}
+
+func (a macAllocatorImpl) writeRegistry(registryPath string, registry map[string]string) error {
+	registryBytes, err := json.Marshal(registry)
+	if err != nil {
+		return err
+	}
+
+	tmpRegistryPath := registryPath + ".tmp"
+	err = ioutil.WriteFile(tmpRegistryPath, registryBytes, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpRegistryPath, registryPath)
+}
+
+func macAddressFromIndex(index int) string {
+	return fmt.Sprintf("%s:%02x:%02x:%02x", macAddressPrefix, (index>>16)&0x3f, (index>>8)&0xff, index&0xff)
+}
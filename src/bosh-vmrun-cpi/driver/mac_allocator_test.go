@@ -0,0 +1,127 @@
+package driver_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/driver/fakes"
+)
+
+var _ = Describe("MacAllocator", func() {
+	var (
+		storeDir      string
+		datastoreDir  string
+		registryPath  string
+		config        *fakes.FakeConfig
+		retryFileLock *fakes.FakeRetryFileLock
+		macAllocator  driver.MacAllocator
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		storeDir, err = ioutil.TempDir("", "vm-store-")
+		Expect(err).ToNot(HaveOccurred())
+
+		datastoreDir, err = ioutil.TempDir("", "datastore-")
+		Expect(err).ToNot(HaveOccurred())
+
+		registryPath = filepath.Join(storeDir, "mac-addresses.json")
+
+		config = &fakes.FakeConfig{}
+		config.VmStorePathReturns(storeDir)
+		config.DatastoresReturns([]driver.Datastore{{Name: "ssd", Path: datastoreDir}})
+		config.MacAddressRegistryPathReturns(registryPath)
+
+		retryFileLock = &fakes.FakeRetryFileLock{}
+		retryFileLock.TryStub = func(lockPath string, maxWait time.Duration, fn func() error) error {
+			return fn()
+		}
+
+		macAllocator = driver.NewMacAllocator(config, retryFileLock, &fakelogger.FakeLogger{})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(storeDir)
+		os.RemoveAll(datastoreDir)
+	})
+
+	readRegistry := func() map[string]string {
+		registry := map[string]string{}
+
+		registryBytes, err := ioutil.ReadFile(registryPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(json.Unmarshal(registryBytes, &registry)).To(Succeed())
+
+		return registry
+	}
+
+	writeVmx := func(storePath, vmName, content string) {
+		Expect(os.MkdirAll(filepath.Join(storePath, vmName), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(storePath, vmName, vmName+".vmx"), []byte(content), 0644)).To(Succeed())
+	}
+
+	It("allocates addresses in the manual VMware range and records them", func() {
+		macAddress, err := macAllocator.Allocate("vm-1")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(macAddress).To(MatchRegexp(`^00:50:56:[0-3][0-9a-f]:[0-9a-f]{2}:[0-9a-f]{2}$`))
+		Expect(readRegistry()).To(Equal(map[string]string{macAddress: "vm-1"}))
+		lockPath, _, _ := retryFileLock.TryArgsForCall(0)
+		Expect(lockPath).To(Equal(registryPath + ".lock"))
+	})
+
+	It("never allocates the same address twice", func() {
+		allocated := map[string]bool{}
+		for i := 0; i < 100; i++ {
+			macAddress, err := macAllocator.Allocate("vm-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allocated).ToNot(HaveKey(macAddress))
+			allocated[macAddress] = true
+		}
+	})
+
+	It("skips addresses used by existing vms", func() {
+		writeVmx(storeDir, "vm-existing", `ethernet0.address = "00:50:56:00:00:01"`)
+		writeVmx(datastoreDir, "vm-other", `ethernet0.address = "00:50:56:00:00:02"`)
+
+		Expect(ioutil.WriteFile(registryPath, []byte(`{"00:50:56:00:00:00":"vm-pending"}`), 0644)).To(Succeed())
+
+		for i := 0; i < 50; i++ {
+			macAddress, err := macAllocator.Allocate("vm-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect([]string{"00:50:56:00:00:00", "00:50:56:00:00:01", "00:50:56:00:00:02"}).ToNot(ContainElement(macAddress))
+		}
+	})
+
+	It("releases addresses allocated to a vm", func() {
+		firstAddress, err := macAllocator.Allocate("vm-1")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = macAllocator.Allocate("vm-1")
+		Expect(err).ToNot(HaveOccurred())
+		otherAddress, err := macAllocator.Allocate("vm-2")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(readRegistry()).To(HaveKeyWithValue(firstAddress, "vm-1"))
+
+		Expect(macAllocator.Release("vm-1")).To(Succeed())
+
+		Expect(readRegistry()).To(Equal(map[string]string{otherAddress: "vm-2"}))
+	})
+
+	It("fails on a corrupt registry", func() {
+		Expect(ioutil.WriteFile(registryPath, []byte(`not json`), 0644)).To(Succeed())
+
+		_, err := macAllocator.Allocate("vm-1")
+		Expect(err).To(MatchError(ContainSubstring("reading mac address registry")))
+	})
+})
@@ -0,0 +1,19 @@
+package driver
+
+import (
+	"bosh-vmrun-cpi/vmdk"
+	"bosh-vmrun-cpi/vmx"
+)
+
+type nativeDiskCreatorImpl struct {
+	vmdkWriter vmdk.VmdkWriter
+}
+
+func NewNativeDiskCreator(vmdkWriter vmdk.VmdkWriter) DiskCreator {
+	return nativeDiskCreatorImpl{vmdkWriter: vmdkWriter}
+}
+
+// CreateDisk creates an empty monolithicSparse disk, like the disks ovftool generates
+func (c nativeDiskCreatorImpl) CreateDisk(diskPath string, diskMB int) error {
+	return c.vmdkWriter.CreateSparse(diskPath, uint64(diskMB)*1024*1024/vmdk.SECTOR_SIZE, vmx.DISK_CONTROLLER_LSILOGIC)
+}
@@ -0,0 +1,153 @@
+package driver
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	govmx "github.com/hooklift/govmx"
+
+	"bosh-vmrun-cpi/ovf"
+	"bosh-vmrun-cpi/vmdk"
+	"bosh-vmrun-cpi/vmx"
+)
+
+type nativeOvfImporterImpl struct {
+	vmxBuilder vmx.VmxBuilder
+	vmdkReader vmdk.VmdkReader
+	vmdkWriter vmdk.VmdkWriter
+	logger     boshlog.Logger
+}
+
+const vmxConfigVersion = "8"
+
+func NewNativeOvfImporter(vmxBuilder vmx.VmxBuilder, vmdkReader vmdk.VmdkReader, vmdkWriter vmdk.VmdkWriter, logger boshlog.Logger) OvfImporter {
+	return nativeOvfImporterImpl{vmxBuilder: vmxBuilder, vmdkReader: vmdkReader, vmdkWriter: vmdkWriter, logger: logger}
+}
+
+// ImportOvf writes a VMX from the OVF's virtual hardware next to hosted sparse copies of its disks. NICs are
+// not imported, VM networks are added when cloning
+func (i nativeOvfImporterImpl) ImportOvf(ovfPath, vmxPath, vmName string) error {
+	ovfContent, err := ioutil.ReadFile(ovfPath)
+	if err != nil {
+		return err
+	}
+
+	envelope, err := ovf.ParseOvf(ovfContent)
+	if err != nil {
+		return err
+	}
+
+	hardware, err := envelope.Hardware()
+	if err != nil {
+		return err
+	}
+
+	vmDir := filepath.Dir(vmxPath)
+	err = os.MkdirAll(vmDir, 0700)
+	if err != nil {
+		return err
+	}
+
+	err = i.importVM(hardware, filepath.Dir(ovfPath), vmxPath, vmName)
+	if err != nil {
+		i.logger.ErrorWithDetails("ovf-importer", "import ovf", err)
+		os.RemoveAll(vmDir)
+		return err
+	}
+
+	return nil
+}
+
+func (i nativeOvfImporterImpl) importVM(hardware ovf.Hardware, ovfDir string, vmxPath string, vmName string) error {
+	vmxVM := &vmx.VM{}
+	vmxVM.Encoding = "UTF-8"
+	vmxVM.Config.Version = vmxConfigVersion
+	vmxVM.Vhardware.Version = hardware.HardwareVersion
+	vmxVM.DisplayName = vmName
+	vmxVM.GuestOS = hardware.GuestOS
+	vmxVM.NumvCPUs = uint(hardware.CPUs)
+	vmxVM.Memsize = uint(hardware.MemoryMB)
+	vmxVM.Firmware = hardware.Firmware
+	vmxVM.VMCI.Present = true
+
+	vmxVM.PCIBridges = vmx.PCIBridges(hardware.HardwareVersion)
+
+	switch hardware.DiskController {
+	case vmx.DISK_CONTROLLER_SATA:
+		vmxVM.SATADevices = []govmx.SATADevice{{Device: govmx.Device{VMXID: "sata0", Present: true}}}
+	case vmx.DISK_CONTROLLER_NVME:
+		// nvme controllers need virtual hardware version 13
+		if vmxVM.Vhardware.Version < 13 {
+			vmxVM.Vhardware.Version = 13
+		}
+		vmxVM.NVMeDevices = []vmx.NVMeDevice{{Device: govmx.Device{VMXID: "nvme0", Present: true}}}
+	default:
+		vmxVM.SCSIDevices = []govmx.SCSIDevice{{Device: govmx.Device{VMXID: "scsi0", Present: true}, VirtualDev: hardware.DiskController}}
+	}
+
+	for n, disk := range hardware.Disks {
+		diskFileName := fmt.Sprintf("%s-disk%d.vmdk", vmName, n+1)
+
+		err := i.importDisk(filepath.Join(ovfDir, disk.FileName), filepath.Join(filepath.Dir(vmxPath), diskFileName))
+		if err != nil {
+			return err
+		}
+
+		device := govmx.Device{Present: true, Type: "disk", Filename: diskFileName}
+		switch hardware.DiskController {
+		case vmx.DISK_CONTROLLER_SATA:
+			device.VMXID = fmt.Sprintf("sata0:%d", disk.Unit)
+			vmxVM.SATADevices = append(vmxVM.SATADevices, govmx.SATADevice{Device: device})
+		case vmx.DISK_CONTROLLER_NVME:
+			device.VMXID = fmt.Sprintf("nvme0:%d", disk.Unit)
+			vmxVM.NVMeDevices = append(vmxVM.NVMeDevices, vmx.NVMeDevice{Device: device})
+		default:
+			device.VMXID = fmt.Sprintf("scsi0:%d", disk.Unit)
+			vmxVM.SCSIDevices = append(vmxVM.SCSIDevices, govmx.SCSIDevice{Device: device})
+		}
+	}
+
+	return i.vmxBuilder.WriteVmx(vmxVM, vmxPath)
+}
+
+// streamOptimized disks are converted to monolithicSparse, other hosted sparse disks without a parent are
+// copied as they are
+func (i nativeOvfImporterImpl) importDisk(sourceDiskPath string, targetDiskPath string) error {
+	diskInfo, err := i.vmdkReader.GetInfo(sourceDiskPath)
+	if err != nil {
+		return err
+	}
+
+	if len(diskInfo.ParentChain) > 0 {
+		return fmt.Errorf("disk %s has a parent disk", sourceDiskPath)
+	}
+
+	switch diskInfo.CreateType {
+	case vmdk.CREATE_TYPE_MONOLITHIC_SPARSE:
+	case vmdk.CREATE_TYPE_STREAM_OPTIMIZED:
+		return i.vmdkWriter.ConvertStreamOptimized(sourceDiskPath, targetDiskPath, vmdk.CREATE_TYPE_MONOLITHIC_SPARSE)
+	default:
+		return fmt.Errorf("unsupported disk type %s: %s", diskInfo.CreateType, sourceDiskPath)
+	}
+
+	i.logger.Debug("ovf-importer", "copying disk %s to %s", sourceDiskPath, targetDiskPath)
+
+	sourceDisk, err := os.Open(sourceDiskPath)
+	if err != nil {
+		return err
+	}
+	defer sourceDisk.Close()
+
+	targetDisk, err := os.OpenFile(targetDiskPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer targetDisk.Close()
+
+	_, err = io.Copy(targetDisk, sourceDisk)
+	return err
+}
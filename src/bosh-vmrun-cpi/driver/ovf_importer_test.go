@@ -0,0 +1,147 @@
+package driver_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/vmdk"
+	"bosh-vmrun-cpi/vmx"
+)
+
+var _ = Describe("NativeOvfImporter", func() {
+	var (
+		ovfDir     string
+		vmDir      string
+		vmxBuilder vmx.VmxBuilder
+		importer   driver.OvfImporter
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		ovfDir, err = ioutil.TempDir("", "ovf-")
+		Expect(err).ToNot(HaveOccurred())
+
+		vmDir, err = ioutil.TempDir("", "vm-")
+		Expect(err).ToNot(HaveOccurred())
+
+		ovfBytes, err := ioutil.ReadFile(filepath.Join("..", "test", "fixtures", "image.ovf"))
+		Expect(err).ToNot(HaveOccurred())
+
+		ovfContent := strings.Replace(string(ovfBytes), "#streamOptimized", "#monolithicSparse", 1)
+		Expect(ioutil.WriteFile(filepath.Join(ovfDir, "image.ovf"), []byte(ovfContent), 0644)).To(Succeed())
+
+		var header bytes.Buffer
+		binary.Write(&header, binary.LittleEndian, vmdk.NewSparseExtentHeader(2048))
+		Expect(ioutil.WriteFile(filepath.Join(ovfDir, "image.vmdk"), header.Bytes(), 0644)).To(Succeed())
+
+		logger := &fakelogger.FakeLogger{}
+		vmxBuilder = vmx.NewVmxBuilder(logger)
+		importer = driver.NewNativeOvfImporter(vmxBuilder, vmdk.NewVmdkReader(logger), vmdk.NewVmdkWriter(logger), logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(ovfDir)
+		os.RemoveAll(vmDir)
+	})
+
+	It("writes a vmx from the ovf hardware and copies the disk", func() {
+		vmxPath := filepath.Join(vmDir, "vm-1", "vm-1.vmx")
+
+		Expect(importer.ImportOvf(filepath.Join(ovfDir, "image.ovf"), vmxPath, "vm-1")).To(Succeed())
+
+		vmxVM, err := vmxBuilder.GetVmx(vmxPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(vmxVM.DisplayName).To(Equal("vm-1"))
+		Expect(vmxVM.Vhardware.Version).To(Equal(9))
+		Expect(vmxVM.GuestOS).To(Equal("ubuntu-64"))
+		Expect(vmxVM.NumvCPUs).To(Equal(uint(1)))
+		Expect(vmxVM.Memsize).To(Equal(uint(512)))
+		Expect(vmxVM.PCIBridges).To(HaveLen(5))
+
+		Expect(vmxVM.SCSIDevices).To(HaveLen(2))
+		Expect(vmxVM.SCSIDevices[0].VMXID).To(Equal("scsi0"))
+		Expect(vmxVM.SCSIDevices[0].VirtualDev).To(Equal("lsilogic"))
+		Expect(vmxVM.SCSIDevices[1].VMXID).To(Equal("scsi0:0"))
+		Expect(vmxVM.SCSIDevices[1].Filename).To(Equal("vm-1-disk1.vmdk"))
+
+		sourceDisk, err := ioutil.ReadFile(filepath.Join(ovfDir, "image.vmdk"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.ReadFile(filepath.Join(vmDir, "vm-1", "vm-1-disk1.vmdk"))).To(Equal(sourceDisk))
+	})
+
+	It("puts disks of nvme controllers on an nvme bus", func() {
+		ovfBytes, err := ioutil.ReadFile(filepath.Join(ovfDir, "image.ovf"))
+		Expect(err).ToNot(HaveOccurred())
+		ovfContent := strings.Replace(string(ovfBytes), "<rasd:ResourceSubType>lsilogic</rasd:ResourceSubType>\n        <rasd:ResourceType>6<",
+			"<rasd:ResourceSubType>vmware.nvme.controller</rasd:ResourceSubType>\n        <rasd:ResourceType>20<", 1)
+		Expect(ioutil.WriteFile(filepath.Join(ovfDir, "image.ovf"), []byte(ovfContent), 0644)).To(Succeed())
+		vmxPath := filepath.Join(vmDir, "vm-1", "vm-1.vmx")
+
+		Expect(importer.ImportOvf(filepath.Join(ovfDir, "image.ovf"), vmxPath, "vm-1")).To(Succeed())
+
+		vmxVM, err := vmxBuilder.GetVmx(vmxPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(vmxVM.Vhardware.Version).To(Equal(13))
+		Expect(vmxVM.SCSIDevices).To(BeEmpty())
+		Expect(vmxVM.NVMeDevices).To(HaveLen(2))
+		Expect(vmxVM.NVMeDevices[0].VMXID).To(Equal("nvme0"))
+		Expect(vmxVM.NVMeDevices[1].VMXID).To(Equal("nvme0:0"))
+		Expect(vmxVM.NVMeDevices[1].Filename).To(Equal("vm-1-disk1.vmdk"))
+		Expect(vmxVM.DiskController()).To(Equal("nvme"))
+	})
+
+	It("converts streamOptimized disks to monolithic sparse", func() {
+		ovfPath := filepath.Join("..", "test", "fixtures", "image.ovf")
+		vmxPath := filepath.Join(vmDir, "vm-1", "vm-1.vmx")
+
+		Expect(importer.ImportOvf(ovfPath, vmxPath, "vm-1")).To(Succeed())
+
+		diskInfo, err := vmdk.NewVmdkReader(&fakelogger.FakeLogger{}).GetInfo(filepath.Join(vmDir, "vm-1", "vm-1-disk1.vmdk"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diskInfo.CreateType).To(Equal("monolithicSparse"))
+		Expect(diskInfo.VirtualSizeBytes).To(Equal(int64(1024 * 1024)))
+	})
+
+	It("removes the vm dir when a disk can not be imported", func() {
+		Expect(ioutil.WriteFile(filepath.Join(ovfDir, "image.vmdk"), []byte("not a disk"), 0644)).To(Succeed())
+		vmxPath := filepath.Join(vmDir, "vm-1", "vm-1.vmx")
+
+		err := importer.ImportOvf(filepath.Join(ovfDir, "image.ovf"), vmxPath, "vm-1")
+		Expect(err).To(HaveOccurred())
+
+		_, err = os.Stat(filepath.Dir(vmxPath))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+})
+
+var _ = Describe("NativeDiskCreator", func() {
+	It("creates an empty sparse disk", func() {
+		diskDir, err := ioutil.TempDir("", "disks-")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(diskDir)
+
+		logger := &fakelogger.FakeLogger{}
+		diskPath := filepath.Join(diskDir, "disk-1.vmdk")
+
+		Expect(driver.NewNativeDiskCreator(vmdk.NewVmdkWriter(logger)).CreateDisk(diskPath, 64)).To(Succeed())
+
+		diskInfo, err := vmdk.NewVmdkReader(logger).GetInfo(diskPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diskInfo.CreateType).To(Equal("monolithicSparse"))
+		Expect(diskInfo.AdapterType).To(Equal("lsilogic"))
+		Expect(diskInfo.VirtualSizeBytes).To(Equal(int64(64 * 1024 * 1024)))
+	})
+})
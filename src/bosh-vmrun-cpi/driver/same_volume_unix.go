@@ -0,0 +1,21 @@
+// +build linux darwin
+
+package driver
+
+import (
+	"syscall"
+)
+
+func sameVolume(pathA string, pathB string) (bool, error) {
+	var statA, statB syscall.Stat_t
+
+	if err := syscall.Stat(pathA, &statA); err != nil {
+		return false, err
+	}
+
+	if err := syscall.Stat(pathB, &statB); err != nil {
+		return false, err
+	}
+
+	return statA.Dev == statB.Dev, nil
+}
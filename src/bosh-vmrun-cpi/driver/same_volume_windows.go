@@ -0,0 +1,20 @@
+package driver
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+func sameVolume(pathA string, pathB string) (bool, error) {
+	absPathA, err := filepath.Abs(pathA)
+	if err != nil {
+		return false, err
+	}
+
+	absPathB, err := filepath.Abs(pathB)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(filepath.VolumeName(absPathA), filepath.VolumeName(absPathB)), nil
+}
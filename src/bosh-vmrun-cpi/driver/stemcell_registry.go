@@ -0,0 +1,197 @@
+package driver
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type stemcellRegistryImpl struct {
+	config        Config
+	retryFileLock RetryFileLock
+	logger        boshlog.Logger
+}
+
+// stemcellRegistryEntry is an imported stemcell VM shared by every upload of the same stemcell image
+type stemcellRegistryEntry struct {
+	Key        string `json:"key"`
+	References int    `json:"references"`
+}
+
+const (
+	stemcellRegistryLockMaxWait = 30 * time.Second
+	stemcellImportLockMaxWait   = 30 * time.Minute
+)
+
+func NewStemcellRegistry(config Config, retryFileLock RetryFileLock, logger boshlog.Logger) StemcellRegistry {
+	return &stemcellRegistryImpl{config: config, retryFileLock: retryFileLock, logger: logger}
+}
+
+// Lock runs fn holding a lock of the stemcell key, so concurrent uploads of the same stemcell acquire the
+// stemcell VM imported by the first one instead of importing it again
+func (r stemcellRegistryImpl) Lock(stemcellKey string, fn func() error) error {
+	lockPath := fmt.Sprintf("%s.%x.lock", r.config.StemcellRegistryPath(), sha1.Sum([]byte(stemcellKey)))
+
+	return r.retryFileLock.Try(lockPath, stemcellImportLockMaxWait, fn)
+}
+
+// Acquire returns a registered stemcell VM of the stemcell key and adds a reference to it, or no name when none
+// is imported. Entries of VMs removed outside of the CPI are dropped
+func (r stemcellRegistryImpl) Acquire(stemcellKey string) (string, error) {
+	var stemcellVmName string
+	registryPath := r.config.StemcellRegistryPath()
+
+	err := r.retryFileLock.Try(registryPath+".lock", stemcellRegistryLockMaxWait, func() error {
+		registry, err := r.readRegistry(registryPath)
+		if err != nil {
+			return err
+		}
+
+		for vmName, entry := range registry {
+			if entry.Key != stemcellKey {
+				continue
+			}
+
+			if _, err := os.Stat(r.config.VmxPath(vmName)); err != nil {
+				r.logger.Debug("stemcell-registry", "dropping missing stemcell vm %s", vmName)
+				delete(registry, vmName)
+				continue
+			}
+
+			entry.References++
+			registry[vmName] = entry
+			stemcellVmName = vmName
+			break
+		}
+
+		return r.writeRegistry(registryPath, registry)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if stemcellVmName != "" {
+		r.logger.Debug("stemcell-registry", "reusing stemcell vm %s for %s", stemcellVmName, stemcellKey)
+	}
+
+	return stemcellVmName, nil
+}
+
+// Register records a newly imported stemcell VM with a single reference
+func (r stemcellRegistryImpl) Register(stemcellKey string, stemcellVmName string) error {
+	registryPath := r.config.StemcellRegistryPath()
+
+	return r.retryFileLock.Try(registryPath+".lock", stemcellRegistryLockMaxWait, func() error {
+		registry, err := r.readRegistry(registryPath)
+		if err != nil {
+			return err
+		}
+
+		registry[stemcellVmName] = stemcellRegistryEntry{Key: stemcellKey, References: 1}
+		r.logger.Debug("stemcell-registry", "registered stemcell vm %s for %s", stemcellVmName, stemcellKey)
+
+		return r.writeRegistry(registryPath, registry)
+	})
+}
+
+// Release removes a reference to a stemcell VM and returns whether the VM is no longer used. Stemcell VMs
+// imported before the registry existed are not registered and always unused
+func (r stemcellRegistryImpl) Release(stemcellVmName string) (bool, error) {
+	unused := true
+	registryPath := r.config.StemcellRegistryPath()
+
+	err := r.retryFileLock.Try(registryPath+".lock", stemcellRegistryLockMaxWait, func() error {
+		registry, err := r.readRegistry(registryPath)
+		if err != nil {
+			return err
+		}
+
+		entry, found := registry[stemcellVmName]
+		if !found {
+			return nil
+		}
+
+		entry.References--
+		if entry.References > 0 {
+			unused = false
+			registry[stemcellVmName] = entry
+		} else {
+			delete(registry, stemcellVmName)
+		}
+
+		r.logger.Debug("stemcell-registry", "released stemcell vm %s, references: %d", stemcellVmName, entry.References)
+
+		return r.writeRegistry(registryPath, registry)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return unused, nil
+}
+
+// Keys returns the stemcell keys of registered stemcell VMs that still exist
+func (r stemcellRegistryImpl) Keys() ([]string, error) {
+	stemcellKeys := []string{}
+	registryPath := r.config.StemcellRegistryPath()
+
+	err := r.retryFileLock.Try(registryPath+".lock", stemcellRegistryLockMaxWait, func() error {
+		registry, err := r.readRegistry(registryPath)
+		if err != nil {
+			return err
+		}
+
+		for vmName, entry := range registry {
+			if _, err := os.Stat(r.config.VmxPath(vmName)); err != nil {
+				continue
+			}
+
+			stemcellKeys = append(stemcellKeys, entry.Key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stemcellKeys, nil
+}
+
+func (r stemcellRegistryImpl) readRegistry(registryPath string) (map[string]stemcellRegistryEntry, error) {
+	registry := map[string]stemcellRegistryEntry{}
+
+	registryBytes, err := ioutil.ReadFile(registryPath)
+	if os.IsNotExist(err) {
+		return registry, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(registryBytes, &registry)
+	if err != nil {
+		return nil, fmt.Errorf("reading stemcell registry %s: %s", registryPath, err)
+	}
+
+	return registry, nil
+}
+
+func (r stemcellRegistryImpl) writeRegistry(registryPath string, registry map[string]stemcellRegistryEntry) error {
+	registryBytes, err := json.Marshal(registry)
+	if err != nil {
+		return err
+	}
+
+	tmpRegistryPath := registryPath + ".tmp"
+	err = ioutil.WriteFile(tmpRegistryPath, registryBytes, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpRegistryPath, registryPath)
+}
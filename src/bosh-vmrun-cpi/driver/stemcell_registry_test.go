@@ -0,0 +1,143 @@
+package driver_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/driver/fakes"
+)
+
+var _ = Describe("StemcellRegistry", func() {
+	var (
+		storeDir         string
+		registryPath     string
+		retryFileLock    *fakes.FakeRetryFileLock
+		stemcellRegistry driver.StemcellRegistry
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		storeDir, err = ioutil.TempDir("", "vm-store-")
+		Expect(err).ToNot(HaveOccurred())
+
+		registryPath = filepath.Join(storeDir, "stemcell-registry.json")
+
+		config := &fakes.FakeConfig{}
+		config.StemcellRegistryPathReturns(registryPath)
+		config.VmxPathStub = func(vmName string) string {
+			return filepath.Join(storeDir, vmName, vmName+".vmx")
+		}
+
+		retryFileLock = &fakes.FakeRetryFileLock{}
+		retryFileLock.TryStub = func(lockPath string, maxWait time.Duration, fn func() error) error {
+			return fn()
+		}
+
+		stemcellRegistry = driver.NewStemcellRegistry(config, retryFileLock, &fakelogger.FakeLogger{})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(storeDir)
+	})
+
+	writeVmx := func(vmName string) {
+		Expect(os.MkdirAll(filepath.Join(storeDir, vmName), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(storeDir, vmName, vmName+".vmx"), []byte{}, 0644)).To(Succeed())
+	}
+
+	It("finds no stemcell vm before one is registered", func() {
+		stemcellVmName, err := stemcellRegistry.Acquire("stemcell-a/1/abcd")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stemcellVmName).To(BeEmpty())
+
+		lockPath, _, _ := retryFileLock.TryArgsForCall(0)
+		Expect(lockPath).To(Equal(registryPath + ".lock"))
+	})
+
+	It("reuses a registered stemcell vm of the same key until every reference is released", func() {
+		writeVmx("cs-1")
+		Expect(stemcellRegistry.Register("stemcell-a/1/abcd", "cs-1")).To(Succeed())
+
+		stemcellVmName, err := stemcellRegistry.Acquire("stemcell-a/1/abcd")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stemcellVmName).To(Equal("cs-1"))
+
+		stemcellVmName, err = stemcellRegistry.Acquire("stemcell-a/2/ef01")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stemcellVmName).To(BeEmpty())
+
+		unused, err := stemcellRegistry.Release("cs-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(unused).To(BeFalse())
+
+		unused, err = stemcellRegistry.Release("cs-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(unused).To(BeTrue())
+
+		stemcellVmName, err = stemcellRegistry.Acquire("stemcell-a/1/abcd")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stemcellVmName).To(BeEmpty())
+	})
+
+	It("drops registered stemcell vms that no longer exist", func() {
+		Expect(stemcellRegistry.Register("stemcell-a/1/abcd", "cs-1")).To(Succeed())
+
+		stemcellVmName, err := stemcellRegistry.Acquire("stemcell-a/1/abcd")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stemcellVmName).To(BeEmpty())
+
+		registryBytes, err := ioutil.ReadFile(registryPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(registryBytes)).To(Equal("{}"))
+	})
+
+	It("treats unregistered stemcell vms as unused", func() {
+		unused, err := stemcellRegistry.Release("cs-imported-before-registry")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(unused).To(BeTrue())
+	})
+
+	It("locks each stemcell key for as long as an import takes", func() {
+		called := false
+		Expect(stemcellRegistry.Lock("stemcell-a/1/abcd", func() error {
+			called = true
+			return nil
+		})).To(Succeed())
+		Expect(stemcellRegistry.Lock("stemcell-b/1/abcd", func() error { return nil })).To(Succeed())
+		Expect(called).To(BeTrue())
+
+		lockPathA, maxWait, _ := retryFileLock.TryArgsForCall(0)
+		lockPathB, _, _ := retryFileLock.TryArgsForCall(1)
+		Expect(lockPathA).To(HavePrefix(registryPath + "."))
+		Expect(lockPathA).To(HaveSuffix(".lock"))
+		Expect(lockPathA).ToNot(Equal(lockPathB))
+		Expect(maxWait).To(Equal(30 * time.Minute))
+	})
+
+	It("lists the keys of registered stemcell vms that still exist", func() {
+		writeVmx("cs-a")
+		Expect(stemcellRegistry.Register("stemcell-a/1/abcd", "cs-a")).To(Succeed())
+		Expect(stemcellRegistry.Register("stemcell-b/1/abcd", "cs-removed")).To(Succeed())
+
+		stemcellKeys, err := stemcellRegistry.Keys()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stemcellKeys).To(ConsistOf("stemcell-a/1/abcd"))
+	})
+
+	It("fails on an invalid registry", func() {
+		Expect(ioutil.WriteFile(registryPath, []byte("invalid"), 0644)).To(Succeed())
+
+		_, err := stemcellRegistry.Acquire("stemcell-a/1/abcd")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("reading stemcell registry"))
+	})
+})
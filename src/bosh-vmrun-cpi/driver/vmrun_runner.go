@@ -2,6 +2,7 @@ package driver
 
 import (
 	"fmt"
+	"net"
 	"path/filepath"
 	"strings"
 	"time"
@@ -10,6 +11,11 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
+const (
+	VARIABLE_TYPE_RUNTIME_CONFIG = "runtimeConfig"
+	VARIABLE_TYPE_GUEST_VAR      = "guestVar"
+)
+
 type vmrunRunnerImpl struct {
 	vmrunBinPath     string
 	vmrunBackendType string
@@ -101,6 +107,56 @@ func (r *vmrunRunnerImpl) Delete(vmxPath string) error {
 	return err
 }
 
+// runtimeConfig variables are VMX entries, written to the running VM and its VMX file. guestVar variables are
+// guestinfo entries named without their `guestinfo.` prefix
+func (r *vmrunRunnerImpl) ReadVariable(vmxPath, variableType, name string) (string, error) {
+	args := []string{"readVariable", vmxPath, variableType, name}
+
+	stdout, err := r.cliCommandNoRetry(args)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(stdout, "\r\n"), nil
+}
+
+func (r *vmrunRunnerImpl) WriteVariable(vmxPath, variableType, name, value string) error {
+	args := []string{"writeVariable", vmxPath, variableType, name, value}
+
+	_, err := r.cliCommandNoRetry(args)
+	return err
+}
+
+func (r *vmrunRunnerImpl) ConnectNamedDevice(vmxPath, deviceName string) error {
+	args := []string{"connectNamedDevice", vmxPath, deviceName}
+
+	_, err := r.cliCommandNoRetry(args)
+	return err
+}
+
+func (r *vmrunRunnerImpl) DisconnectNamedDevice(vmxPath, deviceName string) error {
+	args := []string{"disconnectNamedDevice", vmxPath, deviceName}
+
+	_, err := r.cliCommandNoRetry(args)
+	return err
+}
+
+func (r *vmrunRunnerImpl) GetGuestIPAddress(vmxPath string) (string, error) {
+	args := []string{"getGuestIPAddress", vmxPath}
+
+	stdout, err := r.cliCommand(args, nil)
+	if err != nil {
+		return "", err
+	}
+
+	ipAddress := strings.TrimSpace(stdout)
+	if net.ParseIP(ipAddress) == nil {
+		return "", fmt.Errorf("invalid guest ip address: %s", ipAddress)
+	}
+
+	return ipAddress, nil
+}
+
 func (r *vmrunRunnerImpl) CopyFileFromHostToGuest(vmxPath, hostFilePath, guestFilePath, guestUsername, guestPassword string) error {
 	args := []string{
 		"-gu", guestUsername,
@@ -141,6 +197,15 @@ func (r *vmrunRunnerImpl) ListProcessesInGuest(vmxPath, guestUsername, guestPass
 }
 
 func (r *vmrunRunnerImpl) cliCommand(args []string, flagMap map[string]string) (string, error) {
+	return r.runCommand(args, flagMap, true)
+}
+
+// device and variable commands fail with the retryable error when the operation is unsupported, so they are not retried
+func (r *vmrunRunnerImpl) cliCommandNoRetry(args []string) (string, error) {
+	return r.runCommand(args, nil, false)
+}
+
+func (r *vmrunRunnerImpl) runCommand(args []string, flagMap map[string]string, retryUnsupported bool) (string, error) {
 	var stdout string
 	var err error
 
@@ -169,7 +234,7 @@ func (r *vmrunRunnerImpl) cliCommand(args []string, flagMap map[string]string) (
 			break
 		}
 
-		if strings.Contains(stdout, retryableError) {
+		if retryUnsupported && strings.Contains(stdout, retryableError) {
 			r.logger.Debug("vmrun-runner", "Retryable error: %s: %s (%s)", commandStr, stdout, err.Error())
 			continue
 		} else {
@@ -0,0 +1,66 @@
+package driver_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/driver/fakes"
+)
+
+var _ = Describe("VmrunRunner", func() {
+	var (
+		binDir   string
+		argsPath string
+		runner   driver.VmrunRunner
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip("fake vmrun is a shell script")
+		}
+
+		var err error
+		binDir, err = ioutil.TempDir("", "vmrun-bin-")
+		Expect(err).ToNot(HaveOccurred())
+
+		argsPath = filepath.Join(binDir, "args")
+		vmrunPath := filepath.Join(binDir, "vmrun")
+		Expect(ioutil.WriteFile(vmrunPath, []byte("#!/bin/sh\nprintf '%s\\n' \"$@\" > "+argsPath+"\necho e30=\n"), 0755)).To(Succeed())
+
+		runner = driver.NewVmrunRunner(vmrunPath, &fakes.FakeRetryFileLock{}, &fakelogger.FakeLogger{})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(binDir)
+	})
+
+	readArgs := func() []string {
+		argsBytes, err := ioutil.ReadFile(argsPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		return strings.Split(strings.TrimSuffix(string(argsBytes), "\n"), "\n")
+	}
+
+	It("reads guestinfo variables as guestVar without their prefix", func() {
+		value, err := runner.ReadVariable("/vm-1.vmx", driver.VARIABLE_TYPE_GUEST_VAR, "bosh.agent_env")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal("e30="))
+
+		Expect(readArgs()).To(Equal([]string{"readVariable", "/vm-1.vmx", "guestVar", "bosh.agent_env"}))
+	})
+
+	It("writes runtime config variables", func() {
+		Expect(runner.WriteVariable("/vm-1.vmx", driver.VARIABLE_TYPE_RUNTIME_CONFIG, "scsi0:1.present", "TRUE")).To(Succeed())
+
+		Expect(readArgs()).To(Equal([]string{"writeVariable", "/vm-1.vmx", "runtimeConfig", "scsi0:1.present", "TRUE"}))
+	})
+})
@@ -0,0 +1,35 @@
+package install
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// GarbageCollectStemcellStore runs the stemcell store garbage collection of the installed CPI on the host and
+// prints the removed paths
+func (i *installerImpl) GarbageCollectStemcellStore(configJSON string, keepVersions int, maxUnusedDays int, dryRun bool) error {
+	session, err := i.sshClient.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	command := fmt.Sprintf("%s -configBase64JSON %s -keepVersions %d -maxUnusedDays %d", cpiDestPath(i.cpiConfig), base64.StdEncoding.EncodeToString([]byte(configJSON)), keepVersions, maxUnusedDays)
+	if dryRun {
+		command += " -dryRun"
+	}
+	command += " gc-stemcell-store"
+
+	i.logger.Debug("gc-stemcell-store", "running remote cpi: %s", cpiDestPath(i.cpiConfig))
+
+	session.Stderr = os.Stderr
+	output, err := session.Output(command)
+	if err != nil {
+		i.logger.Error("gc-stemcell-store", "running remote cpi", err)
+		return err
+	}
+
+	fmt.Print(string(output))
+	return nil
+}
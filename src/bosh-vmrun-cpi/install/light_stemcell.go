@@ -0,0 +1,173 @@
+package install
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"gopkg.in/yaml.v2"
+)
+
+// CreateLightStemcell writes a light stemcell for a full stemcell tarball: the same stemcell.MF with
+// cloud_properties naming the stemcell and `light: true`, and an empty image. The CPI resolves light stemcells
+// from its stemcell store, so the full stemcell must be copied there (ex: with sync-director-stemcells)
+func CreateLightStemcell(stemcellPath string, lightStemcellPath string, logger boshlog.Logger) (string, error) {
+	if stemcellPath == "" {
+		return "", errors.New("stemcell path required")
+	}
+
+	if lightStemcellPath == "" {
+		lightStemcellPath = filepath.Join(filepath.Dir(stemcellPath), "light-"+filepath.Base(stemcellPath))
+	}
+
+	manifestContent, err := readTarballManifest(stemcellPath)
+	if err != nil {
+		return "", err
+	}
+
+	lightManifestContent, err := lightStemcellManifest(manifestContent)
+	if err != nil {
+		return "", fmt.Errorf("parsing stemcell.MF of %s: %s", stemcellPath, err)
+	}
+
+	err = writeLightStemcell(lightStemcellPath, lightManifestContent)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("create-light-stemcell", "wrote light stemcell %s", lightStemcellPath)
+
+	return lightStemcellPath, nil
+}
+
+func readTarballManifest(stemcellPath string) ([]byte, error) {
+	stemcellFile, err := os.Open(stemcellPath)
+	if err != nil {
+		return nil, err
+	}
+	defer stemcellFile.Close()
+
+	gzipReader, err := gzip.NewReader(stemcellFile)
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("stemcell.MF not found in %s", stemcellPath)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if filepath.Clean(header.Name) == "stemcell.MF" {
+			return ioutil.ReadAll(tarReader)
+		}
+	}
+}
+
+// lightStemcellManifest keeps the order and fields of the full stemcell manifest, the sha1 is of the
+// empty image
+func lightStemcellManifest(manifestContent []byte) ([]byte, error) {
+	var manifest yaml.MapSlice
+	err := yaml.Unmarshal(manifestContent, &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	// versions like 97.10 are read as text, not as numbers
+	var stemcell struct {
+		Name    string
+		Version string
+	}
+	err = yaml.Unmarshal(manifestContent, &stemcell)
+	if err != nil {
+		return nil, err
+	}
+
+	if stemcell.Name == "" || stemcell.Version == "" {
+		return nil, errors.New("stemcell name and version required")
+	}
+
+	cloudProperties := yaml.MapSlice{}
+	for _, item := range manifest {
+		if item.Key == "cloud_properties" {
+			cloudProperties, _ = item.Value.(yaml.MapSlice)
+		}
+	}
+
+	cloudProperties = setMapItem(cloudProperties, "name", stemcell.Name)
+	cloudProperties = setMapItem(cloudProperties, "version", stemcell.Version)
+	cloudProperties = setMapItem(cloudProperties, "light", true)
+
+	manifest = setMapItem(manifest, "version", stemcell.Version)
+	manifest = setMapItem(manifest, "sha1", fmt.Sprintf("%x", sha1.Sum(nil)))
+	manifest = setMapItem(manifest, "cloud_properties", cloudProperties)
+
+	return yaml.Marshal(manifest)
+}
+
+func writeLightStemcell(lightStemcellPath string, manifestContent []byte) error {
+	lightStemcellFile, err := os.OpenFile(lightStemcellPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = writeLightStemcellTarball(lightStemcellFile, manifestContent)
+	lightStemcellFile.Close()
+	if err != nil {
+		os.Remove(lightStemcellPath)
+		return err
+	}
+
+	return nil
+}
+
+func writeLightStemcellTarball(writer io.Writer, manifestContent []byte) error {
+	gzipWriter := gzip.NewWriter(writer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := tarWriter.WriteHeader(&tar.Header{Name: "stemcell.MF", Size: int64(len(manifestContent)), Mode: 0644, Typeflag: tar.TypeReg})
+	if err != nil {
+		return err
+	}
+
+	_, err = tarWriter.Write(manifestContent)
+	if err != nil {
+		return err
+	}
+
+	err = tarWriter.WriteHeader(&tar.Header{Name: "image", Size: 0, Mode: 0644, Typeflag: tar.TypeReg})
+	if err != nil {
+		return err
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	return gzipWriter.Close()
+}
+
+func setMapItem(items yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range items {
+		if item.Key == key {
+			items[i].Value = value
+			return items
+		}
+	}
+
+	return append(items, yaml.MapItem{Key: key, Value: value})
+}
@@ -0,0 +1,133 @@
+package install_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	"gopkg.in/yaml.v2"
+
+	"bosh-vmrun-cpi/install"
+)
+
+var _ = Describe("CreateLightStemcell", func() {
+	var (
+		tempDir      string
+		stemcellPath string
+		logger       *fakelogger.FakeLogger
+	)
+
+	writeTarball := func(tarballPath string, files map[string]string) {
+		tarballFile, err := os.Create(tarballPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		gzipWriter := gzip.NewWriter(tarballFile)
+		tarWriter := tar.NewWriter(gzipWriter)
+		for name, content := range files {
+			Expect(tarWriter.WriteHeader(&tar.Header{Name: name, Size: int64(len(content)), Mode: 0644, Typeflag: tar.TypeReg})).To(Succeed())
+			_, err = tarWriter.Write([]byte(content))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(tarWriter.Close()).To(Succeed())
+		Expect(gzipWriter.Close()).To(Succeed())
+		Expect(tarballFile.Close()).To(Succeed())
+	}
+
+	readTarball := func(tarballPath string) map[string]string {
+		tarballFile, err := os.Open(tarballPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer tarballFile.Close()
+
+		gzipReader, err := gzip.NewReader(tarballFile)
+		Expect(err).ToNot(HaveOccurred())
+
+		files := map[string]string{}
+		tarReader := tar.NewReader(gzipReader)
+		for {
+			header, err := tarReader.Next()
+			if err != nil {
+				break
+			}
+
+			content, err := ioutil.ReadAll(tarReader)
+			Expect(err).ToNot(HaveOccurred())
+			files[header.Name] = string(content)
+		}
+
+		return files
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		tempDir, err = ioutil.TempDir("", "light-stemcell-")
+		Expect(err).ToNot(HaveOccurred())
+
+		stemcellPath = filepath.Join(tempDir, "bosh-stemcell-1-vsphere.tgz")
+		writeTarball(stemcellPath, map[string]string{
+			"stemcell.MF": "name: bosh-vsphere-esxi-ubuntu-xenial-go_agent\nversion: 97.10\nsha1: abcd\nstemcell_formats:\n- vsphere-ovf\ncloud_properties:\n  infrastructure: vsphere\n",
+			"image":       "full image",
+		})
+
+		logger = &fakelogger.FakeLogger{}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("writes the stemcell manifest with light cloud properties and an empty image", func() {
+		lightStemcellPath, err := install.CreateLightStemcell(stemcellPath, "", logger)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lightStemcellPath).To(Equal(filepath.Join(tempDir, "light-bosh-stemcell-1-vsphere.tgz")))
+
+		files := readTarball(lightStemcellPath)
+		Expect(files).To(HaveKeyWithValue("image", ""))
+
+		var manifest struct {
+			Name            string
+			Version         string
+			Sha1            string
+			StemcellFormats []string `yaml:"stemcell_formats"`
+			CloudProperties struct {
+				Name           string
+				Version        string
+				Light          bool
+				Infrastructure string
+			} `yaml:"cloud_properties"`
+		}
+		Expect(yaml.Unmarshal([]byte(files["stemcell.MF"]), &manifest)).To(Succeed())
+
+		Expect(manifest.Name).To(Equal("bosh-vsphere-esxi-ubuntu-xenial-go_agent"))
+		Expect(manifest.Version).To(Equal("97.10"))
+		Expect(manifest.Sha1).To(Equal("da39a3ee5e6b4b0d3255bfef95601890afd80709"))
+		Expect(manifest.StemcellFormats).To(Equal([]string{"vsphere-ovf"}))
+		Expect(manifest.CloudProperties.Name).To(Equal("bosh-vsphere-esxi-ubuntu-xenial-go_agent"))
+		Expect(manifest.CloudProperties.Version).To(Equal("97.10"))
+		Expect(manifest.CloudProperties.Light).To(BeTrue())
+		Expect(manifest.CloudProperties.Infrastructure).To(Equal("vsphere"))
+	})
+
+	It("does not overwrite an existing light stemcell", func() {
+		lightStemcellPath := filepath.Join(tempDir, "light.tgz")
+		Expect(ioutil.WriteFile(lightStemcellPath, []byte("existing"), 0644)).To(Succeed())
+
+		_, err := install.CreateLightStemcell(stemcellPath, lightStemcellPath, logger)
+		Expect(err).To(HaveOccurred())
+		Expect(ioutil.ReadFile(lightStemcellPath)).To(Equal([]byte("existing")))
+	})
+
+	It("fails for tarballs without a stemcell manifest", func() {
+		writeTarball(stemcellPath, map[string]string{"image": "full image"})
+
+		_, err := install.CreateLightStemcell(stemcellPath, "", logger)
+		Expect(err).To(MatchError("stemcell.MF not found in " + stemcellPath))
+	})
+})
@@ -14,6 +14,8 @@ import (
 
 	cpiconfig "bosh-vmrun-cpi/config"
 	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/vmdk"
+	"bosh-vmrun-cpi/vmnet"
 	"bosh-vmrun-cpi/vmx"
 )
 
@@ -25,6 +27,9 @@ var _ = Describe("driver integration", func() {
 	var vmrunRunner driver.VmrunRunner
 	var ovftoolRunner driver.OvftoolRunner
 	var vmxBuilder vmx.VmxBuilder
+	var datastorePlacer driver.DatastorePlacer
+	var macAllocator driver.MacAllocator
+	var stemcellRegistry driver.StemcellRegistry
 	var logger boshlog.Logger
 
 	BeforeEach(func() {
@@ -51,6 +56,10 @@ var _ = Describe("driver integration", func() {
 
 		ovftoolRunner = driver.NewOvftoolRunner(config.OvftoolPath(), boshRunner, logger)
 		Expect(ovftoolRunner.Configure()).To(Succeed())
+
+		datastorePlacer = driver.NewDatastorePlacer(config, retryFileLock, logger)
+		macAllocator = driver.NewMacAllocator(config, retryFileLock, logger)
+		stemcellRegistry = driver.NewStemcellRegistry(config, retryFileLock, logger)
 	})
 
 	AfterEach(func() {
@@ -67,7 +76,7 @@ var _ = Describe("driver integration", func() {
 
 	Describe("common client options", func() {
 		BeforeEach(func() {
-			client = driver.NewClient(vmrunRunner, ovftoolRunner, ovftoolRunner, vmxBuilder, config, logger)
+			client = driver.NewClient(vmrunRunner, ovftoolRunner, ovftoolRunner, ovftoolRunner, vmxBuilder, vmdk.NewVmdkReader(logger), datastorePlacer, driver.NewDiskMigrator(logger), macAllocator, stemcellRegistry, vmnet.NewDhcpReservations(driver.NewRetryFileLock(logger), logger), vmnet.NewPortForwards(driver.NewRetryFileLock(logger), logger), config, logger)
 		})
 
 		Describe("full lifecycle", func() {
@@ -85,7 +94,7 @@ var _ = Describe("driver integration", func() {
 				found = client.HasVM(vmId)
 				Expect(found).To(Equal(false))
 
-				err = client.CloneVM(stemcellId, vmId)
+				err = client.CloneVM(stemcellId, vmId, "", 0)
 				Expect(err).ToNot(HaveOccurred())
 
 				found = client.HasVM(vmId)
@@ -104,7 +113,7 @@ var _ = Describe("driver integration", func() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(fileInfo.Size()).To(Equal(int64(65536)))
 
-				err = client.SetVMNetworkAdapter(vmId, "fake-network", "00:50:56:3F:00:00")
+				err = client.SetVMNetworkAdapter(vmId, "fake-network", "00:50:56:3F:00:00", "custom", "vmxnet3")
 				Expect(err).ToNot(HaveOccurred())
 
 				vmInfo, err = client.GetVMInfo(vmId)
@@ -142,7 +151,7 @@ var _ = Describe("driver integration", func() {
 				found = client.HasDisk("disk-1")
 				Expect(found).To(Equal(false))
 
-				err = client.CreateDisk("disk-1", 3096)
+				err = client.CreateDisk("disk-1", 3096, "")
 				Expect(err).ToNot(HaveOccurred())
 
 				found = client.HasDisk("disk-1")
@@ -243,7 +252,7 @@ var _ = Describe("driver integration", func() {
 				Skip("can't test linked cloning with player")
 			}
 
-			client = driver.NewClient(vmrunRunner, ovftoolRunner, vmrunRunner, vmxBuilder, config, logger)
+			client = driver.NewClient(vmrunRunner, ovftoolRunner, ovftoolRunner, vmrunRunner, vmxBuilder, vmdk.NewVmdkReader(logger), datastorePlacer, driver.NewDiskMigrator(logger), macAllocator, stemcellRegistry, vmnet.NewDhcpReservations(driver.NewRetryFileLock(logger), logger), vmnet.NewPortForwards(driver.NewRetryFileLock(logger), logger), config, logger)
 		})
 
 		It("clones with linked disks", func() {
@@ -257,7 +266,7 @@ var _ = Describe("driver integration", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(success).To(Equal(true))
 
-			err = client.CloneVM(stemcellId, vmId)
+			err = client.CloneVM(stemcellId, vmId, "", 0)
 			Expect(err).ToNot(HaveOccurred())
 
 			found = client.HasVM(vmId)
@@ -289,7 +298,7 @@ var _ = Describe("driver integration", func() {
 					go func(j int) {
 						parallelVmId := fmt.Sprintf("vm-virtualmachine-%d", j)
 
-						errorChannel <- client.CloneVM(stemcellId, parallelVmId)
+						errorChannel <- client.CloneVM(stemcellId, parallelVmId, "", 0)
 					}(i)
 				}
 
@@ -35,7 +35,7 @@ var _ = Describe("StemcellClient integration", func() {
 		imageTarballPath := filepath.Join(extractedStemcellDir, "image")
 
 		client := stemcell.NewClient(compressor, fs, logger)
-		ovfPath, err := client.ExtractOvf(imageTarballPath)
+		ovfPath, _, err := client.ExtractOvf(imageTarballPath)
 		Expect(err).ToNot(HaveOccurred())
 
 		client.Cleanup()
@@ -0,0 +1,305 @@
+package ovf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"bosh-vmrun-cpi/vmx"
+)
+
+// CIM resource types of VirtualHardwareSection items
+const (
+	RESOURCE_TYPE_CPU                = 3
+	RESOURCE_TYPE_MEMORY             = 4
+	RESOURCE_TYPE_IDE_CONTROLLER     = 5
+	RESOURCE_TYPE_SCSI_CONTROLLER    = 6
+	RESOURCE_TYPE_ETHERNET           = 10
+	RESOURCE_TYPE_CDROM              = 15
+	RESOURCE_TYPE_DISK               = 17
+	RESOURCE_TYPE_STORAGE_CONTROLLER = 20
+)
+
+// subtypes of other storage controllers (resource type 20) in vSphere OVFs
+const (
+	RESOURCE_SUBTYPE_SATA_CONTROLLER = "vmware.sata.ahci"
+	RESOURCE_SUBTYPE_NVME_CONTROLLER = "vmware.nvme.controller"
+)
+
+// allocation units look like: byte * 2^20
+var allocationUnitsRegexp = regexp.MustCompile(`^\s*(?:byte|bytes)?\s*(?:\*\s*(\d+)\s*\^\s*(\d+))?\s*$`)
+
+// Envelope is the subset of an OVF descriptor needed to build a VMX. Element and attribute names match
+// any namespace
+type Envelope struct {
+	XMLName       xml.Name      `xml:"Envelope"`
+	References    []File        `xml:"References>File"`
+	Disks         []Disk        `xml:"DiskSection>Disk"`
+	VirtualSystem VirtualSystem `xml:"VirtualSystem"`
+}
+
+type File struct {
+	ID   string `xml:"id,attr"`
+	Href string `xml:"href,attr"`
+	Size int64  `xml:"size,attr"`
+}
+
+type Disk struct {
+	DiskID                  string `xml:"diskId,attr"`
+	FileRef                 string `xml:"fileRef,attr"`
+	Capacity                string `xml:"capacity,attr"`
+	CapacityAllocationUnits string `xml:"capacityAllocationUnits,attr"`
+	Format                  string `xml:"format,attr"`
+}
+
+type VirtualSystem struct {
+	ID              string                 `xml:"id,attr"`
+	Name            string                 `xml:"Name"`
+	OperatingSystem OperatingSystemSection `xml:"OperatingSystemSection"`
+	Hardware        VirtualHardwareSection `xml:"VirtualHardwareSection"`
+}
+
+type OperatingSystemSection struct {
+	ID     string `xml:"id,attr"`
+	OSType string `xml:"osType,attr"`
+}
+
+type VirtualHardwareSection struct {
+	SystemTypes []string `xml:"System>VirtualSystemType"`
+	Items       []Item   `xml:"Item"`
+	Configs     []Config `xml:"Config"`
+	ExtraConfig []Config `xml:"ExtraConfig"`
+}
+
+type Item struct {
+	InstanceID      string   `xml:"InstanceID"`
+	ResourceType    int      `xml:"ResourceType"`
+	ResourceSubType string   `xml:"ResourceSubType"`
+	ElementName     string   `xml:"ElementName"`
+	Address         string   `xml:"Address"`
+	AddressOnParent string   `xml:"AddressOnParent"`
+	Parent          string   `xml:"Parent"`
+	HostResource    []string `xml:"HostResource"`
+	AllocationUnits string   `xml:"AllocationUnits"`
+	VirtualQuantity int64    `xml:"VirtualQuantity"`
+}
+
+type Config struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Hardware is the virtual hardware of an OVF in VMX terms
+type Hardware struct {
+	Name            string
+	HardwareVersion int
+	GuestOS         string
+	CPUs            int
+	MemoryMB        int
+	DiskController  string
+	Disks           []HardwareDisk
+	Firmware        string
+}
+
+// HardwareDisk is a disk attached to the hardware's disk controller, in unit order
+type HardwareDisk struct {
+	FileName      string
+	Format        string
+	CapacityBytes int64
+	Unit          int
+}
+
+func ParseOvf(content []byte) (*Envelope, error) {
+	envelope := &Envelope{}
+
+	err := xml.Unmarshal(content, envelope)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ovf: %s", err)
+	}
+
+	return envelope, nil
+}
+
+func (e Envelope) Hardware() (Hardware, error) {
+	section := e.VirtualSystem.Hardware
+	hardware := Hardware{
+		Name:    e.VirtualSystem.Name,
+		GuestOS: GuestOS(e.VirtualSystem.OperatingSystem.OSType),
+	}
+
+	if hardware.Name == "" {
+		hardware.Name = e.VirtualSystem.ID
+	}
+
+	for _, systemType := range section.SystemTypes {
+		for _, family := range strings.Fields(systemType) {
+			version, err := strconv.Atoi(strings.TrimPrefix(family, "vmx-"))
+			if err == nil && version > hardware.HardwareVersion {
+				hardware.HardwareVersion = version
+			}
+		}
+	}
+
+	for _, config := range append(section.Configs, section.ExtraConfig...) {
+		if config.Key == "firmware" {
+			hardware.Firmware = config.Value
+		}
+	}
+
+	controllers := map[string]Item{}
+	for _, item := range section.Items {
+		switch item.ResourceType {
+		case RESOURCE_TYPE_CPU:
+			hardware.CPUs = int(item.VirtualQuantity)
+		case RESOURCE_TYPE_MEMORY:
+			multiplier, err := allocationUnitsBytes(item.AllocationUnits)
+			if err != nil {
+				return hardware, err
+			}
+			hardware.MemoryMB = int(item.VirtualQuantity * multiplier / (1024 * 1024))
+		case RESOURCE_TYPE_SCSI_CONTROLLER, RESOURCE_TYPE_STORAGE_CONTROLLER, RESOURCE_TYPE_IDE_CONTROLLER:
+			controllers[item.InstanceID] = item
+		}
+	}
+
+	for _, item := range section.Items {
+		if item.ResourceType != RESOURCE_TYPE_DISK {
+			continue
+		}
+
+		controller, found := controllers[item.Parent]
+		if !found {
+			return hardware, fmt.Errorf("disk %s has no controller", item.ElementName)
+		}
+
+		diskController, err := controllerType(controller)
+		if err != nil {
+			return hardware, err
+		}
+
+		if hardware.DiskController != "" && hardware.DiskController != diskController {
+			return hardware, fmt.Errorf("disks on multiple controller types are not supported: %s, %s", hardware.DiskController, diskController)
+		}
+		hardware.DiskController = diskController
+
+		disk, err := e.hardwareDisk(item)
+		if err != nil {
+			return hardware, err
+		}
+
+		hardware.Disks = append(hardware.Disks, disk)
+	}
+
+	if len(hardware.Disks) == 0 {
+		return hardware, fmt.Errorf("ovf has no disks")
+	}
+
+	return hardware, nil
+}
+
+func (e Envelope) hardwareDisk(item Item) (HardwareDisk, error) {
+	hardwareDisk := HardwareDisk{}
+	if item.AddressOnParent != "" {
+		unit, err := strconv.Atoi(item.AddressOnParent)
+		if err != nil {
+			return hardwareDisk, fmt.Errorf("invalid address of disk %s: %s", item.ElementName, item.AddressOnParent)
+		}
+		hardwareDisk.Unit = unit
+	}
+
+	if len(item.HostResource) == 0 {
+		return hardwareDisk, fmt.Errorf("disk %s has no host resource", item.ElementName)
+	}
+
+	// host resources look like: ovf:/disk/vmdisk1
+	diskID := item.HostResource[0][strings.LastIndex(item.HostResource[0], "/")+1:]
+	for _, disk := range e.Disks {
+		if disk.DiskID != diskID {
+			continue
+		}
+
+		multiplier, err := allocationUnitsBytes(disk.CapacityAllocationUnits)
+		if err != nil {
+			return hardwareDisk, err
+		}
+
+		capacity, err := strconv.ParseInt(disk.Capacity, 10, 64)
+		if err != nil {
+			return hardwareDisk, fmt.Errorf("invalid capacity of disk %s: %s", diskID, disk.Capacity)
+		}
+
+		hardwareDisk.CapacityBytes = capacity * multiplier
+		hardwareDisk.Format = disk.Format[strings.LastIndex(disk.Format, "#")+1:]
+
+		for _, file := range e.References {
+			if file.ID == disk.FileRef {
+				hardwareDisk.FileName = file.Href
+			}
+		}
+
+		if hardwareDisk.FileName == "" {
+			return hardwareDisk, fmt.Errorf("disk %s has no file", diskID)
+		}
+
+		return hardwareDisk, nil
+	}
+
+	return hardwareDisk, fmt.Errorf("disk not found: %s", diskID)
+}
+
+func controllerType(item Item) (string, error) {
+	switch item.ResourceType {
+	case RESOURCE_TYPE_SCSI_CONTROLLER:
+		switch strings.ToLower(item.ResourceSubType) {
+		case "", "lsilogic":
+			return vmx.DISK_CONTROLLER_LSILOGIC, nil
+		case "lsilogicsas":
+			return vmx.DISK_CONTROLLER_LSISAS1068, nil
+		case "virtualscsi":
+			return vmx.DISK_CONTROLLER_PVSCSI, nil
+		default:
+			return "", fmt.Errorf("unsupported scsi controller: %s", item.ResourceSubType)
+		}
+	case RESOURCE_TYPE_STORAGE_CONTROLLER:
+		switch strings.ToLower(item.ResourceSubType) {
+		case RESOURCE_SUBTYPE_SATA_CONTROLLER:
+			return vmx.DISK_CONTROLLER_SATA, nil
+		case RESOURCE_SUBTYPE_NVME_CONTROLLER:
+			return vmx.DISK_CONTROLLER_NVME, nil
+		default:
+			return "", fmt.Errorf("unsupported storage controller: %s", item.ResourceSubType)
+		}
+	default:
+		return "", fmt.Errorf("unsupported controller for disks: %s", item.ElementName)
+	}
+}
+
+func allocationUnitsBytes(units string) (int64, error) {
+	match := allocationUnitsRegexp.FindStringSubmatch(units)
+	if match == nil {
+		return 0, fmt.Errorf("unsupported allocation units: %s", units)
+	}
+
+	if match[1] == "" {
+		return 1, nil
+	}
+
+	base, _ := strconv.ParseFloat(match[1], 64)
+	exponent, _ := strconv.ParseFloat(match[2], 64)
+
+	return int64(math.Pow(base, exponent)), nil
+}
+
+// GuestOS maps a vSphere guest id (ex: ubuntu64Guest) to a VMX guestOS (ex: ubuntu-64)
+func GuestOS(osType string) string {
+	guestOS := strings.ToLower(strings.TrimSuffix(osType, "Guest"))
+
+	if strings.HasSuffix(guestOS, "64") {
+		guestOS = strings.TrimSuffix(strings.TrimSuffix(guestOS, "64"), "_") + "-64"
+	}
+
+	return guestOS
+}
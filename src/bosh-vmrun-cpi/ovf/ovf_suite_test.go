@@ -0,0 +1,13 @@
+package ovf_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestOvf(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OVF Suite")
+}
@@ -0,0 +1,132 @@
+package ovf_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-vmrun-cpi/ovf"
+)
+
+var _ = Describe("Ovf", func() {
+	var ovfContent string
+
+	BeforeEach(func() {
+		ovfBytes, err := ioutil.ReadFile(filepath.Join("..", "test", "fixtures", "image.ovf"))
+		Expect(err).ToNot(HaveOccurred())
+
+		ovfContent = string(ovfBytes)
+	})
+
+	Describe("Hardware", func() {
+		It("reads the virtual hardware of the fixture", func() {
+			envelope, err := ovf.ParseOvf([]byte(ovfContent))
+			Expect(err).ToNot(HaveOccurred())
+
+			hardware, err := envelope.Hardware()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(hardware.Name).To(Equal("test"))
+			Expect(hardware.HardwareVersion).To(Equal(9))
+			Expect(hardware.GuestOS).To(Equal("ubuntu-64"))
+			Expect(hardware.CPUs).To(Equal(1))
+			Expect(hardware.MemoryMB).To(Equal(512))
+			Expect(hardware.DiskController).To(Equal("lsilogic"))
+			Expect(hardware.Disks).To(Equal([]ovf.HardwareDisk{{
+				FileName:      "image.vmdk",
+				Format:        "streamOptimized",
+				CapacityBytes: 1024 * 1024,
+				Unit:          0,
+			}}))
+		})
+
+		It("maps the scsi controller subtype", func() {
+			ovfContent = strings.Replace(ovfContent, "<rasd:ResourceSubType>lsilogic<", "<rasd:ResourceSubType>VirtualSCSI<", 1)
+
+			envelope, err := ovf.ParseOvf([]byte(ovfContent))
+			Expect(err).ToNot(HaveOccurred())
+
+			hardware, err := envelope.Hardware()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hardware.DiskController).To(Equal("pvscsi"))
+		})
+
+		Context("when disks are on another storage controller", func() {
+			storageController := func(subType string) {
+				ovfContent = strings.Replace(ovfContent, "<rasd:ResourceSubType>lsilogic</rasd:ResourceSubType>\n        <rasd:ResourceType>6<",
+					"<rasd:ResourceSubType>"+subType+"</rasd:ResourceSubType>\n        <rasd:ResourceType>20<", 1)
+			}
+
+			It("maps sata controllers", func() {
+				storageController("vmware.sata.ahci")
+
+				envelope, err := ovf.ParseOvf([]byte(ovfContent))
+				Expect(err).ToNot(HaveOccurred())
+
+				hardware, err := envelope.Hardware()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hardware.DiskController).To(Equal("sata"))
+			})
+
+			It("maps nvme controllers", func() {
+				storageController("vmware.nvme.controller")
+
+				envelope, err := ovf.ParseOvf([]byte(ovfContent))
+				Expect(err).ToNot(HaveOccurred())
+
+				hardware, err := envelope.Hardware()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hardware.DiskController).To(Equal("nvme"))
+			})
+
+			It("returns an error for other storage controllers", func() {
+				storageController("vmware.ide.controller")
+
+				envelope, err := ovf.ParseOvf([]byte(ovfContent))
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = envelope.Hardware()
+				Expect(err).To(MatchError("unsupported storage controller: vmware.ide.controller"))
+			})
+		})
+
+		It("returns an error for a missing disk", func() {
+			ovfContent = strings.Replace(ovfContent, `ovf:diskId="vmdisk1"`, `ovf:diskId="vmdisk2"`, 1)
+
+			envelope, err := ovf.ParseOvf([]byte(ovfContent))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = envelope.Hardware()
+			Expect(err).To(MatchError("disk not found: vmdisk1"))
+		})
+
+		It("returns an error for unsupported allocation units", func() {
+			ovfContent = strings.Replace(ovfContent, `ovf:capacityAllocationUnits="byte * 2^20"`, `ovf:capacityAllocationUnits="sectors"`, 1)
+
+			envelope, err := ovf.ParseOvf([]byte(ovfContent))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = envelope.Hardware()
+			Expect(err).To(MatchError("unsupported allocation units: sectors"))
+		})
+	})
+
+	Describe("ParseOvf", func() {
+		It("returns an error for invalid xml", func() {
+			_, err := ovf.ParseOvf([]byte("<Envelope>"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("parsing ovf: "))
+		})
+	})
+
+	Describe("GuestOS", func() {
+		It("maps vsphere guest ids", func() {
+			Expect(ovf.GuestOS("ubuntu64Guest")).To(Equal("ubuntu-64"))
+			Expect(ovf.GuestOS("centos7_64Guest")).To(Equal("centos7-64"))
+			Expect(ovf.GuestOS("otherLinuxGuest")).To(Equal("otherlinux"))
+		})
+	})
+})
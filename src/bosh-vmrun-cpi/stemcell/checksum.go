@@ -0,0 +1,136 @@
+package stemcell
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	CHECKSUM_SHA1   = "sha1"
+	CHECKSUM_SHA256 = "sha256"
+)
+
+type expectedDigest struct {
+	algorithm string
+	value     string
+	hash      hash.Hash
+}
+
+// checksumVerifier hashes what is written to it and compares the result against the stemcell.MF sha1 value,
+// either a bare sha1 or sha256 hex digest or "algorithm:digest" pairs separated by ";". Algorithms other
+// than sha1 and sha256 are ignored
+type checksumVerifier struct {
+	fileName string
+	digests  []expectedDigest
+}
+
+func newChecksumVerifier(fileName string, checksum string) (*checksumVerifier, error) {
+	verifier := &checksumVerifier{fileName: fileName}
+
+	for _, digest := range strings.Split(checksum, ";") {
+		if strings.TrimSpace(digest) == "" {
+			continue
+		}
+
+		algorithm, digest := parseDigest(digest)
+
+		var digestHash hash.Hash
+		switch algorithm {
+		case CHECKSUM_SHA1:
+			digestHash = sha1.New()
+		case CHECKSUM_SHA256:
+			digestHash = sha256.New()
+		default:
+			continue
+		}
+
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != digestHash.Size()*2 {
+			return nil, fmt.Errorf("invalid %s checksum for %s: %q", algorithm, fileName, digest)
+		}
+
+		verifier.digests = append(verifier.digests, expectedDigest{algorithm: algorithm, value: digest, hash: digestHash})
+	}
+
+	if len(verifier.digests) == 0 {
+		return nil, fmt.Errorf("unsupported checksum for %s: %q", fileName, checksum)
+	}
+
+	return verifier, nil
+}
+
+// parseDigest splits a stemcell.MF digest into its algorithm and lowercase hex value, bare digests are told
+// apart by their length
+func parseDigest(digest string) (string, string) {
+	digest = strings.ToLower(strings.TrimSpace(digest))
+
+	if parts := strings.SplitN(digest, ":", 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+
+	if len(digest) == sha256.Size*2 {
+		return CHECKSUM_SHA256, digest
+	}
+
+	return CHECKSUM_SHA1, digest
+}
+
+// sha1Digest returns the sha1 hex digest of a stemcell.MF checksum, empty when it has none
+func sha1Digest(checksum string) string {
+	for _, digest := range strings.Split(checksum, ";") {
+		algorithm, value := parseDigest(digest)
+		if algorithm != CHECKSUM_SHA1 || len(value) != sha1.Size*2 {
+			continue
+		}
+
+		if _, err := hex.DecodeString(value); err == nil {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func (v *checksumVerifier) Write(p []byte) (int, error) {
+	for _, digest := range v.digests {
+		digest.hash.Write(p)
+	}
+
+	return len(p), nil
+}
+
+func (v *checksumVerifier) Verify() error {
+	for _, digest := range v.digests {
+		actual := hex.EncodeToString(digest.hash.Sum(nil))
+		if actual != digest.value {
+			return fmt.Errorf("checksum mismatch for %s: expected %s %s, got %s", v.fileName, digest.algorithm, digest.value, actual)
+		}
+	}
+
+	return nil
+}
+
+// VerifyFileChecksum compares a file against a stemcell.MF style checksum
+func VerifyFileChecksum(filePath string, checksum string) error {
+	verifier, err := newChecksumVerifier(filePath, checksum)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err = io.Copy(verifier, file); err != nil {
+		return err
+	}
+
+	return verifier.Verify()
+}
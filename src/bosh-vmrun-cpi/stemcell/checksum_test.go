@@ -0,0 +1,63 @@
+package stemcell_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-vmrun-cpi/stemcell"
+)
+
+var _ = Describe("VerifyFileChecksum", func() {
+	const (
+		imageSha1   = "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"
+		imageSha256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	)
+
+	var (
+		tempDir   string
+		imagePath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "checksum-")
+		Expect(err).ToNot(HaveOccurred())
+
+		imagePath = filepath.Join(tempDir, "image")
+		Expect(ioutil.WriteFile(imagePath, []byte("hello"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("accepts bare sha1 and sha256 digests", func() {
+		Expect(stemcell.VerifyFileChecksum(imagePath, imageSha1)).To(Succeed())
+		Expect(stemcell.VerifyFileChecksum(imagePath, imageSha256)).To(Succeed())
+	})
+
+	It("accepts multiple digests, ignoring unknown algorithms", func() {
+		Expect(stemcell.VerifyFileChecksum(imagePath, "sha1:"+imageSha1+";sha256:"+imageSha256+";sha512:abc")).To(Succeed())
+	})
+
+	It("names the corrupted file", func() {
+		Expect(ioutil.WriteFile(imagePath, []byte("hell"), 0644)).To(Succeed())
+
+		err := stemcell.VerifyFileChecksum(imagePath, "sha256:"+imageSha256)
+		Expect(err).To(MatchError(ContainSubstring("checksum mismatch for " + imagePath + ": expected sha256 " + imageSha256)))
+	})
+
+	It("fails when any digest does not match", func() {
+		err := stemcell.VerifyFileChecksum(imagePath, "sha1:"+imageSha1+";sha256:"+imageSha1+imageSha1[:24])
+		Expect(err).To(MatchError(ContainSubstring("checksum mismatch for " + imagePath)))
+	})
+
+	It("rejects checksums without a supported digest", func() {
+		Expect(stemcell.VerifyFileChecksum(imagePath, "md5:abc")).To(MatchError(ContainSubstring("unsupported checksum")))
+		Expect(stemcell.VerifyFileChecksum(imagePath, "sha1:xyz")).To(MatchError(ContainSubstring("invalid sha1 checksum")))
+	})
+})
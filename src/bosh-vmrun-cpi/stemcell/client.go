@@ -1,7 +1,17 @@
 package stemcell
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
@@ -9,6 +19,8 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+var gzipMagic = []byte{0x1f, 0x8b}
+
 type StemcellClientImpl struct {
 	fs            boshsys.FileSystem
 	logger        boshlog.Logger
@@ -17,39 +29,272 @@ type StemcellClientImpl struct {
 }
 
 func NewClient(compressor boshcmd.Compressor, fs boshsys.FileSystem, logger boshlog.Logger) StemcellClient {
-	return StemcellClientImpl{compressor: compressor, fs: fs, logger: logger}
+	return &StemcellClientImpl{compressor: compressor, fs: fs, logger: logger}
 }
 
-func (c StemcellClientImpl) ExtractOvf(stemcellTarballPath string) (string, error) {
-	var err error
+// ExtractOvf unpacks a director stemcell image, verifying it against the sha1 of the stemcell.MF the director
+// unpacked next to it. It returns the sha1 of the image hashed while it is unpacked
+func (c *StemcellClientImpl) ExtractOvf(imagePath string) (string, string, error) {
+	if !c.fs.FileExists(imagePath) {
+		return "", "", bosherr.Errorf("stemcell not found at path: %s", imagePath)
+	}
+
+	manifest, err := c.readImageManifest(imagePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	var verifier *checksumVerifier
+	if manifest != nil && manifest.Sha1 != "" {
+		verifier, err = newChecksumVerifier(imagePath, manifest.Sha1)
+		if err != nil {
+			return "", "", err
+		}
+	} else {
+		c.logger.Warn("stemcell-client", "no image checksum in stemcell manifest next to %s, skipping verification", imagePath)
+	}
+
+	imageFile, err := os.Open(imagePath)
+	if err != nil {
+		return "", "", err
+	}
+	defer imageFile.Close()
+
+	return c.extractVerifiedImage(imageFile, verifier)
+}
+
+// ExtractStemcellOvf unpacks the image of a stemcell tarball without writing the image itself to disk,
+// verifying it against the stemcell manifest sha1. It returns the sha1 of the image hashed while it is unpacked
+func (c *StemcellClientImpl) ExtractStemcellOvf(stemcellTarballPath string) (string, string, error) {
+	manifest, err := readStemcellManifest(stemcellTarballPath, c.logger)
+	if err != nil {
+		return "", "", bosherr.WrapErrorf(err, "Reading stemcell manifest of '%s'", stemcellTarballPath)
+	}
+
+	var verifier *checksumVerifier
+	if manifest != nil && manifest.Sha1 != "" {
+		verifier, err = newChecksumVerifier("image in "+stemcellTarballPath, manifest.Sha1)
+		if err != nil {
+			return "", "", err
+		}
+	} else {
+		c.logger.Warn("stemcell-client", "no image checksum in stemcell manifest of %s, skipping verification", stemcellTarballPath)
+	}
+
+	var ovfPath, imageKey string
+	err = withTarballFile(stemcellTarballPath, "image", c.logger, func(imageReader io.Reader) error {
+		ovfPath, imageKey, err = c.extractVerifiedImage(imageReader, verifier)
+		return err
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	if ovfPath == "" {
+		return "", "", bosherr.Errorf("stemcell %s does not contain an image", stemcellTarballPath)
+	}
+
+	return ovfPath, imageKey, nil
+}
+
+// ImageKey identifies a director stemcell image by the sha1 in the stemcell.MF next to it, matching the
+// StemcellImageKey of the stemcell tarball. It is empty when there is no sha1 to read, the image is then keyed
+// by the sha1 ExtractOvf computes
+func (c *StemcellClientImpl) ImageKey(imagePath string) (string, error) {
+	manifest, err := c.readImageManifest(imagePath)
+	if err != nil || manifest == nil {
+		return "", err
+	}
+
+	return manifest.ImageKey(), nil
+}
+
+// StemcellImageKey identifies the image of a stemcell tarball by the sha1 in its stemcell manifest, matching
+// the ImageKey of a director image of the same stemcell. It is empty when the manifest has no sha1
+func (c *StemcellClientImpl) StemcellImageKey(stemcellTarballPath string) (string, error) {
+	manifest, err := readStemcellManifest(stemcellTarballPath, c.logger)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Reading stemcell manifest of '%s'", stemcellTarballPath)
+	}
+
+	if manifest == nil {
+		return "", nil
+	}
+
+	return manifest.ImageKey(), nil
+}
+
+// readImageManifest reads the stemcell.MF next to a director image, nil when there is none
+func (c *StemcellClientImpl) readImageManifest(imagePath string) (*StemcellManifest, error) {
+	manifestPath := filepath.Join(filepath.Dir(imagePath), stemcellManifestFileName)
+	manifestContent, err := ioutil.ReadFile(manifestPath)
+	if os.IsNotExist(err) || (err == nil && len(manifestContent) == 0) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading stemcell manifest '%s'", manifestPath)
+	}
+
+	manifest, err := NewStemcellManifest(manifestContent)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading stemcell manifest '%s'", manifestPath)
+	}
+
+	return manifest, nil
+}
+
+// extractVerifiedImage hashes the image while it is unpacked, reading past the end of its tar archive so
+// the whole image is covered
+func (c *StemcellClientImpl) extractVerifiedImage(imageReader io.Reader, verifier *checksumVerifier) (string, string, error) {
+	imageHash := sha1.New()
+	imageReader = io.TeeReader(imageReader, imageHash)
+	if verifier != nil {
+		imageReader = io.TeeReader(imageReader, verifier)
+	}
+
+	ovfPath, err := c.extractImage(imageReader)
+	if err != nil {
+		return "", "", err
+	}
 
-	if !c.fs.FileExists(stemcellTarballPath) {
-		return "", bosherr.Errorf("stemcell not found at path: %s", stemcellTarballPath)
+	if _, err = io.Copy(ioutil.Discard, imageReader); err != nil {
+		return "", "", err
 	}
 
+	if verifier != nil {
+		if err = verifier.Verify(); err != nil {
+			c.Cleanup()
+			return "", "", err
+		}
+	}
+
+	return ovfPath, hex.EncodeToString(imageHash.Sum(nil)), nil
+}
+
+// extractImage unpacks a gzipped tar of an OVF and its disks, or an OVA (an uncompressed tar), either as the
+// image itself or inside a gzipped tar
+func (c *StemcellClientImpl) extractImage(imageReader io.Reader) (string, error) {
+	var err error
+
 	c.parentTempDir, err = c.fs.TempDir("stemcell-")
 	if err != nil {
 		return "", bosherr.WrapError(err, "creating tempdir failed")
 	}
 
-	err = c.compressor.DecompressFileToDir(stemcellTarballPath, c.parentTempDir, boshcmd.CompressorOptions{})
+	bufferedReader := bufio.NewReader(imageReader)
+	magic, err := bufferedReader.Peek(2)
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "Unpacking outer stemcell tarball '%s' to '%s'", stemcellTarballPath, c.parentTempDir)
+		return "", bosherr.WrapError(err, "Unpacking stemcell image")
 	}
 
-	matches, err := filepath.Glob(filepath.Join(c.parentTempDir, "*.ovf"))
+	if bytes.Equal(magic, gzipMagic) {
+		gzipReader, err := gzip.NewReader(bufferedReader)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Unpacking stemcell image")
+		}
+		defer gzipReader.Close()
+
+		err = c.extractTar(gzipReader, true)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		err = c.extractTar(bufferedReader, false)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	ovfPath, err := c.findOvf()
 	if err != nil {
 		return "", err
 	}
 
-	if len(matches) != 1 {
+	err = verifyOvfManifests(filepath.Dir(ovfPath), c.logger)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Verifying stemcell image manifest")
+	}
+
+	return ovfPath, nil
+}
+
+// extractTar unpacks tar entries into the temp dir, unpacking OVA entries of a gzipped image while they are
+// read so the OVA itself is not written
+func (c *StemcellClientImpl) extractTar(reader io.Reader, unpackOvas bool) error {
+	tarReader := tar.NewReader(reader)
+	for {
+		tarHeader, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return bosherr.WrapError(err, "Unpacking stemcell image")
+		}
+
+		// entries are kept inside the temp dir whatever their names
+		targetPath := filepath.Join(c.parentTempDir, filepath.Clean("/"+tarHeader.Name))
+
+		switch tarHeader.Typeflag {
+		case tar.TypeDir:
+			err = c.fs.MkdirAll(targetPath, 0755)
+		case tar.TypeReg:
+			if unpackOvas && strings.EqualFold(filepath.Ext(tarHeader.Name), ".ova") {
+				c.logger.Debug("stemcell-client", "unpacking ova %s", tarHeader.Name)
+				err = c.extractTar(tarReader, false)
+			} else {
+				err = c.extractFile(targetPath, tarReader)
+			}
+		default:
+			c.logger.Warn("stemcell-client", "skipping stemcell image entry %s of type %c", tarHeader.Name, tarHeader.Typeflag)
+		}
+
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Unpacking stemcell image to '%s'", c.parentTempDir)
+		}
+	}
+}
+
+func (c *StemcellClientImpl) findOvf() (string, error) {
+	ovfPaths, err := filepath.Glob(filepath.Join(c.parentTempDir, "*.ovf"))
+	if err != nil {
+		return "", err
+	}
+
+	if len(ovfPaths) != 1 {
 		return "", bosherr.Error("stemcell does not contain a single ovf")
 	}
 
-	return matches[0], nil
+	return ovfPaths[0], nil
+}
+
+func (c *StemcellClientImpl) extractFile(targetPath string, reader io.Reader) error {
+	err := c.fs.MkdirAll(filepath.Dir(targetPath), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := c.fs.OpenFile(targetPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	bytesWritten, err := io.Copy(file, reader)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Debug("stemcell-client", "extracted %s: %d bytes", strings.TrimPrefix(targetPath, c.parentTempDir), bytesWritten)
+
+	return nil
 }
 
-func (c StemcellClientImpl) Cleanup() {
+func (c *StemcellClientImpl) Cleanup() {
+	if c.parentTempDir == "" {
+		return
+	}
+
 	err := c.fs.RemoveAll(c.parentTempDir)
 	if err != nil {
 		c.logger.Error("stemcell-client", "Cleaning up stemcell temp dir '%s'", c.parentTempDir)
@@ -0,0 +1,337 @@
+package stemcell_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	"bosh-vmrun-cpi/stemcell"
+)
+
+var _ = Describe("StemcellClient", func() {
+	var (
+		tempDir     string
+		fs          *diskUsageFileSystem
+		client      stemcell.StemcellClient
+		vmdkContent []byte
+		image       []byte
+		tarballPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tempDir, err = ioutil.TempDir("", "stemcell-client-")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := &fakelogger.FakeLogger{}
+		osFs := boshsys.NewOsFileSystem(logger)
+		Expect(osFs.ChangeTempRoot(filepath.Join(tempDir, "tmp"))).To(Succeed())
+		fs = &diskUsageFileSystem{FileSystem: osFs}
+
+		client = stemcell.NewClient(boshcmd.NewTarballCompressor(boshsys.NewExecCmdRunner(logger), osFs), fs, logger)
+
+		vmdkContent = make([]byte, 4*1024*1024)
+		rand.New(rand.NewSource(1)).Read(vmdkContent)
+		image = stemcellImage(map[string][]byte{
+			"image.ovf":        []byte("<Envelope/>"),
+			"image-disk1.vmdk": vmdkContent,
+		})
+
+		tarballPath = filepath.Join(tempDir, "stemcell.tgz")
+	})
+
+	AfterEach(func() {
+		client.Cleanup()
+		os.RemoveAll(tempDir)
+	})
+
+	Describe("ExtractStemcellOvf", func() {
+		It("streams the image into a single extracted location", func() {
+			writeStemcellTarballWithImage(tarballPath, fmt.Sprintf("name: stemcell-a\nversion: \"1\"\nsha1: %x", sha1.Sum(image)), image)
+
+			ovfPath, _, err := client.ExtractStemcellOvf(tarballPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Base(ovfPath)).To(Equal("image.ovf"))
+
+			extractedVmdk, err := ioutil.ReadFile(filepath.Join(filepath.Dir(ovfPath), "image-disk1.vmdk"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(extractedVmdk).To(Equal(vmdkContent))
+
+			Expect(fs.peakBytes).To(Equal(int64(len(vmdkContent) + len("<Envelope/>"))))
+		})
+
+		It("fails naming the tarball when the image is corrupted, removing what was extracted", func() {
+			writeStemcellTarballWithImage(tarballPath, "name: stemcell-a\nversion: \"1\"\nsha1: da39a3ee5e6b4b0d3255bfef95601890afd80709", image)
+
+			_, _, err := client.ExtractStemcellOvf(tarballPath)
+			Expect(err).To(MatchError(ContainSubstring("checksum mismatch for image in " + tarballPath)))
+
+			extractedFiles, err := filepath.Glob(filepath.Join(tempDir, "tmp", "*", "*"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(extractedFiles).To(BeEmpty())
+		})
+
+		It("keeps image entries inside the extracted location", func() {
+			image = stemcellImage(map[string][]byte{
+				"image.ovf":     []byte("<Envelope/>"),
+				"../escape.txt": []byte("escaped"),
+			})
+			writeStemcellTarballWithImage(tarballPath, "name: stemcell-a\nversion: \"1\"", image)
+
+			ovfPath, _, err := client.ExtractStemcellOvf(tarballPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Join(filepath.Dir(ovfPath), "escape.txt")).To(BeAnExistingFile())
+			Expect(filepath.Join(tempDir, "tmp", "escape.txt")).ToNot(BeAnExistingFile())
+		})
+	})
+
+	Describe("OVA images", func() {
+		var ova []byte
+
+		BeforeEach(func() {
+			ovfContent := []byte("<Envelope/>")
+			ova = ovaImage(map[string][]byte{
+				"image.ovf":        ovfContent,
+				"image-disk1.vmdk": vmdkContent,
+				"image.mf":         []byte(fmt.Sprintf("SHA256(image.ovf)= %x\nSHA1(image-disk1.vmdk)= %x\n", sha256.Sum256(ovfContent), sha1.Sum(vmdkContent))),
+			})
+		})
+
+		It("extracts OVA images, verifying their manifest", func() {
+			writeStemcellTarballWithImage(tarballPath, "name: stemcell-a\nversion: \"1\"", ova)
+
+			ovfPath, _, err := client.ExtractStemcellOvf(tarballPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Base(ovfPath)).To(Equal("image.ovf"))
+
+			extractedVmdk, err := ioutil.ReadFile(filepath.Join(filepath.Dir(ovfPath), "image-disk1.vmdk"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(extractedVmdk).To(Equal(vmdkContent))
+		})
+
+		It("streams an OVA inside a gzipped image without writing the OVA", func() {
+			image = stemcellImage(map[string][]byte{"image.ova": ova})
+			writeStemcellTarballWithImage(tarballPath, "name: stemcell-a\nversion: \"1\"", image)
+
+			ovfPath, _, err := client.ExtractStemcellOvf(tarballPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Base(ovfPath)).To(Equal("image.ovf"))
+			Expect(filepath.Join(filepath.Dir(ovfPath), "image.ova")).ToNot(BeAnExistingFile())
+
+			Expect(fs.peakBytes).To(BeNumerically("<", len(ova)))
+		})
+
+		It("fails when a file does not match the manifest", func() {
+			ova = ovaImage(map[string][]byte{
+				"image.ovf":        []byte("<Envelope/>"),
+				"image-disk1.vmdk": vmdkContent,
+				"image.mf":         []byte("SHA1(image-disk1.vmdk)= da39a3ee5e6b4b0d3255bfef95601890afd80709\n"),
+			})
+			writeStemcellTarballWithImage(tarballPath, "name: stemcell-a\nversion: \"1\"", ova)
+
+			_, _, err := client.ExtractStemcellOvf(tarballPath)
+			Expect(err).To(MatchError(ContainSubstring("checksum mismatch for ")))
+			Expect(err).To(MatchError(ContainSubstring("image-disk1.vmdk")))
+		})
+
+		It("fails when the manifest names a missing file", func() {
+			ova = ovaImage(map[string][]byte{
+				"image.ovf": []byte("<Envelope/>"),
+				"image.mf":  []byte("SHA1(image-disk1.vmdk)= da39a3ee5e6b4b0d3255bfef95601890afd80709\n"),
+			})
+			writeStemcellTarballWithImage(tarballPath, "name: stemcell-a\nversion: \"1\"", ova)
+
+			_, _, err := client.ExtractStemcellOvf(tarballPath)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ImageKey", func() {
+		var imagePath string
+
+		BeforeEach(func() {
+			imagePath = filepath.Join(tempDir, "image")
+			Expect(ioutil.WriteFile(imagePath, image, 0644)).To(Succeed())
+		})
+
+		It("is the image sha1 of the stemcell manifest next to a director image", func() {
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, "stemcell.MF"), []byte(fmt.Sprintf("name: stemcell-a\nversion: \"1\"\nsha1: sha256:%x;sha1:%X", sha256.Sum256(image), sha1.Sum(image))), 0644)).To(Succeed())
+
+			imageKey, err := client.ImageKey(imagePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageKey).To(Equal(fmt.Sprintf("%x", sha1.Sum(image))))
+		})
+
+		It("is empty without a stemcell manifest next to the image", func() {
+			imageKey, err := client.ImageKey(imagePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageKey).To(BeEmpty())
+		})
+	})
+
+	Describe("StemcellImageKey", func() {
+		It("is the image sha1 of the stemcell manifest", func() {
+			writeStemcellTarballWithImage(tarballPath, fmt.Sprintf("name: stemcell-a\nversion: \"1\"\nsha1: %X", sha1.Sum(image)), image)
+
+			imageKey, err := client.StemcellImageKey(tarballPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageKey).To(Equal(fmt.Sprintf("%x", sha1.Sum(image))))
+		})
+
+		It("takes the sha1 digest out of multi-digest checksums", func() {
+			writeStemcellTarballWithImage(tarballPath, fmt.Sprintf("name: stemcell-a\nversion: \"1\"\nsha1: sha256:%x;sha1:%x", sha256.Sum256(image), sha1.Sum(image)), image)
+
+			imageKey, err := client.StemcellImageKey(tarballPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageKey).To(Equal(fmt.Sprintf("%x", sha1.Sum(image))))
+		})
+
+		It("is empty with only a sha256 digest", func() {
+			writeStemcellTarballWithImage(tarballPath, fmt.Sprintf("name: stemcell-a\nversion: \"1\"\nsha1: %x", sha256.Sum256(image)), image)
+
+			imageKey, err := client.StemcellImageKey(tarballPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageKey).To(BeEmpty())
+		})
+
+		It("is empty without an image sha1", func() {
+			writeStemcellTarballWithImage(tarballPath, "name: stemcell-a\nversion: \"1\"", image)
+
+			imageKey, err := client.StemcellImageKey(tarballPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageKey).To(BeEmpty())
+		})
+	})
+
+	Describe("ExtractOvf", func() {
+		var imagePath string
+
+		BeforeEach(func() {
+			imagePath = filepath.Join(tempDir, "image")
+			Expect(ioutil.WriteFile(imagePath, image, 0644)).To(Succeed())
+		})
+
+		writeManifest := func(content string) {
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, "stemcell.MF"), []byte(content), 0644)).To(Succeed())
+		}
+
+		It("extracts director images without copying them", func() {
+			writeManifest(fmt.Sprintf("name: stemcell-a\nversion: \"1\"\nsha1: sha256:%x", sha256.Sum256(image)))
+
+			ovfPath, _, err := client.ExtractOvf(imagePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Base(ovfPath)).To(Equal("image.ovf"))
+
+			Expect(fs.peakBytes).To(Equal(int64(len(vmdkContent) + len("<Envelope/>"))))
+		})
+
+		It("fails naming the image when it does not match the stemcell manifest next to it", func() {
+			writeManifest("name: stemcell-a\nversion: \"1\"\nsha1: da39a3ee5e6b4b0d3255bfef95601890afd80709")
+
+			_, _, err := client.ExtractOvf(imagePath)
+			Expect(err).To(MatchError(ContainSubstring("checksum mismatch for " + imagePath)))
+		})
+
+		It("extracts images without a stemcell manifest next to them, keying them by their sha1", func() {
+			ovfPath, imageKey, err := client.ExtractOvf(imagePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Base(ovfPath)).To(Equal("image.ovf"))
+			Expect(imageKey).To(Equal(fmt.Sprintf("%x", sha1.Sum(image))))
+		})
+	})
+})
+
+func stemcellImage(files map[string][]byte) []byte {
+	var imageBuffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&imageBuffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for name, content := range files {
+		Expect(tarWriter.WriteHeader(&tar.Header{Name: name, Size: int64(len(content)), Mode: 0644, Typeflag: tar.TypeReg})).To(Succeed())
+		_, err := tarWriter.Write(content)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	Expect(tarWriter.Close()).To(Succeed())
+	Expect(gzipWriter.Close()).To(Succeed())
+
+	return imageBuffer.Bytes()
+}
+
+func ovaImage(files map[string][]byte) []byte {
+	var imageBuffer bytes.Buffer
+	tarWriter := tar.NewWriter(&imageBuffer)
+
+	for _, name := range []string{"image.ovf", "image.mf", "image-disk1.vmdk"} {
+		content, found := files[name]
+		if !found {
+			continue
+		}
+
+		Expect(tarWriter.WriteHeader(&tar.Header{Name: name, Size: int64(len(content)), Mode: 0644, Typeflag: tar.TypeReg})).To(Succeed())
+		_, err := tarWriter.Write(content)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	Expect(tarWriter.Close()).To(Succeed())
+
+	return imageBuffer.Bytes()
+}
+
+// diskUsageFileSystem tracks bytes written through it to measure the peak disk usage of an extraction
+type diskUsageFileSystem struct {
+	boshsys.FileSystem
+	currentBytes int64
+	peakBytes    int64
+}
+
+func (f *diskUsageFileSystem) OpenFile(path string, flag int, perm os.FileMode) (boshsys.File, error) {
+	file, err := f.FileSystem.OpenFile(path, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &diskUsageFile{File: file, fs: f}, nil
+}
+
+func (f *diskUsageFileSystem) RemoveAll(path string) error {
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			f.currentBytes -= info.Size()
+		}
+		return nil
+	})
+
+	return f.FileSystem.RemoveAll(path)
+}
+
+type diskUsageFile struct {
+	boshsys.File
+	fs *diskUsageFileSystem
+}
+
+func (f *diskUsageFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+
+	f.fs.currentBytes += int64(n)
+	if f.fs.currentBytes > f.fs.peakBytes {
+		f.fs.peakBytes = f.fs.currentBytes
+	}
+
+	return n, err
+}
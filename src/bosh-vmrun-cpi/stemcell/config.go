@@ -15,3 +15,7 @@ func NewConfig(cpiConfig cpiconfig.Config) Config {
 func (c ConfigImpl) StemcellStorePath() string {
 	return c.cpiConfig.Cloud.Properties.Vmrun.Stemcell_Store_Path
 }
+
+func (c ConfigImpl) StemcellMirrorURLs() []string {
+	return c.cpiConfig.Cloud.Properties.Vmrun.Stemcell_Mirror_Urls
+}
@@ -11,16 +11,59 @@ type FakeStemcellClient struct {
 	cleanupMutex       sync.RWMutex
 	cleanupArgsForCall []struct {
 	}
-	ExtractOvfStub        func(string) (string, error)
+	ExtractOvfStub        func(string) (string, string, error)
 	extractOvfMutex       sync.RWMutex
 	extractOvfArgsForCall []struct {
 		arg1 string
 	}
 	extractOvfReturns struct {
 		result1 string
-		result2 error
+		result2 string
+		result3 error
 	}
 	extractOvfReturnsOnCall map[int]struct {
+		result1 string
+		result2 string
+		result3 error
+	}
+	ExtractStemcellOvfStub        func(string) (string, string, error)
+	extractStemcellOvfMutex       sync.RWMutex
+	extractStemcellOvfArgsForCall []struct {
+		arg1 string
+	}
+	extractStemcellOvfReturns struct {
+		result1 string
+		result2 string
+		result3 error
+	}
+	extractStemcellOvfReturnsOnCall map[int]struct {
+		result1 string
+		result2 string
+		result3 error
+	}
+	ImageKeyStub        func(string) (string, error)
+	imageKeyMutex       sync.RWMutex
+	imageKeyArgsForCall []struct {
+		arg1 string
+	}
+	imageKeyReturns struct {
+		result1 string
+		result2 error
+	}
+	imageKeyReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	StemcellImageKeyStub        func(string) (string, error)
+	stemcellImageKeyMutex       sync.RWMutex
+	stemcellImageKeyArgsForCall []struct {
+		arg1 string
+	}
+	stemcellImageKeyReturns struct {
+		result1 string
+		result2 error
+	}
+	stemcellImageKeyReturnsOnCall map[int]struct {
 		result1 string
 		result2 error
 	}
@@ -51,7 +94,7 @@ func (fake *FakeStemcellClient) CleanupCalls(stub func()) {
 	fake.CleanupStub = stub
 }
 
-func (fake *FakeStemcellClient) ExtractOvf(arg1 string) (string, error) {
+func (fake *FakeStemcellClient) ExtractOvf(arg1 string) (string, string, error) {
 	fake.extractOvfMutex.Lock()
 	ret, specificReturn := fake.extractOvfReturnsOnCall[len(fake.extractOvfArgsForCall)]
 	fake.extractOvfArgsForCall = append(fake.extractOvfArgsForCall, struct {
@@ -63,10 +106,10 @@ func (fake *FakeStemcellClient) ExtractOvf(arg1 string) (string, error) {
 		return fake.ExtractOvfStub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1, ret.result2, ret.result3
 	}
 	fakeReturns := fake.extractOvfReturns
-	return fakeReturns.result1, fakeReturns.result2
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
 }
 
 func (fake *FakeStemcellClient) ExtractOvfCallCount() int {
@@ -75,7 +118,7 @@ func (fake *FakeStemcellClient) ExtractOvfCallCount() int {
 	return len(fake.extractOvfArgsForCall)
 }
 
-func (fake *FakeStemcellClient) ExtractOvfCalls(stub func(string) (string, error)) {
+func (fake *FakeStemcellClient) ExtractOvfCalls(stub func(string) (string, string, error)) {
 	fake.extractOvfMutex.Lock()
 	defer fake.extractOvfMutex.Unlock()
 	fake.ExtractOvfStub = stub
@@ -88,27 +131,222 @@ func (fake *FakeStemcellClient) ExtractOvfArgsForCall(i int) string {
 	return argsForCall.arg1
 }
 
-func (fake *FakeStemcellClient) ExtractOvfReturns(result1 string, result2 error) {
+func (fake *FakeStemcellClient) ExtractOvfReturns(result1 string, result2 string, result3 error) {
 	fake.extractOvfMutex.Lock()
 	defer fake.extractOvfMutex.Unlock()
 	fake.ExtractOvfStub = nil
 	fake.extractOvfReturns = struct {
 		result1 string
-		result2 error
-	}{result1, result2}
+		result2 string
+		result3 error
+	}{result1, result2, result3}
 }
 
-func (fake *FakeStemcellClient) ExtractOvfReturnsOnCall(i int, result1 string, result2 error) {
+func (fake *FakeStemcellClient) ExtractOvfReturnsOnCall(i int, result1 string, result2 string, result3 error) {
 	fake.extractOvfMutex.Lock()
 	defer fake.extractOvfMutex.Unlock()
 	fake.ExtractOvfStub = nil
 	if fake.extractOvfReturnsOnCall == nil {
 		fake.extractOvfReturnsOnCall = make(map[int]struct {
 			result1 string
-			result2 error
+			result2 string
+			result3 error
 		})
 	}
 	fake.extractOvfReturnsOnCall[i] = struct {
+		result1 string
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeStemcellClient) ExtractStemcellOvf(arg1 string) (string, string, error) {
+	fake.extractStemcellOvfMutex.Lock()
+	ret, specificReturn := fake.extractStemcellOvfReturnsOnCall[len(fake.extractStemcellOvfArgsForCall)]
+	fake.extractStemcellOvfArgsForCall = append(fake.extractStemcellOvfArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ExtractStemcellOvf", []interface{}{arg1})
+	fake.extractStemcellOvfMutex.Unlock()
+	if fake.ExtractStemcellOvfStub != nil {
+		return fake.ExtractStemcellOvfStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	fakeReturns := fake.extractStemcellOvfReturns
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeStemcellClient) ExtractStemcellOvfCallCount() int {
+	fake.extractStemcellOvfMutex.RLock()
+	defer fake.extractStemcellOvfMutex.RUnlock()
+	return len(fake.extractStemcellOvfArgsForCall)
+}
+
+func (fake *FakeStemcellClient) ExtractStemcellOvfCalls(stub func(string) (string, string, error)) {
+	fake.extractStemcellOvfMutex.Lock()
+	defer fake.extractStemcellOvfMutex.Unlock()
+	fake.ExtractStemcellOvfStub = stub
+}
+
+func (fake *FakeStemcellClient) ExtractStemcellOvfArgsForCall(i int) string {
+	fake.extractStemcellOvfMutex.RLock()
+	defer fake.extractStemcellOvfMutex.RUnlock()
+	argsForCall := fake.extractStemcellOvfArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStemcellClient) ExtractStemcellOvfReturns(result1 string, result2 string, result3 error) {
+	fake.extractStemcellOvfMutex.Lock()
+	defer fake.extractStemcellOvfMutex.Unlock()
+	fake.ExtractStemcellOvfStub = nil
+	fake.extractStemcellOvfReturns = struct {
+		result1 string
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeStemcellClient) ExtractStemcellOvfReturnsOnCall(i int, result1 string, result2 string, result3 error) {
+	fake.extractStemcellOvfMutex.Lock()
+	defer fake.extractStemcellOvfMutex.Unlock()
+	fake.ExtractStemcellOvfStub = nil
+	if fake.extractStemcellOvfReturnsOnCall == nil {
+		fake.extractStemcellOvfReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 string
+			result3 error
+		})
+	}
+	fake.extractStemcellOvfReturnsOnCall[i] = struct {
+		result1 string
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeStemcellClient) ImageKey(arg1 string) (string, error) {
+	fake.imageKeyMutex.Lock()
+	ret, specificReturn := fake.imageKeyReturnsOnCall[len(fake.imageKeyArgsForCall)]
+	fake.imageKeyArgsForCall = append(fake.imageKeyArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ImageKey", []interface{}{arg1})
+	fake.imageKeyMutex.Unlock()
+	if fake.ImageKeyStub != nil {
+		return fake.ImageKeyStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.imageKeyReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStemcellClient) ImageKeyCallCount() int {
+	fake.imageKeyMutex.RLock()
+	defer fake.imageKeyMutex.RUnlock()
+	return len(fake.imageKeyArgsForCall)
+}
+
+func (fake *FakeStemcellClient) ImageKeyCalls(stub func(string) (string, error)) {
+	fake.imageKeyMutex.Lock()
+	defer fake.imageKeyMutex.Unlock()
+	fake.ImageKeyStub = stub
+}
+
+func (fake *FakeStemcellClient) ImageKeyArgsForCall(i int) string {
+	fake.imageKeyMutex.RLock()
+	defer fake.imageKeyMutex.RUnlock()
+	argsForCall := fake.imageKeyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStemcellClient) ImageKeyReturns(result1 string, result2 error) {
+	fake.imageKeyMutex.Lock()
+	defer fake.imageKeyMutex.Unlock()
+	fake.ImageKeyStub = nil
+	fake.imageKeyReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStemcellClient) ImageKeyReturnsOnCall(i int, result1 string, result2 error) {
+	fake.imageKeyMutex.Lock()
+	defer fake.imageKeyMutex.Unlock()
+	fake.ImageKeyStub = nil
+	if fake.imageKeyReturnsOnCall == nil {
+		fake.imageKeyReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.imageKeyReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStemcellClient) StemcellImageKey(arg1 string) (string, error) {
+	fake.stemcellImageKeyMutex.Lock()
+	ret, specificReturn := fake.stemcellImageKeyReturnsOnCall[len(fake.stemcellImageKeyArgsForCall)]
+	fake.stemcellImageKeyArgsForCall = append(fake.stemcellImageKeyArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("StemcellImageKey", []interface{}{arg1})
+	fake.stemcellImageKeyMutex.Unlock()
+	if fake.StemcellImageKeyStub != nil {
+		return fake.StemcellImageKeyStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.stemcellImageKeyReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStemcellClient) StemcellImageKeyCallCount() int {
+	fake.stemcellImageKeyMutex.RLock()
+	defer fake.stemcellImageKeyMutex.RUnlock()
+	return len(fake.stemcellImageKeyArgsForCall)
+}
+
+func (fake *FakeStemcellClient) StemcellImageKeyCalls(stub func(string) (string, error)) {
+	fake.stemcellImageKeyMutex.Lock()
+	defer fake.stemcellImageKeyMutex.Unlock()
+	fake.StemcellImageKeyStub = stub
+}
+
+func (fake *FakeStemcellClient) StemcellImageKeyArgsForCall(i int) string {
+	fake.stemcellImageKeyMutex.RLock()
+	defer fake.stemcellImageKeyMutex.RUnlock()
+	argsForCall := fake.stemcellImageKeyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStemcellClient) StemcellImageKeyReturns(result1 string, result2 error) {
+	fake.stemcellImageKeyMutex.Lock()
+	defer fake.stemcellImageKeyMutex.Unlock()
+	fake.StemcellImageKeyStub = nil
+	fake.stemcellImageKeyReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStemcellClient) StemcellImageKeyReturnsOnCall(i int, result1 string, result2 error) {
+	fake.stemcellImageKeyMutex.Lock()
+	defer fake.stemcellImageKeyMutex.Unlock()
+	fake.StemcellImageKeyStub = nil
+	if fake.stemcellImageKeyReturnsOnCall == nil {
+		fake.stemcellImageKeyReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.stemcellImageKeyReturnsOnCall[i] = struct {
 		result1 string
 		result2 error
 	}{result1, result2}
@@ -121,6 +359,12 @@ func (fake *FakeStemcellClient) Invocations() map[string][][]interface{} {
 	defer fake.cleanupMutex.RUnlock()
 	fake.extractOvfMutex.RLock()
 	defer fake.extractOvfMutex.RUnlock()
+	fake.extractStemcellOvfMutex.RLock()
+	defer fake.extractStemcellOvfMutex.RUnlock()
+	fake.imageKeyMutex.RLock()
+	defer fake.imageKeyMutex.RUnlock()
+	fake.stemcellImageKeyMutex.RLock()
+	defer fake.stemcellImageKeyMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -7,6 +7,16 @@ import (
 )
 
 type FakeConfig struct {
+	StemcellMirrorURLsStub        func() []string
+	stemcellMirrorURLsMutex       sync.RWMutex
+	stemcellMirrorURLsArgsForCall []struct {
+	}
+	stemcellMirrorURLsReturns struct {
+		result1 []string
+	}
+	stemcellMirrorURLsReturnsOnCall map[int]struct {
+		result1 []string
+	}
 	StemcellStorePathStub        func() string
 	stemcellStorePathMutex       sync.RWMutex
 	stemcellStorePathArgsForCall []struct {
@@ -21,6 +31,58 @@ type FakeConfig struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeConfig) StemcellMirrorURLs() []string {
+	fake.stemcellMirrorURLsMutex.Lock()
+	ret, specificReturn := fake.stemcellMirrorURLsReturnsOnCall[len(fake.stemcellMirrorURLsArgsForCall)]
+	fake.stemcellMirrorURLsArgsForCall = append(fake.stemcellMirrorURLsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("StemcellMirrorURLs", []interface{}{})
+	fake.stemcellMirrorURLsMutex.Unlock()
+	if fake.StemcellMirrorURLsStub != nil {
+		return fake.StemcellMirrorURLsStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.stemcellMirrorURLsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeConfig) StemcellMirrorURLsCallCount() int {
+	fake.stemcellMirrorURLsMutex.RLock()
+	defer fake.stemcellMirrorURLsMutex.RUnlock()
+	return len(fake.stemcellMirrorURLsArgsForCall)
+}
+
+func (fake *FakeConfig) StemcellMirrorURLsCalls(stub func() []string) {
+	fake.stemcellMirrorURLsMutex.Lock()
+	defer fake.stemcellMirrorURLsMutex.Unlock()
+	fake.StemcellMirrorURLsStub = stub
+}
+
+func (fake *FakeConfig) StemcellMirrorURLsReturns(result1 []string) {
+	fake.stemcellMirrorURLsMutex.Lock()
+	defer fake.stemcellMirrorURLsMutex.Unlock()
+	fake.StemcellMirrorURLsStub = nil
+	fake.stemcellMirrorURLsReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeConfig) StemcellMirrorURLsReturnsOnCall(i int, result1 []string) {
+	fake.stemcellMirrorURLsMutex.Lock()
+	defer fake.stemcellMirrorURLsMutex.Unlock()
+	fake.StemcellMirrorURLsStub = nil
+	if fake.stemcellMirrorURLsReturnsOnCall == nil {
+		fake.stemcellMirrorURLsReturnsOnCall = make(map[int]struct {
+			result1 []string
+		})
+	}
+	fake.stemcellMirrorURLsReturnsOnCall[i] = struct {
+		result1 []string
+	}{result1}
+}
+
 func (fake *FakeConfig) StemcellStorePath() string {
 	fake.stemcellStorePathMutex.Lock()
 	ret, specificReturn := fake.stemcellStorePathReturnsOnCall[len(fake.stemcellStorePathArgsForCall)]
@@ -76,6 +138,8 @@ func (fake *FakeConfig) StemcellStorePathReturnsOnCall(i int, result1 string) {
 func (fake *FakeConfig) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.stemcellMirrorURLsMutex.RLock()
+	defer fake.stemcellMirrorURLsMutex.RUnlock()
 	fake.stemcellStorePathMutex.RLock()
 	defer fake.stemcellStorePathMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -7,9 +7,19 @@ import (
 )
 
 type FakeStemcellStore struct {
-	CleanupStub        func()
-	cleanupMutex       sync.RWMutex
-	cleanupArgsForCall []struct {
+	GarbageCollectStub        func(stemcell.RetentionPolicy, bool) ([]string, error)
+	garbageCollectMutex       sync.RWMutex
+	garbageCollectArgsForCall []struct {
+		arg1 stemcell.RetentionPolicy
+		arg2 bool
+	}
+	garbageCollectReturns struct {
+		result1 []string
+		result2 error
+	}
+	garbageCollectReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
 	}
 	GetByImagePathMappingStub        func(string) (string, error)
 	getByImagePathMappingMutex       sync.RWMutex
@@ -42,27 +52,68 @@ type FakeStemcellStore struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeStemcellStore) Cleanup() {
-	fake.cleanupMutex.Lock()
-	fake.cleanupArgsForCall = append(fake.cleanupArgsForCall, struct {
-	}{})
-	fake.recordInvocation("Cleanup", []interface{}{})
-	fake.cleanupMutex.Unlock()
-	if fake.CleanupStub != nil {
-		fake.CleanupStub()
+func (fake *FakeStemcellStore) GarbageCollect(arg1 stemcell.RetentionPolicy, arg2 bool) ([]string, error) {
+	fake.garbageCollectMutex.Lock()
+	ret, specificReturn := fake.garbageCollectReturnsOnCall[len(fake.garbageCollectArgsForCall)]
+	fake.garbageCollectArgsForCall = append(fake.garbageCollectArgsForCall, struct {
+		arg1 stemcell.RetentionPolicy
+		arg2 bool
+	}{arg1, arg2})
+	fake.recordInvocation("GarbageCollect", []interface{}{arg1, arg2})
+	fake.garbageCollectMutex.Unlock()
+	if fake.GarbageCollectStub != nil {
+		return fake.GarbageCollectStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
 	}
+	fakeReturns := fake.garbageCollectReturns
+	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeStemcellStore) CleanupCallCount() int {
-	fake.cleanupMutex.RLock()
-	defer fake.cleanupMutex.RUnlock()
-	return len(fake.cleanupArgsForCall)
+func (fake *FakeStemcellStore) GarbageCollectCallCount() int {
+	fake.garbageCollectMutex.RLock()
+	defer fake.garbageCollectMutex.RUnlock()
+	return len(fake.garbageCollectArgsForCall)
 }
 
-func (fake *FakeStemcellStore) CleanupCalls(stub func()) {
-	fake.cleanupMutex.Lock()
-	defer fake.cleanupMutex.Unlock()
-	fake.CleanupStub = stub
+func (fake *FakeStemcellStore) GarbageCollectCalls(stub func(stemcell.RetentionPolicy, bool) ([]string, error)) {
+	fake.garbageCollectMutex.Lock()
+	defer fake.garbageCollectMutex.Unlock()
+	fake.GarbageCollectStub = stub
+}
+
+func (fake *FakeStemcellStore) GarbageCollectArgsForCall(i int) (stemcell.RetentionPolicy, bool) {
+	fake.garbageCollectMutex.RLock()
+	defer fake.garbageCollectMutex.RUnlock()
+	argsForCall := fake.garbageCollectArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeStemcellStore) GarbageCollectReturns(result1 []string, result2 error) {
+	fake.garbageCollectMutex.Lock()
+	defer fake.garbageCollectMutex.Unlock()
+	fake.GarbageCollectStub = nil
+	fake.garbageCollectReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStemcellStore) GarbageCollectReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.garbageCollectMutex.Lock()
+	defer fake.garbageCollectMutex.Unlock()
+	fake.GarbageCollectStub = nil
+	if fake.garbageCollectReturnsOnCall == nil {
+		fake.garbageCollectReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.garbageCollectReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *FakeStemcellStore) GetByImagePathMapping(arg1 string) (string, error) {
@@ -195,8 +246,8 @@ func (fake *FakeStemcellStore) GetByMetadataReturnsOnCall(i int, result1 string,
 func (fake *FakeStemcellStore) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.cleanupMutex.RLock()
-	defer fake.cleanupMutex.RUnlock()
+	fake.garbageCollectMutex.RLock()
+	defer fake.garbageCollectMutex.RUnlock()
 	fake.getByImagePathMappingMutex.RLock()
 	defer fake.getByImagePathMappingMutex.RUnlock()
 	fake.getByMetadataMutex.RLock()
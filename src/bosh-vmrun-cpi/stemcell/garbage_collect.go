@@ -0,0 +1,208 @@
+package stemcell
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy decides which stemcell tarballs of the store are kept. Tarballs beyond the newest
+// KeepVersions versions of a stemcell name are removed, as are tarballs that are not referenced and that
+// have not changed or been handed out for MaxUnusedAge. A zero value disables that rule. Tarballs are referenced by mappings and
+// by ImportedImageKeys, the image keys of imported stemcell VMs (ex: of light stemcells)
+type RetentionPolicy struct {
+	KeepVersions      int
+	MaxUnusedAge      time.Duration
+	ImportedImageKeys []string
+}
+
+// GarbageCollect removes stemcell tarballs and mappings outside of the retention policy and returns their
+// paths. Mappings are kept for MaxUnusedAge since they were written or handed out, mappings of missing tarballs are always removed. With dryRun
+// nothing is removed. The index lock is held for the whole pass
+func (s stemcellStoreImpl) GarbageCollect(policy RetentionPolicy, dryRun bool) ([]string, error) {
+	if policy.KeepVersions <= 0 && policy.MaxUnusedAge <= 0 {
+		return nil, errors.New("retention policy requires keep versions or max unused age")
+	}
+
+	if !s.fs.FileExists(s.storePath) {
+		s.logger.Debug("stemcell-store", "stemcell store %s does not exist", s.storePath)
+		return nil, nil
+	}
+
+	var removePaths []string
+	err := s.retryFileLock.Try(s.indexPath()+".lock", indexLockMaxWait, func() error {
+		index, err := s.readIndex()
+		if err != nil {
+			return err
+		}
+
+		newEntries, err := s.newIndexEntries(index)
+		if err != nil {
+			return err
+		}
+
+		index, err = s.refreshIndex(newEntries)
+		if err != nil {
+			return err
+		}
+
+		removePaths, err = s.garbageCollectIndex(index, policy, dryRun)
+		return err
+	})
+
+	return removePaths, err
+}
+
+func (s stemcellStoreImpl) garbageCollectIndex(index stemcellIndex, policy RetentionPolicy, dryRun bool) ([]string, error) {
+	now := time.Now()
+	removePaths := []string{}
+
+	mappingPaths, err := s.fs.Glob(filepath.Join(s.storePath, "mappings", "*.mapping"))
+	if err != nil {
+		return nil, err
+	}
+
+	storedTarballPaths := map[string]bool{}
+	for _, entry := range index.Stemcells {
+		storedTarballPaths[filepath.Clean(entry.Path)] = true
+	}
+
+	referencedTarballPaths := map[string]bool{}
+	for _, mappingPath := range mappingPaths {
+		fileInfo, err := os.Stat(mappingPath)
+		if err != nil {
+			return nil, err
+		}
+
+		stemcellTarballPathBytes, err := ioutil.ReadFile(mappingPath)
+		if err != nil {
+			return nil, err
+		}
+		stemcellTarballPath := filepath.Clean(string(stemcellTarballPathBytes))
+
+		expired := policy.MaxUnusedAge > 0 && now.Sub(fileInfo.ModTime()) > policy.MaxUnusedAge
+		if expired || !storedTarballPaths[stemcellTarballPath] {
+			removePaths = append(removePaths, mappingPath)
+			continue
+		}
+
+		referencedTarballPaths[stemcellTarballPath] = true
+	}
+
+	importedImageKeys := map[string]bool{}
+	for _, imageKey := range policy.ImportedImageKeys {
+		importedImageKeys[imageKey] = true
+	}
+
+	for _, entry := range index.Stemcells {
+		if entry.ImageKey != "" && importedImageKeys[entry.ImageKey] {
+			referencedTarballPaths[filepath.Clean(entry.Path)] = true
+		}
+	}
+
+	removedTarballPaths := map[string]bool{}
+	for _, entry := range index.Stemcells {
+		if referencedTarballPaths[filepath.Clean(entry.Path)] {
+			continue
+		}
+
+		if policy.MaxUnusedAge > 0 && now.Sub(entry.lastUsed()) > policy.MaxUnusedAge {
+			removedTarballPaths[entry.Path] = true
+		}
+	}
+
+	if policy.KeepVersions > 0 {
+		for _, entries := range index.byName() {
+			sort.SliceStable(entries, func(i, j int) bool {
+				return compareVersions(entries[i].Version, entries[j].Version) > 0
+			})
+
+			keptVersions := map[string]bool{}
+			for _, entry := range entries {
+				if len(keptVersions) < policy.KeepVersions || keptVersions[entry.Version] {
+					keptVersions[entry.Version] = true
+					continue
+				}
+
+				if !referencedTarballPaths[filepath.Clean(entry.Path)] {
+					removedTarballPaths[entry.Path] = true
+				}
+			}
+		}
+	}
+
+	for _, entry := range index.Stemcells {
+		if removedTarballPaths[entry.Path] {
+			removePaths = append(removePaths, entry.Path)
+		}
+	}
+
+	for _, removePath := range removePaths {
+		if dryRun {
+			s.logger.Info("stemcell-store", "would remove %s", removePath)
+			continue
+		}
+
+		s.logger.Info("stemcell-store", "removing %s", removePath)
+		err = os.Remove(removePath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return removePaths, nil
+}
+
+// byName groups the entries of valid tarballs by stemcell name
+func (i stemcellIndex) byName() map[string][]stemcellIndexEntry {
+	entriesByName := map[string][]stemcellIndexEntry{}
+
+	for _, entry := range i.Stemcells {
+		if entry.Name == "" {
+			continue
+		}
+
+		entriesByName[entry.Name] = append(entriesByName[entry.Name], entry)
+	}
+
+	return entriesByName
+}
+
+// compareVersions compares dot separated stemcell versions (ex: 97.18 > 97.9), parts that are not numbers
+// are compared as text
+func compareVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for n := 0; n < len(aParts) || n < len(bParts); n++ {
+		if n >= len(aParts) {
+			return -1
+		}
+		if n >= len(bParts) {
+			return 1
+		}
+
+		aNumber, aErr := strconv.Atoi(aParts[n])
+		bNumber, bErr := strconv.Atoi(bParts[n])
+		if aErr == nil && bErr == nil {
+			if aNumber != bNumber {
+				if aNumber > bNumber {
+					return 1
+				}
+				return -1
+			}
+			continue
+		}
+
+		if comparison := strings.Compare(aParts[n], bParts[n]); comparison != 0 {
+			return comparison
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,300 @@
+package stemcell
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	indexFileName    = "index.json"
+	indexVersion     = 2
+	indexLockMaxWait = 30 * time.Second
+
+	// lastUsedInterval limits how often handing out a tarball rewrites the index
+	lastUsedInterval = time.Hour
+)
+
+// stemcellIndexEntry describes a stemcell tarball in the store. Invalid tarballs are kept with an empty name
+// so they are not read again until they change
+type stemcellIndexEntry struct {
+	Name     string    `json:"name"`
+	Version  string    `json:"version"`
+	ImageKey string    `json:"image_key"`
+	Path     string    `json:"path"`
+	ModTime  time.Time `json:"mtime"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+func (e stemcellIndexEntry) matches(fileInfo os.FileInfo) bool {
+	return e.ModTime.Equal(fileInfo.ModTime()) && e.Size == fileInfo.Size()
+}
+
+type stemcellIndex struct {
+	Version   int                  `json:"version"`
+	Stemcells []stemcellIndexEntry `json:"stemcells"`
+}
+
+func (i stemcellIndex) find(name, version string) (stemcellIndexEntry, bool) {
+	for _, entry := range i.Stemcells {
+		if entry.Name == name && entry.Version == version {
+			return entry, true
+		}
+	}
+
+	return stemcellIndexEntry{}, false
+}
+
+func (i stemcellIndex) entry(path string, fileInfo os.FileInfo) (stemcellIndexEntry, bool) {
+	for _, entry := range i.Stemcells {
+		if entry.Path == path && entry.matches(fileInfo) {
+			return entry, true
+		}
+	}
+
+	return stemcellIndexEntry{}, false
+}
+
+// lastUsed returns when the tarball was last handed out or changed
+func (e stemcellIndexEntry) lastUsed() time.Time {
+	if e.LastUsed.After(e.ModTime) {
+		return e.LastUsed
+	}
+
+	return e.ModTime
+}
+
+func (i stemcellIndex) entryByPath(path string) (int, bool) {
+	for n, entry := range i.Stemcells {
+		if filepath.Clean(entry.Path) == filepath.Clean(path) {
+			return n, true
+		}
+	}
+
+	return -1, false
+}
+
+func (s stemcellStoreImpl) indexPath() string {
+	return filepath.Join(s.storePath, indexFileName)
+}
+
+// updateIndex reads the manifest of tarballs that are new or changed since the last update and drops
+// entries of removed tarballs. Manifests are read without holding the index lock, the index is only
+// locked and rewritten when it changed
+func (s stemcellStoreImpl) updateIndex() (stemcellIndex, error) {
+	index, err := s.readIndex()
+	if err != nil {
+		return index, err
+	}
+
+	newEntries, err := s.newIndexEntries(index)
+	if err != nil {
+		return index, err
+	}
+
+	updatedIndex, changed, err := s.mergeIndex(index, newEntries)
+	if err != nil || !changed {
+		return updatedIndex, err
+	}
+
+	err = s.retryFileLock.Try(s.indexPath()+".lock", indexLockMaxWait, func() error {
+		// another CPI may have updated the index since it was read
+		updatedIndex, err = s.refreshIndex(newEntries)
+		return err
+	})
+
+	return updatedIndex, err
+}
+
+// refreshIndex merges new entries into the current index and writes it when it changed, the index lock
+// must be held
+func (s stemcellStoreImpl) refreshIndex(newEntries map[string]stemcellIndexEntry) (stemcellIndex, error) {
+	index, err := s.readIndex()
+	if err != nil {
+		return index, err
+	}
+
+	updatedIndex, changed, err := s.mergeIndex(index, newEntries)
+	if err != nil || !changed {
+		return updatedIndex, err
+	}
+
+	return updatedIndex, s.writeIndex(updatedIndex)
+}
+
+// markUsed records that a tarball was handed out so garbage collection keeps it for MaxUnusedAge. The index
+// is only locked when the tarball was not used within lastUsedInterval, failures are logged as the tarball
+// itself is usable
+func (s stemcellStoreImpl) markUsed(index stemcellIndex, tarballPath string) {
+	now := time.Now()
+
+	n, found := index.entryByPath(tarballPath)
+	if !found || now.Sub(index.Stemcells[n].lastUsed()) < lastUsedInterval {
+		return
+	}
+
+	err := s.retryFileLock.Try(s.indexPath()+".lock", indexLockMaxWait, func() error {
+		index, err := s.readIndex()
+		if err != nil {
+			return err
+		}
+
+		n, found := index.entryByPath(tarballPath)
+		if !found {
+			return nil
+		}
+
+		index.Stemcells[n].LastUsed = now
+		return s.writeIndex(index)
+	})
+	if err != nil {
+		s.logger.Warn("stemcell-store", "recording use of stemcell %s: %s", tarballPath, err)
+	}
+}
+
+func (s stemcellStoreImpl) readIndex() (stemcellIndex, error) {
+	var index stemcellIndex
+
+	indexContent, err := ioutil.ReadFile(s.indexPath())
+	if err != nil && !os.IsNotExist(err) {
+		return index, err
+	}
+
+	if len(indexContent) > 0 {
+		if err = json.Unmarshal(indexContent, &index); err != nil {
+			s.logger.Warn("stemcell-store", "rebuilding invalid stemcell index %s: %s", s.indexPath(), err)
+			index = stemcellIndex{}
+		}
+	}
+
+	// entries of older indexes lack fields or hold image keys that are not bare sha1 digests, they are all read again
+	if index.Version != indexVersion {
+		index = stemcellIndex{}
+	}
+
+	return index, nil
+}
+
+func (s stemcellStoreImpl) writeIndex(index stemcellIndex) error {
+	indexContent, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpIndexPath := s.indexPath() + ".tmp"
+	if err = ioutil.WriteFile(tmpIndexPath, indexContent, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpIndexPath, s.indexPath())
+}
+
+// newIndexEntries reads the manifest of tarballs missing from the index or changed since they were indexed
+func (s stemcellStoreImpl) newIndexEntries(index stemcellIndex) (map[string]stemcellIndexEntry, error) {
+	filePaths, err := s.fs.Glob(filepath.Join(s.storePath, "*.tgz"))
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.DebugWithDetails("stemcell-store", "Stemcell Store files:", filePaths)
+
+	newEntries := map[string]stemcellIndexEntry{}
+	for _, filePath := range filePaths {
+		fileInfo, err := os.Stat(filePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if fileInfo.IsDir() {
+			continue
+		}
+
+		if _, found := index.entry(filePath, fileInfo); found {
+			continue
+		}
+
+		newEntries[filePath], err = s.newIndexEntry(filePath, fileInfo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newEntries, nil
+}
+
+// mergeIndex returns the index entries of the tarballs in the store, taken from the index or from the new
+// entries. Tarballs that changed since their new entry was read are left for the next update
+func (s stemcellStoreImpl) mergeIndex(index stemcellIndex, newEntries map[string]stemcellIndexEntry) (stemcellIndex, bool, error) {
+	filePaths, err := s.fs.Glob(filepath.Join(s.storePath, "*.tgz"))
+	if err != nil {
+		return index, false, err
+	}
+
+	changed := false
+	updatedIndex := stemcellIndex{Version: indexVersion, Stemcells: []stemcellIndexEntry{}}
+	for _, filePath := range filePaths {
+		fileInfo, err := os.Stat(filePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return index, false, err
+		}
+
+		if fileInfo.IsDir() {
+			continue
+		}
+
+		entry, found := index.entry(filePath, fileInfo)
+		if !found {
+			entry, found = newEntries[filePath]
+			if !found || !entry.matches(fileInfo) {
+				continue
+			}
+			changed = true
+		}
+
+		updatedIndex.Stemcells = append(updatedIndex.Stemcells, entry)
+	}
+
+	if len(updatedIndex.Stemcells) != len(index.Stemcells) || index.Version != indexVersion {
+		changed = true
+	}
+
+	return updatedIndex, changed, nil
+}
+
+func (s stemcellStoreImpl) newIndexEntry(filePath string, fileInfo os.FileInfo) (stemcellIndexEntry, error) {
+	entry := stemcellIndexEntry{Path: filePath, ModTime: fileInfo.ModTime(), Size: fileInfo.Size()}
+
+	s.logger.Debug("stemcell-store", "indexing stemcell file %s", filePath)
+
+	manifest, err := readStemcellManifest(filePath, s.logger)
+
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		s.logger.Warn("stemcell-store", "skipping invalid stemcell file %s:", filePath)
+		return entry, nil
+	}
+
+	if err != nil {
+		return entry, err
+	}
+
+	if manifest == nil {
+		s.logger.Warn("stemcell-store", "skipping invalid stemcell with no manifest:", filePath)
+		return entry, nil
+	}
+
+	entry.Name = manifest.Name
+	entry.Version = manifest.Version
+	entry.ImageKey = manifest.ImageKey()
+
+	return entry, nil
+}
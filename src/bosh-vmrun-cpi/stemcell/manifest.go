@@ -4,9 +4,12 @@ import (
 	"github.com/go-yaml/yaml"
 )
 
+const stemcellManifestFileName = "stemcell.MF"
+
 type StemcellManifest struct {
 	Name    string `yaml:"name"`
 	Version string `yaml:"version"`
+	Sha1    string `yaml:"sha1"`
 }
 
 func NewStemcellManifest(data []byte) (*StemcellManifest, error) {
@@ -19,3 +22,9 @@ func NewStemcellManifest(data []byte) (*StemcellManifest, error) {
 
 	return newManifest, nil
 }
+
+// ImageKey identifies the stemcell image by its sha1, the key of the stemcell VM imported from it. It is empty
+// when the manifest only has digests of other algorithms
+func (m StemcellManifest) ImageKey() string {
+	return sha1Digest(m.Sha1)
+}
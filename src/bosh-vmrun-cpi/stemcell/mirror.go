@@ -0,0 +1,280 @@
+package stemcell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	mirrorDownloadLockMaxWait = 30 * time.Minute
+	mirrorDownloadAttempts    = 3
+	mirrorConnectTimeout      = 30 * time.Second
+	mirrorIdleTimeout         = 60 * time.Second
+)
+
+var errNotOnMirror = errors.New("stemcell not on mirror")
+
+// mirrorFileName returns the file name mirrors use for a stemcell, the same as bosh.io and
+// sync-director-stemcells (ex: bosh-stemcell-97.18-vsphere-esxi-ubuntu-xenial-go_agent.tgz)
+func mirrorFileName(name, version string) string {
+	return fmt.Sprintf("bosh-stemcell-%s-%s.tgz", version, strings.Replace(name, "bosh-", "", 1))
+}
+
+// newMirrorHTTPClient returns a client that gives up on mirrors that do not connect or stop sending data, so a
+// stalled mirror does not hold the download lock
+func newMirrorHTTPClient() *http.Client {
+	dialer := &net.Dialer{Timeout: mirrorConnectTimeout, KeepAlive: 30 * time.Second}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				conn, err := dialer.DialContext(ctx, network, address)
+				if err != nil {
+					return nil, err
+				}
+
+				return idleTimeoutConn{Conn: conn, timeout: mirrorIdleTimeout}, nil
+			},
+			TLSHandshakeTimeout:   mirrorConnectTimeout,
+			ResponseHeaderTimeout: mirrorIdleTimeout,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+}
+
+// idleTimeoutConn fails reads and writes that make no progress for the timeout
+type idleTimeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c idleTimeoutConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Read(b)
+}
+
+func (c idleTimeoutConn) Write(b []byte) (int, error) {
+	if err := c.Conn.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Write(b)
+}
+
+// getFromMirrors downloads a stemcell missing from the store from the first mirror that has it. Mirrors without
+// the stemcell are skipped
+func (s stemcellStoreImpl) getFromMirrors(name, version string) (string, error) {
+	if len(s.mirrorURLs) == 0 {
+		return "", nil
+	}
+
+	err := os.MkdirAll(s.storePath, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	fileName := mirrorFileName(name, version)
+	stemcellTarballPath := filepath.Join(s.storePath, fileName)
+
+	var mirrorErr error
+	for _, mirrorURL := range s.mirrorURLs {
+		tarballURL := strings.TrimSuffix(mirrorURL, "/") + "/" + fileName
+
+		downloaded := false
+		err = s.retryFileLock.Try(stemcellTarballPath+".lock", mirrorDownloadLockMaxWait, func() error {
+			// another CPI may have downloaded it while waiting for the lock
+			if _, err := os.Stat(stemcellTarballPath); err == nil {
+				return nil
+			}
+
+			downloaded = true
+			return s.downloadFromMirror(tarballURL, stemcellTarballPath)
+		})
+		if err == errNotOnMirror {
+			s.logger.Debug("stemcell-store", "no stemcell %s on mirror %s", fileName, mirrorURL)
+			continue
+		}
+
+		if err != nil {
+			s.logger.Warn("stemcell-store", "downloading stemcell from mirror %s: %s", mirrorURL, err)
+			mirrorErr = err
+			continue
+		}
+
+		index, err := s.updateIndex()
+		if err != nil {
+			return "", err
+		}
+
+		entry, found := index.find(name, version)
+		if !found {
+			if downloaded {
+				os.Remove(stemcellTarballPath)
+			}
+
+			mirrorErr = fmt.Errorf("stemcell %s does not contain %s/%s", tarballURL, name, version)
+			continue
+		}
+
+		s.logger.Info("stemcell-store", "stored stemcell name: %s version: %s from %s", name, version, tarballURL)
+
+		return entry.Path, nil
+	}
+
+	return "", mirrorErr
+}
+
+// downloadFromMirror downloads a tarball next to its target path, resuming partial downloads, and moves it in
+// place once it matches the mirror's checksum. Without a checksum on the mirror the image of the tarball has to
+// match the sha1 of its stemcell manifest
+func (s stemcellStoreImpl) downloadFromMirror(tarballURL string, stemcellTarballPath string) error {
+	checksum, err := s.mirrorChecksum(tarballURL)
+	if err != nil {
+		return err
+	}
+
+	partPath := stemcellTarballPath + ".part"
+	for attempt := 1; ; attempt++ {
+		err = s.resumeDownload(tarballURL, partPath)
+		if err == nil || err == errNotOnMirror || attempt == mirrorDownloadAttempts {
+			break
+		}
+
+		s.logger.Warn("stemcell-store", "resuming download of %s after: %s", tarballURL, err)
+	}
+	if err == errNotOnMirror {
+		if partInfo, statErr := os.Stat(partPath); statErr == nil && partInfo.Size() == 0 {
+			os.Remove(partPath)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if checksum != "" {
+		err = VerifyFileChecksum(partPath, checksum)
+	} else {
+		s.logger.Debug("stemcell-store", "no checksum for %s on mirror, verifying its image", tarballURL)
+		err = verifyStemcellImage(partPath, s.logger)
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return err
+	}
+
+	return os.Rename(partPath, stemcellTarballPath)
+}
+
+// mirrorChecksum reads the `.sha256` or `.sha1` file of a tarball, either a bare digest or sha256sum/sha1sum
+// output. It is empty when the mirror has neither
+func (s stemcellStoreImpl) mirrorChecksum(tarballURL string) (string, error) {
+	for _, algorithm := range []string{CHECKSUM_SHA256, CHECKSUM_SHA1} {
+		response, err := s.httpClient.Get(tarballURL + "." + algorithm)
+		if err != nil {
+			return "", err
+		}
+
+		checksumContent, err := ioutil.ReadAll(io.LimitReader(response.Body, 4096))
+		response.Body.Close()
+		if err != nil {
+			return "", err
+		}
+
+		if response.StatusCode == http.StatusNotFound {
+			continue
+		}
+
+		if response.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("downloading %s.%s: %s", tarballURL, algorithm, response.Status)
+		}
+
+		fields := strings.Fields(string(checksumContent))
+		if len(fields) == 0 {
+			return "", fmt.Errorf("empty checksum %s.%s", tarballURL, algorithm)
+		}
+
+		return algorithm + ":" + fields[0], nil
+	}
+
+	return "", nil
+}
+
+// resumeDownload appends the rest of a tarball to a partial download, or restarts it when the mirror does not
+// support ranges
+func (s stemcellStoreImpl) resumeDownload(tarballURL string, partPath string) error {
+	partFile, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer partFile.Close()
+
+	offset, err := partFile.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodGet, tarballURL, nil)
+	if err != nil {
+		return err
+	}
+
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusPartialContent:
+		if !strings.HasPrefix(response.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			return fmt.Errorf("downloading %s: unexpected content range %q", tarballURL, response.Header.Get("Content-Range"))
+		}
+
+		s.logger.Debug("stemcell-store", "resuming download of %s at %d bytes", tarballURL, offset)
+
+	case http.StatusOK:
+		if err = partFile.Truncate(0); err != nil {
+			return err
+		}
+
+		if _, err = partFile.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		s.logger.Debug("stemcell-store", "downloading %s", tarballURL)
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial download is complete, the checksum decides whether it is valid
+		return nil
+
+	case http.StatusNotFound:
+		return errNotOnMirror
+
+	default:
+		return fmt.Errorf("downloading %s: %s", tarballURL, response.Status)
+	}
+
+	_, err = io.Copy(partFile, response.Body)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %s", tarballURL, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package stemcell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// OVF manifest lines look like: SHA256(image.ovf)= 2cf24dba...
+var ovfManifestLineRegexp = regexp.MustCompile(`^(\w+)\((.+)\)\s*=\s*(\w+)$`)
+
+// verifyOvfManifests verifies the checksums of every *.mf in an extracted OVF or OVA
+func verifyOvfManifests(ovfDir string, logger boshlog.Logger) error {
+	manifestPaths, err := filepath.Glob(filepath.Join(ovfDir, "*.mf"))
+	if err != nil {
+		return err
+	}
+
+	for _, manifestPath := range manifestPaths {
+		err = verifyOvfManifest(manifestPath, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func verifyOvfManifest(manifestPath string, logger boshlog.Logger) error {
+	manifestFile, err := os.Open(manifestPath)
+	if err != nil {
+		return err
+	}
+	defer manifestFile.Close()
+
+	scanner := bufio.NewScanner(manifestFile)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		match := ovfManifestLineRegexp.FindStringSubmatch(line)
+		if match == nil {
+			return fmt.Errorf("invalid line in %s: %q", filepath.Base(manifestPath), line)
+		}
+
+		// manifest entries are kept inside the ovf dir like the image entries
+		filePath := filepath.Join(filepath.Dir(manifestPath), filepath.Clean("/"+match[2]))
+
+		err = VerifyFileChecksum(filePath, strings.ToLower(match[1])+":"+match[3])
+		if err != nil {
+			return err
+		}
+
+		logger.Debug("stemcell-client", "verified %s checksum of %s", match[1], match[2])
+	}
+
+	return scanner.Err()
+}
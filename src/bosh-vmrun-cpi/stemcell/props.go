@@ -7,6 +7,7 @@ import (
 type stemcellProps struct {
 	Name    string
 	Version string
+	Light   bool
 }
 
 func NewStemcellProps(cloudProps apiv1.StemcellCloudProps) (*stemcellProps, error) {
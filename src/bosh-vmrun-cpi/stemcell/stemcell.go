@@ -1,8 +1,20 @@
 package stemcell
 
+import (
+	"time"
+)
+
+// RetryFileLock guards the stemcell store index
+type RetryFileLock interface {
+	Try(lockFilePath string, maxWait time.Duration, fn func() error) error
+}
+
 //go:generate counterfeiter -o fakes/fake_client.go stemcell.go StemcellClient
 type StemcellClient interface {
-	ExtractOvf(string) (string, error)
+	ExtractOvf(string) (string, string, error)
+	ExtractStemcellOvf(string) (string, string, error)
+	ImageKey(string) (string, error)
+	StemcellImageKey(string) (string, error)
 	Cleanup()
 }
 
@@ -10,10 +22,11 @@ type StemcellClient interface {
 type StemcellStore interface {
 	GetByMetadata(string, string) (string, error)
 	GetByImagePathMapping(string) (string, error)
-	Cleanup()
+	GarbageCollect(RetentionPolicy, bool) ([]string, error)
 }
 
 //go:generate counterfeiter -o fakes/fake_config.go stemcell.go Config
 type Config interface {
 	StemcellStorePath() string
+	StemcellMirrorURLs() []string
 }
@@ -1,15 +1,14 @@
 package stemcell
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"crypto/sha1"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -18,29 +17,25 @@ import (
 
 type stemcellStoreImpl struct {
 	storePath     string
-	parentTempDir string
+	mirrorURLs    []string
+	httpClient    *http.Client
+	retryFileLock RetryFileLock
 	fs            boshsys.FileSystem
 	logger        boshlog.Logger
 	compressor    boshcmd.Compressor
 }
 
-func NewStemcellStore(config Config, compressor boshcmd.Compressor, fs boshsys.FileSystem, logger boshlog.Logger) StemcellStore {
-	parentTempDir, _ := fs.TempDir("stemcell-store")
-	return stemcellStoreImpl{storePath: config.StemcellStorePath(), parentTempDir: parentTempDir, compressor: compressor, fs: fs, logger: logger}
+func NewStemcellStore(config Config, retryFileLock RetryFileLock, compressor boshcmd.Compressor, fs boshsys.FileSystem, logger boshlog.Logger) StemcellStore {
+	return stemcellStoreImpl{storePath: config.StemcellStorePath(), mirrorURLs: config.StemcellMirrorURLs(), httpClient: newMirrorHTTPClient(), retryFileLock: retryFileLock, compressor: compressor, fs: fs, logger: logger}
 }
 
+// GetByImagePathMapping returns the stemcell tarball synced for a director image path, if any
 func (s stemcellStoreImpl) GetByImagePathMapping(imagePath string) (string, error) {
-	var err error
-	var extractedImagePath string
-
-	if s.fs.FileExists(imagePath) {
-		return imagePath, nil
-	}
-
 	expectedMappingFileName := fmt.Sprintf("%x.mapping", sha1.Sum([]byte(imagePath)))
 	expectedMappingPath := filepath.Join(s.storePath, "mappings", expectedMappingFileName)
-	var stemcellTarballPathBytes []byte
-	if stemcellTarballPathBytes, err = ioutil.ReadFile(expectedMappingPath); err != nil {
+
+	stemcellTarballPathBytes, err := ioutil.ReadFile(expectedMappingPath)
+	if err != nil {
 		s.logger.Debug("stemcell-store", "no stemcell mapping %s for image %s", expectedMappingFileName, imagePath)
 		return "", nil
 	}
@@ -48,157 +43,47 @@ func (s stemcellStoreImpl) GetByImagePathMapping(imagePath string) (string, erro
 	stemcellTarballPath := string(stemcellTarballPathBytes)
 	s.logger.Debug("stemcell-store", "found stemcell mapping %s for stemcell %s", expectedMappingFileName, stemcellTarballPath)
 
-	err = s.WithTarballFile(stemcellTarballPath, "image", func(imageReader io.Reader) error {
-		extractedImagePath = filepath.Join(s.parentTempDir, "image")
-		var extractedImageFile *os.File
-		if extractedImageFile, err = os.Create(extractedImagePath); err != nil {
-			return err
-		}
-		defer extractedImageFile.Close()
-
-		var bytesWritten int64
-		if bytesWritten, err = io.Copy(extractedImageFile, imageReader); err != nil {
-			return err
-		}
-
-		s.logger.Debug("stemcell-store", "wrote stemcell image to %s: %d bytes", extractedImagePath, bytesWritten)
+	// mappings expire by mtime, touch it so garbage collection keeps mappings in use
+	now := time.Now()
+	if err = os.Chtimes(expectedMappingPath, now, now); err != nil {
+		s.logger.Warn("stemcell-store", "touching stemcell mapping %s: %s", expectedMappingPath, err)
+	}
 
-		return nil
-	})
+	index, err := s.readIndex()
 	if err != nil {
-		return "", err
+		s.logger.Warn("stemcell-store", "reading stemcell index: %s", err)
+		return stemcellTarballPath, nil
 	}
+	s.markUsed(index, stemcellTarballPath)
 
-	return extractedImagePath, nil
+	return stemcellTarballPath, nil
 }
 
+// GetByMetadata returns the stemcell tarball with the given name and version, if any. Stemcells missing from
+// the store are downloaded from the stemcell mirrors
 func (s stemcellStoreImpl) GetByMetadata(name, version string) (string, error) {
-	var err error
-	var extractedImagePath string
-
 	if name == "" || version == "" {
 		return "", errors.New("stemcell store requires name and version from cloud properties")
 	}
 
-	filePaths, err := s.fs.Glob(filepath.Join(s.storePath, "*.tgz"))
-	if err != nil {
-		return "", err
+	if !s.fs.FileExists(s.storePath) {
+		s.logger.Debug("stemcell-store", "stemcell store %s does not exist", s.storePath)
+		return s.getFromMirrors(name, version)
 	}
 
-	s.logger.DebugWithDetails("stemcell-store", "Stemcell Store files:", filePaths)
-
-	for _, filePath := range filePaths {
-		var manifest *StemcellManifest
-
-		err = s.WithTarballFile(filePath, "stemcell.MF", func(manifestReader io.Reader) error {
-			var manifestContent []byte
-			if manifestContent, err = ioutil.ReadAll(manifestReader); err != nil {
-				return err
-			}
-
-			if manifest, err = NewStemcellManifest(manifestContent); err != nil {
-				return err
-			}
-
-			return nil
-		})
-
-		if err == io.ErrUnexpectedEOF || err == io.EOF {
-			s.logger.Warn("stemcell-store", "skipping invalid stemcell file %s:", filePath)
-			continue
-		}
-
-		if err != nil {
-			return "", err
-		}
-
-		if manifest == nil {
-			s.logger.Warn("stemcell-store", "skipping invalid stemcell with no manifest:", filePath)
-			continue
-		}
-		s.logger.Debug("stemcell-store", "checking existing stemcell name: %s version: %s", manifest.Name, manifest.Version)
-
-		if manifest.Name == name && manifest.Version == version {
-			err = s.WithTarballFile(filePath, "image", func(imageReader io.Reader) error {
-				extractedImagePath = filepath.Join(s.parentTempDir, "image")
-				var extractedImageFile *os.File
-				if extractedImageFile, err = os.Create(extractedImagePath); err != nil {
-					return err
-				}
-				defer extractedImageFile.Close()
-
-				var bytesWritten int64
-				if bytesWritten, err = io.Copy(extractedImageFile, imageReader); err != nil {
-					return err
-				}
-
-				s.logger.Debug("stemcell-store", "wrote stemcell image to %s: %d bytes", extractedImagePath, bytesWritten)
-
-				return nil
-			})
-
-			//exit if image was extracted
-			break
-		}
-
-	}
-
-	return extractedImagePath, nil
-}
-
-func (s stemcellStoreImpl) WithTarballFile(tarGzPath string, desiredTarballFilePath string, callback func(io.Reader) error) error {
-	var err error
-	var gzipFile *os.File
-
-	if gzipFile, err = os.Open(tarGzPath); err != nil {
-		return err
-	}
-	defer gzipFile.Close()
-
-	var gzipReader *gzip.Reader
-	gzipReader, err = gzip.NewReader(gzipFile)
-
+	index, err := s.updateIndex()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	defer gzipReader.Close()
-
-	tarReader := tar.NewReader(gzipReader) // no Close()
-
-	for {
-		var tarHeader *tar.Header
-		tarHeader, err = tarReader.Next()
-
-		if err == io.EOF {
-			break // End of archive
-		}
-
-		if err != nil {
-			return err
-		}
-
-		tarHeaderFilePath := tarHeader.Name
-
-		switch tarHeader.Typeflag {
-		case tar.TypeDir:
-			continue
-		case tar.TypeReg:
-			s.logger.Debug("stemcell-store", "stemcell content file %s", tarHeaderFilePath)
-
-			if tarHeaderFilePath == desiredTarballFilePath || tarHeaderFilePath == "./"+desiredTarballFilePath {
-
-				//call callback and exit early if file was found
-				return callback(tarReader)
-			}
-		default:
-			s.logger.Error("stemcell-store", "unable to determine file type %c in file %s", tarHeader.Typeflag, tarHeaderFilePath)
-		}
+	entry, found := index.find(name, version)
+	if !found {
+		s.logger.Debug("stemcell-store", "no stemcell with name: %s version: %s in index", name, version)
+		return s.getFromMirrors(name, version)
 	}
 
-	return nil
-}
+	s.logger.Debug("stemcell-store", "found stemcell name: %s version: %s at %s", name, version, entry.Path)
+	s.markUsed(index, entry.Path)
 
-func (s stemcellStoreImpl) Cleanup() {
-	os.RemoveAll(s.parentTempDir)
+	return entry.Path, nil
 }
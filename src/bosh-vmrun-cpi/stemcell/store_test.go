@@ -2,14 +2,22 @@ package stemcell_test
 
 import (
 	"archive/tar"
+	"bosh-vmrun-cpi/driver"
+	driverfakes "bosh-vmrun-cpi/driver/fakes"
 	"bosh-vmrun-cpi/stemcell"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -19,6 +27,7 @@ import (
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
 	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/gofrs/flock"
 )
 
 var _ = Describe("StemcellStore", func() {
@@ -50,7 +59,7 @@ var _ = Describe("StemcellStore", func() {
 
 				config.StemcellStorePathReturns(storeDir)
 
-				stemcellStore = stemcell.NewStemcellStore(config, compressor, fs, logger)
+				stemcellStore = stemcell.NewStemcellStore(config, driver.NewRetryFileLock(logger), compressor, fs, logger)
 			})
 
 			AfterEach(func() {
@@ -87,38 +96,129 @@ version: "97.18"
 					}
 					Expect(tarWriter.WriteHeader(imageHeader)).To(Succeed())
 
-					Expect(gzipWriter.Close()).To(Succeed())
 					Expect(tarWriter.Close()).To(Succeed())
+					Expect(gzipWriter.Close()).To(Succeed())
+					Expect(tarGzFile.Close()).To(Succeed())
 				})
 
 				Context("with valid params", func() {
-					It("returns path to the extracted stemcell image", func() {
-						imagePath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.18")
-						defer stemcellStore.Cleanup()
+					It("returns path to the stemcell tarball", func() {
+						stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.18")
 
 						Expect(err).ToNot(HaveOccurred())
-						Expect(imagePath).To(HaveSuffix(filepath.Join("image")))
+						Expect(stemcellPath).To(Equal(filepath.Join(storeDir, "valid-stecemll.tgz")))
 					})
 				})
 
 				Context("with non-matching params", func() {
 					It("returns an error", func() {
-						imagePath, err := stemcellStore.GetByMetadata("", "")
-						defer stemcellStore.Cleanup()
+						stemcellPath, err := stemcellStore.GetByMetadata("", "")
 
 						Expect(err).To(HaveOccurred())
-						Expect(imagePath).To(Equal(""))
+						Expect(stemcellPath).To(Equal(""))
 					})
 				})
 			})
 
+			Context("stemcell index", func() {
+				var tarballPath string
+
+				readIndex := func() string {
+					indexContent, err := ioutil.ReadFile(filepath.Join(storeDir, "index.json"))
+					Expect(err).ToNot(HaveOccurred())
+					return string(indexContent)
+				}
+
+				BeforeEach(func() {
+					tarballPath = filepath.Join(storeDir, "stemcell.tgz")
+					writeStemcellTarball(tarballPath, "name: stemcell-a\nversion: \"1\"")
+				})
+
+				It("records name, version, path and mtime of tarballs", func() {
+					stemcellPath, err := stemcellStore.GetByMetadata("stemcell-a", "1")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(stemcellPath).To(Equal(tarballPath))
+
+					index := readIndex()
+					Expect(index).To(ContainSubstring(`"name": "stemcell-a"`))
+					Expect(index).To(ContainSubstring(`"version": "1"`))
+					Expect(index).To(ContainSubstring(`"path": "` + tarballPath + `"`))
+					Expect(index).To(ContainSubstring(`"mtime": `))
+				})
+
+				It("does not lock an unchanged index", func() {
+					_, err := stemcellStore.GetByMetadata("stemcell-a", "1")
+					Expect(err).ToNot(HaveOccurred())
+
+					indexLock := flock.New(filepath.Join(storeDir, "index.json.lock"))
+					Expect(indexLock.Lock()).To(Succeed())
+					defer indexLock.Unlock()
+
+					stemcellPath, err := stemcellStore.GetByMetadata("stemcell-a", "1")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(stemcellPath).To(Equal(tarballPath))
+				})
+
+				It("reads the tarballs of an index without a version again", func() {
+					_, err := stemcellStore.GetByMetadata("stemcell-a", "1")
+					Expect(err).ToNot(HaveOccurred())
+
+					index := strings.Replace(readIndex(), `"name": "stemcell-a"`, `"name": "stemcell-b"`, 1)
+					index = strings.Replace(index, `"version": 2,`, "", 1)
+					Expect(ioutil.WriteFile(filepath.Join(storeDir, "index.json"), []byte(index), 0644)).To(Succeed())
+
+					stemcellPath, err := stemcellStore.GetByMetadata("stemcell-a", "1")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(stemcellPath).To(Equal(tarballPath))
+				})
+
+				It("does not read unchanged tarballs again", func() {
+					_, err := stemcellStore.GetByMetadata("stemcell-a", "1")
+					Expect(err).ToNot(HaveOccurred())
+
+					index := strings.Replace(readIndex(), `"name": "stemcell-a"`, `"name": "stemcell-b"`, 1)
+					Expect(ioutil.WriteFile(filepath.Join(storeDir, "index.json"), []byte(index), 0644)).To(Succeed())
+
+					stemcellPath, err := stemcellStore.GetByMetadata("stemcell-b", "1")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(stemcellPath).To(Equal(tarballPath))
+				})
+
+				It("reindexes changed tarballs and drops removed ones", func() {
+					_, err := stemcellStore.GetByMetadata("stemcell-a", "1")
+					Expect(err).ToNot(HaveOccurred())
+
+					writeStemcellTarball(tarballPath, "name: stemcell-a\nversion: \"2\"")
+					Expect(os.Chtimes(tarballPath, time.Now(), time.Now().Add(time.Minute))).To(Succeed())
+
+					stemcellPath, err := stemcellStore.GetByMetadata("stemcell-a", "2")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(stemcellPath).To(Equal(tarballPath))
+
+					Expect(os.Remove(tarballPath)).To(Succeed())
+
+					stemcellPath, err = stemcellStore.GetByMetadata("stemcell-a", "2")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(stemcellPath).To(BeEmpty())
+					Expect(readIndex()).ToNot(ContainSubstring(tarballPath))
+				})
+
+				It("rebuilds a corrupted index", func() {
+					Expect(ioutil.WriteFile(filepath.Join(storeDir, "index.json"), []byte("{"), 0644)).To(Succeed())
+
+					stemcellPath, err := stemcellStore.GetByMetadata("stemcell-a", "1")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(stemcellPath).To(Equal(tarballPath))
+					Expect(readIndex()).To(ContainSubstring(`"name": "stemcell-a"`))
+				})
+			})
+
 			Context("when no stemcell exists", func() {
 				It("returns empty string", func() {
-					imagePath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
-					defer stemcellStore.Cleanup()
+					stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
 
 					Expect(err).ToNot(HaveOccurred())
-					Expect(imagePath).To(Equal(""))
+					Expect(stemcellPath).To(Equal(""))
 				})
 
 				It("ignores empty files", func() {
@@ -126,11 +226,10 @@ version: "97.18"
 					Expect(err).ToNot(HaveOccurred())
 					Expect(emptyFile.Close()).To(Succeed())
 
-					imagePath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
-					defer stemcellStore.Cleanup()
+					stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
 
 					Expect(err).ToNot(HaveOccurred())
-					Expect(imagePath).To(Equal(""))
+					Expect(stemcellPath).To(Equal(""))
 				})
 
 				It("ignores tar/gz without manifest", func() {
@@ -148,14 +247,14 @@ version: "97.18"
 
 					Expect(tarWriter.WriteHeader(header)).To(Succeed())
 
-					Expect(gzipWriter.Close()).To(Succeed())
 					Expect(tarWriter.Close()).To(Succeed())
+					Expect(gzipWriter.Close()).To(Succeed())
+					Expect(tarGzFile.Close()).To(Succeed())
 
-					imagePath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
-					defer stemcellStore.Cleanup()
+					stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
 
 					Expect(err).ToNot(HaveOccurred())
-					Expect(imagePath).To(Equal(""))
+					Expect(stemcellPath).To(Equal(""))
 				})
 
 				It("ignores gzip files without tar", func() {
@@ -170,22 +269,20 @@ version: "97.18"
 					err = ioutil.WriteFile(filepath.Join(storeDir, "invalid.tgz"), gzipBuffer.Bytes(), 0666)
 					Expect(err).ToNot(HaveOccurred())
 
-					imagePath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
-					defer stemcellStore.Cleanup()
+					stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
 
 					Expect(err).ToNot(HaveOccurred())
-					Expect(imagePath).To(Equal(""))
+					Expect(stemcellPath).To(Equal(""))
 				})
 
 				It("ignores directories", func() {
 					err = os.Mkdir(filepath.Join(storeDir, "some-dir"), 0777)
 					Expect(err).ToNot(HaveOccurred())
 
-					imagePath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
-					defer stemcellStore.Cleanup()
+					stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
 
 					Expect(err).ToNot(HaveOccurred())
-					Expect(imagePath).To(Equal(""))
+					Expect(stemcellPath).To(Equal(""))
 				})
 			})
 		})
@@ -194,16 +291,380 @@ version: "97.18"
 			BeforeEach(func() {
 				storeDir := filepath.Join("a", "fake", "dir")
 				config.StemcellStorePathReturns(storeDir)
-				stemcellStore = stemcell.NewStemcellStore(config, compressor, fs, logger)
+				stemcellStore = stemcell.NewStemcellStore(config, driver.NewRetryFileLock(logger), compressor, fs, logger)
 			})
 
 			It("returns empty string", func() {
-				imagePath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
-				defer stemcellStore.Cleanup()
+				stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "foobar")
 
 				Expect(err).ToNot(HaveOccurred())
-				Expect(imagePath).To(Equal(""))
+				Expect(stemcellPath).To(Equal(""))
 			})
 		})
 	})
+
+	Describe("GetByMetadata from mirrors", func() {
+		var (
+			tarballContent []byte
+			checksumFiles  map[string]string
+			requests       []*http.Request
+			server         *httptest.Server
+			fileName       string
+		)
+
+		BeforeEach(func() {
+			storeDir, err = ioutil.TempDir("", "stemcell-store-")
+			Expect(err).ToNot(HaveOccurred())
+
+			fileName = "bosh-stemcell-97.18-vsphere-esxi-ubuntu-xenial-go_agent.tgz"
+			image := bytes.Repeat([]byte("image"), 1000)
+			tarballContent = mirrorTarball(fmt.Sprintf("name: bosh-vsphere-esxi-ubuntu-xenial-go_agent\nversion: \"97.18\"\nsha1: %x", sha1.Sum(image)), image)
+
+			checksumFiles = map[string]string{
+				"/mirror/" + fileName + ".sha256": fmt.Sprintf("%x  %s\n", sha256.Sum256(tarballContent), fileName),
+			}
+
+			requests = nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r)
+
+				if checksum, found := checksumFiles[r.URL.Path]; found {
+					w.Write([]byte(checksum))
+					return
+				}
+
+				if r.URL.Path == "/mirror/"+fileName {
+					http.ServeContent(w, r, fileName, time.Now(), bytes.NewReader(tarballContent))
+					return
+				}
+
+				http.NotFound(w, r)
+			}))
+
+			config.StemcellStorePathReturns(filepath.Join(storeDir, "store"))
+			config.StemcellMirrorURLsReturns([]string{server.URL + "/empty-mirror", server.URL + "/mirror/"})
+			stemcellStore = stemcell.NewStemcellStore(config, driver.NewRetryFileLock(logger), compressor, fs, logger)
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.RemoveAll(storeDir)
+		})
+
+		It("downloads missing stemcells into the store from the first mirror that has them", func() {
+			stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.18")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(stemcellPath).To(Equal(filepath.Join(storeDir, "store", fileName)))
+			Expect(ioutil.ReadFile(stemcellPath)).To(Equal(tarballContent))
+			Expect(stemcellPath + ".part").ToNot(BeAnExistingFile())
+
+			stemcellPath, err = stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.18")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcellPath).To(Equal(filepath.Join(storeDir, "store", fileName)))
+			Expect(requests[len(requests)-1].URL.Path).To(Equal("/mirror/" + fileName))
+		})
+
+		It("resumes partial downloads", func() {
+			partPath := filepath.Join(storeDir, "store", fileName+".part")
+			Expect(os.MkdirAll(filepath.Dir(partPath), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(partPath, tarballContent[:100], 0644)).To(Succeed())
+
+			stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.18")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ioutil.ReadFile(stemcellPath)).To(Equal(tarballContent))
+			Expect(requests[len(requests)-1].Header.Get("Range")).To(Equal("bytes=100-"))
+		})
+
+		It("verifies sha1 checksums", func() {
+			checksumFiles = map[string]string{
+				"/mirror/" + fileName + ".sha1": fmt.Sprintf("%x", sha1.Sum(tarballContent)),
+			}
+
+			stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.18")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcellPath).To(Equal(filepath.Join(storeDir, "store", fileName)))
+		})
+
+		It("rejects downloads that do not match the checksum", func() {
+			checksumFiles["/mirror/"+fileName+".sha256"] = fmt.Sprintf("%x", sha256.Sum256([]byte("other")))
+
+			stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.18")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("checksum mismatch"))
+
+			Expect(stemcellPath).To(BeEmpty())
+			Expect(filepath.Join(storeDir, "store", fileName)).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(storeDir, "store", fileName+".part")).ToNot(BeAnExistingFile())
+		})
+
+		Context("when the mirror has no checksum file", func() {
+			BeforeEach(func() {
+				checksumFiles = map[string]string{}
+			})
+
+			It("verifies the image against the sha1 of the stemcell manifest", func() {
+				stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.18")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(stemcellPath).To(Equal(filepath.Join(storeDir, "store", fileName)))
+				Expect(ioutil.ReadFile(stemcellPath)).To(Equal(tarballContent))
+			})
+
+			It("rejects stemcells whose image does not match the stemcell manifest", func() {
+				tarballContent = mirrorTarball("name: bosh-vsphere-esxi-ubuntu-xenial-go_agent\nversion: \"97.18\"\nsha1: da39a3ee5e6b4b0d3255bfef95601890afd80709", []byte("image"))
+
+				stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.18")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("checksum mismatch"))
+
+				Expect(stemcellPath).To(BeEmpty())
+				Expect(filepath.Join(storeDir, "store", fileName)).ToNot(BeAnExistingFile())
+				Expect(filepath.Join(storeDir, "store", fileName+".part")).ToNot(BeAnExistingFile())
+			})
+
+			It("rejects stemcells without an image sha1", func() {
+				tarballContent = mirrorTarball("name: bosh-vsphere-esxi-ubuntu-xenial-go_agent\nversion: \"97.18\"", []byte("image"))
+
+				_, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.18")
+				Expect(err).To(MatchError(ContainSubstring("no image checksum in stemcell manifest")))
+				Expect(filepath.Join(storeDir, "store", fileName)).ToNot(BeAnExistingFile())
+			})
+		})
+
+		It("returns no stemcell when no mirror has it", func() {
+			stemcellPath, err := stemcellStore.GetByMetadata("bosh-vsphere-esxi-ubuntu-xenial-go_agent", "97.19")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcellPath).To(BeEmpty())
+
+			partPaths, err := filepath.Glob(filepath.Join(storeDir, "store", "*.part"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(partPaths).To(BeEmpty())
+		})
+	})
+
+	Describe("GarbageCollect", func() {
+		var (
+			mappingsDir string
+			oldTime     time.Time
+		)
+
+		BeforeEach(func() {
+			storeDir, err = ioutil.TempDir("", "stemcell-store-")
+			Expect(err).ToNot(HaveOccurred())
+
+			mappingsDir = filepath.Join(storeDir, "mappings")
+			Expect(os.Mkdir(mappingsDir, 0755)).To(Succeed())
+
+			config.StemcellStorePathReturns(storeDir)
+			stemcellStore = stemcell.NewStemcellStore(config, driver.NewRetryFileLock(logger), compressor, fs, logger)
+
+			oldTime = time.Now().Add(-10 * 24 * time.Hour)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(storeDir)
+		})
+
+		writeStoreTarball := func(fileName, name, version string, modTime time.Time) string {
+			tarballPath := filepath.Join(storeDir, fileName)
+			writeStemcellTarball(tarballPath, fmt.Sprintf("name: %s\nversion: \"%s\"", name, version))
+			Expect(os.Chtimes(tarballPath, modTime, modTime)).To(Succeed())
+			return tarballPath
+		}
+
+		writeMapping := func(fileName, tarballPath string, modTime time.Time) string {
+			mappingPath := filepath.Join(mappingsDir, fileName)
+			Expect(ioutil.WriteFile(mappingPath, []byte(tarballPath), 0644)).To(Succeed())
+			Expect(os.Chtimes(mappingPath, modTime, modTime)).To(Succeed())
+			return mappingPath
+		}
+
+		It("keeps the newest versions of each stemcell name", func() {
+			a9 := writeStoreTarball("a-9.tgz", "stemcell-a", "97.9", time.Now())
+			a18 := writeStoreTarball("a-18.tgz", "stemcell-a", "97.18", time.Now())
+			a100 := writeStoreTarball("a-100.tgz", "stemcell-a", "100", time.Now())
+			b1 := writeStoreTarball("b-1.tgz", "stemcell-b", "1", time.Now())
+
+			removedPaths, err := stemcellStore.GarbageCollect(stemcell.RetentionPolicy{KeepVersions: 2}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(removedPaths).To(ConsistOf(a9))
+			Expect(a9).ToNot(BeAnExistingFile())
+			Expect(a18).To(BeAnExistingFile())
+			Expect(a100).To(BeAnExistingFile())
+			Expect(b1).To(BeAnExistingFile())
+
+			stemcellPath, err := stemcellStore.GetByMetadata("stemcell-a", "97.9")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcellPath).To(BeEmpty())
+		})
+
+		It("removes unreferenced stemcells and mappings older than the max unused age", func() {
+			oldTarball := writeStoreTarball("old.tgz", "stemcell-a", "1", oldTime)
+			newTarball := writeStoreTarball("new.tgz", "stemcell-a", "2", time.Now())
+			referencedTarball := writeStoreTarball("referenced.tgz", "stemcell-b", "1", oldTime)
+			referencingMapping := writeMapping("referencing.mapping", referencedTarball, time.Now())
+			oldMapping := writeMapping("old.mapping", newTarball, oldTime)
+
+			removedPaths, err := stemcellStore.GarbageCollect(stemcell.RetentionPolicy{MaxUnusedAge: 7 * 24 * time.Hour}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(removedPaths).To(ConsistOf(oldTarball, oldMapping))
+			Expect(oldTarball).ToNot(BeAnExistingFile())
+			Expect(oldMapping).ToNot(BeAnExistingFile())
+			Expect(newTarball).To(BeAnExistingFile())
+			Expect(referencedTarball).To(BeAnExistingFile())
+			Expect(referencingMapping).To(BeAnExistingFile())
+		})
+
+		It("keeps old stemcells that were handed out within the max unused age", func() {
+			usedTarball := writeStoreTarball("used.tgz", "stemcell-a", "1", oldTime)
+			mappedTarball := writeStoreTarball("mapped.tgz", "stemcell-b", "1", oldTime)
+			usedMapping := writeMapping(fmt.Sprintf("%x.mapping", sha1.Sum([]byte("/image"))), mappedTarball, oldTime)
+
+			stemcellPath, err := stemcellStore.GetByMetadata("stemcell-a", "1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcellPath).To(Equal(usedTarball))
+
+			stemcellPath, err = stemcellStore.GetByImagePathMapping("/image")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcellPath).To(Equal(mappedTarball))
+
+			Expect(os.Remove(usedMapping)).To(Succeed())
+
+			removedPaths, err := stemcellStore.GarbageCollect(stemcell.RetentionPolicy{MaxUnusedAge: 7 * 24 * time.Hour}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(removedPaths).To(BeEmpty())
+			Expect(usedTarball).To(BeAnExistingFile())
+			Expect(mappedTarball).To(BeAnExistingFile())
+		})
+
+		It("keeps old mappings that were handed out within the max unused age", func() {
+			tarball := writeStoreTarball("mapped.tgz", "stemcell-a", "1", time.Now())
+			usedMapping := writeMapping(fmt.Sprintf("%x.mapping", sha1.Sum([]byte("/image"))), tarball, oldTime)
+
+			_, err := stemcellStore.GetByImagePathMapping("/image")
+			Expect(err).ToNot(HaveOccurred())
+
+			removedPaths, err := stemcellStore.GarbageCollect(stemcell.RetentionPolicy{MaxUnusedAge: 7 * 24 * time.Hour}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(removedPaths).To(BeEmpty())
+			Expect(usedMapping).To(BeAnExistingFile())
+		})
+
+		It("keeps referenced stemcells beyond the kept versions", func() {
+			a1 := writeStoreTarball("a-1.tgz", "stemcell-a", "1", time.Now())
+			writeStoreTarball("a-2.tgz", "stemcell-a", "2", time.Now())
+			writeMapping("a-1.mapping", a1, time.Now())
+
+			removedPaths, err := stemcellStore.GarbageCollect(stemcell.RetentionPolicy{KeepVersions: 1}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(removedPaths).To(BeEmpty())
+			Expect(a1).To(BeAnExistingFile())
+		})
+
+		It("keeps stemcells of imported images beyond the kept versions and max unused age", func() {
+			a1 := filepath.Join(storeDir, "a-1.tgz")
+			writeStemcellTarball(a1, "name: stemcell-a\nversion: \"1\"\nsha1: DA39A3EE5E6B4B0D3255BFEF95601890AFD80709")
+			Expect(os.Chtimes(a1, oldTime, oldTime)).To(Succeed())
+			writeStoreTarball("a-2.tgz", "stemcell-a", "2", time.Now())
+
+			removedPaths, err := stemcellStore.GarbageCollect(stemcell.RetentionPolicy{
+				KeepVersions:      1,
+				MaxUnusedAge:      7 * 24 * time.Hour,
+				ImportedImageKeys: []string{"da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+			}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(removedPaths).To(BeEmpty())
+			Expect(a1).To(BeAnExistingFile())
+		})
+
+		It("holds the index lock for the whole pass", func() {
+			retryFileLock := &driverfakes.FakeRetryFileLock{}
+			retryFileLock.TryStub = func(lockPath string, maxWait time.Duration, fn func() error) error {
+				return fn()
+			}
+			stemcellStore = stemcell.NewStemcellStore(config, retryFileLock, compressor, fs, logger)
+
+			a1 := writeStoreTarball("a-1.tgz", "stemcell-a", "1", time.Now())
+			writeStoreTarball("a-2.tgz", "stemcell-a", "2", time.Now())
+
+			removedPaths, err := stemcellStore.GarbageCollect(stemcell.RetentionPolicy{KeepVersions: 1}, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removedPaths).To(ConsistOf(a1))
+
+			Expect(retryFileLock.TryCallCount()).To(Equal(1))
+			lockPath, _, _ := retryFileLock.TryArgsForCall(0)
+			Expect(lockPath).To(Equal(filepath.Join(storeDir, "index.json.lock")))
+		})
+
+		It("removes mappings of missing stemcells", func() {
+			missingMapping := writeMapping("missing.mapping", filepath.Join(storeDir, "missing.tgz"), time.Now())
+
+			removedPaths, err := stemcellStore.GarbageCollect(stemcell.RetentionPolicy{KeepVersions: 1}, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(removedPaths).To(ConsistOf(missingMapping))
+			Expect(missingMapping).ToNot(BeAnExistingFile())
+		})
+
+		It("only lists what would be removed in dry-run mode", func() {
+			a1 := writeStoreTarball("a-1.tgz", "stemcell-a", "1", oldTime)
+			writeStoreTarball("a-2.tgz", "stemcell-a", "2", time.Now())
+
+			removedPaths, err := stemcellStore.GarbageCollect(stemcell.RetentionPolicy{KeepVersions: 1, MaxUnusedAge: 7 * 24 * time.Hour}, true)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(removedPaths).To(ConsistOf(a1))
+			Expect(a1).To(BeAnExistingFile())
+		})
+
+		It("requires a retention policy", func() {
+			_, err := stemcellStore.GarbageCollect(stemcell.RetentionPolicy{}, true)
+			Expect(err).To(MatchError("retention policy requires keep versions or max unused age"))
+		})
+	})
 })
+
+func mirrorTarball(manifestContent string, image []byte) []byte {
+	tarballFile, err := ioutil.TempFile("", "mirror-tarball-")
+	Expect(err).ToNot(HaveOccurred())
+	tarballFile.Close()
+	defer os.Remove(tarballFile.Name())
+
+	writeStemcellTarballWithImage(tarballFile.Name(), manifestContent, image)
+
+	tarballContent, err := ioutil.ReadFile(tarballFile.Name())
+	Expect(err).ToNot(HaveOccurred())
+
+	return tarballContent
+}
+
+func writeStemcellTarball(tarballPath string, manifestContent string) {
+	writeStemcellTarballWithImage(tarballPath, manifestContent, []byte{})
+}
+
+func writeStemcellTarballWithImage(tarballPath string, manifestContent string, image []byte) {
+	tarGzFile, err := os.Create(tarballPath)
+	Expect(err).ToNot(HaveOccurred())
+
+	gzipWriter := gzip.NewWriter(tarGzFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	Expect(tarWriter.WriteHeader(&tar.Header{Name: "stemcell.MF", Size: int64(len(manifestContent)), Mode: 0666})).To(Succeed())
+	_, err = tarWriter.Write([]byte(manifestContent))
+	Expect(err).ToNot(HaveOccurred())
+
+	Expect(tarWriter.WriteHeader(&tar.Header{Name: "image", Size: int64(len(image)), Mode: 0666})).To(Succeed())
+	_, err = tarWriter.Write(image)
+	Expect(err).ToNot(HaveOccurred())
+
+	Expect(tarWriter.Close()).To(Succeed())
+	Expect(gzipWriter.Close()).To(Succeed())
+	Expect(tarGzFile.Close()).To(Succeed())
+}
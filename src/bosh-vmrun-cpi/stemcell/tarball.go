@@ -0,0 +1,114 @@
+package stemcell
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+func withTarballFile(tarGzPath string, desiredTarballFilePath string, logger boshlog.Logger, callback func(io.Reader) error) error {
+	var err error
+	var gzipFile *os.File
+
+	if gzipFile, err = os.Open(tarGzPath); err != nil {
+		return err
+	}
+	defer gzipFile.Close()
+
+	var gzipReader *gzip.Reader
+	gzipReader, err = gzip.NewReader(gzipFile)
+
+	if err != nil {
+		return err
+	}
+
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader) // no Close()
+
+	for {
+		var tarHeader *tar.Header
+		tarHeader, err = tarReader.Next()
+
+		if err == io.EOF {
+			break // End of archive
+		}
+
+		if err != nil {
+			return err
+		}
+
+		tarHeaderFilePath := tarHeader.Name
+
+		switch tarHeader.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+			logger.Debug("stemcell-store", "stemcell content file %s", tarHeaderFilePath)
+
+			if tarHeaderFilePath == desiredTarballFilePath || tarHeaderFilePath == "./"+desiredTarballFilePath {
+
+				//call callback and exit early if file was found
+				return callback(tarReader)
+			}
+		default:
+			logger.Error("stemcell-store", "unable to determine file type %c in file %s", tarHeader.Typeflag, tarHeaderFilePath)
+		}
+	}
+
+	return nil
+}
+
+func readStemcellManifest(stemcellTarballPath string, logger boshlog.Logger) (*StemcellManifest, error) {
+	var manifest *StemcellManifest
+
+	err := withTarballFile(stemcellTarballPath, stemcellManifestFileName, logger, func(manifestReader io.Reader) error {
+		manifestContent, err := ioutil.ReadAll(manifestReader)
+		if err != nil {
+			return err
+		}
+
+		manifest, err = NewStemcellManifest(manifestContent)
+		return err
+	})
+
+	return manifest, err
+}
+
+// verifyStemcellImage compares the image of a stemcell tarball against the sha1 of its stemcell manifest
+func verifyStemcellImage(stemcellTarballPath string, logger boshlog.Logger) error {
+	manifest, err := readStemcellManifest(stemcellTarballPath, logger)
+	if err != nil {
+		return err
+	}
+
+	if manifest == nil || manifest.Sha1 == "" {
+		return fmt.Errorf("no image checksum in stemcell manifest of %s", stemcellTarballPath)
+	}
+
+	verifier, err := newChecksumVerifier("image in "+stemcellTarballPath, manifest.Sha1)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	err = withTarballFile(stemcellTarballPath, "image", logger, func(imageReader io.Reader) error {
+		found = true
+		_, err := io.Copy(verifier, imageReader)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("stemcell %s does not contain an image", stemcellTarballPath)
+	}
+
+	return verifier.Verify()
+}
@@ -1,8 +1,7 @@
 package vm
 
 import (
-	"crypto/rand"
-	"fmt"
+	"encoding/base64"
 	"io"
 	"io/ioutil"
 	"os"
@@ -92,35 +91,26 @@ func (s AgentSettingsImpl) GetIsoAgentEnv(isoPath string) (apiv1.AgentEnv, error
 	return agentEnv, nil
 }
 
-func (s AgentSettingsImpl) GetNetworkSettings(network apiv1.Network) (adapterName string, macAddress string, err error) {
-	var networkCloudProps struct {
-		Name string
-		Type string //remove?
-	}
-	err = network.CloudProps().As(&networkCloudProps)
+func (s AgentSettingsImpl) GenerateAgentEnvGuestInfo(agentEnv apiv1.AgentEnv) (string, error) {
+	envBytes, err := agentEnv.AsBytes()
 	if err != nil {
-		return "", "", err
+		return "", bosherr.WrapError(err, "unmarshal agent env")
 	}
-	adapterName = networkCloudProps.Name
 
-	macAddress, err = s.generateMacAddress()
+	return base64.StdEncoding.EncodeToString(envBytes), nil
+}
+
+func (s AgentSettingsImpl) GetGuestInfoAgentEnv(guestInfoValue string) (apiv1.AgentEnv, error) {
+	envBytes, err := base64.StdEncoding.DecodeString(guestInfoValue)
 	if err != nil {
-		return "", "", err
+		return nil, bosherr.WrapError(err, "decoding guestinfo agent env")
 	}
 
-	return adapterName, macAddress, err
+	return s.agentEnvFactory.FromBytes(envBytes)
 }
 
-func (s AgentSettingsImpl) generateMacAddress() (string, error) {
-	buf := make([]byte, 2)
-	_, err := rand.Read(buf)
-	if err != nil {
-		return "", err
-	}
-	buf[0] |= 2
-	//MAC OUI when manually set: https://pubs.vmware.com/vsphere-4-esx-vcenter/index.jsp?topic=/com.vmware.vsphere.server_configclassic.doc_41/esx_server_config/advanced_networking/c_setting_up_mac_addresses.html
-	//note: naive implementation, limited to 64 hosts. The actual range is 00:50:56:00:00:00 to 00:50:56:3f:ff:ff
-	return fmt.Sprintf("00:50:56:3f:%02x:%02x", buf[0], buf[1]), nil
+func (s AgentSettingsImpl) GetNetworkSettings(network apiv1.Network) (*NetworkProps, error) {
+	return NewNetworkProps(network.CloudProps())
 }
 
 func (s AgentSettingsImpl) Cleanup() {
@@ -46,6 +46,26 @@ var _ = Describe("AgentSettings", func() {
 		})
 	})
 
+	Describe("GenerateAgentEnvGuestInfo", func() {
+		It("round trips the agent env through a base64 guestinfo value", func() {
+			agentEnv, err := agentEnvFactory.FromBytes([]byte(`{"agent_id":"agent-0","vm":{"name":"vm-1","id":"vm-1"}}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			guestInfoValue, err := agentSettings.GenerateAgentEnvGuestInfo(agentEnv)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(guestInfoValue).To(MatchRegexp(`^[A-Za-z0-9+/=]+$`))
+
+			actualAgentEnv, err := agentSettings.GetGuestInfoAgentEnv(guestInfoValue)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualAgentEnv).To(Equal(agentEnv))
+		})
+
+		It("returns an error for values that are not base64", func() {
+			_, err := agentSettings.GetGuestInfoAgentEnv("{not base64}")
+			Expect(err).To(MatchError(ContainSubstring("decoding guestinfo agent env")))
+		})
+	})
+
 	Describe("AgentEnvBytesFromFile", func() {
 		It("returns AgentEnv from the env iso", func() {
 			isoPath := "../test/fixtures/env.iso"
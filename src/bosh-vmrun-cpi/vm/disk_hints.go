@@ -0,0 +1,70 @@
+package vm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bosh-vmrun-cpi/vmx"
+)
+
+type diskHint struct {
+	Path     string `json:"path"`      //can be removed?
+	VolumeID string `json:"volume_id"` //should be 3?
+	Lun      string `json:"lun"`
+}
+
+// EphemeralDiskHint describes the ephemeral disk by the device it was attached as (ex: sata0:1), scsiDisks is
+// the number of disks on scsi controllers that come before sata disks
+func EphemeralDiskHint(diskVMXID string, scsiDisks int) interface{} {
+	switch diskBus(diskVMXID) {
+	case vmx.DISK_CONTROLLER_NVME:
+		return diskHint{Path: nvmeDevicePath(diskVMXID), VolumeID: "1", Lun: "0"}
+	case vmx.DISK_CONTROLLER_SATA:
+		return diskHint{Path: sataDevicePath(diskVMXID, scsiDisks), VolumeID: "1", Lun: "0"}
+	}
+
+	return strconv.Itoa(diskUnit(diskVMXID))
+}
+
+func PersistentDiskHint(diskVMXID string, scsiDisks int) interface{} {
+	switch diskBus(diskVMXID) {
+	case vmx.DISK_CONTROLLER_NVME:
+		return diskHint{Path: nvmeDevicePath(diskVMXID), VolumeID: "2", Lun: "0"}
+	case vmx.DISK_CONTROLLER_SATA:
+		return diskHint{Path: sataDevicePath(diskVMXID, scsiDisks), VolumeID: "2", Lun: "0"}
+	}
+
+	return diskHint{Path: "/dev/sdc", VolumeID: "2", Lun: "0"}
+}
+
+// diskBus is the controller type of a device id (ex: sata for sata0:1), scsi devices are all lsilogic
+func diskBus(diskVMXID string) string {
+	switch {
+	case strings.HasPrefix(diskVMXID, "nvme"):
+		return vmx.DISK_CONTROLLER_NVME
+	case strings.HasPrefix(diskVMXID, "sata"):
+		return vmx.DISK_CONTROLLER_SATA
+	}
+
+	return vmx.DISK_CONTROLLER_LSILOGIC
+}
+
+// sata disks follow the scsi disks in port order: with a scsi system disk sata0:0 is /dev/sdb
+func sataDevicePath(diskVMXID string, scsiDisks int) string {
+	return fmt.Sprintf("/dev/sd%c", 'a'+scsiDisks+diskUnit(diskVMXID))
+}
+
+// each NVMe disk is a namespace of the controller: nvme0:0 is /dev/nvme0n1
+func nvmeDevicePath(diskVMXID string) string {
+	return fmt.Sprintf("/dev/nvme0n%d", diskUnit(diskVMXID)+1)
+}
+
+func diskUnit(diskVMXID string) int {
+	unit := 0
+	if parts := strings.SplitN(diskVMXID, ":", 2); len(parts) == 2 {
+		unit, _ = strconv.Atoi(parts[1])
+	}
+
+	return unit
+}
@@ -0,0 +1,60 @@
+package vm_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-vmrun-cpi/vm"
+)
+
+var _ = Describe("DiskHints", func() {
+	Describe("EphemeralDiskHint", func() {
+		It("uses the scsi id of scsi disks", func() {
+			Expect(vm.EphemeralDiskHint("scsi0:1", 2)).To(Equal("1"))
+		})
+
+		It("uses the namespace matching the nvme unit", func() {
+			hintJson, err := json.Marshal(vm.EphemeralDiskHint("nvme0:0", 1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hintJson).To(MatchJSON(`{"path":"/dev/nvme0n1","volume_id":"1","lun":"0"}`))
+
+			hintJson, err = json.Marshal(vm.EphemeralDiskHint("nvme0:1", 0))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hintJson).To(MatchJSON(`{"path":"/dev/nvme0n2","volume_id":"1","lun":"0"}`))
+		})
+
+		It("uses the sata device after the scsi disks", func() {
+			hintJson, err := json.Marshal(vm.EphemeralDiskHint("sata0:0", 1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hintJson).To(MatchJSON(`{"path":"/dev/sdb","volume_id":"1","lun":"0"}`))
+		})
+
+		It("uses the sata device after a sata system disk", func() {
+			hintJson, err := json.Marshal(vm.EphemeralDiskHint("sata0:1", 0))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hintJson).To(MatchJSON(`{"path":"/dev/sdb","volume_id":"1","lun":"0"}`))
+		})
+	})
+
+	Describe("PersistentDiskHint", func() {
+		It("uses the scsi device by default", func() {
+			hintJson, err := json.Marshal(vm.PersistentDiskHint("scsi0:2", 2))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hintJson).To(MatchJSON(`{"path":"/dev/sdc","volume_id":"2","lun":"0"}`))
+		})
+
+		It("uses the namespace matching the nvme unit", func() {
+			hintJson, err := json.Marshal(vm.PersistentDiskHint("nvme0:1", 1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hintJson).To(MatchJSON(`{"path":"/dev/nvme0n2","volume_id":"2","lun":"0"}`))
+		})
+
+		It("uses the device matching the sata unit", func() {
+			hintJson, err := json.Marshal(vm.PersistentDiskHint("sata0:1", 1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hintJson).To(MatchJSON(`{"path":"/dev/sdc","volume_id":"2","lun":"0"}`))
+		})
+	})
+})
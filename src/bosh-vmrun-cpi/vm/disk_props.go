@@ -0,0 +1,20 @@
+package vm
+
+import (
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+)
+
+type DiskProps struct {
+	Datastore string
+}
+
+func NewDiskProps(cloudProps apiv1.DiskCloudProps) (*DiskProps, error) {
+	diskProps := &DiskProps{}
+
+	err := cloudProps.As(&diskProps)
+	if err != nil {
+		return &DiskProps{}, err
+	}
+
+	return diskProps, nil
+}
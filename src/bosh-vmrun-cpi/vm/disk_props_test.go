@@ -0,0 +1,34 @@
+package vm_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-vmrun-cpi/vm"
+
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+)
+
+var _ = Describe("DiskProps", func() {
+	Describe("NewDiskProps", func() {
+		It("defaults to no datastore", func() {
+			var cloudProps apiv1.CloudPropsImpl
+			Expect(json.Unmarshal([]byte(`{}`), &cloudProps)).To(Succeed())
+
+			diskProps, err := vm.NewDiskProps(cloudProps)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskProps.Datastore).To(Equal(""))
+		})
+
+		It("sets the datastore", func() {
+			var cloudProps apiv1.CloudPropsImpl
+			Expect(json.Unmarshal([]byte(`{"datastore":"ssd"}`), &cloudProps)).To(Succeed())
+
+			diskProps, err := vm.NewDiskProps(cloudProps)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskProps.Datastore).To(Equal("ssd"))
+		})
+	})
+})
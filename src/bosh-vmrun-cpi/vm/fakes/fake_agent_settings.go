@@ -13,6 +13,19 @@ type FakeAgentSettings struct {
 	cleanupMutex       sync.RWMutex
 	cleanupArgsForCall []struct {
 	}
+	GenerateAgentEnvGuestInfoStub        func(apiv1.AgentEnv) (string, error)
+	generateAgentEnvGuestInfoMutex       sync.RWMutex
+	generateAgentEnvGuestInfoArgsForCall []struct {
+		arg1 apiv1.AgentEnv
+	}
+	generateAgentEnvGuestInfoReturns struct {
+		result1 string
+		result2 error
+	}
+	generateAgentEnvGuestInfoReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	GenerateAgentEnvIsoStub        func(apiv1.AgentEnv) (string, error)
 	generateAgentEnvIsoMutex       sync.RWMutex
 	generateAgentEnvIsoArgsForCall []struct {
@@ -26,6 +39,19 @@ type FakeAgentSettings struct {
 		result1 string
 		result2 error
 	}
+	GetGuestInfoAgentEnvStub        func(string) (apiv1.AgentEnv, error)
+	getGuestInfoAgentEnvMutex       sync.RWMutex
+	getGuestInfoAgentEnvArgsForCall []struct {
+		arg1 string
+	}
+	getGuestInfoAgentEnvReturns struct {
+		result1 apiv1.AgentEnv
+		result2 error
+	}
+	getGuestInfoAgentEnvReturnsOnCall map[int]struct {
+		result1 apiv1.AgentEnv
+		result2 error
+	}
 	GetIsoAgentEnvStub        func(string) (apiv1.AgentEnv, error)
 	getIsoAgentEnvMutex       sync.RWMutex
 	getIsoAgentEnvArgsForCall []struct {
@@ -39,20 +65,18 @@ type FakeAgentSettings struct {
 		result1 apiv1.AgentEnv
 		result2 error
 	}
-	GetNetworkSettingsStub        func(apiv1.Network) (string, string, error)
+	GetNetworkSettingsStub        func(apiv1.Network) (*vm.NetworkProps, error)
 	getNetworkSettingsMutex       sync.RWMutex
 	getNetworkSettingsArgsForCall []struct {
 		arg1 apiv1.Network
 	}
 	getNetworkSettingsReturns struct {
-		result1 string
-		result2 string
-		result3 error
+		result1 *vm.NetworkProps
+		result2 error
 	}
 	getNetworkSettingsReturnsOnCall map[int]struct {
-		result1 string
-		result2 string
-		result3 error
+		result1 *vm.NetworkProps
+		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
@@ -81,6 +105,69 @@ func (fake *FakeAgentSettings) CleanupCalls(stub func()) {
 	fake.CleanupStub = stub
 }
 
+func (fake *FakeAgentSettings) GenerateAgentEnvGuestInfo(arg1 apiv1.AgentEnv) (string, error) {
+	fake.generateAgentEnvGuestInfoMutex.Lock()
+	ret, specificReturn := fake.generateAgentEnvGuestInfoReturnsOnCall[len(fake.generateAgentEnvGuestInfoArgsForCall)]
+	fake.generateAgentEnvGuestInfoArgsForCall = append(fake.generateAgentEnvGuestInfoArgsForCall, struct {
+		arg1 apiv1.AgentEnv
+	}{arg1})
+	fake.recordInvocation("GenerateAgentEnvGuestInfo", []interface{}{arg1})
+	fake.generateAgentEnvGuestInfoMutex.Unlock()
+	if fake.GenerateAgentEnvGuestInfoStub != nil {
+		return fake.GenerateAgentEnvGuestInfoStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.generateAgentEnvGuestInfoReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentSettings) GenerateAgentEnvGuestInfoCallCount() int {
+	fake.generateAgentEnvGuestInfoMutex.RLock()
+	defer fake.generateAgentEnvGuestInfoMutex.RUnlock()
+	return len(fake.generateAgentEnvGuestInfoArgsForCall)
+}
+
+func (fake *FakeAgentSettings) GenerateAgentEnvGuestInfoCalls(stub func(apiv1.AgentEnv) (string, error)) {
+	fake.generateAgentEnvGuestInfoMutex.Lock()
+	defer fake.generateAgentEnvGuestInfoMutex.Unlock()
+	fake.GenerateAgentEnvGuestInfoStub = stub
+}
+
+func (fake *FakeAgentSettings) GenerateAgentEnvGuestInfoArgsForCall(i int) apiv1.AgentEnv {
+	fake.generateAgentEnvGuestInfoMutex.RLock()
+	defer fake.generateAgentEnvGuestInfoMutex.RUnlock()
+	argsForCall := fake.generateAgentEnvGuestInfoArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAgentSettings) GenerateAgentEnvGuestInfoReturns(result1 string, result2 error) {
+	fake.generateAgentEnvGuestInfoMutex.Lock()
+	defer fake.generateAgentEnvGuestInfoMutex.Unlock()
+	fake.GenerateAgentEnvGuestInfoStub = nil
+	fake.generateAgentEnvGuestInfoReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentSettings) GenerateAgentEnvGuestInfoReturnsOnCall(i int, result1 string, result2 error) {
+	fake.generateAgentEnvGuestInfoMutex.Lock()
+	defer fake.generateAgentEnvGuestInfoMutex.Unlock()
+	fake.GenerateAgentEnvGuestInfoStub = nil
+	if fake.generateAgentEnvGuestInfoReturnsOnCall == nil {
+		fake.generateAgentEnvGuestInfoReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.generateAgentEnvGuestInfoReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentSettings) GenerateAgentEnvIso(arg1 apiv1.AgentEnv) (string, error) {
 	fake.generateAgentEnvIsoMutex.Lock()
 	ret, specificReturn := fake.generateAgentEnvIsoReturnsOnCall[len(fake.generateAgentEnvIsoArgsForCall)]
@@ -144,6 +231,69 @@ func (fake *FakeAgentSettings) GenerateAgentEnvIsoReturnsOnCall(i int, result1 s
 	}{result1, result2}
 }
 
+func (fake *FakeAgentSettings) GetGuestInfoAgentEnv(arg1 string) (apiv1.AgentEnv, error) {
+	fake.getGuestInfoAgentEnvMutex.Lock()
+	ret, specificReturn := fake.getGuestInfoAgentEnvReturnsOnCall[len(fake.getGuestInfoAgentEnvArgsForCall)]
+	fake.getGuestInfoAgentEnvArgsForCall = append(fake.getGuestInfoAgentEnvArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetGuestInfoAgentEnv", []interface{}{arg1})
+	fake.getGuestInfoAgentEnvMutex.Unlock()
+	if fake.GetGuestInfoAgentEnvStub != nil {
+		return fake.GetGuestInfoAgentEnvStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getGuestInfoAgentEnvReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentSettings) GetGuestInfoAgentEnvCallCount() int {
+	fake.getGuestInfoAgentEnvMutex.RLock()
+	defer fake.getGuestInfoAgentEnvMutex.RUnlock()
+	return len(fake.getGuestInfoAgentEnvArgsForCall)
+}
+
+func (fake *FakeAgentSettings) GetGuestInfoAgentEnvCalls(stub func(string) (apiv1.AgentEnv, error)) {
+	fake.getGuestInfoAgentEnvMutex.Lock()
+	defer fake.getGuestInfoAgentEnvMutex.Unlock()
+	fake.GetGuestInfoAgentEnvStub = stub
+}
+
+func (fake *FakeAgentSettings) GetGuestInfoAgentEnvArgsForCall(i int) string {
+	fake.getGuestInfoAgentEnvMutex.RLock()
+	defer fake.getGuestInfoAgentEnvMutex.RUnlock()
+	argsForCall := fake.getGuestInfoAgentEnvArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAgentSettings) GetGuestInfoAgentEnvReturns(result1 apiv1.AgentEnv, result2 error) {
+	fake.getGuestInfoAgentEnvMutex.Lock()
+	defer fake.getGuestInfoAgentEnvMutex.Unlock()
+	fake.GetGuestInfoAgentEnvStub = nil
+	fake.getGuestInfoAgentEnvReturns = struct {
+		result1 apiv1.AgentEnv
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentSettings) GetGuestInfoAgentEnvReturnsOnCall(i int, result1 apiv1.AgentEnv, result2 error) {
+	fake.getGuestInfoAgentEnvMutex.Lock()
+	defer fake.getGuestInfoAgentEnvMutex.Unlock()
+	fake.GetGuestInfoAgentEnvStub = nil
+	if fake.getGuestInfoAgentEnvReturnsOnCall == nil {
+		fake.getGuestInfoAgentEnvReturnsOnCall = make(map[int]struct {
+			result1 apiv1.AgentEnv
+			result2 error
+		})
+	}
+	fake.getGuestInfoAgentEnvReturnsOnCall[i] = struct {
+		result1 apiv1.AgentEnv
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentSettings) GetIsoAgentEnv(arg1 string) (apiv1.AgentEnv, error) {
 	fake.getIsoAgentEnvMutex.Lock()
 	ret, specificReturn := fake.getIsoAgentEnvReturnsOnCall[len(fake.getIsoAgentEnvArgsForCall)]
@@ -207,7 +357,7 @@ func (fake *FakeAgentSettings) GetIsoAgentEnvReturnsOnCall(i int, result1 apiv1.
 	}{result1, result2}
 }
 
-func (fake *FakeAgentSettings) GetNetworkSettings(arg1 apiv1.Network) (string, string, error) {
+func (fake *FakeAgentSettings) GetNetworkSettings(arg1 apiv1.Network) (*vm.NetworkProps, error) {
 	fake.getNetworkSettingsMutex.Lock()
 	ret, specificReturn := fake.getNetworkSettingsReturnsOnCall[len(fake.getNetworkSettingsArgsForCall)]
 	fake.getNetworkSettingsArgsForCall = append(fake.getNetworkSettingsArgsForCall, struct {
@@ -219,10 +369,10 @@ func (fake *FakeAgentSettings) GetNetworkSettings(arg1 apiv1.Network) (string, s
 		return fake.GetNetworkSettingsStub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2, ret.result3
+		return ret.result1, ret.result2
 	}
 	fakeReturns := fake.getNetworkSettingsReturns
-	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeAgentSettings) GetNetworkSettingsCallCount() int {
@@ -231,7 +381,7 @@ func (fake *FakeAgentSettings) GetNetworkSettingsCallCount() int {
 	return len(fake.getNetworkSettingsArgsForCall)
 }
 
-func (fake *FakeAgentSettings) GetNetworkSettingsCalls(stub func(apiv1.Network) (string, string, error)) {
+func (fake *FakeAgentSettings) GetNetworkSettingsCalls(stub func(apiv1.Network) (*vm.NetworkProps, error)) {
 	fake.getNetworkSettingsMutex.Lock()
 	defer fake.getNetworkSettingsMutex.Unlock()
 	fake.GetNetworkSettingsStub = stub
@@ -244,33 +394,30 @@ func (fake *FakeAgentSettings) GetNetworkSettingsArgsForCall(i int) apiv1.Networ
 	return argsForCall.arg1
 }
 
-func (fake *FakeAgentSettings) GetNetworkSettingsReturns(result1 string, result2 string, result3 error) {
+func (fake *FakeAgentSettings) GetNetworkSettingsReturns(result1 *vm.NetworkProps, result2 error) {
 	fake.getNetworkSettingsMutex.Lock()
 	defer fake.getNetworkSettingsMutex.Unlock()
 	fake.GetNetworkSettingsStub = nil
 	fake.getNetworkSettingsReturns = struct {
-		result1 string
-		result2 string
-		result3 error
-	}{result1, result2, result3}
+		result1 *vm.NetworkProps
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeAgentSettings) GetNetworkSettingsReturnsOnCall(i int, result1 string, result2 string, result3 error) {
+func (fake *FakeAgentSettings) GetNetworkSettingsReturnsOnCall(i int, result1 *vm.NetworkProps, result2 error) {
 	fake.getNetworkSettingsMutex.Lock()
 	defer fake.getNetworkSettingsMutex.Unlock()
 	fake.GetNetworkSettingsStub = nil
 	if fake.getNetworkSettingsReturnsOnCall == nil {
 		fake.getNetworkSettingsReturnsOnCall = make(map[int]struct {
-			result1 string
-			result2 string
-			result3 error
+			result1 *vm.NetworkProps
+			result2 error
 		})
 	}
 	fake.getNetworkSettingsReturnsOnCall[i] = struct {
-		result1 string
-		result2 string
-		result3 error
-	}{result1, result2, result3}
+		result1 *vm.NetworkProps
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *FakeAgentSettings) Invocations() map[string][][]interface{} {
@@ -278,8 +425,12 @@ func (fake *FakeAgentSettings) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.cleanupMutex.RLock()
 	defer fake.cleanupMutex.RUnlock()
+	fake.generateAgentEnvGuestInfoMutex.RLock()
+	defer fake.generateAgentEnvGuestInfoMutex.RUnlock()
 	fake.generateAgentEnvIsoMutex.RLock()
 	defer fake.generateAgentEnvIsoMutex.RUnlock()
+	fake.getGuestInfoAgentEnvMutex.RLock()
+	defer fake.getGuestInfoAgentEnvMutex.RUnlock()
 	fake.getIsoAgentEnvMutex.RLock()
 	defer fake.getIsoAgentEnvMutex.RUnlock()
 	fake.getNetworkSettingsMutex.RLock()
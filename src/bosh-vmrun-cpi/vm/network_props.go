@@ -0,0 +1,32 @@
+package vm
+
+import (
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+)
+
+type NetworkProps struct {
+	Name            string
+	Type            string //remove?
+	Connection_Type string
+	Device_Type     string
+	Isolated        bool
+}
+
+func NewNetworkProps(cloudProps apiv1.NetworkCloudProps) (*NetworkProps, error) {
+	networkProps := &NetworkProps{
+		Connection_Type: "custom",
+		Device_Type:     "vmxnet3",
+	}
+
+	err := cloudProps.As(&networkProps)
+	if err != nil {
+		return &NetworkProps{}, err
+	}
+
+	// isolated networks are LAN segments, only reachable by VMs on the same segment
+	if networkProps.Isolated {
+		networkProps.Connection_Type = "pvn"
+	}
+
+	return networkProps, nil
+}
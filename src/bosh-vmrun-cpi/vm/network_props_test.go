@@ -0,0 +1,52 @@
+package vm_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-vmrun-cpi/vm"
+
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+)
+
+var _ = Describe("NetworkProps", func() {
+	Describe("NewNetworkProps", func() {
+		It("defaults to a custom vmnet with a vmxnet3 device", func() {
+			var cloudProps apiv1.CloudPropsImpl
+			Expect(json.Unmarshal([]byte(`{"name": "VM Network"}`), &cloudProps)).To(Succeed())
+
+			networkProps, err := vm.NewNetworkProps(cloudProps)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(networkProps.Name).To(Equal("VM Network"))
+			Expect(networkProps.Connection_Type).To(Equal("custom"))
+			Expect(networkProps.Device_Type).To(Equal("vmxnet3"))
+		})
+
+		It("overrides the connection and device types", func() {
+			var cloudProps apiv1.CloudPropsImpl
+			Expect(json.Unmarshal([]byte(`{"connection_type": "nat", "device_type": "e1000"}`), &cloudProps)).To(Succeed())
+
+			networkProps, err := vm.NewNetworkProps(cloudProps)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(networkProps.Name).To(Equal(""))
+			Expect(networkProps.Connection_Type).To(Equal("nat"))
+			Expect(networkProps.Device_Type).To(Equal("e1000"))
+		})
+
+		It("uses a LAN segment for isolated networks", func() {
+			var cloudProps apiv1.CloudPropsImpl
+			Expect(json.Unmarshal([]byte(`{"name": "deployment-a", "isolated": true}`), &cloudProps)).To(Succeed())
+
+			networkProps, err := vm.NewNetworkProps(cloudProps)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(networkProps.Name).To(Equal("deployment-a"))
+			Expect(networkProps.Isolated).To(BeTrue())
+			Expect(networkProps.Connection_Type).To(Equal("pvn"))
+		})
+	})
+})
@@ -4,10 +4,15 @@ import (
 	"github.com/cppforlife/bosh-cpi-go/apiv1"
 )
 
+// guestinfo variable holding the base64 encoded agent env, read by the agent as guestinfo.bosh.agent_env
+const AGENT_ENV_GUESTINFO_NAME = "bosh.agent_env"
+
 //go:generate counterfeiter -o fakes/fake_agent_settings.go agent_settings.go AgentSettings
 type AgentSettings interface {
 	Cleanup()
 	GenerateAgentEnvIso(apiv1.AgentEnv) (string, error)
-	GetNetworkSettings(apiv1.Network) (macAddress string, adapterName string, err error)
+	GetNetworkSettings(apiv1.Network) (*NetworkProps, error)
 	GetIsoAgentEnv(string) (apiv1.AgentEnv, error)
+	GenerateAgentEnvGuestInfo(apiv1.AgentEnv) (string, error)
+	GetGuestInfoAgentEnv(string) (apiv1.AgentEnv, error)
 }
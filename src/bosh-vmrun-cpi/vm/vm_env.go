@@ -0,0 +1,40 @@
+package vm
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+)
+
+type vmEnv struct {
+	Bosh struct {
+		// the director sends [director, deployment, instance group, ...]
+		Groups []string
+	}
+}
+
+func NewVMEnv(apiVMEnv apiv1.VMEnv) (*vmEnv, error) {
+	data, err := apiVMEnv.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	env := &vmEnv{}
+	err = json.Unmarshal(data, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// SegmentName scopes a LAN segment named after a bosh network to the director and deployment of the vm, so
+// deployments with the same network names get separate segments. Without bosh groups the network name is used
+func (e *vmEnv) SegmentName(networkName string) string {
+	if len(e.Bosh.Groups) < 2 {
+		return networkName
+	}
+
+	return strings.Join([]string{e.Bosh.Groups[0], e.Bosh.Groups[1], networkName}, "/")
+}
@@ -0,0 +1,31 @@
+package vm_test
+
+import (
+	"github.com/cppforlife/bosh-cpi-go/apiv1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-vmrun-cpi/vm"
+)
+
+var _ = Describe("VMEnv", func() {
+	Describe("SegmentName", func() {
+		It("scopes the network name to the director and deployment", func() {
+			env, err := vm.NewVMEnv(apiv1.NewVMEnv(map[string]interface{}{
+				"bosh": map[string]interface{}{
+					"groups": []interface{}{"director-1", "deployment-1", "web", "director-1-deployment-1"},
+				},
+			}))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(env.SegmentName("private")).To(Equal("director-1/deployment-1/private"))
+		})
+
+		It("uses the network name without bosh groups", func() {
+			env, err := vm.NewVMEnv(apiv1.NewVMEnv(map[string]interface{}{}))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(env.SegmentName("private")).To(Equal("private"))
+		})
+	})
+})
@@ -21,11 +21,21 @@ type boostrapProps struct {
 	Max_Wait time.Duration
 }
 
+type portForwardProps struct {
+	Host_Port  int
+	Guest_Port int
+	Protocol   string
+	Network    string
+}
+
 type VMProps struct {
-	CPU       int
-	RAM       int
-	Disk      int
-	Bootstrap boostrapProps
+	CPU             int
+	RAM             int
+	Disk            int
+	Disk_Controller string
+	Datastore       string
+	Bootstrap       boostrapProps
+	Port_Forwards   []portForwardProps
 }
 
 func NewVMProps(cloudProps apiv1.VMCloudProps) (*VMProps, error) {
@@ -44,6 +54,12 @@ func NewVMProps(cloudProps apiv1.VMCloudProps) (*VMProps, error) {
 
 	vmProps.Bootstrap.setDurations()
 
+	for i := range vmProps.Port_Forwards {
+		if vmProps.Port_Forwards[i].Protocol == "" {
+			vmProps.Port_Forwards[i].Protocol = "tcp"
+		}
+	}
+
 	return vmProps, nil
 }
 
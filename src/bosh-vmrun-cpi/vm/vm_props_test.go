@@ -27,6 +27,8 @@ var _ = Describe("VMProps", func() {
 				Expect(vmProps.CPU).To(Equal(1))
 				Expect(vmProps.RAM).To(Equal(1024))
 				Expect(vmProps.Disk).To(Equal(0))
+				Expect(vmProps.Disk_Controller).To(Equal(""))
+				Expect(vmProps.Datastore).To(Equal(""))
 				Expect(vmProps.Bootstrap.Script_Content).To(Equal(""))
 				Expect(vmProps.Bootstrap.Script_Path).To(Equal(""))
 				Expect(vmProps.Bootstrap.Interpreter_Path).To(Equal(""))
@@ -35,6 +37,7 @@ var _ = Describe("VMProps", func() {
 				Expect(vmProps.Bootstrap.Password).To(Equal(""))
 				Expect(vmProps.Bootstrap.Min_Wait).To(Equal(time.Duration(0)))
 				Expect(vmProps.Bootstrap.Max_Wait).To(Equal(600 * time.Second))
+				Expect(vmProps.Port_Forwards).To(BeEmpty())
 			})
 		})
 
@@ -44,6 +47,8 @@ var _ = Describe("VMProps", func() {
 					"CPU": 2,
 					"RAM": 2048,
 					"Disk": 10000,
+					"Disk_Controller": "nvme",
+					"Datastore": "ssd",
 					"Bootstrap": {
 						"Script_Content": "foo",
 						"Script_Path": "bar",
@@ -53,7 +58,11 @@ var _ = Describe("VMProps", func() {
 						"Password": "doe",
 						"Min_Wait_Seconds": 10,
 						"Max_Wait_Seconds": 20
-					}
+					},
+					"Port_Forwards": [
+						{"Host_Port": 8080, "Guest_Port": 80},
+						{"Host_Port": 5353, "Guest_Port": 53, "Protocol": "udp", "Network": "nat"}
+					]
 				}`)
 
 				var cloudProps apiv1.CloudPropsImpl
@@ -65,6 +74,8 @@ var _ = Describe("VMProps", func() {
 				Expect(vmProps.CPU).To(Equal(2))
 				Expect(vmProps.RAM).To(Equal(2048))
 				Expect(vmProps.Disk).To(Equal(10000))
+				Expect(vmProps.Disk_Controller).To(Equal("nvme"))
+				Expect(vmProps.Datastore).To(Equal("ssd"))
 				Expect(vmProps.Bootstrap.Script_Content).To(Equal("foo"))
 				Expect(vmProps.Bootstrap.Script_Path).To(Equal("bar"))
 				Expect(vmProps.Bootstrap.Interpreter_Path).To(Equal("baz"))
@@ -73,6 +84,12 @@ var _ = Describe("VMProps", func() {
 				Expect(vmProps.Bootstrap.Password).To(Equal("doe"))
 				Expect(vmProps.Bootstrap.Min_Wait).To(Equal(10 * time.Second))
 				Expect(vmProps.Bootstrap.Max_Wait).To(Equal(20 * time.Second))
+				Expect(vmProps.Port_Forwards).To(HaveLen(2))
+				Expect(vmProps.Port_Forwards[0].Host_Port).To(Equal(8080))
+				Expect(vmProps.Port_Forwards[0].Guest_Port).To(Equal(80))
+				Expect(vmProps.Port_Forwards[0].Protocol).To(Equal("tcp"))
+				Expect(vmProps.Port_Forwards[1].Protocol).To(Equal("udp"))
+				Expect(vmProps.Port_Forwards[1].Network).To(Equal("nat"))
 			})
 		})
 	})
@@ -0,0 +1,114 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-vmrun-cpi/vmdk"
+	"sync"
+)
+
+type FakeVmdkReader struct {
+	GetInfoStub        func(string) (vmdk.Info, error)
+	getInfoMutex       sync.RWMutex
+	getInfoArgsForCall []struct {
+		arg1 string
+	}
+	getInfoReturns struct {
+		result1 vmdk.Info
+		result2 error
+	}
+	getInfoReturnsOnCall map[int]struct {
+		result1 vmdk.Info
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeVmdkReader) GetInfo(arg1 string) (vmdk.Info, error) {
+	fake.getInfoMutex.Lock()
+	ret, specificReturn := fake.getInfoReturnsOnCall[len(fake.getInfoArgsForCall)]
+	fake.getInfoArgsForCall = append(fake.getInfoArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetInfo", []interface{}{arg1})
+	fake.getInfoMutex.Unlock()
+	if fake.GetInfoStub != nil {
+		return fake.GetInfoStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getInfoReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeVmdkReader) GetInfoCallCount() int {
+	fake.getInfoMutex.RLock()
+	defer fake.getInfoMutex.RUnlock()
+	return len(fake.getInfoArgsForCall)
+}
+
+func (fake *FakeVmdkReader) GetInfoCalls(stub func(string) (vmdk.Info, error)) {
+	fake.getInfoMutex.Lock()
+	defer fake.getInfoMutex.Unlock()
+	fake.GetInfoStub = stub
+}
+
+func (fake *FakeVmdkReader) GetInfoArgsForCall(i int) string {
+	fake.getInfoMutex.RLock()
+	defer fake.getInfoMutex.RUnlock()
+	argsForCall := fake.getInfoArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeVmdkReader) GetInfoReturns(result1 vmdk.Info, result2 error) {
+	fake.getInfoMutex.Lock()
+	defer fake.getInfoMutex.Unlock()
+	fake.GetInfoStub = nil
+	fake.getInfoReturns = struct {
+		result1 vmdk.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVmdkReader) GetInfoReturnsOnCall(i int, result1 vmdk.Info, result2 error) {
+	fake.getInfoMutex.Lock()
+	defer fake.getInfoMutex.Unlock()
+	fake.GetInfoStub = nil
+	if fake.getInfoReturnsOnCall == nil {
+		fake.getInfoReturnsOnCall = make(map[int]struct {
+			result1 vmdk.Info
+			result2 error
+		})
+	}
+	fake.getInfoReturnsOnCall[i] = struct {
+		result1 vmdk.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVmdkReader) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.getInfoMutex.RLock()
+	defer fake.getInfoMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeVmdkReader) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ vmdk.VmdkReader = new(FakeVmdkReader)
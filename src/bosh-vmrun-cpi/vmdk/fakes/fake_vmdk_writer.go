@@ -0,0 +1,190 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"bosh-vmrun-cpi/vmdk"
+	"sync"
+)
+
+type FakeVmdkWriter struct {
+	ConvertStreamOptimizedStub        func(string, string, string) error
+	convertStreamOptimizedMutex       sync.RWMutex
+	convertStreamOptimizedArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	convertStreamOptimizedReturns struct {
+		result1 error
+	}
+	convertStreamOptimizedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CreateSparseStub        func(string, uint64, string) error
+	createSparseMutex       sync.RWMutex
+	createSparseArgsForCall []struct {
+		arg1 string
+		arg2 uint64
+		arg3 string
+	}
+	createSparseReturns struct {
+		result1 error
+	}
+	createSparseReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeVmdkWriter) ConvertStreamOptimized(arg1 string, arg2 string, arg3 string) error {
+	fake.convertStreamOptimizedMutex.Lock()
+	ret, specificReturn := fake.convertStreamOptimizedReturnsOnCall[len(fake.convertStreamOptimizedArgsForCall)]
+	fake.convertStreamOptimizedArgsForCall = append(fake.convertStreamOptimizedArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("ConvertStreamOptimized", []interface{}{arg1, arg2, arg3})
+	fake.convertStreamOptimizedMutex.Unlock()
+	if fake.ConvertStreamOptimizedStub != nil {
+		return fake.ConvertStreamOptimizedStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.convertStreamOptimizedReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeVmdkWriter) ConvertStreamOptimizedCallCount() int {
+	fake.convertStreamOptimizedMutex.RLock()
+	defer fake.convertStreamOptimizedMutex.RUnlock()
+	return len(fake.convertStreamOptimizedArgsForCall)
+}
+
+func (fake *FakeVmdkWriter) ConvertStreamOptimizedCalls(stub func(string, string, string) error) {
+	fake.convertStreamOptimizedMutex.Lock()
+	defer fake.convertStreamOptimizedMutex.Unlock()
+	fake.ConvertStreamOptimizedStub = stub
+}
+
+func (fake *FakeVmdkWriter) ConvertStreamOptimizedArgsForCall(i int) (string, string, string) {
+	fake.convertStreamOptimizedMutex.RLock()
+	defer fake.convertStreamOptimizedMutex.RUnlock()
+	argsForCall := fake.convertStreamOptimizedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeVmdkWriter) ConvertStreamOptimizedReturns(result1 error) {
+	fake.convertStreamOptimizedMutex.Lock()
+	defer fake.convertStreamOptimizedMutex.Unlock()
+	fake.ConvertStreamOptimizedStub = nil
+	fake.convertStreamOptimizedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmdkWriter) ConvertStreamOptimizedReturnsOnCall(i int, result1 error) {
+	fake.convertStreamOptimizedMutex.Lock()
+	defer fake.convertStreamOptimizedMutex.Unlock()
+	fake.ConvertStreamOptimizedStub = nil
+	if fake.convertStreamOptimizedReturnsOnCall == nil {
+		fake.convertStreamOptimizedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.convertStreamOptimizedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmdkWriter) CreateSparse(arg1 string, arg2 uint64, arg3 string) error {
+	fake.createSparseMutex.Lock()
+	ret, specificReturn := fake.createSparseReturnsOnCall[len(fake.createSparseArgsForCall)]
+	fake.createSparseArgsForCall = append(fake.createSparseArgsForCall, struct {
+		arg1 string
+		arg2 uint64
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("CreateSparse", []interface{}{arg1, arg2, arg3})
+	fake.createSparseMutex.Unlock()
+	if fake.CreateSparseStub != nil {
+		return fake.CreateSparseStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.createSparseReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeVmdkWriter) CreateSparseCallCount() int {
+	fake.createSparseMutex.RLock()
+	defer fake.createSparseMutex.RUnlock()
+	return len(fake.createSparseArgsForCall)
+}
+
+func (fake *FakeVmdkWriter) CreateSparseCalls(stub func(string, uint64, string) error) {
+	fake.createSparseMutex.Lock()
+	defer fake.createSparseMutex.Unlock()
+	fake.CreateSparseStub = stub
+}
+
+func (fake *FakeVmdkWriter) CreateSparseArgsForCall(i int) (string, uint64, string) {
+	fake.createSparseMutex.RLock()
+	defer fake.createSparseMutex.RUnlock()
+	argsForCall := fake.createSparseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeVmdkWriter) CreateSparseReturns(result1 error) {
+	fake.createSparseMutex.Lock()
+	defer fake.createSparseMutex.Unlock()
+	fake.CreateSparseStub = nil
+	fake.createSparseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmdkWriter) CreateSparseReturnsOnCall(i int, result1 error) {
+	fake.createSparseMutex.Lock()
+	defer fake.createSparseMutex.Unlock()
+	fake.CreateSparseStub = nil
+	if fake.createSparseReturnsOnCall == nil {
+		fake.createSparseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createSparseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmdkWriter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.convertStreamOptimizedMutex.RLock()
+	defer fake.convertStreamOptimizedMutex.RUnlock()
+	fake.createSparseMutex.RLock()
+	defer fake.createSparseMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeVmdkWriter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ vmdk.VmdkWriter = new(FakeVmdkWriter)
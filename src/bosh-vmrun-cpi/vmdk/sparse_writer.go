@@ -0,0 +1,300 @@
+package vmdk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sparseExtentWriter writes a hosted sparse extent. Grains are appended after the metadata as they are
+// written, the grain directories and tables are written on close
+type sparseExtentWriter struct {
+	file            *os.File
+	header          SparseExtentHeader
+	descriptor      []byte
+	grainTables     []uint32
+	gdSectors       uint64
+	gtSectors       uint64
+	nextGrainSector uint64
+}
+
+// sparseDiskWriter writes the grains of a disk to its extents, a single extent with an embedded descriptor
+// for monolithicSparse or 2GB extents next to a descriptor file for twoGbMaxExtentSparse
+type sparseDiskWriter struct {
+	extents       []*sparseExtentWriter
+	extentSectors uint64
+	grainSize     uint64
+	paths         []string
+}
+
+func newSparseExtentWriter(extentPath string, capacitySectors uint64, grainSize uint64, descriptor []byte) (*sparseExtentWriter, error) {
+	header := NewSparseExtentHeader(capacitySectors)
+	header.GrainSize = grainSize
+
+	numGTs := divRoundUp(capacitySectors, grainSize*uint64(header.NumGTEsPerGT))
+	gtSectors := divRoundUp(uint64(header.NumGTEsPerGT)*4, SECTOR_SIZE)
+	gdSectors := divRoundUp(numGTs*4, SECTOR_SIZE)
+
+	header.RgdOffset = 1
+	if descriptor != nil {
+		if len(descriptor) > EMBEDDED_DESCRIPTOR_SECTORS*SECTOR_SIZE {
+			return nil, fmt.Errorf("descriptor is too long to embed: %d bytes", len(descriptor))
+		}
+
+		header.DescriptorOffset = 1
+		header.DescriptorSize = EMBEDDED_DESCRIPTOR_SECTORS
+		header.RgdOffset += EMBEDDED_DESCRIPTOR_SECTORS
+	}
+	header.GdOffset = header.RgdOffset + gdSectors + numGTs*gtSectors
+	header.OverHead = divRoundUp(header.GdOffset+gdSectors+numGTs*gtSectors, grainSize) * grainSize
+
+	file, err := os.OpenFile(extentPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sparseExtentWriter{
+		file:            file,
+		header:          header,
+		descriptor:      descriptor,
+		grainTables:     make([]uint32, numGTs*uint64(header.NumGTEsPerGT)),
+		gdSectors:       gdSectors,
+		gtSectors:       gtSectors,
+		nextGrainSector: header.OverHead,
+	}, nil
+}
+
+// writeGrain writes a whole grain, zero grains are left unallocated
+func (w *sparseExtentWriter) writeGrain(grainIndex uint64, grain []byte) error {
+	grainSector := uint64(w.grainTables[grainIndex])
+	if grainSector == 0 {
+		if isZero(grain) {
+			return nil
+		}
+
+		if w.nextGrainSector > math.MaxUint32 {
+			return fmt.Errorf("sparse extent is too large: %s", w.file.Name())
+		}
+
+		grainSector = w.nextGrainSector
+		w.grainTables[grainIndex] = uint32(grainSector)
+		w.nextGrainSector += w.header.GrainSize
+	}
+
+	_, err := w.file.WriteAt(grain, int64(grainSector*SECTOR_SIZE))
+	return err
+}
+
+func (w *sparseExtentWriter) close() error {
+	err := w.writeMetadata()
+	closeErr := w.file.Close()
+	if err != nil {
+		return err
+	}
+
+	return closeErr
+}
+
+// writeMetadata writes the header, descriptor, redundant grain directory and tables, then the grain
+// directory and tables
+func (w *sparseExtentWriter) writeMetadata() error {
+	var metadata bytes.Buffer
+
+	err := binary.Write(&metadata, binary.LittleEndian, w.header)
+	if err != nil {
+		return err
+	}
+
+	metadata.Write(w.descriptor)
+	padToSector(&metadata, w.header.RgdOffset)
+
+	for _, gdOffset := range []uint64{w.header.RgdOffset, w.header.GdOffset} {
+		firstGTSector := gdOffset + w.gdSectors
+		numGTs := uint64(len(w.grainTables)) / uint64(w.header.NumGTEsPerGT)
+
+		for n := uint64(0); n < numGTs; n++ {
+			binary.Write(&metadata, binary.LittleEndian, uint32(firstGTSector+n*w.gtSectors))
+		}
+		padToSector(&metadata, firstGTSector)
+
+		for n := uint64(0); n < numGTs; n++ {
+			gtStart := n * uint64(w.header.NumGTEsPerGT)
+			binary.Write(&metadata, binary.LittleEndian, w.grainTables[gtStart:gtStart+uint64(w.header.NumGTEsPerGT)])
+			padToSector(&metadata, firstGTSector+(n+1)*w.gtSectors)
+		}
+	}
+	padToSector(&metadata, w.header.OverHead)
+
+	_, err = w.file.WriteAt(metadata.Bytes(), 0)
+	return err
+}
+
+func newSparseDiskWriter(diskPath string, createType string, capacitySectors uint64, grainSize uint64, descriptor Descriptor) (*sparseDiskWriter, error) {
+	writer := &sparseDiskWriter{grainSize: grainSize}
+	descriptor.CreateType = createType
+	descriptor.Extents = nil
+
+	switch createType {
+	case CREATE_TYPE_MONOLITHIC_SPARSE:
+		writer.extentSectors = capacitySectors
+		descriptor.Extents = []Extent{{Access: "RW", Sectors: int64(capacitySectors), Type: "SPARSE", FileName: filepath.Base(diskPath)}}
+
+		err := writer.addExtent(diskPath, capacitySectors, FormatDescriptor(descriptor))
+		if err != nil {
+			return nil, err
+		}
+
+	case CREATE_TYPE_TWO_GB_MAX_EXTENT_SPARSE:
+		if TWO_GB_EXTENT_SECTORS%grainSize != 0 {
+			return nil, fmt.Errorf("unsupported grain size for 2GB extents: %d", grainSize)
+		}
+		writer.extentSectors = TWO_GB_EXTENT_SECTORS
+
+		diskBaseName := strings.TrimSuffix(filepath.Base(diskPath), filepath.Ext(diskPath))
+		for n := uint64(0); n*TWO_GB_EXTENT_SECTORS < capacitySectors; n++ {
+			extentSectors := capacitySectors - n*TWO_GB_EXTENT_SECTORS
+			if extentSectors > TWO_GB_EXTENT_SECTORS {
+				extentSectors = TWO_GB_EXTENT_SECTORS
+			}
+
+			extentFileName := fmt.Sprintf("%s-s%03d.vmdk", diskBaseName, n+1)
+			descriptor.Extents = append(descriptor.Extents, Extent{Access: "RW", Sectors: int64(extentSectors), Type: "SPARSE", FileName: extentFileName})
+
+			err := writer.addExtent(filepath.Join(filepath.Dir(diskPath), extentFileName), extentSectors, nil)
+			if err != nil {
+				writer.abort()
+				return nil, err
+			}
+		}
+
+		descriptorFile, err := os.OpenFile(diskPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err != nil {
+			writer.abort()
+			return nil, err
+		}
+		writer.paths = append(writer.paths, diskPath)
+
+		_, err = descriptorFile.Write(FormatDescriptor(descriptor))
+		descriptorFile.Close()
+		if err != nil {
+			writer.abort()
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported sparse disk type: %s", createType)
+	}
+
+	return writer, nil
+}
+
+func (w *sparseDiskWriter) addExtent(extentPath string, capacitySectors uint64, descriptor []byte) error {
+	extent, err := newSparseExtentWriter(extentPath, capacitySectors, w.grainSize, descriptor)
+	if err != nil {
+		return err
+	}
+
+	w.extents = append(w.extents, extent)
+	w.paths = append(w.paths, extentPath)
+
+	return nil
+}
+
+// writeGrain writes the grain starting at a disk sector to the extent containing it
+func (w *sparseDiskWriter) writeGrain(sector uint64, grain []byte) error {
+	extentIndex := sector / w.extentSectors
+	if sector%w.grainSize != 0 || extentIndex >= uint64(len(w.extents)) {
+		return fmt.Errorf("invalid grain at sector %d", sector)
+	}
+
+	return w.extents[extentIndex].writeGrain((sector%w.extentSectors)/w.grainSize, grain)
+}
+
+func (w *sparseDiskWriter) close() error {
+	var err error
+
+	for _, extent := range w.extents {
+		closeErr := extent.close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// abort removes the disk files after a failed write
+func (w *sparseDiskWriter) abort() {
+	for _, extent := range w.extents {
+		extent.file.Close()
+	}
+
+	for _, path := range w.paths {
+		os.Remove(path)
+	}
+}
+
+// FormatDescriptor returns the text of a descriptor, with the disk database in key order
+func FormatDescriptor(descriptor Descriptor) []byte {
+	var content bytes.Buffer
+
+	version := descriptor.Version
+	if version == "" {
+		version = "1"
+	}
+
+	parentCID := descriptor.ParentCID
+	if parentCID == "" {
+		parentCID = NO_PARENT_CID
+	}
+
+	fmt.Fprintf(&content, "%s\nversion=%s\nCID=%s\nparentCID=%s\ncreateType=\"%s\"\n", DESCRIPTOR_HEADER, version, descriptor.CID, parentCID, descriptor.CreateType)
+	if descriptor.ParentFileNameHint != "" {
+		fmt.Fprintf(&content, "parentFileNameHint=\"%s\"\n", descriptor.ParentFileNameHint)
+	}
+
+	content.WriteString("\n# Extent description\n")
+	for _, extent := range descriptor.Extents {
+		fmt.Fprintf(&content, "%s %d %s \"%s\"", extent.Access, extent.Sectors, extent.Type, extent.FileName)
+		if extent.Offset > 0 {
+			fmt.Fprintf(&content, " %d", extent.Offset)
+		}
+		content.WriteString("\n")
+	}
+
+	keys := []string{}
+	for key := range descriptor.DDB {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	content.WriteString("\n# The Disk Data Base\n#DDB\n\n")
+	for _, key := range keys {
+		fmt.Fprintf(&content, "%s = \"%s\"\n", key, descriptor.DDB[key])
+	}
+
+	return content.Bytes()
+}
+
+func padToSector(buffer *bytes.Buffer, sector uint64) {
+	buffer.Write(make([]byte, int(sector*SECTOR_SIZE)-buffer.Len()))
+}
+
+func divRoundUp(value uint64, divisor uint64) uint64 {
+	return (value + divisor - 1) / divisor
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,118 @@
+package vmdk
+
+// Disk create types from the VMDK descriptor
+const (
+	CREATE_TYPE_MONOLITHIC_SPARSE        = "monolithicSparse"
+	CREATE_TYPE_MONOLITHIC_FLAT          = "monolithicFlat"
+	CREATE_TYPE_TWO_GB_MAX_EXTENT_SPARSE = "twoGbMaxExtentSparse"
+	CREATE_TYPE_TWO_GB_MAX_EXTENT_FLAT   = "twoGbMaxExtentFlat"
+	CREATE_TYPE_STREAM_OPTIMIZED         = "streamOptimized"
+)
+
+const (
+	SECTOR_SIZE        = 512
+	SPARSE_MAGIC       = 0x564d444b // "KDMV"
+	DESCRIPTOR_HEADER  = "# Disk DescriptorFile"
+	NO_PARENT_CID      = "ffffffff"
+	MAX_PARENT_CHAIN   = 32
+	MAX_DESCRIPTOR_LEN = 1024 * 1024
+)
+
+// Sparse extent header flags, compression and stream markers
+const (
+	SPARSE_FLAG_VALID_NEWLINE_TEST = 1 << 0
+	SPARSE_FLAG_REDUNDANT_GT       = 1 << 1
+	SPARSE_FLAG_COMPRESSED         = 1 << 16
+	SPARSE_FLAG_MARKERS            = 1 << 17
+
+	COMPRESSION_DEFLATE = 1
+	GD_AT_END           = 0xffffffffffffffff
+
+	MARKER_EOS    = 0
+	MARKER_GT     = 1
+	MARKER_GD     = 2
+	MARKER_FOOTER = 3
+)
+
+const (
+	DEFAULT_GRAIN_SIZE          = 128
+	EMBEDDED_DESCRIPTOR_SECTORS = 20
+	TWO_GB_EXTENT_SECTORS       = 4192256
+)
+
+// SparseExtentHeader is the on-disk layout of the first sector of a hosted sparse extent
+type SparseExtentHeader struct {
+	MagicNumber        uint32
+	Version            uint32
+	Flags              uint32
+	Capacity           uint64
+	GrainSize          uint64
+	DescriptorOffset   uint64
+	DescriptorSize     uint64
+	NumGTEsPerGT       uint32
+	RgdOffset          uint64
+	GdOffset           uint64
+	OverHead           uint64
+	UncleanShutdown    uint8
+	SingleEndLineChar  byte
+	NonEndLineChar     byte
+	DoubleEndLineChar1 byte
+	DoubleEndLineChar2 byte
+	CompressAlgorithm  uint16
+	Pad                [433]uint8
+}
+
+type Extent struct {
+	Access   string
+	Sectors  int64
+	Type     string
+	FileName string
+	Offset   int64
+}
+
+type Descriptor struct {
+	Version            string
+	CID                string
+	ParentCID          string
+	CreateType         string
+	ParentFileNameHint string
+	Extents            []Extent
+	DDB                map[string]string
+}
+
+type Info struct {
+	Path               string
+	CreateType         string
+	AdapterType        string
+	VirtualSizeBytes   int64
+	AllocatedSizeBytes int64
+	Extents            []Extent
+	ParentChain        []string
+}
+
+//go:generate counterfeiter -o fakes/fake_vmdk_reader.go vmdk.go VmdkReader
+type VmdkReader interface {
+	GetInfo(string) (Info, error)
+}
+
+//go:generate counterfeiter -o fakes/fake_vmdk_writer.go vmdk.go VmdkWriter
+type VmdkWriter interface {
+	CreateSparse(diskPath string, capacitySectors uint64, adapterType string) error
+	ConvertStreamOptimized(sourcePath, targetPath, createType string) error
+}
+
+// NewSparseExtentHeader returns a header for an empty sparse extent without an embedded descriptor
+func NewSparseExtentHeader(capacitySectors uint64) SparseExtentHeader {
+	return SparseExtentHeader{
+		MagicNumber:        SPARSE_MAGIC,
+		Version:            1,
+		Flags:              SPARSE_FLAG_VALID_NEWLINE_TEST | SPARSE_FLAG_REDUNDANT_GT,
+		Capacity:           capacitySectors,
+		GrainSize:          128,
+		NumGTEsPerGT:       512,
+		SingleEndLineChar:  '\n',
+		NonEndLineChar:     ' ',
+		DoubleEndLineChar1: '\r',
+		DoubleEndLineChar2: '\n',
+	}
+}
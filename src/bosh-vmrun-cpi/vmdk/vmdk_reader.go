@@ -0,0 +1,219 @@
+package vmdk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type VmdkReaderImpl struct {
+	logger boshlog.Logger
+}
+
+// extent lines look like: RW 8323072 SPARSE "disk-s001.vmdk" 0
+var extentRegexp = regexp.MustCompile(`^(RW|RDONLY|NOACCESS)\s+(\d+)\s+(\S+)(?:\s+"([^"]*)"(?:\s+(\d+))?)?\s*$`)
+
+func NewVmdkReader(logger boshlog.Logger) VmdkReader {
+	return VmdkReaderImpl{logger: logger}
+}
+
+func (r VmdkReaderImpl) GetInfo(diskPath string) (Info, error) {
+	return r.getInfo(diskPath, 0)
+}
+
+func (r VmdkReaderImpl) getInfo(diskPath string, depth int) (Info, error) {
+	var info Info
+
+	if depth > MAX_PARENT_CHAIN {
+		return info, fmt.Errorf("disk parent chain is too long: %s", diskPath)
+	}
+
+	descriptor, header, err := r.readDescriptor(diskPath)
+	if err != nil {
+		return info, err
+	}
+
+	info.Path = diskPath
+	info.CreateType = descriptor.CreateType
+	info.AdapterType = descriptor.DDB["ddb.adapterType"]
+	info.Extents = descriptor.Extents
+
+	diskFileInfo, err := os.Stat(diskPath)
+	if err != nil {
+		return info, err
+	}
+	info.AllocatedSizeBytes = diskFileInfo.Size()
+
+	for _, extent := range descriptor.Extents {
+		info.VirtualSizeBytes += extent.Sectors * SECTOR_SIZE
+
+		if extent.FileName == "" || extent.Type == "ZERO" {
+			continue
+		}
+
+		// an embedded descriptor describes its own extent, which may have been renamed since (ex: in an ovf)
+		extentPath := r.resolvePath(diskPath, extent.FileName)
+		if extentPath == diskPath || header != nil {
+			continue
+		}
+
+		extentFileInfo, err := os.Stat(extentPath)
+		if err != nil {
+			return info, fmt.Errorf("disk extent is missing: %s", extentPath)
+		}
+		info.AllocatedSizeBytes += extentFileInfo.Size()
+	}
+
+	if len(descriptor.Extents) == 0 && header != nil {
+		info.VirtualSizeBytes = int64(header.Capacity) * SECTOR_SIZE
+	}
+
+	if descriptor.ParentFileNameHint != "" {
+		parentPath := r.resolvePath(diskPath, descriptor.ParentFileNameHint)
+
+		parentInfo, err := r.getInfo(parentPath, depth+1)
+		if err != nil {
+			return info, fmt.Errorf("reading parent disk %s: %s", parentPath, err)
+		}
+
+		info.ParentChain = append([]string{parentPath}, parentInfo.ParentChain...)
+	}
+
+	r.logger.Debug("vmdk", "disk %s: type %s, virtual size %d bytes, allocated size %d bytes", diskPath, info.CreateType, info.VirtualSizeBytes, info.AllocatedSizeBytes)
+
+	return info, nil
+}
+
+// readDescriptor returns the text descriptor file or the descriptor embedded in a sparse extent
+func (r VmdkReaderImpl) readDescriptor(diskPath string) (Descriptor, *SparseExtentHeader, error) {
+	diskFile, err := os.Open(diskPath)
+	if err != nil {
+		return Descriptor{}, nil, err
+	}
+	defer diskFile.Close()
+
+	header, err := ReadSparseExtentHeader(diskFile)
+	if err == nil {
+		descriptor, err := ReadEmbeddedDescriptor(diskFile, header)
+		return descriptor, &header, err
+	}
+
+	descriptorBytes, err := ioutil.ReadAll(io.LimitReader(diskFile, MAX_DESCRIPTOR_LEN))
+	if err != nil {
+		return Descriptor{}, nil, err
+	}
+
+	if !bytes.HasPrefix(descriptorBytes, []byte(DESCRIPTOR_HEADER)) {
+		return Descriptor{}, nil, fmt.Errorf("not a vmdk disk: %s", diskPath)
+	}
+
+	descriptor, err := ParseDescriptor(descriptorBytes)
+	return descriptor, nil, err
+}
+
+func (r VmdkReaderImpl) resolvePath(diskPath string, fileName string) string {
+	if filepath.IsAbs(fileName) {
+		return fileName
+	}
+
+	return filepath.Join(filepath.Dir(diskPath), fileName)
+}
+
+func ReadSparseExtentHeader(reader io.ReaderAt) (SparseExtentHeader, error) {
+	var header SparseExtentHeader
+
+	headerBytes := make([]byte, SECTOR_SIZE)
+	_, err := reader.ReadAt(headerBytes, 0)
+	if err != nil {
+		return header, err
+	}
+
+	err = binary.Read(bytes.NewReader(headerBytes), binary.LittleEndian, &header)
+	if err != nil {
+		return header, err
+	}
+
+	if header.MagicNumber != SPARSE_MAGIC {
+		return header, fmt.Errorf("invalid sparse extent magic number: %x", header.MagicNumber)
+	}
+
+	return header, nil
+}
+
+// ReadEmbeddedDescriptor returns the descriptor embedded in a sparse extent, extents without one are
+// monolithic sparse disks
+func ReadEmbeddedDescriptor(reader io.ReaderAt, header SparseExtentHeader) (Descriptor, error) {
+	if header.DescriptorOffset == 0 || header.DescriptorSize == 0 {
+		return Descriptor{CreateType: CREATE_TYPE_MONOLITHIC_SPARSE, DDB: map[string]string{}}, nil
+	}
+
+	descriptorBytes := make([]byte, header.DescriptorSize*SECTOR_SIZE)
+	_, err := reader.ReadAt(descriptorBytes, int64(header.DescriptorOffset*SECTOR_SIZE))
+	if err != nil && err != io.EOF {
+		return Descriptor{}, err
+	}
+
+	return ParseDescriptor(bytes.TrimRight(descriptorBytes, "\x00"))
+}
+
+func ParseDescriptor(content []byte) (Descriptor, error) {
+	descriptor := Descriptor{DDB: map[string]string{}}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if match := extentRegexp.FindStringSubmatch(line); match != nil {
+			sectors, err := strconv.ParseInt(match[2], 10, 64)
+			if err != nil {
+				return descriptor, err
+			}
+
+			extent := Extent{Access: match[1], Sectors: sectors, Type: match[3], FileName: match[4]}
+			if match[5] != "" {
+				extent.Offset, _ = strconv.ParseInt(match[5], 10, 64)
+			}
+
+			descriptor.Extents = append(descriptor.Extents, extent)
+			continue
+		}
+
+		keyValue := strings.SplitN(line, "=", 2)
+		if len(keyValue) != 2 {
+			return descriptor, fmt.Errorf("invalid descriptor line: %s", line)
+		}
+
+		key := strings.TrimSpace(keyValue[0])
+		value := strings.Trim(strings.TrimSpace(keyValue[1]), `"`)
+
+		switch key {
+		case "version":
+			descriptor.Version = value
+		case "CID":
+			descriptor.CID = value
+		case "parentCID":
+			descriptor.ParentCID = value
+		case "createType":
+			descriptor.CreateType = value
+		case "parentFileNameHint":
+			descriptor.ParentFileNameHint = value
+		default:
+			descriptor.DDB[key] = value
+		}
+	}
+
+	return descriptor, scanner.Err()
+}
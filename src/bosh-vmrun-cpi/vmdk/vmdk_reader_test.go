@@ -0,0 +1,217 @@
+package vmdk_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/vmdk"
+)
+
+var _ = Describe("VmdkReader", func() {
+	var (
+		diskDir    string
+		vmdkReader vmdk.VmdkReader
+	)
+
+	writeSparseDisk := func(diskPath string, capacitySectors uint64, descriptor string) {
+		header := vmdk.NewSparseExtentHeader(capacitySectors)
+		if descriptor != "" {
+			header.DescriptorOffset = 1
+			header.DescriptorSize = 20
+		}
+
+		var content bytes.Buffer
+		Expect(binary.Write(&content, binary.LittleEndian, header)).To(Succeed())
+		content.WriteString(descriptor)
+		content.Write(make([]byte, 21*vmdk.SECTOR_SIZE-content.Len()))
+
+		Expect(ioutil.WriteFile(diskPath, content.Bytes(), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		diskDir, err = ioutil.TempDir("", "vmdk-")
+		Expect(err).ToNot(HaveOccurred())
+
+		vmdkReader = vmdk.NewVmdkReader(&fakelogger.FakeLogger{})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(diskDir)
+	})
+
+	It("reads monolithic sparse disks with an embedded descriptor", func() {
+		diskPath := filepath.Join(diskDir, "disk-1.vmdk")
+		writeSparseDisk(diskPath, 2048, `# Disk DescriptorFile
+version=1
+CID=fffffffe
+parentCID=ffffffff
+createType="monolithicSparse"
+
+# Extent description
+RW 2048 SPARSE "disk-1.vmdk"
+
+# The Disk Data Base
+ddb.adapterType = "lsilogic"
+`)
+
+		info, err := vmdkReader.GetInfo(diskPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(info.Path).To(Equal(diskPath))
+		Expect(info.CreateType).To(Equal("monolithicSparse"))
+		Expect(info.AdapterType).To(Equal("lsilogic"))
+		Expect(info.VirtualSizeBytes).To(Equal(int64(1024 * 1024)))
+		Expect(info.AllocatedSizeBytes).To(Equal(int64(21 * vmdk.SECTOR_SIZE)))
+		Expect(info.Extents).To(Equal([]vmdk.Extent{{Access: "RW", Sectors: 2048, Type: "SPARSE", FileName: "disk-1.vmdk"}}))
+		Expect(info.ParentChain).To(BeEmpty())
+	})
+
+	It("reads the stream optimized fixture, renamed since its descriptor was written", func() {
+		info, err := vmdkReader.GetInfo(filepath.Join("..", "test", "fixtures", "image.vmdk"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(info.CreateType).To(Equal("streamOptimized"))
+		Expect(info.VirtualSizeBytes).To(Equal(int64(1024 * 1024)))
+	})
+
+	It("reads sparse disks without a descriptor from the header", func() {
+		diskPath := filepath.Join(diskDir, "disk-1.vmdk")
+		writeSparseDisk(diskPath, 4096, "")
+
+		info, err := vmdkReader.GetInfo(diskPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(info.CreateType).To(Equal("monolithicSparse"))
+		Expect(info.VirtualSizeBytes).To(Equal(int64(2 * 1024 * 1024)))
+	})
+
+	It("reads descriptor files with split extents", func() {
+		diskPath := filepath.Join(diskDir, "disk-1.vmdk")
+		Expect(ioutil.WriteFile(diskPath, []byte(`# Disk DescriptorFile
+version=1
+createType="twoGbMaxExtentSparse"
+
+RW 4096 SPARSE "disk-1-s001.vmdk"
+RW 2048 SPARSE "disk-1-s002.vmdk"
+
+ddb.adapterType = "lsilogic"
+`), 0644)).To(Succeed())
+		writeSparseDisk(filepath.Join(diskDir, "disk-1-s001.vmdk"), 4096, "")
+		writeSparseDisk(filepath.Join(diskDir, "disk-1-s002.vmdk"), 2048, "")
+
+		descriptorInfo, err := os.Stat(diskPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		info, err := vmdkReader.GetInfo(diskPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(info.CreateType).To(Equal("twoGbMaxExtentSparse"))
+		Expect(info.VirtualSizeBytes).To(Equal(int64(3 * 1024 * 1024)))
+		Expect(info.AllocatedSizeBytes).To(Equal(descriptorInfo.Size() + 2*21*vmdk.SECTOR_SIZE))
+		Expect(info.Extents).To(HaveLen(2))
+	})
+
+	It("follows the parent chain of linked clones", func() {
+		writeSparseDisk(filepath.Join(diskDir, "base.vmdk"), 2048, `# Disk DescriptorFile
+createType="monolithicSparse"
+RW 2048 SPARSE "base.vmdk"
+`)
+		writeSparseDisk(filepath.Join(diskDir, "delta-1.vmdk"), 2048, `# Disk DescriptorFile
+createType="monolithicSparse"
+parentFileNameHint="base.vmdk"
+RW 2048 SPARSE "delta-1.vmdk"
+`)
+		writeSparseDisk(filepath.Join(diskDir, "delta-2.vmdk"), 2048, `# Disk DescriptorFile
+createType="monolithicSparse"
+parentFileNameHint="`+filepath.Join(diskDir, "delta-1.vmdk")+`"
+RW 2048 SPARSE "delta-2.vmdk"
+`)
+
+		info, err := vmdkReader.GetInfo(filepath.Join(diskDir, "delta-2.vmdk"))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(info.ParentChain).To(Equal([]string{
+			filepath.Join(diskDir, "delta-1.vmdk"),
+			filepath.Join(diskDir, "base.vmdk"),
+		}))
+	})
+
+	It("fails when a parent disk is missing", func() {
+		writeSparseDisk(filepath.Join(diskDir, "delta.vmdk"), 2048, `# Disk DescriptorFile
+createType="monolithicSparse"
+parentFileNameHint="missing.vmdk"
+RW 2048 SPARSE "delta.vmdk"
+`)
+
+		_, err := vmdkReader.GetInfo(filepath.Join(diskDir, "delta.vmdk"))
+		Expect(err).To(MatchError(ContainSubstring("reading parent disk")))
+	})
+
+	It("fails when an extent is missing", func() {
+		diskPath := filepath.Join(diskDir, "disk-1.vmdk")
+		Expect(ioutil.WriteFile(diskPath, []byte(`# Disk DescriptorFile
+createType="monolithicFlat"
+RW 2048 FLAT "disk-1-flat.vmdk" 0
+`), 0644)).To(Succeed())
+
+		_, err := vmdkReader.GetInfo(diskPath)
+		Expect(err).To(MatchError(ContainSubstring("disk extent is missing")))
+	})
+
+	It("fails for files that are not disks", func() {
+		diskPath := filepath.Join(diskDir, "disk-1.vmdk")
+		Expect(ioutil.WriteFile(diskPath, []byte("garbage"), 0644)).To(Succeed())
+
+		_, err := vmdkReader.GetInfo(diskPath)
+		Expect(err).To(MatchError(ContainSubstring("not a vmdk disk")))
+	})
+
+	Describe("ParseDescriptor", func() {
+		It("parses fields, extents and the disk database", func() {
+			descriptor, err := vmdk.ParseDescriptor([]byte(`# Disk DescriptorFile
+version=1
+encoding="UTF-8"
+CID=12345678
+parentCID=ffffffff
+createType="streamOptimized"
+
+# Extent description
+RDONLY 8388608 SPARSE "disk.vmdk"
+RW 2048 FLAT "disk-flat.vmdk" 128
+RW 1024 ZERO
+
+ddb.virtualHWVersion = "4"
+ddb.geometry.cylinders = "522"
+`))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(descriptor.Version).To(Equal("1"))
+			Expect(descriptor.CID).To(Equal("12345678"))
+			Expect(descriptor.ParentCID).To(Equal("ffffffff"))
+			Expect(descriptor.CreateType).To(Equal("streamOptimized"))
+			Expect(descriptor.Extents).To(Equal([]vmdk.Extent{
+				{Access: "RDONLY", Sectors: 8388608, Type: "SPARSE", FileName: "disk.vmdk"},
+				{Access: "RW", Sectors: 2048, Type: "FLAT", FileName: "disk-flat.vmdk", Offset: 128},
+				{Access: "RW", Sectors: 1024, Type: "ZERO"},
+			}))
+			Expect(descriptor.DDB).To(HaveKeyWithValue("ddb.virtualHWVersion", "4"))
+			Expect(descriptor.DDB).To(HaveKeyWithValue("ddb.geometry.cylinders", "522"))
+			Expect(descriptor.DDB).To(HaveKeyWithValue("encoding", "UTF-8"))
+		})
+
+		It("fails on malformed lines", func() {
+			_, err := vmdk.ParseDescriptor([]byte("not a descriptor line"))
+			Expect(err).To(MatchError("invalid descriptor line: not a descriptor line"))
+		})
+	})
+})
@@ -0,0 +1,218 @@
+package vmdk
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type VmdkWriterImpl struct {
+	logger boshlog.Logger
+}
+
+// streamMarker starts every grain and metadata block of a streamOptimized extent. Grain markers hold the
+// grain sector and compressed size, metadata markers have a size of 0 and hold the metadata sector count
+type streamMarker struct {
+	Value uint64
+	Size  uint32
+}
+
+const streamMarkerSize = 12
+
+func NewVmdkWriter(logger boshlog.Logger) VmdkWriter {
+	return VmdkWriterImpl{logger: logger}
+}
+
+// CreateSparse creates an empty monolithicSparse disk
+func (w VmdkWriterImpl) CreateSparse(diskPath string, capacitySectors uint64, adapterType string) error {
+	cid, err := newCID()
+	if err != nil {
+		return err
+	}
+
+	cylinders, heads, sectors := diskGeometry(capacitySectors)
+	descriptor := Descriptor{
+		CID: cid,
+		DDB: map[string]string{
+			"ddb.adapterType":        adapterType,
+			"ddb.geometry.cylinders": strconv.FormatUint(cylinders, 10),
+			"ddb.geometry.heads":     strconv.FormatUint(heads, 10),
+			"ddb.geometry.sectors":   strconv.FormatUint(sectors, 10),
+			"ddb.virtualHWVersion":   "4",
+		},
+	}
+
+	writer, err := newSparseDiskWriter(diskPath, CREATE_TYPE_MONOLITHIC_SPARSE, capacitySectors, DEFAULT_GRAIN_SIZE, descriptor)
+	if err != nil {
+		return err
+	}
+
+	err = writer.close()
+	if err != nil {
+		writer.abort()
+		return err
+	}
+
+	return nil
+}
+
+// ConvertStreamOptimized writes the grains of a streamOptimized disk (ex: from an OVF) to a hosted sparse
+// disk of createType, keeping the disk database of the source
+func (w VmdkWriterImpl) ConvertStreamOptimized(sourcePath, targetPath, createType string) error {
+	sourceFile, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	header, err := ReadSparseExtentHeader(sourceFile)
+	if err != nil {
+		return err
+	}
+
+	if header.Flags&SPARSE_FLAG_MARKERS == 0 || header.Flags&SPARSE_FLAG_COMPRESSED == 0 {
+		return fmt.Errorf("not a streamOptimized disk: %s", sourcePath)
+	}
+
+	if header.CompressAlgorithm != COMPRESSION_DEFLATE {
+		return fmt.Errorf("unsupported compression of disk %s: %d", sourcePath, header.CompressAlgorithm)
+	}
+
+	descriptor, err := ReadEmbeddedDescriptor(sourceFile, header)
+	if err != nil {
+		return err
+	}
+	descriptor.ParentCID = NO_PARENT_CID
+	descriptor.ParentFileNameHint = ""
+
+	writer, err := newSparseDiskWriter(targetPath, createType, header.Capacity, header.GrainSize, descriptor)
+	if err != nil {
+		return err
+	}
+
+	w.logger.Debug("vmdk", "converting streamOptimized disk %s to %s disk %s", sourcePath, createType, targetPath)
+
+	err = w.copyStreamGrains(sourceFile, header, writer)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = fmt.Errorf("stream ended without an end of stream marker")
+	} else if err == nil {
+		err = writer.close()
+	}
+
+	if err != nil {
+		w.logger.ErrorWithDetails("vmdk", "converting disk", err)
+		writer.abort()
+		return fmt.Errorf("converting disk %s: %s", sourcePath, err)
+	}
+
+	return nil
+}
+
+// copyStreamGrains reads the markers following the stream's overhead until the end of stream marker,
+// the grain tables and directory of the stream are skipped
+func (w VmdkWriterImpl) copyStreamGrains(source io.ReaderAt, header SparseExtentHeader, writer *sparseDiskWriter) error {
+	streamOffset := int64(header.OverHead * SECTOR_SIZE)
+	stream := bufio.NewReaderSize(io.NewSectionReader(source, streamOffset, 1<<62), 1024*1024)
+
+	grain := make([]byte, header.GrainSize*SECTOR_SIZE)
+	compressedGrain := []byte{}
+	var decompressor io.ReadCloser
+
+	for {
+		var marker streamMarker
+		err := binary.Read(stream, binary.LittleEndian, &marker)
+		if err != nil {
+			return err
+		}
+
+		if marker.Size == 0 {
+			var markerType uint32
+			err = binary.Read(stream, binary.LittleEndian, &markerType)
+			if err != nil {
+				return err
+			}
+
+			if markerType == MARKER_EOS {
+				return nil
+			}
+
+			_, err = stream.Discard(SECTOR_SIZE - streamMarkerSize - 4 + int(marker.Value*SECTOR_SIZE))
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if marker.Value >= header.Capacity {
+			return fmt.Errorf("invalid grain at sector %d", marker.Value)
+		}
+
+		if uint32(cap(compressedGrain)) < marker.Size {
+			compressedGrain = make([]byte, marker.Size)
+		}
+		compressedGrain = compressedGrain[:marker.Size]
+
+		_, err = io.ReadFull(stream, compressedGrain)
+		if err != nil {
+			return err
+		}
+
+		markerLength := divRoundUp(uint64(streamMarkerSize)+uint64(marker.Size), SECTOR_SIZE) * SECTOR_SIZE
+		_, err = stream.Discard(int(markerLength - streamMarkerSize - uint64(marker.Size)))
+		if err != nil {
+			return err
+		}
+
+		if decompressor == nil {
+			decompressor, err = zlib.NewReader(bytes.NewReader(compressedGrain))
+		} else {
+			err = decompressor.(zlib.Resetter).Reset(bytes.NewReader(compressedGrain), nil)
+		}
+		if err != nil {
+			return fmt.Errorf("decompressing grain at sector %d: %s", marker.Value, err)
+		}
+
+		// the last grain may be shorter than the grain size
+		n, err := io.ReadFull(decompressor, grain)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return fmt.Errorf("decompressing grain at sector %d: %s", marker.Value, err)
+		}
+		for i := n; i < len(grain); i++ {
+			grain[i] = 0
+		}
+
+		err = writer.writeGrain(marker.Value, grain)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// diskGeometry returns the cylinders, heads and sectors VMware uses for SCSI disks
+func diskGeometry(capacitySectors uint64) (uint64, uint64, uint64) {
+	heads, sectors := uint64(255), uint64(63)
+	if capacitySectors < 1024*1024*1024/SECTOR_SIZE {
+		heads, sectors = 64, 32
+	}
+
+	return capacitySectors / (heads * sectors), heads, sectors
+}
+
+func newCID() (string, error) {
+	cidBytes := make([]byte, 4)
+	_, err := rand.Read(cidBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", cidBytes), nil
+}
@@ -0,0 +1,331 @@
+package vmdk_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/vmdk"
+)
+
+var _ = Describe("VmdkWriter", func() {
+	const grainBytes = vmdk.DEFAULT_GRAIN_SIZE * vmdk.SECTOR_SIZE
+
+	var (
+		diskDir    string
+		vmdkReader vmdk.VmdkReader
+		vmdkWriter vmdk.VmdkWriter
+	)
+
+	// writeStreamOptimized writes a streamOptimized disk like ovftool exports: grain markers in sector order,
+	// followed by grain table, footer and end of stream markers
+	writeStreamOptimized := func(diskPath string, capacitySectors uint64, grains map[uint64][]byte, grainOrder []uint64) {
+		header := vmdk.NewSparseExtentHeader(capacitySectors)
+		header.Version = 3
+		header.Flags = vmdk.SPARSE_FLAG_VALID_NEWLINE_TEST | vmdk.SPARSE_FLAG_COMPRESSED | vmdk.SPARSE_FLAG_MARKERS
+		header.CompressAlgorithm = vmdk.COMPRESSION_DEFLATE
+		header.DescriptorOffset = 1
+		header.DescriptorSize = 2
+		header.GdOffset = vmdk.GD_AT_END
+		header.OverHead = vmdk.DEFAULT_GRAIN_SIZE
+
+		padTo := func(content *bytes.Buffer, size int) {
+			content.Write(make([]byte, size-content.Len()))
+		}
+		padToSector := func(content *bytes.Buffer) {
+			padTo(content, (content.Len()+vmdk.SECTOR_SIZE-1)/vmdk.SECTOR_SIZE*vmdk.SECTOR_SIZE)
+		}
+		writeMetadataMarker := func(content *bytes.Buffer, sectors uint64, markerType uint32) {
+			binary.Write(content, binary.LittleEndian, sectors)
+			binary.Write(content, binary.LittleEndian, uint32(0))
+			binary.Write(content, binary.LittleEndian, markerType)
+			padToSector(content)
+			content.Write(make([]byte, sectors*vmdk.SECTOR_SIZE))
+		}
+
+		var content bytes.Buffer
+		Expect(binary.Write(&content, binary.LittleEndian, header)).To(Succeed())
+		content.WriteString(`# Disk DescriptorFile
+version=1
+CID=12345678
+parentCID=ffffffff
+createType="streamOptimized"
+
+# Extent description
+RDONLY 2048 SPARSE "generated-stream.vmdk"
+
+# The Disk Data Base
+ddb.adapterType = "lsilogic"
+ddb.virtualHWVersion = "9"
+`)
+		padTo(&content, int(header.OverHead*vmdk.SECTOR_SIZE))
+
+		for _, sector := range grainOrder {
+			var compressedGrain bytes.Buffer
+			compressor := zlib.NewWriter(&compressedGrain)
+			compressor.Write(grains[sector])
+			Expect(compressor.Close()).To(Succeed())
+
+			binary.Write(&content, binary.LittleEndian, sector)
+			binary.Write(&content, binary.LittleEndian, uint32(compressedGrain.Len()))
+			content.Write(compressedGrain.Bytes())
+			padToSector(&content)
+		}
+
+		writeMetadataMarker(&content, 4, vmdk.MARKER_GT)
+		writeMetadataMarker(&content, 1, vmdk.MARKER_GD)
+		writeMetadataMarker(&content, 1, vmdk.MARKER_FOOTER)
+		writeMetadataMarker(&content, 0, vmdk.MARKER_EOS)
+
+		Expect(ioutil.WriteFile(diskPath, content.Bytes(), 0644)).To(Succeed())
+	}
+
+	// readSparseGrains returns the allocated grains of a hosted sparse extent by sector, following both of
+	// its grain directories
+	readSparseGrains := func(extentPath string) map[uint64][]byte {
+		extentBytes, err := ioutil.ReadFile(extentPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		header, err := vmdk.ReadSparseExtentHeader(bytes.NewReader(extentBytes))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(header.Flags & vmdk.SPARSE_FLAG_COMPRESSED).To(BeZero())
+
+		gtCoverage := header.GrainSize * uint64(header.NumGTEsPerGT)
+		numGTs := (header.Capacity + gtCoverage - 1) / gtCoverage
+
+		readUint32 := func(offset uint64) uint64 {
+			return uint64(binary.LittleEndian.Uint32(extentBytes[offset : offset+4]))
+		}
+
+		grainsByDirectory := []map[uint64][]byte{}
+		for _, gdOffset := range []uint64{header.GdOffset, header.RgdOffset} {
+			grains := map[uint64][]byte{}
+
+			for gt := uint64(0); gt < numGTs; gt++ {
+				gtSector := readUint32(gdOffset*vmdk.SECTOR_SIZE + gt*4)
+
+				for gte := uint64(0); gte < uint64(header.NumGTEsPerGT); gte++ {
+					grainSector := readUint32(gtSector*vmdk.SECTOR_SIZE + gte*4)
+					if grainSector == 0 {
+						continue
+					}
+
+					grainStart := grainSector * vmdk.SECTOR_SIZE
+					grains[(gt*uint64(header.NumGTEsPerGT)+gte)*header.GrainSize] = extentBytes[grainStart : grainStart+header.GrainSize*vmdk.SECTOR_SIZE]
+				}
+			}
+
+			grainsByDirectory = append(grainsByDirectory, grains)
+		}
+
+		Expect(grainsByDirectory[0]).To(Equal(grainsByDirectory[1]))
+		return grainsByDirectory[0]
+	}
+
+	filledGrain := func(value byte) []byte {
+		return bytes.Repeat([]byte{value}, grainBytes)
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		diskDir, err = ioutil.TempDir("", "vmdk-")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := &fakelogger.FakeLogger{}
+		vmdkReader = vmdk.NewVmdkReader(logger)
+		vmdkWriter = vmdk.NewVmdkWriter(logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(diskDir)
+	})
+
+	Describe("CreateSparse", func() {
+		It("creates an empty monolithic sparse disk", func() {
+			diskPath := filepath.Join(diskDir, "disk-1.vmdk")
+
+			Expect(vmdkWriter.CreateSparse(diskPath, 2*1024*1024, "lsisas1068")).To(Succeed())
+
+			header, err := readHeader(diskPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(header.OverHead % vmdk.DEFAULT_GRAIN_SIZE).To(BeZero())
+
+			info, err := vmdkReader.GetInfo(diskPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.CreateType).To(Equal("monolithicSparse"))
+			Expect(info.AdapterType).To(Equal("lsisas1068"))
+			Expect(info.AllocatedSizeBytes).To(Equal(int64(header.OverHead * vmdk.SECTOR_SIZE)))
+			Expect(info.VirtualSizeBytes).To(Equal(int64(1024 * 1024 * 1024)))
+			Expect(readSparseGrains(diskPath)).To(BeEmpty())
+		})
+
+		It("does not overwrite existing disks", func() {
+			diskPath := filepath.Join(diskDir, "disk-1.vmdk")
+			Expect(ioutil.WriteFile(diskPath, []byte("existing"), 0644)).To(Succeed())
+
+			Expect(vmdkWriter.CreateSparse(diskPath, 2048, "lsilogic")).ToNot(Succeed())
+			Expect(ioutil.ReadFile(diskPath)).To(Equal([]byte("existing")))
+		})
+	})
+
+	Describe("ConvertStreamOptimized", func() {
+		// two grain tables of 512 grains
+		const streamCapacity = 2 * 512 * vmdk.DEFAULT_GRAIN_SIZE
+
+		var (
+			sourcePath string
+			grains     map[uint64][]byte
+		)
+
+		BeforeEach(func() {
+			sourcePath = filepath.Join(diskDir, "stream.vmdk")
+			grains = map[uint64][]byte{
+				0:                                        filledGrain(1),
+				vmdk.DEFAULT_GRAIN_SIZE:                  make([]byte, grainBytes),
+				vmdk.DEFAULT_GRAIN_SIZE * 5:              filledGrain(2),
+				vmdk.DEFAULT_GRAIN_SIZE * 512:            filledGrain(3),
+				streamCapacity - vmdk.DEFAULT_GRAIN_SIZE: filledGrain(4),
+			}
+			writeStreamOptimized(sourcePath, streamCapacity, grains, []uint64{0, vmdk.DEFAULT_GRAIN_SIZE, vmdk.DEFAULT_GRAIN_SIZE * 5, vmdk.DEFAULT_GRAIN_SIZE * 512, streamCapacity - vmdk.DEFAULT_GRAIN_SIZE})
+		})
+
+		expectedGrains := func() map[uint64][]byte {
+			nonZeroGrains := map[uint64][]byte{}
+			for sector, grain := range grains {
+				if !isZero(grain) {
+					nonZeroGrains[sector] = grain
+				}
+			}
+			return nonZeroGrains
+		}
+
+		It("writes the grains to a monolithic sparse disk", func() {
+			targetPath := filepath.Join(diskDir, "disk-1.vmdk")
+
+			Expect(vmdkWriter.ConvertStreamOptimized(sourcePath, targetPath, vmdk.CREATE_TYPE_MONOLITHIC_SPARSE)).To(Succeed())
+
+			info, err := vmdkReader.GetInfo(targetPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.CreateType).To(Equal("monolithicSparse"))
+			Expect(info.AdapterType).To(Equal("lsilogic"))
+			Expect(info.VirtualSizeBytes).To(Equal(int64(streamCapacity * vmdk.SECTOR_SIZE)))
+			Expect(info.Extents).To(Equal([]vmdk.Extent{{Access: "RW", Sectors: streamCapacity, Type: "SPARSE", FileName: "disk-1.vmdk"}}))
+
+			Expect(readSparseGrains(targetPath)).To(Equal(expectedGrains()))
+		})
+
+		It("does not allocate zero grains", func() {
+			targetPath := filepath.Join(diskDir, "disk-1.vmdk")
+
+			Expect(vmdkWriter.ConvertStreamOptimized(sourcePath, targetPath, vmdk.CREATE_TYPE_MONOLITHIC_SPARSE)).To(Succeed())
+
+			header, err := readHeader(targetPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			targetInfo, err := os.Stat(targetPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(targetInfo.Size()).To(Equal(int64(header.OverHead*vmdk.SECTOR_SIZE + 4*grainBytes)))
+		})
+
+		It("writes grains in any order", func() {
+			writeStreamOptimized(sourcePath, streamCapacity, grains, []uint64{streamCapacity - vmdk.DEFAULT_GRAIN_SIZE, vmdk.DEFAULT_GRAIN_SIZE * 512, 0, vmdk.DEFAULT_GRAIN_SIZE * 5})
+			targetPath := filepath.Join(diskDir, "disk-1.vmdk")
+
+			Expect(vmdkWriter.ConvertStreamOptimized(sourcePath, targetPath, vmdk.CREATE_TYPE_MONOLITHIC_SPARSE)).To(Succeed())
+
+			Expect(readSparseGrains(targetPath)).To(Equal(expectedGrains()))
+		})
+
+		It("writes the grains to 2GB extents", func() {
+			capacitySectors := uint64(vmdk.TWO_GB_EXTENT_SECTORS + 4096)
+			lastGrainSector := capacitySectors - vmdk.DEFAULT_GRAIN_SIZE
+			grains = map[uint64][]byte{
+				0:                          filledGrain(1),
+				vmdk.TWO_GB_EXTENT_SECTORS: filledGrain(2),
+				lastGrainSector:            filledGrain(3),
+			}
+			writeStreamOptimized(sourcePath, capacitySectors, grains, []uint64{0, vmdk.TWO_GB_EXTENT_SECTORS, lastGrainSector})
+			targetPath := filepath.Join(diskDir, "disk-1.vmdk")
+
+			Expect(vmdkWriter.ConvertStreamOptimized(sourcePath, targetPath, vmdk.CREATE_TYPE_TWO_GB_MAX_EXTENT_SPARSE)).To(Succeed())
+
+			info, err := vmdkReader.GetInfo(targetPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.CreateType).To(Equal("twoGbMaxExtentSparse"))
+			Expect(info.VirtualSizeBytes).To(Equal(int64(capacitySectors * vmdk.SECTOR_SIZE)))
+			Expect(info.Extents).To(Equal([]vmdk.Extent{
+				{Access: "RW", Sectors: vmdk.TWO_GB_EXTENT_SECTORS, Type: "SPARSE", FileName: "disk-1-s001.vmdk"},
+				{Access: "RW", Sectors: 4096, Type: "SPARSE", FileName: "disk-1-s002.vmdk"},
+			}))
+
+			Expect(readSparseGrains(filepath.Join(diskDir, "disk-1-s001.vmdk"))).To(Equal(map[uint64][]byte{
+				0: filledGrain(1),
+			}))
+			Expect(readSparseGrains(filepath.Join(diskDir, "disk-1-s002.vmdk"))).To(Equal(map[uint64][]byte{
+				0: filledGrain(2),
+				lastGrainSector - vmdk.TWO_GB_EXTENT_SECTORS: filledGrain(3),
+			}))
+		})
+
+		It("converts the ovftool fixture", func() {
+			targetPath := filepath.Join(diskDir, "disk-1.vmdk")
+
+			Expect(vmdkWriter.ConvertStreamOptimized(filepath.Join("..", "test", "fixtures", "image.vmdk"), targetPath, vmdk.CREATE_TYPE_MONOLITHIC_SPARSE)).To(Succeed())
+
+			info, err := vmdkReader.GetInfo(targetPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.CreateType).To(Equal("monolithicSparse"))
+			Expect(info.VirtualSizeBytes).To(Equal(int64(1024 * 1024)))
+			Expect(info.AdapterType).To(Equal("lsilogic"))
+		})
+
+		It("removes the target when the stream is truncated", func() {
+			sourceBytes, err := ioutil.ReadFile(sourcePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(sourcePath, sourceBytes[:len(sourceBytes)-vmdk.SECTOR_SIZE*4], 0644)).To(Succeed())
+			targetPath := filepath.Join(diskDir, "disk-1.vmdk")
+
+			err = vmdkWriter.ConvertStreamOptimized(sourcePath, targetPath, vmdk.CREATE_TYPE_MONOLITHIC_SPARSE)
+			Expect(err).To(MatchError(ContainSubstring("stream ended without an end of stream marker")))
+
+			_, err = os.Stat(targetPath)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("rejects disks that are not streamOptimized", func() {
+			targetPath := filepath.Join(diskDir, "disk-1.vmdk")
+			Expect(vmdkWriter.CreateSparse(sourcePath+".sparse", 2048, "lsilogic")).To(Succeed())
+
+			err := vmdkWriter.ConvertStreamOptimized(sourcePath+".sparse", targetPath, vmdk.CREATE_TYPE_MONOLITHIC_SPARSE)
+			Expect(err).To(MatchError(ContainSubstring("not a streamOptimized disk")))
+		})
+
+		It("rejects unsupported target types", func() {
+			err := vmdkWriter.ConvertStreamOptimized(sourcePath, filepath.Join(diskDir, "disk-1.vmdk"), vmdk.CREATE_TYPE_MONOLITHIC_FLAT)
+			Expect(err).To(MatchError("unsupported sparse disk type: monolithicFlat"))
+		})
+	})
+})
+
+func readHeader(diskPath string) (vmdk.SparseExtentHeader, error) {
+	diskFile, err := os.Open(diskPath)
+	if err != nil {
+		return vmdk.SparseExtentHeader{}, err
+	}
+	defer diskFile.Close()
+
+	return vmdk.ReadSparseExtentHeader(diskFile)
+}
+
+func isZero(data []byte) bool {
+	return bytes.Count(data, []byte{0}) == len(data)
+}
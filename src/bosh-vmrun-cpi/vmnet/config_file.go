@@ -0,0 +1,41 @@
+package vmnet
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+const (
+	lockMaxWait = 30 * time.Second
+)
+
+// updateConfigFile rewrites a vmnet config file under a lock, keeping its file mode. The file is left
+// untouched when update fails
+func updateConfigFile(retryFileLock RetryFileLock, configPath string, update func([]byte) ([]byte, error)) error {
+	return retryFileLock.Try(configPath+".lock", lockMaxWait, func() error {
+		fileMode := os.FileMode(0644)
+
+		content, err := ioutil.ReadFile(configPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if fileInfo, err := os.Stat(configPath); err == nil {
+			fileMode = fileInfo.Mode()
+		}
+
+		newContent, err := update(content)
+		if err != nil {
+			return err
+		}
+
+		tmpConfigPath := configPath + ".tmp"
+		err = ioutil.WriteFile(tmpConfigPath, newContent, fileMode)
+		if err != nil {
+			return err
+		}
+
+		return os.Rename(tmpConfigPath, configPath)
+	})
+}
@@ -0,0 +1,186 @@
+package vmnet
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DhcpConfig is an ISC dhcpd config (vmnetdhcp.conf, dhcpd.conf) where everything but top-level host
+// declarations is kept verbatim
+type DhcpConfig struct {
+	sections []dhcpSection
+}
+
+type dhcpSection struct {
+	raw  string
+	host *DhcpHost
+}
+
+var (
+	dhcpHostHeaderRegexp   = regexp.MustCompile(`^host\s+("[^"]+"|\S+)$`)
+	dhcpHardwareRegexp     = regexp.MustCompile(`(?m)^\s*hardware\s+ethernet\s+([0-9A-Fa-f:]+)\s*;`)
+	dhcpFixedAddressRegexp = regexp.MustCompile(`(?m)^\s*fixed-address\s+([^;\s]+)\s*;`)
+)
+
+func ParseDhcpConfig(content []byte) (*DhcpConfig, error) {
+	config := &DhcpConfig{}
+	text := string(content)
+
+	depth := 0
+	inComment := false
+	inQuote := false
+	statementStart := 0
+
+	for i := 0; i < len(text); i++ {
+		char := text[i]
+
+		switch {
+		case inComment:
+			if char == '\n' {
+				inComment = false
+			}
+		case inQuote:
+			if char == '"' {
+				inQuote = false
+			}
+		case char == '#':
+			inComment = true
+		case char == '"':
+			inQuote = true
+		case char == '{':
+			depth++
+		case char == '}':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unexpected '}' at offset %d", i)
+			}
+			if depth == 0 {
+				config.addStatement(text[statementStart : i+1])
+				statementStart = i + 1
+			}
+		case char == ';':
+			if depth == 0 {
+				config.addStatement(text[statementStart : i+1])
+				statementStart = i + 1
+			}
+		}
+	}
+
+	if depth != 0 || inQuote {
+		return nil, fmt.Errorf("unterminated declaration at end of config")
+	}
+
+	if statementStart < len(text) {
+		config.sections = append(config.sections, dhcpSection{raw: text[statementStart:]})
+	}
+
+	return config, nil
+}
+
+// leading whitespace and comments are kept separate so they survive removal of the declaration that follows them
+func (c *DhcpConfig) addStatement(statement string) {
+	start := 0
+	for start < len(statement) {
+		if statement[start] == '#' {
+			end := strings.IndexByte(statement[start:], '\n')
+			if end < 0 {
+				break
+			}
+			start += end + 1
+		} else if strings.ContainsRune(" \t\r\n", rune(statement[start])) {
+			start++
+		} else {
+			break
+		}
+	}
+
+	if start > 0 {
+		c.sections = append(c.sections, dhcpSection{raw: statement[:start]})
+	}
+
+	c.sections = append(c.sections, dhcpSection{raw: statement[start:], host: parseDhcpHost(statement[start:])})
+}
+
+func parseDhcpHost(declaration string) *DhcpHost {
+	braceIndex := strings.IndexByte(declaration, '{')
+	if braceIndex < 0 {
+		return nil
+	}
+
+	headerMatch := dhcpHostHeaderRegexp.FindStringSubmatch(strings.TrimSpace(declaration[:braceIndex]))
+	if headerMatch == nil {
+		return nil
+	}
+
+	host := &DhcpHost{Name: strings.Trim(headerMatch[1], `"`)}
+	body := declaration[braceIndex+1 : len(declaration)-1]
+
+	if match := dhcpHardwareRegexp.FindStringSubmatch(body); match != nil {
+		host.MACAddress = strings.ToLower(match[1])
+	}
+	if match := dhcpFixedAddressRegexp.FindStringSubmatch(body); match != nil {
+		host.IPAddress = match[1]
+	}
+
+	return host
+}
+
+func (c *DhcpConfig) Hosts() []DhcpHost {
+	hosts := []DhcpHost{}
+	for _, section := range c.sections {
+		if section.host != nil {
+			hosts = append(hosts, *section.host)
+		}
+	}
+
+	return hosts
+}
+
+// AddHost replaces any host declaration with the same name or MAC address
+func (c *DhcpConfig) AddHost(host DhcpHost) {
+	host.MACAddress = strings.ToLower(host.MACAddress)
+
+	c.removeHosts(func(existing DhcpHost) bool {
+		return existing.Name == host.Name || existing.MACAddress == host.MACAddress
+	})
+
+	if len(c.sections) > 0 && !strings.HasSuffix(c.sections[len(c.sections)-1].raw, "\n") {
+		c.sections = append(c.sections, dhcpSection{raw: "\n"})
+	}
+
+	raw := fmt.Sprintf("host %s {\n    hardware ethernet %s;\n    fixed-address %s;\n}\n", host.Name, host.MACAddress, host.IPAddress)
+	c.sections = append(c.sections, dhcpSection{raw: raw, host: &host})
+}
+
+func (c *DhcpConfig) RemoveHosts(hostNamePrefix string) {
+	c.removeHosts(func(existing DhcpHost) bool {
+		return strings.HasPrefix(existing.Name, hostNamePrefix)
+	})
+}
+
+func (c *DhcpConfig) removeHosts(matches func(DhcpHost) bool) {
+	sections := []dhcpSection{}
+	for _, section := range c.sections {
+		if section.host != nil && matches(*section.host) {
+			// drop the line break that ends the removed declaration
+			if len(sections) > 0 && sections[len(sections)-1].host == nil {
+				sections[len(sections)-1].raw = strings.TrimSuffix(sections[len(sections)-1].raw, "\n")
+			}
+			continue
+		}
+		sections = append(sections, section)
+	}
+
+	c.sections = sections
+}
+
+func (c *DhcpConfig) Bytes() []byte {
+	var content bytes.Buffer
+	for _, section := range c.sections {
+		content.WriteString(section.raw)
+	}
+
+	return content.Bytes()
+}
@@ -0,0 +1,93 @@
+package vmnet_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-vmrun-cpi/vmnet"
+)
+
+var _ = Describe("DhcpConfig", func() {
+	const vmnetDhcpConf = `# Configuration file for ISC 2.0 vmnet-dhcpd operating on vmnet8.
+allow unknown-clients;
+default-lease-time 1800;                # default is 30 minutes
+max-lease-time 7200;                    # default is 2 hours
+
+subnet 192.168.80.0 netmask 255.255.255.0 {
+	range 192.168.80.128 192.168.80.254;
+	option domain-name "localdomain; not a statement";
+	host nested {
+		hardware ethernet 00:0c:29:00:00:01;
+		fixed-address 192.168.80.10;
+	}
+}
+host static-host {
+	hardware ethernet 00:0C:29:00:00:02;
+	fixed-address 192.168.80.11;
+}
+# trailing comment
+`
+
+	It("parses top-level hosts", func() {
+		config, err := vmnet.ParseDhcpConfig([]byte(vmnetDhcpConf))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(config.Hosts()).To(Equal([]vmnet.DhcpHost{
+			{Name: "static-host", MACAddress: "00:0c:29:00:00:02", IPAddress: "192.168.80.11"},
+		}))
+		Expect(string(config.Bytes())).To(Equal(vmnetDhcpConf))
+	})
+
+	It("adds and removes hosts while preserving unrelated entries", func() {
+		config, err := vmnet.ParseDhcpConfig([]byte(vmnetDhcpConf))
+		Expect(err).ToNot(HaveOccurred())
+
+		config.AddHost(vmnet.DhcpHost{Name: "vm-1-005056000001", MACAddress: "00:50:56:00:00:01", IPAddress: "192.168.80.20"})
+		config.AddHost(vmnet.DhcpHost{Name: "vm-1-005056000002", MACAddress: "00:50:56:00:00:02", IPAddress: "192.168.80.21"})
+
+		Expect(string(config.Bytes())).To(Equal(vmnetDhcpConf + `host vm-1-005056000001 {
+    hardware ethernet 00:50:56:00:00:01;
+    fixed-address 192.168.80.20;
+}
+host vm-1-005056000002 {
+    hardware ethernet 00:50:56:00:00:02;
+    fixed-address 192.168.80.21;
+}
+`))
+
+		reparsedConfig, err := vmnet.ParseDhcpConfig(config.Bytes())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reparsedConfig.Hosts()).To(HaveLen(3))
+
+		reparsedConfig.RemoveHosts("vm-1-")
+		Expect(string(reparsedConfig.Bytes())).To(Equal(vmnetDhcpConf))
+	})
+
+	It("replaces hosts with the same name or MAC address", func() {
+		config, err := vmnet.ParseDhcpConfig([]byte(vmnetDhcpConf))
+		Expect(err).ToNot(HaveOccurred())
+
+		config.AddHost(vmnet.DhcpHost{Name: "vm-1-000c29000002", MACAddress: "00:0C:29:00:00:02", IPAddress: "192.168.80.30"})
+
+		Expect(config.Hosts()).To(Equal([]vmnet.DhcpHost{
+			{Name: "vm-1-000c29000002", MACAddress: "00:0c:29:00:00:02", IPAddress: "192.168.80.30"},
+		}))
+	})
+
+	It("adds hosts to an empty config", func() {
+		config, err := vmnet.ParseDhcpConfig([]byte{})
+		Expect(err).ToNot(HaveOccurred())
+
+		config.AddHost(vmnet.DhcpHost{Name: "vm-1", MACAddress: "00:50:56:00:00:01", IPAddress: "10.0.0.5"})
+
+		Expect(string(config.Bytes())).To(Equal("host vm-1 {\n    hardware ethernet 00:50:56:00:00:01;\n    fixed-address 10.0.0.5;\n}\n"))
+	})
+
+	It("fails on unbalanced braces", func() {
+		_, err := vmnet.ParseDhcpConfig([]byte("subnet 10.0.0.0 netmask 255.0.0.0 {\n"))
+		Expect(err).To(MatchError("unterminated declaration at end of config"))
+
+		_, err = vmnet.ParseDhcpConfig([]byte("}\n"))
+		Expect(err).To(MatchError("unexpected '}' at offset 0"))
+	})
+})
@@ -0,0 +1,44 @@
+package vmnet
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type DhcpReservationsImpl struct {
+	retryFileLock RetryFileLock
+	logger        boshlog.Logger
+}
+
+func NewDhcpReservations(retryFileLock RetryFileLock, logger boshlog.Logger) DhcpReservations {
+	return DhcpReservationsImpl{retryFileLock: retryFileLock, logger: logger}
+}
+
+func (r DhcpReservationsImpl) Add(configPath string, host DhcpHost) error {
+	return r.updateConfig(configPath, func(config *DhcpConfig) {
+		r.logger.Debug("dhcp-reservations", "adding host %s (%s -> %s) to %s", host.Name, host.MACAddress, host.IPAddress, configPath)
+
+		config.AddHost(host)
+	})
+}
+
+func (r DhcpReservationsImpl) Remove(configPath string, hostNamePrefix string) error {
+	return r.updateConfig(configPath, func(config *DhcpConfig) {
+		r.logger.Debug("dhcp-reservations", "removing hosts %s* from %s", hostNamePrefix, configPath)
+
+		config.RemoveHosts(hostNamePrefix)
+	})
+}
+
+func (r DhcpReservationsImpl) updateConfig(configPath string, update func(*DhcpConfig)) error {
+	return updateConfigFile(r.retryFileLock, configPath, func(content []byte) ([]byte, error) {
+		config, err := ParseDhcpConfig(content)
+		if err != nil {
+			r.logger.ErrorWithDetails("dhcp-reservations", "parsing %s", configPath, err)
+			return nil, err
+		}
+
+		update(config)
+
+		return config.Bytes(), nil
+	})
+}
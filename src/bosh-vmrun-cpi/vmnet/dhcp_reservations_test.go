@@ -0,0 +1,73 @@
+package vmnet_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/vmnet"
+)
+
+var _ = Describe("DhcpReservations", func() {
+	var (
+		configDir        string
+		configPath       string
+		dhcpReservations vmnet.DhcpReservations
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		configDir, err = ioutil.TempDir("", "dhcp-config-")
+		Expect(err).ToNot(HaveOccurred())
+		configPath = filepath.Join(configDir, "dhcpd.conf")
+
+		dhcpReservations = vmnet.NewDhcpReservations(driver.NewRetryFileLock(&fakelogger.FakeLogger{}), &fakelogger.FakeLogger{})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(configDir)
+	})
+
+	It("adds and removes reservations in the config file", func() {
+		Expect(ioutil.WriteFile(configPath, []byte("allow unknown-clients;\n"), 0600)).To(Succeed())
+
+		Expect(dhcpReservations.Add(configPath, vmnet.DhcpHost{Name: "vm-1-005056000001", MACAddress: "00:50:56:00:00:01", IPAddress: "10.0.0.5"})).To(Succeed())
+		Expect(dhcpReservations.Add(configPath, vmnet.DhcpHost{Name: "vm-2-005056000002", MACAddress: "00:50:56:00:00:02", IPAddress: "10.0.0.6"})).To(Succeed())
+
+		content, err := ioutil.ReadFile(configPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("host vm-1-005056000001 {"))
+		Expect(string(content)).To(ContainSubstring("host vm-2-005056000002 {"))
+
+		Expect(dhcpReservations.Remove(configPath, "vm-1-")).To(Succeed())
+
+		content, err = ioutil.ReadFile(configPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("allow unknown-clients;\nhost vm-2-005056000002 {\n    hardware ethernet 00:50:56:00:00:02;\n    fixed-address 10.0.0.6;\n}\n"))
+
+		fileInfo, err := os.Stat(configPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileInfo.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("creates a missing config file", func() {
+		Expect(dhcpReservations.Add(configPath, vmnet.DhcpHost{Name: "vm-1", MACAddress: "00:50:56:00:00:01", IPAddress: "10.0.0.5"})).To(Succeed())
+		Expect(configPath).To(BeAnExistingFile())
+	})
+
+	It("does not modify unparseable config files", func() {
+		Expect(ioutil.WriteFile(configPath, []byte("subnet {\n"), 0644)).To(Succeed())
+
+		err := dhcpReservations.Add(configPath, vmnet.DhcpHost{Name: "vm-1", MACAddress: "00:50:56:00:00:01", IPAddress: "10.0.0.5"})
+		Expect(err).To(HaveOccurred())
+
+		Expect(ioutil.ReadFile(configPath)).To(Equal([]byte("subnet {\n")))
+	})
+})
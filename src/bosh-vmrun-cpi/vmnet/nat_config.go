@@ -0,0 +1,193 @@
+package vmnet
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NatConfig is a vmnet NAT config (nat.conf, vmnetnat.conf). Port forwards added by the CPI are preceded by an
+// owner comment so they can be removed later, everything else is kept verbatim
+type NatConfig struct {
+	lines []string
+}
+
+type natEntry struct {
+	lineIndex   int
+	markerIndex int
+	portForward PortForward
+}
+
+const natOwnerMarker = "# bosh-vmrun-cpi: "
+
+var (
+	natSectionRegexp     = regexp.MustCompile(`^\s*\[([^\]]+)\]\s*$`)
+	natPortForwardRegexp = regexp.MustCompile(`^\s*(\d+)\s*=\s*([^\s:#]+):(\d+)\s*(#.*)?$`)
+)
+
+func ParseNatConfig(content []byte) *NatConfig {
+	text := strings.TrimSuffix(string(content), "\n")
+	if text == "" {
+		return &NatConfig{}
+	}
+
+	return &NatConfig{lines: strings.Split(text, "\n")}
+}
+
+func (c *NatConfig) PortForwards() []PortForward {
+	portForwards := []PortForward{}
+	for _, entry := range c.entries() {
+		portForwards = append(portForwards, entry.portForward)
+	}
+
+	return portForwards
+}
+
+// AddPortForward adds a forward from a free host port, a host port already forwarded by any owner or by hand is a
+// conflict
+func (c *NatConfig) AddPortForward(portForward PortForward) error {
+	if portForward.Protocol != PROTOCOL_TCP && portForward.Protocol != PROTOCOL_UDP {
+		return fmt.Errorf("unsupported port forward protocol: %s", portForward.Protocol)
+	}
+
+	if !validPort(portForward.HostPort) || !validPort(portForward.GuestPort) {
+		return fmt.Errorf("invalid port forward %d -> %d", portForward.HostPort, portForward.GuestPort)
+	}
+
+	for _, existing := range c.PortForwards() {
+		if existing.Protocol != portForward.Protocol || existing.HostPort != portForward.HostPort {
+			continue
+		}
+
+		owner := existing.Owner
+		if owner == "" {
+			owner = "an unmanaged rule"
+		}
+
+		return fmt.Errorf("host port %d/%s is already forwarded to %s:%d by %s", existing.HostPort, existing.Protocol, existing.GuestIP, existing.GuestPort, owner)
+	}
+
+	sectionName := "incoming" + portForward.Protocol
+	insertIndex := c.sectionEnd(sectionName)
+	if insertIndex < 0 {
+		if len(c.lines) > 0 && strings.TrimSpace(c.lines[len(c.lines)-1]) != "" {
+			c.lines = append(c.lines, "")
+		}
+		c.lines = append(c.lines, "["+sectionName+"]")
+		insertIndex = len(c.lines)
+	}
+
+	newLines := []string{
+		natOwnerMarker + portForward.Owner,
+		fmt.Sprintf("%d = %s:%d", portForward.HostPort, portForward.GuestIP, portForward.GuestPort),
+	}
+
+	lines := append([]string{}, c.lines[:insertIndex]...)
+	lines = append(lines, newLines...)
+	c.lines = append(lines, c.lines[insertIndex:]...)
+
+	return nil
+}
+
+func (c *NatConfig) RemovePortForwards(owner string) {
+	c.removePortForwards(func(existing PortForward) bool {
+		return existing.Owner == owner
+	})
+}
+
+func (c *NatConfig) Bytes() []byte {
+	if len(c.lines) == 0 {
+		return []byte{}
+	}
+
+	return []byte(strings.Join(c.lines, "\n") + "\n")
+}
+
+func (c *NatConfig) removePortForwards(matches func(PortForward) bool) {
+	removed := map[int]bool{}
+	for _, entry := range c.entries() {
+		if entry.portForward.Owner != "" && matches(entry.portForward) {
+			removed[entry.lineIndex] = true
+			removed[entry.markerIndex] = true
+		}
+	}
+
+	lines := []string{}
+	for i, line := range c.lines {
+		if !removed[i] {
+			lines = append(lines, line)
+		}
+	}
+
+	c.lines = lines
+}
+
+func (c *NatConfig) entries() []natEntry {
+	entries := []natEntry{}
+	section := ""
+
+	for i, line := range c.lines {
+		if match := natSectionRegexp.FindStringSubmatch(line); match != nil {
+			section = strings.ToLower(match[1])
+			continue
+		}
+
+		if section != "incoming"+PROTOCOL_TCP && section != "incoming"+PROTOCOL_UDP {
+			continue
+		}
+
+		match := natPortForwardRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		hostPort, _ := strconv.Atoi(match[1])
+		guestPort, _ := strconv.Atoi(match[3])
+		entry := natEntry{
+			lineIndex:   i,
+			markerIndex: -1,
+			portForward: PortForward{
+				Protocol:  strings.TrimPrefix(section, "incoming"),
+				HostPort:  hostPort,
+				GuestIP:   match[2],
+				GuestPort: guestPort,
+			},
+		}
+
+		if i > 0 && strings.HasPrefix(c.lines[i-1], natOwnerMarker) {
+			entry.markerIndex = i - 1
+			entry.portForward.Owner = strings.TrimPrefix(c.lines[i-1], natOwnerMarker)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// sectionEnd is the index after the last non-blank line of a section, or -1 when the section is missing
+func (c *NatConfig) sectionEnd(sectionName string) int {
+	end := -1
+	inSection := false
+
+	for i, line := range c.lines {
+		if match := natSectionRegexp.FindStringSubmatch(line); match != nil {
+			inSection = strings.ToLower(match[1]) == sectionName
+			if inSection {
+				end = i + 1
+			}
+			continue
+		}
+
+		if inSection && strings.TrimSpace(line) != "" {
+			end = i + 1
+		}
+	}
+
+	return end
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,90 @@
+package vmnet_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-vmrun-cpi/vmnet"
+)
+
+var _ = Describe("NatConfig", func() {
+	const natConf = `# VMware NAT configuration file
+
+[host]
+ip = 192.168.80.2
+netmask = 255.255.255.0
+
+[incomingtcp]
+# Use these with care - anyone can enter into your VM through these...
+#8080 = 172.16.3.128:80
+2222 = 192.168.80.20:22
+
+[incomingudp]
+# UDP port forwarding example
+#6000 = 172.16.3.0:6001
+`
+
+	It("parses port forwards and keeps the config verbatim", func() {
+		config := vmnet.ParseNatConfig([]byte(natConf))
+
+		Expect(config.PortForwards()).To(Equal([]vmnet.PortForward{
+			{Protocol: "tcp", HostPort: 2222, GuestIP: "192.168.80.20", GuestPort: 22},
+		}))
+		Expect(string(config.Bytes())).To(Equal(natConf))
+	})
+
+	It("adds port forwards to their section and removes them by owner", func() {
+		config := vmnet.ParseNatConfig([]byte(natConf))
+
+		Expect(config.AddPortForward(vmnet.PortForward{Protocol: "tcp", HostPort: 8443, GuestIP: "192.168.80.10", GuestPort: 443, Owner: "vm-1"})).To(Succeed())
+		Expect(config.AddPortForward(vmnet.PortForward{Protocol: "udp", HostPort: 5353, GuestIP: "192.168.80.10", GuestPort: 53, Owner: "vm-1"})).To(Succeed())
+
+		Expect(string(config.Bytes())).To(ContainSubstring("2222 = 192.168.80.20:22\n# bosh-vmrun-cpi: vm-1\n8443 = 192.168.80.10:443\n"))
+		Expect(string(config.Bytes())).To(ContainSubstring("#6000 = 172.16.3.0:6001\n# bosh-vmrun-cpi: vm-1\n5353 = 192.168.80.10:53\n"))
+		Expect(config.PortForwards()).To(ContainElement(vmnet.PortForward{Protocol: "udp", HostPort: 5353, GuestIP: "192.168.80.10", GuestPort: 53, Owner: "vm-1"}))
+
+		config.RemovePortForwards("vm-1")
+		Expect(string(config.Bytes())).To(Equal(natConf))
+	})
+
+	It("adds missing sections", func() {
+		config := vmnet.ParseNatConfig([]byte("[host]\nip = 192.168.80.2\n"))
+
+		Expect(config.AddPortForward(vmnet.PortForward{Protocol: "tcp", HostPort: 8080, GuestIP: "192.168.80.10", GuestPort: 80, Owner: "vm-1"})).To(Succeed())
+		Expect(string(config.Bytes())).To(Equal("[host]\nip = 192.168.80.2\n\n[incomingtcp]\n# bosh-vmrun-cpi: vm-1\n8080 = 192.168.80.10:80\n"))
+	})
+
+	It("detects conflicts with other owners and unmanaged rules", func() {
+		config := vmnet.ParseNatConfig([]byte(natConf))
+
+		err := config.AddPortForward(vmnet.PortForward{Protocol: "tcp", HostPort: 2222, GuestIP: "192.168.80.10", GuestPort: 22, Owner: "vm-1"})
+		Expect(err).To(MatchError("host port 2222/tcp is already forwarded to 192.168.80.20:22 by an unmanaged rule"))
+
+		Expect(config.AddPortForward(vmnet.PortForward{Protocol: "tcp", HostPort: 8080, GuestIP: "192.168.80.10", GuestPort: 80, Owner: "vm-1"})).To(Succeed())
+
+		err = config.AddPortForward(vmnet.PortForward{Protocol: "tcp", HostPort: 8080, GuestIP: "192.168.80.11", GuestPort: 80, Owner: "vm-2"})
+		Expect(err).To(MatchError("host port 8080/tcp is already forwarded to 192.168.80.10:80 by vm-1"))
+
+		Expect(config.AddPortForward(vmnet.PortForward{Protocol: "udp", HostPort: 8080, GuestIP: "192.168.80.11", GuestPort: 80, Owner: "vm-2"})).To(Succeed())
+	})
+
+	It("detects conflicts with port forwards of the same owner", func() {
+		config := vmnet.ParseNatConfig([]byte{})
+
+		Expect(config.AddPortForward(vmnet.PortForward{Protocol: "tcp", HostPort: 8080, GuestIP: "192.168.80.10", GuestPort: 80, Owner: "vm-1"})).To(Succeed())
+
+		err := config.AddPortForward(vmnet.PortForward{Protocol: "tcp", HostPort: 8080, GuestIP: "192.168.80.10", GuestPort: 8080, Owner: "vm-1"})
+		Expect(err).To(MatchError("host port 8080/tcp is already forwarded to 192.168.80.10:80 by vm-1"))
+
+		Expect(config.PortForwards()).To(Equal([]vmnet.PortForward{
+			{Protocol: "tcp", HostPort: 8080, GuestIP: "192.168.80.10", GuestPort: 80, Owner: "vm-1"},
+		}))
+	})
+
+	It("rejects invalid port forwards", func() {
+		config := vmnet.ParseNatConfig([]byte{})
+
+		Expect(config.AddPortForward(vmnet.PortForward{Protocol: "icmp", HostPort: 8080, GuestPort: 80})).To(MatchError("unsupported port forward protocol: icmp"))
+		Expect(config.AddPortForward(vmnet.PortForward{Protocol: "tcp", HostPort: 70000, GuestPort: 80})).To(MatchError("invalid port forward 70000 -> 80"))
+	})
+})
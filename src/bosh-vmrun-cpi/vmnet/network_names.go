@@ -0,0 +1,71 @@
+package vmnet
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+var (
+	// `networking` (Linux, macOS) and vnetlib exports (Windows): answer VNET_8_NAT yes, add_adapter vmnet8
+	networkingAnswerRegexp  = regexp.MustCompile(`^answer\s+VNET_(\d+)_\S+`)
+	networkingAdapterRegexp = regexp.MustCompile(`^add_adapter\s+(vmnet\d+)`)
+
+	// netmap.conf: network8.device = "vmnet8", network8.name = "NAT"
+	netmapEntryRegexp = regexp.MustCompile(`^network(\d+)\.(device|name)\s*=\s*"([^"]*)"`)
+)
+
+// ParseNetworkNames maps the vmnet devices of a config file to themselves and netmap.conf display names to their
+// device (ex: NAT -> vmnet8)
+func ParseNetworkNames(content []byte) map[string]string {
+	names := map[string]string{}
+	netmapDevices := map[string]string{}
+	netmapNames := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if match := networkingAnswerRegexp.FindStringSubmatch(line); match != nil {
+			names["vmnet"+match[1]] = "vmnet" + match[1]
+		} else if match := networkingAdapterRegexp.FindStringSubmatch(line); match != nil {
+			names[match[1]] = match[1]
+		} else if match := netmapEntryRegexp.FindStringSubmatch(line); match != nil && match[3] != "" {
+			if match[2] == "device" {
+				netmapDevices[match[1]] = match[3]
+			} else {
+				netmapNames[match[1]] = match[3]
+			}
+		}
+	}
+
+	for index, device := range netmapDevices {
+		names[device] = device
+		if name, found := netmapNames[index]; found {
+			names[name] = device
+		}
+	}
+
+	return names
+}
+
+// ReadNetworkNames merges the network names of all config files
+func ReadNetworkNames(configPaths []string) (map[string]string, error) {
+	names := map[string]string{}
+
+	for _, configPath := range configPaths {
+		content, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading vmnet config %s: %s", configPath, err)
+		}
+
+		for name, device := range ParseNetworkNames(content) {
+			names[name] = device
+		}
+	}
+
+	return names, nil
+}
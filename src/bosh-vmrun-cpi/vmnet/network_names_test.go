@@ -0,0 +1,84 @@
+package vmnet_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bosh-vmrun-cpi/vmnet"
+)
+
+var _ = Describe("NetworkNames", func() {
+	const networking = `VERSION=1,0
+answer VNET_1_DHCP yes
+answer VNET_1_HOSTONLY_NETMASK 255.255.255.0
+answer VNET_8_NAT yes
+answer VNET_8_VIRTUAL_ADAPTER yes
+add_adapter vmnet3
+`
+
+	const netmapConf = `# This file is automatically generated.
+network0.name = "Bridged"
+network0.device = "vmnet0"
+network8.name = "NAT"
+network8.device = "vmnet8"
+network9.name = ""
+`
+
+	It("parses vmnets from networking and vnetlib export files", func() {
+		Expect(vmnet.ParseNetworkNames([]byte(networking))).To(Equal(map[string]string{
+			"vmnet1": "vmnet1",
+			"vmnet3": "vmnet3",
+			"vmnet8": "vmnet8",
+		}))
+	})
+
+	It("resolves netmap.conf display names to their devices", func() {
+		Expect(vmnet.ParseNetworkNames([]byte(netmapConf))).To(Equal(map[string]string{
+			"Bridged": "vmnet0",
+			"NAT":     "vmnet8",
+			"vmnet0":  "vmnet0",
+			"vmnet8":  "vmnet8",
+		}))
+	})
+
+	Describe("ReadNetworkNames", func() {
+		var tmpDir string
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "vmnet-names-")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		It("merges names from all config files", func() {
+			networkingPath := filepath.Join(tmpDir, "networking")
+			netmapPath := filepath.Join(tmpDir, "netmap.conf")
+			Expect(ioutil.WriteFile(networkingPath, []byte(networking), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(netmapPath, []byte(netmapConf), 0644)).To(Succeed())
+
+			names, err := vmnet.ReadNetworkNames([]string{networkingPath, netmapPath})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(Equal(map[string]string{
+				"Bridged": "vmnet0",
+				"NAT":     "vmnet8",
+				"vmnet0":  "vmnet0",
+				"vmnet1":  "vmnet1",
+				"vmnet3":  "vmnet3",
+				"vmnet8":  "vmnet8",
+			}))
+		})
+
+		It("fails when a config file is missing", func() {
+			_, err := vmnet.ReadNetworkNames([]string{filepath.Join(tmpDir, "missing")})
+			Expect(err).To(MatchError(ContainSubstring("reading vmnet config")))
+		})
+	})
+})
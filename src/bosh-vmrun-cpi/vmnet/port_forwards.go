@@ -0,0 +1,44 @@
+package vmnet
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type PortForwardsImpl struct {
+	retryFileLock RetryFileLock
+	logger        boshlog.Logger
+}
+
+func NewPortForwards(retryFileLock RetryFileLock, logger boshlog.Logger) PortForwards {
+	return PortForwardsImpl{retryFileLock: retryFileLock, logger: logger}
+}
+
+// Add writes all port forwards or none of them
+func (f PortForwardsImpl) Add(configPath string, portForwards []PortForward) error {
+	return updateConfigFile(f.retryFileLock, configPath, func(content []byte) ([]byte, error) {
+		config := ParseNatConfig(content)
+
+		for _, portForward := range portForwards {
+			f.logger.Debug("port-forwards", "adding %d/%s -> %s:%d for %s to %s", portForward.HostPort, portForward.Protocol, portForward.GuestIP, portForward.GuestPort, portForward.Owner, configPath)
+
+			err := config.AddPortForward(portForward)
+			if err != nil {
+				f.logger.ErrorWithDetails("port-forwards", "adding port forward to %s", configPath, err)
+				return nil, err
+			}
+		}
+
+		return config.Bytes(), nil
+	})
+}
+
+func (f PortForwardsImpl) Remove(configPath string, owner string) error {
+	return updateConfigFile(f.retryFileLock, configPath, func(content []byte) ([]byte, error) {
+		f.logger.Debug("port-forwards", "removing port forwards of %s from %s", owner, configPath)
+
+		config := ParseNatConfig(content)
+		config.RemovePortForwards(owner)
+
+		return config.Bytes(), nil
+	})
+}
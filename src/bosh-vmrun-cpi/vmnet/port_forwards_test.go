@@ -0,0 +1,70 @@
+package vmnet_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	fakelogger "github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
+
+	"bosh-vmrun-cpi/driver"
+	"bosh-vmrun-cpi/vmnet"
+)
+
+var _ = Describe("PortForwards", func() {
+	var (
+		configDir    string
+		configPath   string
+		portForwards vmnet.PortForwards
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		configDir, err = ioutil.TempDir("", "nat-config-")
+		Expect(err).ToNot(HaveOccurred())
+		configPath = filepath.Join(configDir, "nat.conf")
+
+		portForwards = vmnet.NewPortForwards(driver.NewRetryFileLock(&fakelogger.FakeLogger{}), &fakelogger.FakeLogger{})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(configDir)
+	})
+
+	It("adds and removes port forwards in the config file", func() {
+		Expect(ioutil.WriteFile(configPath, []byte("[incomingtcp]\n"), 0600)).To(Succeed())
+
+		Expect(portForwards.Add(configPath, []vmnet.PortForward{
+			{Protocol: "tcp", HostPort: 8080, GuestIP: "192.168.80.10", GuestPort: 80, Owner: "vm-1"},
+		})).To(Succeed())
+		Expect(portForwards.Add(configPath, []vmnet.PortForward{
+			{Protocol: "tcp", HostPort: 8443, GuestIP: "192.168.80.11", GuestPort: 443, Owner: "vm-2"},
+		})).To(Succeed())
+
+		Expect(portForwards.Remove(configPath, "vm-1")).To(Succeed())
+
+		content, err := ioutil.ReadFile(configPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("[incomingtcp]\n# bosh-vmrun-cpi: vm-2\n8443 = 192.168.80.11:443\n"))
+
+		fileInfo, err := os.Stat(configPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileInfo.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("does not write any port forward when one conflicts", func() {
+		Expect(ioutil.WriteFile(configPath, []byte("[incomingtcp]\n2222 = 192.168.80.20:22\n"), 0644)).To(Succeed())
+
+		err := portForwards.Add(configPath, []vmnet.PortForward{
+			{Protocol: "tcp", HostPort: 8080, GuestIP: "192.168.80.10", GuestPort: 80, Owner: "vm-1"},
+			{Protocol: "tcp", HostPort: 2222, GuestIP: "192.168.80.10", GuestPort: 22, Owner: "vm-1"},
+		})
+		Expect(err).To(HaveOccurred())
+
+		Expect(ioutil.ReadFile(configPath)).To(Equal([]byte("[incomingtcp]\n2222 = 192.168.80.20:22\n")))
+	})
+})
@@ -0,0 +1,41 @@
+package vmnet
+
+import (
+	"time"
+)
+
+// RetryFileLock guards vmnet config file updates
+type RetryFileLock interface {
+	Try(lockFilePath string, maxWait time.Duration, fn func() error) error
+}
+
+type DhcpHost struct {
+	Name       string
+	MACAddress string
+	IPAddress  string
+}
+
+//go:generate counterfeiter -o fakes/fake_dhcp_reservations.go vmnet.go DhcpReservations
+type DhcpReservations interface {
+	Add(configPath string, host DhcpHost) error
+	Remove(configPath string, hostNamePrefix string) error
+}
+
+const (
+	PROTOCOL_TCP = "tcp"
+	PROTOCOL_UDP = "udp"
+)
+
+type PortForward struct {
+	Protocol  string
+	HostPort  int
+	GuestIP   string
+	GuestPort int
+	Owner     string
+}
+
+//go:generate counterfeiter -o fakes/fake_port_forwards.go vmnet.go PortForwards
+type PortForwards interface {
+	Add(configPath string, portForwards []PortForward) error
+	Remove(configPath string, owner string) error
+}
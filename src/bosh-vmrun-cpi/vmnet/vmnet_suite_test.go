@@ -0,0 +1,13 @@
+package vmnet_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVmnet(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Vmnet Suite")
+}
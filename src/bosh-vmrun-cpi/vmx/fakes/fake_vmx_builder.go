@@ -7,12 +7,14 @@ import (
 )
 
 type FakeVmxBuilder struct {
-	AddNetworkInterfaceStub        func(string, string, string) error
+	AddNetworkInterfaceStub        func(string, string, string, string, string) error
 	addNetworkInterfaceMutex       sync.RWMutex
 	addNetworkInterfaceArgsForCall []struct {
 		arg1 string
 		arg2 string
 		arg3 string
+		arg4 string
+		arg5 string
 	}
 	addNetworkInterfaceReturns struct {
 		result1 error
@@ -80,6 +82,31 @@ type FakeVmxBuilder struct {
 	initHardwareReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetDiskControllerStub        func(string, string) error
+	setDiskControllerMutex       sync.RWMutex
+	setDiskControllerArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	setDiskControllerReturns struct {
+		result1 error
+	}
+	setDiskControllerReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetGuestInfoStub        func(string, string, string) error
+	setGuestInfoMutex       sync.RWMutex
+	setGuestInfoArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	setGuestInfoReturns struct {
+		result1 error
+	}
+	setGuestInfoReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetVMDisplayNameStub        func(string, string) error
 	setVMDisplayNameMutex       sync.RWMutex
 	setVMDisplayNameArgsForCall []struct {
@@ -105,22 +132,36 @@ type FakeVmxBuilder struct {
 	setVMResourcesReturnsOnCall map[int]struct {
 		result1 error
 	}
+	WriteVmxStub        func(*vmx.VM, string) error
+	writeVmxMutex       sync.RWMutex
+	writeVmxArgsForCall []struct {
+		arg1 *vmx.VM
+		arg2 string
+	}
+	writeVmxReturns struct {
+		result1 error
+	}
+	writeVmxReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeVmxBuilder) AddNetworkInterface(arg1 string, arg2 string, arg3 string) error {
+func (fake *FakeVmxBuilder) AddNetworkInterface(arg1 string, arg2 string, arg3 string, arg4 string, arg5 string) error {
 	fake.addNetworkInterfaceMutex.Lock()
 	ret, specificReturn := fake.addNetworkInterfaceReturnsOnCall[len(fake.addNetworkInterfaceArgsForCall)]
 	fake.addNetworkInterfaceArgsForCall = append(fake.addNetworkInterfaceArgsForCall, struct {
 		arg1 string
 		arg2 string
 		arg3 string
-	}{arg1, arg2, arg3})
-	fake.recordInvocation("AddNetworkInterface", []interface{}{arg1, arg2, arg3})
+		arg4 string
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("AddNetworkInterface", []interface{}{arg1, arg2, arg3, arg4, arg5})
 	fake.addNetworkInterfaceMutex.Unlock()
 	if fake.AddNetworkInterfaceStub != nil {
-		return fake.AddNetworkInterfaceStub(arg1, arg2, arg3)
+		return fake.AddNetworkInterfaceStub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1
@@ -135,17 +176,17 @@ func (fake *FakeVmxBuilder) AddNetworkInterfaceCallCount() int {
 	return len(fake.addNetworkInterfaceArgsForCall)
 }
 
-func (fake *FakeVmxBuilder) AddNetworkInterfaceCalls(stub func(string, string, string) error) {
+func (fake *FakeVmxBuilder) AddNetworkInterfaceCalls(stub func(string, string, string, string, string) error) {
 	fake.addNetworkInterfaceMutex.Lock()
 	defer fake.addNetworkInterfaceMutex.Unlock()
 	fake.AddNetworkInterfaceStub = stub
 }
 
-func (fake *FakeVmxBuilder) AddNetworkInterfaceArgsForCall(i int) (string, string, string) {
+func (fake *FakeVmxBuilder) AddNetworkInterfaceArgsForCall(i int) (string, string, string, string, string) {
 	fake.addNetworkInterfaceMutex.RLock()
 	defer fake.addNetworkInterfaceMutex.RUnlock()
 	argsForCall := fake.addNetworkInterfaceArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *FakeVmxBuilder) AddNetworkInterfaceReturns(result1 error) {
@@ -477,6 +518,129 @@ func (fake *FakeVmxBuilder) InitHardwareReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeVmxBuilder) SetDiskController(arg1 string, arg2 string) error {
+	fake.setDiskControllerMutex.Lock()
+	ret, specificReturn := fake.setDiskControllerReturnsOnCall[len(fake.setDiskControllerArgsForCall)]
+	fake.setDiskControllerArgsForCall = append(fake.setDiskControllerArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("SetDiskController", []interface{}{arg1, arg2})
+	fake.setDiskControllerMutex.Unlock()
+	if fake.SetDiskControllerStub != nil {
+		return fake.SetDiskControllerStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.setDiskControllerReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeVmxBuilder) SetDiskControllerCallCount() int {
+	fake.setDiskControllerMutex.RLock()
+	defer fake.setDiskControllerMutex.RUnlock()
+	return len(fake.setDiskControllerArgsForCall)
+}
+
+func (fake *FakeVmxBuilder) SetDiskControllerCalls(stub func(string, string) error) {
+	fake.setDiskControllerMutex.Lock()
+	defer fake.setDiskControllerMutex.Unlock()
+	fake.SetDiskControllerStub = stub
+}
+
+func (fake *FakeVmxBuilder) SetDiskControllerArgsForCall(i int) (string, string) {
+	fake.setDiskControllerMutex.RLock()
+	defer fake.setDiskControllerMutex.RUnlock()
+	argsForCall := fake.setDiskControllerArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeVmxBuilder) SetDiskControllerReturns(result1 error) {
+	fake.setDiskControllerMutex.Lock()
+	defer fake.setDiskControllerMutex.Unlock()
+	fake.SetDiskControllerStub = nil
+	fake.setDiskControllerReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmxBuilder) SetDiskControllerReturnsOnCall(i int, result1 error) {
+	fake.setDiskControllerMutex.Lock()
+	defer fake.setDiskControllerMutex.Unlock()
+	fake.SetDiskControllerStub = nil
+	if fake.setDiskControllerReturnsOnCall == nil {
+		fake.setDiskControllerReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setDiskControllerReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmxBuilder) SetGuestInfo(arg1 string, arg2 string, arg3 string) error {
+	fake.setGuestInfoMutex.Lock()
+	ret, specificReturn := fake.setGuestInfoReturnsOnCall[len(fake.setGuestInfoArgsForCall)]
+	fake.setGuestInfoArgsForCall = append(fake.setGuestInfoArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("SetGuestInfo", []interface{}{arg1, arg2, arg3})
+	fake.setGuestInfoMutex.Unlock()
+	if fake.SetGuestInfoStub != nil {
+		return fake.SetGuestInfoStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.setGuestInfoReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeVmxBuilder) SetGuestInfoCallCount() int {
+	fake.setGuestInfoMutex.RLock()
+	defer fake.setGuestInfoMutex.RUnlock()
+	return len(fake.setGuestInfoArgsForCall)
+}
+
+func (fake *FakeVmxBuilder) SetGuestInfoCalls(stub func(string, string, string) error) {
+	fake.setGuestInfoMutex.Lock()
+	defer fake.setGuestInfoMutex.Unlock()
+	fake.SetGuestInfoStub = stub
+}
+
+func (fake *FakeVmxBuilder) SetGuestInfoArgsForCall(i int) (string, string, string) {
+	fake.setGuestInfoMutex.RLock()
+	defer fake.setGuestInfoMutex.RUnlock()
+	argsForCall := fake.setGuestInfoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeVmxBuilder) SetGuestInfoReturns(result1 error) {
+	fake.setGuestInfoMutex.Lock()
+	defer fake.setGuestInfoMutex.Unlock()
+	fake.SetGuestInfoStub = nil
+	fake.setGuestInfoReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmxBuilder) SetGuestInfoReturnsOnCall(i int, result1 error) {
+	fake.setGuestInfoMutex.Lock()
+	defer fake.setGuestInfoMutex.Unlock()
+	fake.SetGuestInfoStub = nil
+	if fake.setGuestInfoReturnsOnCall == nil {
+		fake.setGuestInfoReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setGuestInfoReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeVmxBuilder) SetVMDisplayName(arg1 string, arg2 string) error {
 	fake.setVMDisplayNameMutex.Lock()
 	ret, specificReturn := fake.setVMDisplayNameReturnsOnCall[len(fake.setVMDisplayNameArgsForCall)]
@@ -600,6 +764,67 @@ func (fake *FakeVmxBuilder) SetVMResourcesReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeVmxBuilder) WriteVmx(arg1 *vmx.VM, arg2 string) error {
+	fake.writeVmxMutex.Lock()
+	ret, specificReturn := fake.writeVmxReturnsOnCall[len(fake.writeVmxArgsForCall)]
+	fake.writeVmxArgsForCall = append(fake.writeVmxArgsForCall, struct {
+		arg1 *vmx.VM
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("WriteVmx", []interface{}{arg1, arg2})
+	fake.writeVmxMutex.Unlock()
+	if fake.WriteVmxStub != nil {
+		return fake.WriteVmxStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.writeVmxReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeVmxBuilder) WriteVmxCallCount() int {
+	fake.writeVmxMutex.RLock()
+	defer fake.writeVmxMutex.RUnlock()
+	return len(fake.writeVmxArgsForCall)
+}
+
+func (fake *FakeVmxBuilder) WriteVmxCalls(stub func(*vmx.VM, string) error) {
+	fake.writeVmxMutex.Lock()
+	defer fake.writeVmxMutex.Unlock()
+	fake.WriteVmxStub = stub
+}
+
+func (fake *FakeVmxBuilder) WriteVmxArgsForCall(i int) (*vmx.VM, string) {
+	fake.writeVmxMutex.RLock()
+	defer fake.writeVmxMutex.RUnlock()
+	argsForCall := fake.writeVmxArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeVmxBuilder) WriteVmxReturns(result1 error) {
+	fake.writeVmxMutex.Lock()
+	defer fake.writeVmxMutex.Unlock()
+	fake.WriteVmxStub = nil
+	fake.writeVmxReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeVmxBuilder) WriteVmxReturnsOnCall(i int, result1 error) {
+	fake.writeVmxMutex.Lock()
+	defer fake.writeVmxMutex.Unlock()
+	fake.WriteVmxStub = nil
+	if fake.writeVmxReturnsOnCall == nil {
+		fake.writeVmxReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.writeVmxReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeVmxBuilder) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -615,10 +840,16 @@ func (fake *FakeVmxBuilder) Invocations() map[string][][]interface{} {
 	defer fake.getVmxMutex.RUnlock()
 	fake.initHardwareMutex.RLock()
 	defer fake.initHardwareMutex.RUnlock()
+	fake.setDiskControllerMutex.RLock()
+	defer fake.setDiskControllerMutex.RUnlock()
+	fake.setGuestInfoMutex.RLock()
+	defer fake.setGuestInfoMutex.RUnlock()
 	fake.setVMDisplayNameMutex.RLock()
 	defer fake.setVMDisplayNameMutex.RUnlock()
 	fake.setVMResourcesMutex.RLock()
 	defer fake.setVMResourcesMutex.RUnlock()
+	fake.writeVmxMutex.RLock()
+	defer fake.writeVmxMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -1,9 +1,62 @@
 package vmx
 
 import (
+	"fmt"
+
 	govmx "github.com/hooklift/govmx"
 )
 
+// Disk controller types for ephemeral and persistent disks
+const (
+	DISK_CONTROLLER_LSILOGIC   = "lsilogic"
+	DISK_CONTROLLER_PVSCSI     = "pvscsi"
+	DISK_CONTROLLER_LSISAS1068 = "lsisas1068"
+	DISK_CONTROLLER_SATA       = "sata"
+	DISK_CONTROLLER_NVME       = "nvme"
+)
+
+// Network connection types and virtual NIC devices
+const (
+	NETWORK_CONNECTION_CUSTOM   = "custom"
+	NETWORK_CONNECTION_NAT      = "nat"
+	NETWORK_CONNECTION_BRIDGED  = "bridged"
+	NETWORK_CONNECTION_HOSTONLY = "hostonly"
+	NETWORK_CONNECTION_PVN      = "pvn"
+
+	NETWORK_DEVICE_VMXNET3 = "vmxnet3"
+	NETWORK_DEVICE_VMXNET  = "vmxnet"
+	NETWORK_DEVICE_E1000   = "e1000"
+	NETWORK_DEVICE_E1000E  = "e1000e"
+	NETWORK_DEVICE_VLANCE  = "vlance"
+)
+
+const (
+	pcieRootPortFirst      = 4
+	pcieRootPortCount      = 4
+	pcieRootPortFunctions  = 8
+	pcieMinHardwareVersion = 7
+)
+
+// PCIBridges returns the bridges VMware adds to new VMs: pciBridge0 and, from hardware version 7, the
+// pcie root ports
+func PCIBridges(hardwareVersion int) []govmx.PCIBridge {
+	bridges := []govmx.PCIBridge{{VMXID: "pciBridge0", Present: true}}
+	if hardwareVersion < pcieMinHardwareVersion {
+		return bridges
+	}
+
+	for n := pcieRootPortFirst; n < pcieRootPortFirst+pcieRootPortCount; n++ {
+		bridges = append(bridges, govmx.PCIBridge{
+			VMXID:      fmt.Sprintf("pciBridge%d", n),
+			Present:    true,
+			VirtualDev: "pcieRootPort",
+			Functions:  pcieRootPortFunctions,
+		})
+	}
+
+	return bridges
+}
+
 type VM struct {
 	// Disable swap: https://kb.vmware.com/s/article/1008885
 	MinVmMemPct                 int    `vmx:"prefvmx.minVmMemPct"`
@@ -13,17 +66,80 @@ type VM struct {
 	UseRecommendedLockedMemSize bool   `vmx:"prefvmx.useRecommendedLockedMemSize"`
 	MainmemBacking              string `vmx:"mainmem.backing"`
 
+	Firmware string `vmx:"firmware,omitempty"`
+
+	// govmx has no nvme support, encoded separately by the builder
+	NVMeDevices []NVMeDevice `vmx:"nvme,omit"`
+
+	// govmx has no LAN segment support, pvnIDs are kept in NIC order and encoded separately by the builder
+	EthernetPVNIDs []string `vmx:"ethernetpvnids,omit"`
+
+	// guestinfo.* variables readable from the guest, keyed without the guestinfo. prefix
+	GuestInfo map[string]string `vmx:"guestinfovars,omit"`
+
 	govmx.VirtualMachine
 }
 
+type NVMeDevice struct {
+	govmx.Device
+}
+
+// DiskController returns the controller type that new disks are attached to: the nvme or sata bus holding disks,
+// or an empty one added for them. A sata controller that only holds a CD-ROM does not take disks
+func (vm VM) DiskController() string {
+	if takesDisks(nvmeDevices(vm.NVMeDevices)) {
+		return DISK_CONTROLLER_NVME
+	}
+
+	if takesDisks(sataDevices(vm.SATADevices)) {
+		return DISK_CONTROLLER_SATA
+	}
+
+	for _, device := range vm.SCSIDevices {
+		if device.VirtualDev != "" {
+			return device.VirtualDev
+		}
+	}
+
+	return DISK_CONTROLLER_LSILOGIC
+}
+
+// SCSIDisks counts the disks on scsi controllers, the first devices of the guest (ex: /dev/sda)
+func (vm VM) SCSIDisks() int {
+	scsiDisks := 0
+	for _, device := range vm.SCSIDevices {
+		if !isControllerDevice(device.Device) && !isCdromDevice(device.Device) {
+			scsiDisks++
+		}
+	}
+
+	return scsiDisks
+}
+
+// hasPCIeRootPorts is true for the pciBridge4-7 root ports that VMware places NICs on
+func (vm VM) hasPCIeRootPorts() bool {
+	rootPorts := 0
+	for _, bridge := range vm.PCIBridges {
+		index := vmxIDIndex(bridge.VMXID)
+		if bridge.Present && index >= pcieRootPortFirst && index < pcieRootPortFirst+pcieRootPortCount {
+			rootPorts++
+		}
+	}
+
+	return rootPorts == pcieRootPortCount
+}
+
 //go:generate counterfeiter -o fakes/fake_vmx_builder.go vmx.go VmxBuilder
 type VmxBuilder interface {
 	InitHardware(string) error
-	AddNetworkInterface(string, string, string) error
+	AddNetworkInterface(string, string, string, string, string) error
 	SetVMResources(int, int, string) error
 	SetVMDisplayName(string, string) error
+	SetDiskController(string, string) error
 	AttachDisk(string, string) error
 	DetachDisk(string, string) error
 	AttachCdrom(string, string) error
+	SetGuestInfo(string, string, string) error
 	GetVmx(string) (*VM, error)
+	WriteVmx(*VM, string) error
 }
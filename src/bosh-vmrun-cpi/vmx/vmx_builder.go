@@ -1,9 +1,13 @@
 package vmx
 
 import (
+	"bytes"
+	"crypto/sha1"
 	"fmt"
 	"io/ioutil"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	govmx "github.com/hooklift/govmx"
@@ -11,6 +15,11 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
+var (
+	ethernetPVNIDRegexp = regexp.MustCompile(`(?mi)^ethernet(\d+)\.pvnid\s*=\s*"([^"]*)"`)
+	guestInfoRegexp     = regexp.MustCompile(`(?mi)^guestinfo\.(\S+)\s*=\s*"([^"]*)"`)
+)
+
 type VmxBuilderImpl struct {
 	logger boshlog.Logger
 }
@@ -43,14 +52,43 @@ func (p VmxBuilderImpl) InitHardware(vmxPath string) error {
 	return err
 }
 
-func (p VmxBuilderImpl) AddNetworkInterface(networkName, macAddress, vmxPath string) error {
+func (p VmxBuilderImpl) AddNetworkInterface(networkName, macAddress, connectionType, deviceType, vmxPath string) error {
+	switch connectionType {
+	case NETWORK_CONNECTION_CUSTOM:
+		if networkName == "" {
+			return fmt.Errorf("network name is required for custom connection type")
+		}
+	case NETWORK_CONNECTION_PVN:
+		if networkName == "" {
+			return fmt.Errorf("network name is required for pvn connection type")
+		}
+	case NETWORK_CONNECTION_NAT, NETWORK_CONNECTION_BRIDGED, NETWORK_CONNECTION_HOSTONLY:
+		// built-in vmnets, the network name is not used
+		networkName = ""
+	default:
+		return fmt.Errorf("unsupported network connection type: %s", connectionType)
+	}
+
+	switch deviceType {
+	case NETWORK_DEVICE_VMXNET3, NETWORK_DEVICE_VMXNET, NETWORK_DEVICE_E1000, NETWORK_DEVICE_E1000E, NETWORK_DEVICE_VLANCE:
+	default:
+		return fmt.Errorf("unsupported network device type: %s", deviceType)
+	}
+
+	pvnID := ""
+	if connectionType == NETWORK_CONNECTION_PVN {
+		pvnID = PVNID(networkName)
+		networkName = ""
+	}
+
 	err := p.replaceVmx(vmxPath, func(vmxVM *VM) *VM {
+		vmxVM.EthernetPVNIDs = append(vmxVM.EthernetPVNIDs, pvnID)
 		vmxVM.Ethernet = append(vmxVM.Ethernet, govmx.Ethernet{
 			VNetwork:       networkName,
 			Address:        macAddress,
 			AddressType:    "static",
-			VirtualDev:     "vmxnet3",
-			ConnectionType: "custom",
+			VirtualDev:     deviceType,
+			ConnectionType: connectionType,
 			Present:        true,
 		})
 
@@ -81,14 +119,85 @@ func (p VmxBuilderImpl) SetVMDisplayName(newName, vmxPath string) error {
 	return err
 }
 
+func (p VmxBuilderImpl) SetDiskController(controllerType, vmxPath string) error {
+	var minHardwareVersion int
+
+	switch controllerType {
+	case DISK_CONTROLLER_LSILOGIC, DISK_CONTROLLER_PVSCSI, DISK_CONTROLLER_LSISAS1068:
+	case DISK_CONTROLLER_SATA:
+		minHardwareVersion = 10
+	case DISK_CONTROLLER_NVME:
+		minHardwareVersion = 13
+	default:
+		return fmt.Errorf("unsupported disk controller: %s", controllerType)
+	}
+
+	var diskController string
+	err := p.replaceVmx(vmxPath, func(vmxVM *VM) *VM {
+		if vmxVM.Vhardware.Version < minHardwareVersion {
+			vmxVM.Vhardware.Version = minHardwareVersion
+		}
+
+		switch controllerType {
+		case DISK_CONTROLLER_SATA:
+			if !hasControllerDevice(sataDevices(vmxVM.SATADevices)) {
+				controller := govmx.SATADevice{Device: govmx.Device{VMXID: "sata0", Present: true}}
+				vmxVM.SATADevices = append([]govmx.SATADevice{controller}, vmxVM.SATADevices...)
+			}
+		case DISK_CONTROLLER_NVME:
+			if !hasControllerDevice(nvmeDevices(vmxVM.NVMeDevices)) {
+				controller := NVMeDevice{Device: govmx.Device{VMXID: "nvme0", Present: true}}
+				vmxVM.NVMeDevices = append([]NVMeDevice{controller}, vmxVM.NVMeDevices...)
+			}
+		default:
+			//system disk shares the scsi controller
+			for i, device := range vmxVM.SCSIDevices {
+				if device.VirtualDev != "" {
+					vmxVM.SCSIDevices[i].VirtualDev = controllerType
+				}
+			}
+		}
+
+		diskController = vmxVM.DiskController()
+		return vmxVM
+	})
+	if err != nil {
+		return err
+	}
+
+	// ex: a sata bus holding a cd-rom, or a system disk on another bus, keeps disks off the controller
+	if diskController != controllerType {
+		return fmt.Errorf("disks of %s attach to its %s controller, not %s", vmxPath, diskController, controllerType)
+	}
+
+	return nil
+}
+
 func (p VmxBuilderImpl) AttachDisk(diskPath, vmxPath string) error {
 	err := p.replaceVmx(vmxPath, func(vmxVM *VM) *VM {
-		newSCSIDevice := govmx.SCSIDevice{Device: govmx.Device{
-			Filename: diskPath,
-			Present:  true,
-			VMXID:    fmt.Sprintf("scsi0:%d", len(vmxVM.SCSIDevices)-1),
-		}}
-		vmxVM.SCSIDevices = append(vmxVM.SCSIDevices, newSCSIDevice)
+		switch vmxVM.DiskController() {
+		case DISK_CONTROLLER_SATA:
+			newSATADevice := govmx.SATADevice{Device: govmx.Device{
+				Filename: diskPath,
+				Present:  true,
+				VMXID:    fmt.Sprintf("sata0:%d", nextDeviceUnit(sataDevices(vmxVM.SATADevices))),
+			}}
+			vmxVM.SATADevices = append(vmxVM.SATADevices, newSATADevice)
+		case DISK_CONTROLLER_NVME:
+			newNVMeDevice := NVMeDevice{Device: govmx.Device{
+				Filename: diskPath,
+				Present:  true,
+				VMXID:    fmt.Sprintf("nvme0:%d", nextDeviceUnit(nvmeDevices(vmxVM.NVMeDevices))),
+			}}
+			vmxVM.NVMeDevices = append(vmxVM.NVMeDevices, newNVMeDevice)
+		default:
+			newSCSIDevice := govmx.SCSIDevice{Device: govmx.Device{
+				Filename: diskPath,
+				Present:  true,
+				VMXID:    fmt.Sprintf("scsi0:%d", len(vmxVM.SCSIDevices)-1),
+			}}
+			vmxVM.SCSIDevices = append(vmxVM.SCSIDevices, newSCSIDevice)
+		}
 
 		return vmxVM
 	})
@@ -107,6 +216,22 @@ func (p VmxBuilderImpl) DetachDisk(diskPath string, vmxPath string) error {
 			}
 		}
 
+		for i, device := range vmxVM.SATADevices {
+			if device.Filename == diskPath {
+				vmxVM.SATADevices = append(vmxVM.SATADevices[:i], vmxVM.SATADevices[i+1:]...)
+
+				return vmxVM
+			}
+		}
+
+		for i, device := range vmxVM.NVMeDevices {
+			if device.Filename == diskPath {
+				vmxVM.NVMeDevices = append(vmxVM.NVMeDevices[:i], vmxVM.NVMeDevices[i+1:]...)
+
+				return vmxVM
+			}
+		}
+
 		return vmxVM
 	})
 
@@ -131,10 +256,25 @@ func (p VmxBuilderImpl) AttachCdrom(isoPath, vmxPath string) error {
 	return err
 }
 
+func (p VmxBuilderImpl) SetGuestInfo(name, value, vmxPath string) error {
+	err := p.replaceVmx(vmxPath, func(vmxVM *VM) *VM {
+		vmxVM.GuestInfo[strings.ToLower(name)] = value
+
+		return vmxVM
+	})
+
+	return err
+}
+
 func (p VmxBuilderImpl) GetVmx(vmxPath string) (*VM, error) {
 	return p.getVmx(vmxPath)
 }
 
+// WriteVmx writes a whole VMX, for VMs that are not cloned from an existing one
+func (p VmxBuilderImpl) WriteVmx(vmxVM *VM, vmxPath string) error {
+	return p.writeVmx(vmxVM, vmxPath)
+}
+
 func (p VmxBuilderImpl) replaceVmx(vmxPath string, vmUpdateFunc func(*VM) *VM) error {
 	vmxVM, err := p.getVmx(vmxPath)
 	if err != nil {
@@ -171,6 +311,34 @@ func (p VmxBuilderImpl) getVmx(vmxPath string) (*VM, error) {
 	sort.SliceStable(vmxVM.SCSIDevices, func(i, j int) bool {
 		return vmxVM.SCSIDevices[i].VMXID < vmxVM.SCSIDevices[j].VMXID
 	})
+	sort.SliceStable(vmxVM.SATADevices, func(i, j int) bool {
+		return vmxVM.SATADevices[i].VMXID < vmxVM.SATADevices[j].VMXID
+	})
+	sort.SliceStable(vmxVM.NVMeDevices, func(i, j int) bool {
+		return vmxVM.NVMeDevices[i].VMXID < vmxVM.NVMeDevices[j].VMXID
+	})
+
+	//govmx decodes slices in map order, NICs and bridges are numbered so sort by number
+	sort.SliceStable(vmxVM.Ethernet, func(i, j int) bool {
+		return vmxIDIndex(vmxVM.Ethernet[i].VMXID) < vmxIDIndex(vmxVM.Ethernet[j].VMXID)
+	})
+	sort.SliceStable(vmxVM.PCIBridges, func(i, j int) bool {
+		return vmxIDIndex(vmxVM.PCIBridges[i].VMXID) < vmxIDIndex(vmxVM.PCIBridges[j].VMXID)
+	})
+
+	pvnIDs := map[string]string{}
+	for _, match := range ethernetPVNIDRegexp.FindAllStringSubmatch(string(vmxBytes), -1) {
+		pvnIDs["ethernet"+match[1]] = match[2]
+	}
+	vmxVM.EthernetPVNIDs = make([]string, len(vmxVM.Ethernet))
+	for i, ethernet := range vmxVM.Ethernet {
+		vmxVM.EthernetPVNIDs[i] = pvnIDs[ethernet.VMXID]
+	}
+
+	vmxVM.GuestInfo = map[string]string{}
+	for _, match := range guestInfoRegexp.FindAllStringSubmatch(string(vmxBytes), -1) {
+		vmxVM.GuestInfo[strings.ToLower(match[1])] = unescapeValue(match[2])
+	}
 
 	return &vmxVM, nil
 }
@@ -179,27 +347,44 @@ func (p VmxBuilderImpl) writeVmx(vmxVM *VM, vmxPath string) error {
 	var err error
 
 	for i, existingDevice := range vmxVM.SCSIDevices {
-		filename := existingDevice.Filename
-		filename = strings.Replace(filename, `\`, `\\`, -1)
-		filename = strings.Replace(filename, `"`, ``, -1)
-
-		vmxVM.SCSIDevices[i].Filename = filename
+		vmxVM.SCSIDevices[i].Filename = escapeFilename(existingDevice.Filename)
 	}
 
 	for i, existingDevice := range vmxVM.IDEDevices {
-		filename := existingDevice.Filename
-		filename = strings.Replace(filename, `\`, `\\`, -1)
-		filename = strings.Replace(filename, `"`, ``, -1)
+		vmxVM.IDEDevices[i].Filename = escapeFilename(existingDevice.Filename)
+	}
+
+	for i, existingDevice := range vmxVM.SATADevices {
+		vmxVM.SATADevices[i].Filename = escapeFilename(existingDevice.Filename)
+	}
 
-		vmxVM.IDEDevices[i].Filename = filename
+	for i, existingDevice := range vmxVM.NVMeDevices {
+		vmxVM.NVMeDevices[i].Filename = escapeFilename(existingDevice.Filename)
 	}
 
+	//govmx numbers every sata entry as a disk (including the controller), so sata is encoded like nvme
+	sataVMXDevices := vmxVM.SATADevices
+	vmxVM.SATADevices = nil
+
+	//govmx renumbers bridges from 0, NIC slots depend on the pcie root ports keeping their numbers
+	pciBridges := vmxVM.PCIBridges
+	vmxVM.PCIBridges = nil
+
 	vmxBytes, err := govmx.Marshal(vmxVM)
+	vmxVM.SATADevices = sataVMXDevices
+	vmxVM.PCIBridges = pciBridges
 	if err != nil {
 		p.logger.ErrorWithDetails("vmx-builder", "marshaling content: %+v", vmxVM)
 		return err
 	}
 
+	vmxBytes = append(vmxBytes, marshalDevices(DISK_CONTROLLER_SATA, sataDevices(vmxVM.SATADevices))...)
+	vmxBytes = append(vmxBytes, marshalDevices(DISK_CONTROLLER_NVME, nvmeDevices(vmxVM.NVMeDevices))...)
+	vmxBytes = append(vmxBytes, marshalPCIBridges(vmxVM.PCIBridges)...)
+	vmxBytes = append(vmxBytes, marshalEthernetPCISlots(vmxVM)...)
+	vmxBytes = append(vmxBytes, marshalEthernetPVNIDs(vmxVM.EthernetPVNIDs)...)
+	vmxBytes = append(vmxBytes, marshalGuestInfo(vmxVM.GuestInfo)...)
+
 	err = ioutil.WriteFile(vmxPath, vmxBytes, 0644)
 	if err != nil {
 		p.logger.ErrorWithDetails("vmx-builder", "writing file: %s", vmxPath)
@@ -208,3 +393,232 @@ func (p VmxBuilderImpl) writeVmx(vmxVM *VM, vmxPath string) error {
 
 	return nil
 }
+
+func escapeFilename(filename string) string {
+	filename = strings.Replace(filename, `\`, `\\`, -1)
+	filename = strings.Replace(filename, `"`, ``, -1)
+
+	return filename
+}
+
+// controller entries have no unit number (ex: sata0), devices keep their unit number (ex: sata0:1)
+func marshalDevices(busType string, devices []govmx.Device) []byte {
+	var buf bytes.Buffer
+
+	for _, device := range devices {
+		if isControllerDevice(device) {
+			fmt.Fprintf(&buf, "%s0.present = \"%v\"\n", busType, device.Present)
+			continue
+		}
+
+		key := fmt.Sprintf("%s0:%d", busType, deviceUnit(device))
+		fmt.Fprintf(&buf, "%s.present = \"%v\"\n", key, device.Present)
+		if device.Type != "" {
+			fmt.Fprintf(&buf, "%s.devicetype = \"%s\"\n", key, device.Type)
+		}
+		if device.Filename != "" {
+			fmt.Fprintf(&buf, "%s.filename = \"%s\"\n", key, device.Filename)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func marshalPCIBridges(bridges []govmx.PCIBridge) []byte {
+	var buf bytes.Buffer
+
+	for _, bridge := range bridges {
+		key := fmt.Sprintf("pciBridge%d", vmxIDIndex(bridge.VMXID))
+		fmt.Fprintf(&buf, "%s.present = \"%v\"\n", key, bridge.Present)
+		if bridge.VirtualDev != "" {
+			fmt.Fprintf(&buf, "%s.virtualDev = \"%s\"\n", key, bridge.VirtualDev)
+		}
+		if bridge.SlotNumber != 0 {
+			fmt.Fprintf(&buf, "%s.pciSlotNumber = \"%d\"\n", key, bridge.SlotNumber)
+		}
+		if bridge.Functions != 0 {
+			fmt.Fprintf(&buf, "%s.functions = \"%d\"\n", key, bridge.Functions)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// NICs get the slots VMware itself assigns on the pcie root ports (pciBridge4-7): 160 for the first function
+// of pciBridge4, +32 per bridge and +1024 per function, spread across bridges first. Without the root ports
+// the slots are left to VMware, which assigns them in NIC order
+func marshalEthernetPCISlots(vmxVM *VM) []byte {
+	var buf bytes.Buffer
+
+	if !vmxVM.hasPCIeRootPorts() {
+		return buf.Bytes()
+	}
+
+	for i := range vmxVM.Ethernet {
+		if i >= pcieRootPortCount*pcieRootPortFunctions {
+			break
+		}
+
+		slot := 160 + 32*(i%pcieRootPortCount) + 1024*(i/pcieRootPortCount)
+		fmt.Fprintf(&buf, "ethernet%d.pciSlotNumber = \"%d\"\n", i, slot)
+	}
+
+	return buf.Bytes()
+}
+
+func marshalEthernetPVNIDs(pvnIDs []string) []byte {
+	var buf bytes.Buffer
+
+	for i, pvnID := range pvnIDs {
+		if pvnID != "" {
+			fmt.Fprintf(&buf, "ethernet%d.pvnID = \"%s\"\n", i, pvnID)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func marshalGuestInfo(guestInfo map[string]string) []byte {
+	var buf bytes.Buffer
+
+	names := []string{}
+	for name := range guestInfo {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(&buf, "guestinfo.%s = \"%s\"\n", name, escapeValue(guestInfo[name]))
+	}
+
+	return buf.Bytes()
+}
+
+// VMX values encode special characters as |XX hex escapes
+func escapeValue(value string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(value); i++ {
+		if value[i] == '|' || value[i] == '"' || value[i] < 0x20 {
+			fmt.Fprintf(&buf, "|%02X", value[i])
+		} else {
+			buf.WriteByte(value[i])
+		}
+	}
+
+	return buf.String()
+}
+
+func unescapeValue(value string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(value); i++ {
+		if value[i] == '|' && i+2 < len(value) {
+			if char, err := strconv.ParseUint(value[i+1:i+3], 16, 8); err == nil {
+				buf.WriteByte(byte(char))
+				i += 2
+				continue
+			}
+		}
+		buf.WriteByte(value[i])
+	}
+
+	return buf.String()
+}
+
+// PVNID derives a stable LAN segment id from its name, formatted like the ids VMware generates
+// (ex: 52 9b 2f 0d 71 48 5a 3e-8c 61 1f 0a 77 d2 c4 95)
+func PVNID(segmentName string) string {
+	sum := sha1.Sum([]byte(segmentName))
+
+	octets := make([]string, 16)
+	for i := range octets {
+		octets[i] = fmt.Sprintf("%02x", sum[i])
+	}
+
+	return strings.Join(octets[:8], " ") + "-" + strings.Join(octets[8:], " ")
+}
+
+// vmxIDIndex is the number in ids like ethernet1 or pcibridge4
+func vmxIDIndex(vmxID string) int {
+	index, _ := strconv.Atoi(strings.TrimLeft(vmxID, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+
+	return index
+}
+
+func isControllerDevice(device govmx.Device) bool {
+	return !strings.Contains(device.VMXID, ":")
+}
+
+// deviceUnit is the unit in device ids like sata0:1
+func deviceUnit(device govmx.Device) int {
+	parts := strings.SplitN(device.VMXID, ":", 2)
+	if len(parts) != 2 {
+		return -1
+	}
+
+	unit, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return -1
+	}
+
+	return unit
+}
+
+func nextDeviceUnit(devices []govmx.Device) int {
+	nextUnit := 0
+	for _, device := range devices {
+		if unit := deviceUnit(device); unit >= nextUnit {
+			nextUnit = unit + 1
+		}
+	}
+
+	return nextUnit
+}
+
+func isCdromDevice(device govmx.Device) bool {
+	return strings.Contains(strings.ToLower(device.Type), "cdrom")
+}
+
+// takesDisks is true for a bus with disks, or with a controller and no devices yet
+func takesDisks(devices []govmx.Device) bool {
+	hasController, hasDevices := false, false
+	for _, device := range devices {
+		switch {
+		case isControllerDevice(device):
+			hasController = true
+		case isCdromDevice(device):
+			hasDevices = true
+		default:
+			return true
+		}
+	}
+
+	return hasController && !hasDevices
+}
+
+func hasControllerDevice(devices []govmx.Device) bool {
+	for _, device := range devices {
+		if isControllerDevice(device) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sataDevices(vmxDevices []govmx.SATADevice) []govmx.Device {
+	var devices []govmx.Device
+	for _, vmxDevice := range vmxDevices {
+		devices = append(devices, vmxDevice.Device)
+	}
+
+	return devices
+}
+
+func nvmeDevices(vmxDevices []NVMeDevice) []govmx.Device {
+	var devices []govmx.Device
+	for _, vmxDevice := range vmxDevices {
+		devices = append(devices, vmxDevice.Device)
+	}
+
+	return devices
+}
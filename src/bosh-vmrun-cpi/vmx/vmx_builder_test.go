@@ -1,6 +1,7 @@
 package vmx_test
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -80,7 +81,7 @@ var _ = Describe("VmxBuilder", func() {
 
 	Describe("AddNetworkInterface", func() {
 		It("add a NIC", func() {
-			err := builder.AddNetworkInterface("fooNetwork", "00:11:22:33:44:55", vmxPath)
+			err := builder.AddNetworkInterface("fooNetwork", "00:11:22:33:44:55", "custom", "vmxnet3", vmxPath)
 			Expect(err).ToNot(HaveOccurred())
 
 			vmxVM, err := builder.GetVmx(vmxPath)
@@ -90,8 +91,98 @@ var _ = Describe("VmxBuilder", func() {
 			Expect(vmxVM.Ethernet[0].Address).To(Equal("00:11:22:33:44:55"))
 			Expect(vmxVM.Ethernet[0].AddressType).To(Equal(govmx.EthernetAddressType("static")))
 			Expect(vmxVM.Ethernet[0].VirtualDev).To(Equal("vmxnet3"))
+			Expect(vmxVM.Ethernet[0].ConnectionType).To(Equal("custom"))
 			Expect(vmxVM.Ethernet[0].Present).To(BeTrue())
 		})
+
+		It("adds a NIC on a built-in network with another device type", func() {
+			err := builder.AddNetworkInterface("fooNetwork", "00:11:22:33:44:55", "nat", "e1000", vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			vmxVM, err := builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vmxVM.Ethernet[0].VNetwork).To(Equal(""))
+			Expect(vmxVM.Ethernet[0].ConnectionType).To(Equal("nat"))
+			Expect(vmxVM.Ethernet[0].VirtualDev).To(Equal("e1000"))
+		})
+
+		It("keeps NIC order and assigns pci slots on the pcie root ports", func() {
+			for i, networkName := range []string{"net0", "net1", "net2", "net3", "net4"} {
+				err := builder.AddNetworkInterface(networkName, fmt.Sprintf("00:11:22:33:44:0%d", i), "custom", "vmxnet3", vmxPath)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(builder.SetVMResources(2, 2048, vmxPath)).To(Succeed())
+
+			vmxVM, err := builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			for i, ethernet := range vmxVM.Ethernet {
+				Expect(ethernet.VNetwork).To(Equal(fmt.Sprintf("net%d", i)))
+			}
+
+			vmxBytes, err := ioutil.ReadFile(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			vmxContent := string(vmxBytes)
+			Expect(vmxContent).To(ContainSubstring(`ethernet0.pciSlotNumber = "160"`))
+			Expect(vmxContent).To(ContainSubstring(`ethernet1.pciSlotNumber = "192"`))
+			Expect(vmxContent).To(ContainSubstring(`ethernet2.pciSlotNumber = "224"`))
+			Expect(vmxContent).To(ContainSubstring(`ethernet3.pciSlotNumber = "256"`))
+			Expect(vmxContent).To(ContainSubstring(`ethernet4.pciSlotNumber = "1184"`))
+			Expect(vmxContent).To(ContainSubstring(`pciBridge7.virtualDev = "pcieRootPort"`))
+			Expect(vmxContent).ToNot(ContainSubstring(`pciBridge1.`))
+		})
+
+		It("adds NICs on LAN segments keyed by name", func() {
+			Expect(builder.AddNetworkInterface("segment-a", "00:11:22:33:44:55", "custom", "vmxnet3", vmxPath)).To(Succeed())
+			Expect(builder.AddNetworkInterface("segment-a", "00:11:22:33:44:56", "pvn", "vmxnet3", vmxPath)).To(Succeed())
+			Expect(builder.AddNetworkInterface("segment-b", "00:11:22:33:44:57", "pvn", "vmxnet3", vmxPath)).To(Succeed())
+			Expect(builder.SetVMResources(2, 2048, vmxPath)).To(Succeed())
+
+			vmxVM, err := builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vmxVM.Ethernet[1].ConnectionType).To(Equal("pvn"))
+			Expect(vmxVM.Ethernet[1].VNetwork).To(Equal(""))
+			Expect(vmxVM.EthernetPVNIDs).To(Equal([]string{"", vmx.PVNID("segment-a"), vmx.PVNID("segment-b")}))
+
+			Expect(vmx.PVNID("segment-a")).To(MatchRegexp(`^([0-9a-f]{2} ){7}[0-9a-f]{2}-([0-9a-f]{2} ){7}[0-9a-f]{2}$`))
+			Expect(vmx.PVNID("segment-a")).ToNot(Equal(vmx.PVNID("segment-b")))
+
+			vmxBytes, err := ioutil.ReadFile(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(vmxBytes)).To(ContainSubstring(`ethernet1.pvnID = "` + vmx.PVNID("segment-a") + `"`))
+			Expect(string(vmxBytes)).ToNot(ContainSubstring(`ethernet0.pvnID`))
+		})
+
+		It("rejects unsupported connection and device types", func() {
+			err := builder.AddNetworkInterface("fooNetwork", "00:11:22:33:44:55", "vpn", "vmxnet3", vmxPath)
+			Expect(err).To(MatchError("unsupported network connection type: vpn"))
+
+			err = builder.AddNetworkInterface("fooNetwork", "00:11:22:33:44:55", "custom", "rtl8139", vmxPath)
+			Expect(err).To(MatchError("unsupported network device type: rtl8139"))
+
+			err = builder.AddNetworkInterface("", "00:11:22:33:44:55", "custom", "vmxnet3", vmxPath)
+			Expect(err).To(MatchError("network name is required for custom connection type"))
+
+			err = builder.AddNetworkInterface("", "00:11:22:33:44:55", "pvn", "vmxnet3", vmxPath)
+			Expect(err).To(MatchError("network name is required for pvn connection type"))
+		})
+	})
+
+	Describe("SetGuestInfo", func() {
+		It("keeps guestinfo variables across rewrites", func() {
+			Expect(builder.SetGuestInfo("bosh.agent_env", "eyJhIjoiYiJ9", vmxPath)).To(Succeed())
+			Expect(builder.SetGuestInfo("quoted", `a "b" | c`, vmxPath)).To(Succeed())
+			Expect(builder.SetVMResources(2, 2048, vmxPath)).To(Succeed())
+
+			vmxVM, err := builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vmxVM.GuestInfo).To(Equal(map[string]string{"bosh.agent_env": "eyJhIjoiYiJ9", "quoted": `a "b" | c`}))
+
+			vmxBytes, err := ioutil.ReadFile(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(vmxBytes)).To(ContainSubstring(`guestinfo.bosh.agent_env = "eyJhIjoiYiJ9"`))
+			Expect(string(vmxBytes)).To(ContainSubstring(`guestinfo.quoted = "a |22b|22 |7C c"`))
+		})
 	})
 
 	Describe("SetVMResources", func() {
@@ -119,6 +210,66 @@ var _ = Describe("VmxBuilder", func() {
 		})
 	})
 
+	Describe("SetDiskController", func() {
+		It("changes the scsi controller type", func() {
+			err := builder.SetDiskController("pvscsi", vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			vmxVM, err := builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vmxVM.SCSIDevices[0].VirtualDev).To(Equal("pvscsi"))
+			Expect(vmxVM.SCSIDevices[1].Filename).To(Equal("image.vmdk"))
+			Expect(vmxVM.DiskController()).To(Equal("pvscsi"))
+		})
+
+		It("adds a sata controller", func() {
+			err := builder.SetDiskController("sata", vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			vmxVM, err := builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vmxVM.SATADevices[0].VMXID).To(Equal("sata0"))
+			Expect(vmxVM.SATADevices[0].Present).To(BeTrue())
+			Expect(vmxVM.Vhardware.Version).To(Equal(10))
+			Expect(vmxVM.SCSIDevices[0].VirtualDev).To(Equal("lsilogic"))
+			Expect(vmxVM.DiskController()).To(Equal("sata"))
+		})
+
+		It("adds an nvme controller", func() {
+			err := builder.SetDiskController("nvme", vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = builder.SetDiskController("nvme", vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			vmxVM, err := builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(len(vmxVM.NVMeDevices)).To(Equal(1))
+			Expect(vmxVM.NVMeDevices[0].VMXID).To(Equal("nvme0"))
+			Expect(vmxVM.NVMeDevices[0].Present).To(BeTrue())
+			Expect(vmxVM.Vhardware.Version).To(Equal(13))
+			Expect(vmxVM.DiskController()).To(Equal("nvme"))
+		})
+
+		It("returns an error when disks stay on another controller", func() {
+			vmxBytes, err := ioutil.ReadFile(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			vmxBytes = append(vmxBytes, []byte("sata0.present = \"TRUE\"\nsata0:0.present = \"TRUE\"\nsata0:0.deviceType = \"cdrom-raw\"\n")...)
+			Expect(ioutil.WriteFile(vmxPath, vmxBytes, 0644)).To(Succeed())
+
+			err = builder.SetDiskController("sata", vmxPath)
+			Expect(err).To(MatchError(ContainSubstring("attach to its lsilogic controller, not sata")))
+		})
+
+		It("returns an error for unknown controllers", func() {
+			err := builder.SetDiskController("floppy", vmxPath)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("AttachDisk", func() {
 		It("adds a disk entry", func() {
 			err := builder.AttachDisk(filepath.Join("disk", "path.vmdk"), vmxPath)
@@ -141,6 +292,82 @@ var _ = Describe("VmxBuilder", func() {
 		})
 	})
 
+	Describe("AttachDisk with an nvme controller", func() {
+		It("adds disk entries to the nvme controller", func() {
+			err := builder.SetDiskController("nvme", vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = builder.AttachDisk(filepath.Join("disk", "ephemeral.vmdk"), vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = builder.AttachDisk(filepath.Join("disk", "persistent.vmdk"), vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			vmxBytes, err := ioutil.ReadFile(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(vmxBytes)).To(ContainSubstring(`nvme0.present = "true"`))
+			Expect(string(vmxBytes)).To(ContainSubstring(`nvme0:1.present = "true"`))
+
+			vmxVM, err := builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			disks := vmxVM.NVMeDevices
+
+			Expect(len(vmxVM.SCSIDevices)).To(Equal(2))
+			Expect(disks[1].VMXID).To(Equal("nvme0:0"))
+			Expect(disks[1].Filename).To(Equal(filepath.Join("disk", "ephemeral.vmdk")))
+			Expect(disks[2].VMXID).To(Equal("nvme0:1"))
+			Expect(disks[2].Filename).To(Equal(filepath.Join("disk", "persistent.vmdk")))
+
+			err = builder.DetachDisk(filepath.Join("disk", "ephemeral.vmdk"), vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			vmxVM, err = builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(len(vmxVM.NVMeDevices)).To(Equal(2))
+			Expect(vmxVM.NVMeDevices[1].Filename).To(Equal(filepath.Join("disk", "persistent.vmdk")))
+			Expect(vmxVM.NVMeDevices[1].VMXID).To(Equal("nvme0:1"))
+
+			err = builder.AttachDisk(filepath.Join("disk", "other.vmdk"), vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			vmxVM, err = builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vmxVM.NVMeDevices[2].VMXID).To(Equal("nvme0:2"))
+			Expect(vmxVM.NVMeDevices[2].Filename).To(Equal(filepath.Join("disk", "other.vmdk")))
+		})
+	})
+
+	Describe("AttachDisk with a sata cdrom", func() {
+		BeforeEach(func() {
+			vmxFile, err := os.OpenFile(vmxPath, os.O_APPEND|os.O_WRONLY, 0644)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = vmxFile.WriteString("sata0.present = \"TRUE\"\nsata0:1.present = \"TRUE\"\nsata0:1.deviceType = \"cdrom-raw\"\n")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vmxFile.Close()).To(Succeed())
+		})
+
+		It("keeps adding disks to the scsi controller holding the system disk", func() {
+			vmxVM, err := builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vmxVM.DiskController()).To(Equal("lsilogic"))
+
+			err = builder.AttachDisk(filepath.Join("disk", "path.vmdk"), vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			vmxBytes, err := ioutil.ReadFile(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(vmxBytes)).To(ContainSubstring(`sata0:1.devicetype = "cdrom-raw"`))
+
+			vmxVM, err = builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vmxVM.SCSIDevices[len(vmxVM.SCSIDevices)-1].Filename).To(Equal(filepath.Join("disk", "path.vmdk")))
+			Expect(len(vmxVM.SATADevices)).To(Equal(2))
+		})
+	})
+
 	Describe("DetachDisk", func() {
 		Context("when disk is attached", func() {
 			It("adds a disk entry", func() {
@@ -183,4 +410,27 @@ var _ = Describe("VmxBuilder", func() {
 			Expect(vmxVM.IDEDevices[0].Present).To(BeTrue())
 		})
 	})
+	Describe("WriteVmx", func() {
+		It("writes a new vm with firmware and pci bridges", func() {
+			vmxVM := &vmx.VM{}
+			vmxVM.DisplayName = "vm-new"
+			vmxVM.Firmware = "efi"
+			vmxVM.PCIBridges = vmx.PCIBridges(9)
+
+			Expect(builder.WriteVmx(vmxVM, vmxPath)).To(Succeed())
+
+			vmxVM, err := builder.GetVmx(vmxPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vmxVM.DisplayName).To(Equal("vm-new"))
+			Expect(vmxVM.Firmware).To(Equal("efi"))
+			Expect(vmxVM.PCIBridges).To(HaveLen(5))
+			Expect(vmxVM.PCIBridges[1].VMXID).To(Equal("pcibridge4"))
+			Expect(vmxVM.PCIBridges[1].VirtualDev).To(Equal("pcieRootPort"))
+		})
+
+		It("only adds pcie root ports from hardware version 7", func() {
+			Expect(vmx.PCIBridges(4)).To(HaveLen(1))
+		})
+	})
 })